@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/mcules/llm-router/internal/llama"
+)
+
+// genSpeedAlpha is the EWMA weight of a new generation speed sample.
+const genSpeedAlpha = 0.2
+
+// The estimate fades with age, so a node that was slow once (and is then rarely picked)
+// is not judged by it forever: the weight of the previous estimate halves for every
+// genSpeedHalfLife since its last sample, and after genSpeedMaxAge without samples the
+// speed is reported as unknown.
+const (
+	genSpeedHalfLife = 5 * time.Minute
+	genSpeedMaxAge   = 15 * time.Minute
+)
+
+// genSpeed estimates the generation speed (tokens/sec per request) from consecutive
+// /slots polls: the decoded token count of a slot that keeps working on the same task
+// grows by the tokens generated since the last poll.
+type genSpeed struct {
+	prev map[int]slotSample // by slot id
+	ewma float64
+	last time.Time // of the last sample in ewma
+}
+
+type slotSample struct {
+	task    int
+	decoded int
+	at      time.Time
+}
+
+// observe records a /slots poll and updates the EWMA from the slots that generated tokens.
+func (g *genSpeed) observe(slots *llama.SlotsResponse, now time.Time) {
+	next := make(map[int]slotSample, len(slots.Slots))
+	var (
+		sum float64
+		n   int
+	)
+	for _, s := range slots.Slots {
+		if !s.IsProcessing {
+			continue
+		}
+		cur := slotSample{task: s.IDTask, decoded: s.NextToken.NDecoded, at: now}
+		next[s.ID] = cur
+
+		p, ok := g.prev[s.ID]
+		if !ok || p.task != cur.task || cur.decoded <= p.decoded {
+			continue
+		}
+		if dt := cur.at.Sub(p.at).Seconds(); dt > 0 {
+			sum += float64(cur.decoded-p.decoded) / dt
+			n++
+		}
+	}
+	g.prev = next
+
+	if n == 0 {
+		return
+	}
+	rate := sum / float64(n)
+	if g.ewma == 0 {
+		g.ewma = rate
+	} else {
+		keep := (1 - genSpeedAlpha) * math.Exp2(-now.Sub(g.last).Seconds()/genSpeedHalfLife.Seconds())
+		g.ewma = (1-keep)*rate + keep*g.ewma
+	}
+	g.last = now
+}
+
+// tokensPerSecond returns the estimate at now (0 = no generation observed, or none
+// within genSpeedMaxAge).
+func (g *genSpeed) tokensPerSecond(now time.Time) float64 {
+	if g.ewma == 0 || now.Sub(g.last) > genSpeedMaxAge {
+		return 0
+	}
+	return g.ewma
+}
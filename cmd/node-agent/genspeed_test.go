@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/llama"
+)
+
+// generate feeds g two polls of one slot generating at rate tokens/sec, the second at end.
+func generate(g *genSpeed, task int, rate float64, end time.Time) {
+	start := end.Add(-time.Second)
+	g.observe(&llama.SlotsResponse{Slots: []llama.Slot{{ID: 0, IDTask: task, IsProcessing: true, NextToken: llama.SlotNextToken{NDecoded: 10}}}}, start)
+	g.observe(&llama.SlotsResponse{Slots: []llama.Slot{{ID: 0, IDTask: task, IsProcessing: true, NextToken: llama.SlotNextToken{NDecoded: 10 + int(rate)}}}}, end)
+}
+
+func TestGenSpeedFades(t *testing.T) {
+	t0 := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	approx := func(got, want float64) bool { return math.Abs(got-want) < want/100 }
+
+	var g genSpeed
+	if got := g.tokensPerSecond(t0); got != 0 {
+		t.Fatalf("speed before any generation = %v, want 0", got)
+	}
+	generate(&g, 1, 10, t0)
+	if got := g.tokensPerSecond(t0); !approx(got, 10) {
+		t.Fatalf("speed after first sample = %v, want 10", got)
+	}
+
+	// Right after the last sample the EWMA weighs the new one with genSpeedAlpha.
+	generate(&g, 2, 60, t0.Add(time.Second))
+	if got := g.tokensPerSecond(t0.Add(time.Second)); !approx(got, 20) {
+		t.Fatalf("speed after quick second sample = %v, want 20", got)
+	}
+
+	// One half-life later the old estimate only keeps half of its usual weight.
+	later := t0.Add(time.Second + genSpeedHalfLife)
+	generate(&g, 3, 60, later)
+	if got, want := g.tokensPerSecond(later), 0.4*20+0.6*60; !approx(got, want) {
+		t.Fatalf("speed one half-life later = %v, want %v", got, want)
+	}
+
+	if got := g.tokensPerSecond(later.Add(genSpeedMaxAge)); got == 0 {
+		t.Error("estimate expired before genSpeedMaxAge")
+	}
+	if got := g.tokensPerSecond(later.Add(genSpeedMaxAge + time.Second)); got != 0 {
+		t.Errorf("speed after genSpeedMaxAge without samples = %v, want 0 (unknown)", got)
+	}
+}
@@ -3,18 +3,33 @@ package main
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
 	"github.com/mcules/llm-router/internal/llama"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	// fastPollInterval is the /models poll interval right after a command was handled.
+	fastPollInterval = 500 * time.Millisecond
+	// fastPollWindow is how long the fast poll lasts after a command.
+	fastPollWindow = 10 * time.Second
+	// cancelLoadTimeout bounds waiting for a load to finish when it cannot be aborted.
+	cancelLoadTimeout = 10 * time.Minute
+	// loadModelTimeout bounds the load call; llama.cpp answers once the load has started.
+	loadModelTimeout = 30 * time.Second
 )
 
 func main() {
@@ -27,7 +42,37 @@ func main() {
 	// External URL for server->llama (must be reachable from server)
 	dataPlane := envOr("DATA_PLANE_URL", llamaBase)
 
-	meminfoPath := envOr("HOST_MEMINFO_PATH", "/host/proc/meminfo")
+	// TLS of an https data plane, reported to the server: CA bundle to trust for this
+	// node (e.g. a self-signed cert) or no verification at all.
+	var dataPlaneCA string
+	if path := os.Getenv("DATA_PLANE_CA_FILE"); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("read DATA_PLANE_CA_FILE: %v", err)
+		}
+		dataPlaneCA = string(pem)
+	}
+	dataPlaneInsecure := envOrBool("DATA_PLANE_TLS_INSECURE", false)
+
+	// Upstream paths of backends without the OpenAI paths, e.g. "chat=/generate".
+	endpointPaths, err := parseEndpointPaths(os.Getenv("DATA_PLANE_PATHS"))
+	if err != nil {
+		log.Fatalf("DATA_PLANE_PATHS: %v", err)
+	}
+
+	// Kernels without MemAvailable: report MemFree (default, pessimistic) or estimate it
+	// from MemFree plus reclaimable cache (MEMFREE_FALLBACK=cache).
+	mem := meminfo{path: envOr("HOST_MEMINFO_PATH", "/host/proc/meminfo")}
+	switch fb := envOr("MEMFREE_FALLBACK", "memfree"); fb {
+	case "memfree":
+	case "cache":
+		mem.cacheFallback = true
+	default:
+		log.Fatalf("MEMFREE_FALLBACK must be memfree or cache, got %q", fb)
+	}
+
+	// Availability zone / group of this node for zone-aware placement (optional).
+	zone := os.Getenv("NODE_ZONE")
 
 	heartbeatSec := envOrInt("HEARTBEAT_SECONDS", 1)
 	pollModelsBaseSec := envOrInt("POLL_MODELS_SECONDS", 5)
@@ -35,7 +80,29 @@ func main() {
 
 	ll := llama.New(llamaBase)
 
-	conn, err := grpc.NewClient(serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// Use TLS when the server terminates TLS on the (possibly shared) control plane port.
+	creds := insecure.NewCredentials()
+	if envOrBool("SERVER_GRPC_TLS", false) {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	// Keepalive pings detect a silently dropped connection to the server, so the agent
+	// reconnects instead of waiting for TCP timeouts. The server rejects intervals
+	// below 10 seconds.
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if ka := envOrInt("GRPC_KEEPALIVE_SECONDS", 30); ka > 0 {
+		if ka < 10 {
+			log.Printf("GRPC_KEEPALIVE_SECONDS=%d is below the server minimum, using 10", ka)
+			ka = 10
+		}
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Duration(ka) * time.Second,
+			Timeout:             time.Duration(envOrInt("GRPC_KEEPALIVE_TIMEOUT_SECONDS", 10)) * time.Second,
+			PermitWithoutStream: true,
+		}))
+	}
+
+	conn, err := grpc.NewClient(serverAddr, dialOpts...)
 	if err != nil {
 		log.Fatalf("grpc dial: %v", err)
 	}
@@ -43,8 +110,20 @@ func main() {
 
 	client := controlplanev1.NewNodeControlClient(conn)
 
+	hello := &controlplanev1.NodeHello{
+		NodeId:       nodeID,
+		Version:      "dev",
+		LlamaBaseUrl: ll.BaseURL,
+		DataPlaneUrl: dataPlane,
+		Zone:         zone,
+
+		DataPlaneCaPem:       dataPlaneCA,
+		DataPlaneTlsInsecure: dataPlaneInsecure,
+		EndpointPaths:        endpointPaths,
+	}
+
 	for {
-		if err := runOnce(client, ll, nodeID, meminfoPath, dataPlane, heartbeatSec, pollModelsBaseSec, pollSlotsSec); err != nil {
+		if err := runOnce(client, ll, hello, mem, heartbeatSec, pollModelsBaseSec, pollSlotsSec); err != nil {
 			log.Printf("stream ended: %v", err)
 		}
 		time.Sleep(2 * time.Second)
@@ -54,7 +133,8 @@ func main() {
 func runOnce(
 	client controlplanev1.NodeControlClient,
 	ll *llama.Client,
-	nodeID, meminfoPath, dataPlaneURL string,
+	hello *controlplanev1.NodeHello,
+	mem meminfo,
 	heartbeatSec, pollModelsBaseSec, pollSlotsSec int,
 ) error {
 	ctx := context.Background()
@@ -66,21 +146,26 @@ func runOnce(
 	// Send hello.
 	if err := stream.Send(&controlplanev1.NodeMessage{
 		Msg: &controlplanev1.NodeMessage_Hello{
-			Hello: &controlplanev1.NodeHello{
-				NodeId:       nodeID,
-				Version:      "dev",
-				LlamaBaseUrl: ll.BaseURL,
-				DataPlaneUrl: dataPlaneURL,
-			},
+			Hello: hello,
 		},
 	}); err != nil {
 		return fmt.Errorf("send hello: %w", err)
 	}
 
+	// gRPC streams do not allow concurrent Send calls (acks vs. status pushes).
+	var sendMu sync.Mutex
+	send := func(msg *controlplanev1.NodeMessage) error {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		return stream.Send(msg)
+	}
+
 	// Receive loop (commands and pings) in background.
 	cmdErr := make(chan error, 1)
 	// We use a channel to trigger immediate status updates on Ping
 	pingTrigger := make(chan struct{}, 1)
+	// Commands change model state; re-read /models right away instead of waiting for the poll.
+	refreshTrigger := make(chan struct{}, 1)
 
 	go func() {
 		for {
@@ -103,9 +188,61 @@ func runOnce(
 					ack.Error = err.Error()
 				}
 
-				_ = stream.Send(&controlplanev1.NodeMessage{
+				_ = send(&controlplanev1.NodeMessage{
 					Msg: &controlplanev1.NodeMessage_Ack{Ack: ack},
 				})
+
+				select {
+				case refreshTrigger <- struct{}{}:
+				default:
+				}
+			case *controlplanev1.ServerMessage_CancelLoad:
+				// May wait for the load to finish; keep receiving meanwhile.
+				go func(reqID, modelID string) {
+					ctx, cancel := context.WithTimeout(context.Background(), cancelLoadTimeout)
+					defer cancel()
+
+					err := ll.CancelLoad(ctx, modelID)
+					ack := &controlplanev1.CommandAck{
+						RequestId: reqID,
+						Ok:        err == nil,
+					}
+					if err != nil {
+						ack.Error = err.Error()
+					}
+
+					_ = send(&controlplanev1.NodeMessage{
+						Msg: &controlplanev1.NodeMessage_Ack{Ack: ack},
+					})
+
+					select {
+					case refreshTrigger <- struct{}{}:
+					default:
+					}
+				}(msg.CancelLoad.RequestId, msg.CancelLoad.ModelId)
+			case *controlplanev1.ServerMessage_LoadModel:
+				go func(reqID, modelID string) {
+					ctx, cancel := context.WithTimeout(context.Background(), loadModelTimeout)
+					defer cancel()
+
+					err := ll.LoadModel(ctx, modelID)
+					ack := &controlplanev1.CommandAck{
+						RequestId: reqID,
+						Ok:        err == nil,
+					}
+					if err != nil {
+						ack.Error = err.Error()
+					}
+
+					_ = send(&controlplanev1.NodeMessage{
+						Msg: &controlplanev1.NodeMessage_Ack{Ack: ack},
+					})
+
+					select {
+					case refreshTrigger <- struct{}{}:
+					default:
+					}
+				}(msg.LoadModel.RequestId, msg.LoadModel.ModelId)
 			case *controlplanev1.ServerMessage_Ping:
 				// Trigger immediate status send
 				select {
@@ -121,47 +258,66 @@ func runOnce(
 	var (
 		lastModels *llama.ModelsResponse
 		inflight   uint32
+		totalSlots uint32
+		speed      genSpeed
 	)
 
 	// Prime initial reads quickly.
 	_ = refreshModels(ctx, ll, &lastModels)
-	_ = refreshSlots(ctx, ll, &inflight)
+	_ = refreshSlots(ctx, ll, &inflight, &totalSlots, &speed)
 
 	tHeartbeat := time.NewTicker(time.Duration(heartbeatSec) * time.Second)
 	defer tHeartbeat.Stop()
 
-	// Models polling: dynamic (fast while any model is loading)
+	// Models polling: dynamic (fast while any model is loading or right after a command)
 	modelsTicker := time.NewTicker(time.Duration(pollModelsBaseSec) * time.Second)
 	defer modelsTicker.Stop()
+	var fastPollUntil time.Time
 
 	tSlots := time.NewTicker(time.Duration(pollSlotsSec) * time.Second)
 	defer tSlots.Stop()
 
-	for {
-		// Helper function to send status
-		sendStatus := func() error {
-			ramTotal, ramAvail, err := readMeminfo(meminfoPath)
-			if err != nil {
-				log.Printf("meminfo: %v", err)
-				return nil // continue loop
-			}
+	// Helper function to send status
+	sendStatus := func() error {
+		ramTotal, ramAvail, ramSource, err := mem.read()
+		if err != nil {
+			log.Printf("meminfo: %v", err)
+			return nil // continue loop
+		}
 
-			status := &controlplanev1.NodeStatus{
-				TsUnixMs:          time.Now().UnixMilli(),
-				RamTotalBytes:     ramTotal,
-				RamAvailableBytes: ramAvail,
-				InflightRequests:  inflight,
-				Models:            convertModels(lastModels),
-			}
+		status := &controlplanev1.NodeStatus{
+			TsUnixMs:           time.Now().UnixMilli(),
+			RamTotalBytes:      ramTotal,
+			RamAvailableBytes:  ramAvail,
+			InflightRequests:   inflight,
+			Models:             convertModels(lastModels),
+			GenTokensPerSecond: speed.tokensPerSecond(time.Now()),
+			RamSource:          ramSource,
+			TotalSlots:         totalSlots,
+		}
 
-			if err := stream.Send(&controlplanev1.NodeMessage{
-				Msg: &controlplanev1.NodeMessage_Status{Status: status},
-			}); err != nil {
-				return fmt.Errorf("send status: %w", err)
-			}
+		if err := send(&controlplanev1.NodeMessage{
+			Msg: &controlplanev1.NodeMessage_Status{Status: status},
+		}); err != nil {
+			return fmt.Errorf("send status: %w", err)
+		}
+		return nil
+	}
+
+	// Re-read /models and push a status immediately if any model changed state,
+	// so the server can wake waiters without waiting for the next heartbeat.
+	refreshAndPush := func() error {
+		prev := lastModels
+		if err := refreshModels(ctx, ll, &lastModels); err != nil {
 			return nil
 		}
+		if modelsChanged(prev, lastModels) {
+			return sendStatus()
+		}
+		return nil
+	}
 
+	for {
 		select {
 		case err := <-cmdErr:
 			return fmt.Errorf("recv loop: %w", err)
@@ -171,16 +327,28 @@ func runOnce(
 				return err
 			}
 
+		case <-refreshTrigger:
+			fastPollUntil = time.Now().Add(fastPollWindow)
+			if err := refreshAndPush(); err != nil {
+				return err
+			}
+			modelsTicker.Reset(fastPollInterval)
+
 		case <-tSlots.C:
-			_ = refreshSlots(ctx, ll, &inflight)
+			_ = refreshSlots(ctx, ll, &inflight, &totalSlots, &speed)
 
 		case <-modelsTicker.C:
-			_ = refreshModels(ctx, ll, &lastModels)
+			if err := refreshAndPush(); err != nil {
+				return err
+			}
 
-			// If any model is loading, temporarily poll faster (1s).
-			if anyLoading(lastModels) && pollModelsBaseSec > 1 {
+			// Poll faster right after a command, or while any model is loading (1s).
+			switch {
+			case time.Now().Before(fastPollUntil):
+				modelsTicker.Reset(fastPollInterval)
+			case anyLoading(lastModels) && pollModelsBaseSec > 1:
 				modelsTicker.Reset(1 * time.Second)
-			} else {
+			default:
 				modelsTicker.Reset(time.Duration(pollModelsBaseSec) * time.Second)
 			}
 
@@ -201,15 +369,38 @@ func refreshModels(ctx context.Context, ll *llama.Client, last **llama.ModelsRes
 	return nil
 }
 
-func refreshSlots(ctx context.Context, ll *llama.Client, inflight *uint32) error {
-	n, err := ll.GetSlotsInflight(ctx)
+func refreshSlots(ctx context.Context, ll *llama.Client, inflight, total *uint32, speed *genSpeed) error {
+	slots, err := ll.GetSlots(ctx)
 	if err != nil {
 		return err
 	}
-	*inflight = n
+	*inflight = slots.Inflight()
+	*total = slots.Total()
+	speed.observe(slots, time.Now())
 	return nil
 }
 
+// modelsChanged reports whether the set of models or any model status differs.
+func modelsChanged(a, b *llama.ModelsResponse) bool {
+	if a == nil || b == nil {
+		return a != b
+	}
+	if len(a.Data) != len(b.Data) {
+		return true
+	}
+	prev := make(map[string]string, len(a.Data))
+	for _, x := range a.Data {
+		prev[x.ID] = fmt.Sprintf("%s/%v", x.Status.Value, x.Status.Failed)
+	}
+	for _, x := range b.Data {
+		st, ok := prev[x.ID]
+		if !ok || st != fmt.Sprintf("%s/%v", x.Status.Value, x.Status.Failed) {
+			return true
+		}
+	}
+	return false
+}
+
 func anyLoading(m *llama.ModelsResponse) bool {
 	if m == nil {
 		return false
@@ -230,10 +421,17 @@ func convertModels(m *llama.ModelsResponse) []*controlplanev1.ModelResidency {
 	now := time.Now().UnixMilli()
 
 	for _, x := range m.Data {
+		var loadErr string
+		if x.Status.Failed {
+			loadErr = fmt.Sprintf("load failed (exit code %d)", x.Status.ExitCode)
+		}
 		out = append(out, &controlplanev1.ModelResidency{
 			ModelId:           x.ID,
 			State:             mapLlamaStatus(x.Status.Value, x.Status.Failed),
 			LoadedSinceUnixMs: now, // best effort for now
+			Error:             loadErr,
+			Capabilities:      x.Capabilities,
+			MaxContext:        x.Meta.NCtxTrain,
 		})
 	}
 	return out
@@ -255,32 +453,53 @@ func mapLlamaStatus(value string, failed bool) controlplanev1.ModelState {
 	}
 }
 
-func readMeminfo(path string) (totalBytes uint64, availBytes uint64, err error) {
+// RAM sources reported in NodeStatus.ram_source.
+const (
+	ramSourceMemAvailable = "memavailable"
+	ramSourceMemFree      = "memfree"
+	ramSourceMemFreeCache = "memfree+cache"
+	ramSourceStatic       = "static"
+)
+
+// meminfo reads the node's RAM from a meminfo file.
+type meminfo struct {
+	path string
+	// cacheFallback estimates available RAM as MemFree + Buffers + Cached + SReclaimable
+	// when MemAvailable is missing, instead of reporting MemFree alone.
+	cacheFallback bool
+}
+
+// read returns total and available RAM and where the available figure comes from.
+func (m meminfo) read() (totalBytes, availBytes uint64, source string, err error) {
 	// Try the provided path (likely /proc/meminfo)
-	f, err := os.Open(path)
+	f, err := os.Open(m.path)
 	if err == nil {
 		defer f.Close()
-		var totalKB, availKB uint64
+		kb := map[string]uint64{}
 		sc := bufio.NewScanner(f)
 		for sc.Scan() {
 			line := sc.Text()
-			if strings.HasPrefix(line, "MemTotal:") {
-				totalKB = parseMeminfoKB(line)
-			} else if strings.HasPrefix(line, "MemAvailable:") {
-				availKB = parseMeminfoKB(line)
-			} else if strings.HasPrefix(line, "MemFree:") && availKB == 0 {
-				// Fallback to MemFree if MemAvailable is not present (older kernels)
-				availKB = parseMeminfoKB(line)
+			if name, _, ok := strings.Cut(line, ":"); ok {
+				kb[name] = parseMeminfoKB(line)
 			}
 		}
-		if sc.Err() == nil && totalKB > 0 {
-			return totalKB * 1024, availKB * 1024, nil
+		if sc.Err() == nil && kb["MemTotal"] > 0 {
+			total := kb["MemTotal"] * 1024
+			if avail, ok := kb["MemAvailable"]; ok {
+				return total, avail * 1024, ramSourceMemAvailable, nil
+			}
+			// Older kernels: MemFree excludes reclaimable cache and understates usable RAM.
+			if m.cacheFallback {
+				est := kb["MemFree"] + kb["Buffers"] + kb["Cached"] + kb["SReclaimable"]
+				return total, min(est, kb["MemTotal"]) * 1024, ramSourceMemFreeCache, nil
+			}
+			return total, kb["MemFree"] * 1024, ramSourceMemFree, nil
 		}
 	}
 
 	// Fallback for development (Windows/Darwin or missing /proc/meminfo)
 	// Return some static values so the agent can still run locally.
-	return 16 * 1024 * 1024 * 1024, 8 * 1024 * 1024 * 1024, nil
+	return 16 * 1024 * 1024 * 1024, 8 * 1024 * 1024 * 1024, ramSourceStatic, nil
 }
 
 func parseMeminfoKB(line string) uint64 {
@@ -300,6 +519,27 @@ func mustEnv(k string) string {
 	return v
 }
 
+// parseEndpointPaths parses "endpoint=/path,endpoint:model=/path" (empty = none).
+func parseEndpointPaths(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	out := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || !strings.HasPrefix(v, "/") {
+			return nil, fmt.Errorf("invalid entry %q (want endpoint=/path)", part)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
 func envOr(k, def string) string {
 	v := os.Getenv(k)
 	if v == "" {
@@ -319,3 +559,15 @@ func envOrInt(k string, def int) int {
 	}
 	return n
 }
+
+func envOrBool(k string, def bool) bool {
+	v := os.Getenv(k)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeMeminfo(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "meminfo")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMeminfoRead(t *testing.T) {
+	const (
+		modern = "MemTotal:       16000 kB\nMemFree:         2000 kB\nMemAvailable:    9000 kB\nBuffers:          500 kB\nCached:          5000 kB\n"
+		// Kernels before 3.14 have no MemAvailable.
+		freeOnly = "MemTotal:       16000 kB\nMemFree:         2000 kB\nBuffers:          500 kB\nCached:          5000 kB\nSReclaimable:    1000 kB\n"
+	)
+	for _, tc := range []struct {
+		name          string
+		content       string
+		cacheFallback bool
+		avail         uint64
+		source        string
+	}{
+		{"MemAvailable", modern, false, 9000 << 10, ramSourceMemAvailable},
+		{"MemAvailable over cache fallback", modern, true, 9000 << 10, ramSourceMemAvailable},
+		{"MemFree only", freeOnly, false, 2000 << 10, ramSourceMemFree},
+		{"MemFree only with cache fallback", freeOnly, true, 8500 << 10, ramSourceMemFreeCache},
+		{"cache estimate capped at MemTotal", "MemTotal: 1000 kB\nMemFree: 800 kB\nCached: 900 kB\n", true, 1000 << 10, ramSourceMemFreeCache},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			total, avail, source, err := meminfo{path: writeMeminfo(t, tc.content), cacheFallback: tc.cacheFallback}.read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total == 0 || avail != tc.avail || source != tc.source {
+				t.Errorf("read = %d, %d, %q, want available %d from %q", total, avail, source, tc.avail, tc.source)
+			}
+		})
+	}
+}
+
+func TestMeminfoMissing(t *testing.T) {
+	_, _, source, err := meminfo{path: filepath.Join(t.TempDir(), "none")}.read()
+	if err != nil || source != ramSourceStatic {
+		t.Errorf("read = %q, %v, want the static fallback", source, err)
+	}
+}
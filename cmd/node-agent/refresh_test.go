@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+	"github.com/mcules/llm-router/internal/llama"
+)
+
+// fakeLlama is a minimal llama.cpp router: /models reports the state map, load and
+// unload switch a model to "loading" and "unloaded". slots is signalled on every
+// /slots read.
+type fakeLlama struct {
+	mu     sync.Mutex
+	models map[string]string
+	slots  chan struct{}
+}
+
+func (f *fakeLlama) set(id, status string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.models[id] = status
+}
+
+func (f *fakeLlama) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/models":
+		type status struct {
+			Value string `json:"value"`
+		}
+		type model struct {
+			ID     string `json:"id"`
+			Status status `json:"status"`
+		}
+		var data []model
+		for id, s := range f.models {
+			data = append(data, model{ID: id, Status: status{Value: s}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	case "/models/load", "/models/unload":
+		var req struct {
+			Model string `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path == "/models/load" {
+			f.models[req.Model] = "loading"
+		} else {
+			f.models[req.Model] = "unloaded"
+		}
+		_, _ = io.WriteString(w, `{"success":true}`)
+	default:
+		if r.URL.Path == "/slots" {
+			select {
+			case f.slots <- struct{}{}:
+			default:
+			}
+		}
+		http.NotFound(w, r)
+	}
+}
+
+// fakeStream plays the server side of the control stream: Recv hands out the commands
+// queued on in (io.EOF once in is closed), Send records every node message.
+type fakeStream struct {
+	grpc.ClientStream
+	in  chan *controlplanev1.ServerMessage
+	out chan *controlplanev1.NodeMessage
+}
+
+func (s *fakeStream) Send(m *controlplanev1.NodeMessage) error {
+	s.out <- m
+	return nil
+}
+
+func (s *fakeStream) Recv() (*controlplanev1.ServerMessage, error) {
+	m, ok := <-s.in
+	if !ok {
+		return nil, io.EOF
+	}
+	return m, nil
+}
+
+type fakeControl struct {
+	stream *fakeStream
+}
+
+func (c fakeControl) Stream(context.Context, ...grpc.CallOption) (grpc.BidiStreamingClient[controlplanev1.NodeMessage, controlplanev1.ServerMessage], error) {
+	return c.stream, nil
+}
+
+// TestCommandAckTriggersRefresh checks that every command ack re-reads /models at once
+// and switches to the fast poll, so state changes reach the server long before the
+// regular poll or heartbeat.
+func TestCommandAckTriggersRefresh(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		initial string
+		cmd     *controlplanev1.ServerMessage
+		// after is the state right after the command, next a later change picked up
+		// by the fast poll.
+		after, next controlplanev1.ModelState
+		nextStatus  string
+	}{
+		{
+			name:    "load",
+			initial: "unloaded",
+			cmd: &controlplanev1.ServerMessage{Msg: &controlplanev1.ServerMessage_LoadModel{
+				LoadModel: &controlplanev1.LoadModel{RequestId: "r1", ModelId: "m"},
+			}},
+			after:      controlplanev1.ModelState_MODEL_STATE_LOADING,
+			next:       controlplanev1.ModelState_MODEL_STATE_READY,
+			nextStatus: "loaded",
+		},
+		{
+			name:    "unload",
+			initial: "loaded",
+			cmd: &controlplanev1.ServerMessage{Msg: &controlplanev1.ServerMessage_UnloadModel{
+				UnloadModel: &controlplanev1.UnloadModel{RequestId: "r1", ModelId: "m"},
+			}},
+			after:      controlplanev1.ModelState_MODEL_STATE_UNLOADED,
+			next:       controlplanev1.ModelState_MODEL_STATE_LOADING,
+			nextStatus: "loading",
+		},
+		{
+			name:    "cancel load",
+			initial: "loading",
+			cmd: &controlplanev1.ServerMessage{Msg: &controlplanev1.ServerMessage_CancelLoad{
+				CancelLoad: &controlplanev1.CancelLoad{RequestId: "r1", ModelId: "m"},
+			}},
+			after:      controlplanev1.ModelState_MODEL_STATE_UNLOADED,
+			next:       controlplanev1.ModelState_MODEL_STATE_LOADING,
+			nextStatus: "loading",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := &fakeLlama{models: map[string]string{"m": tc.initial}, slots: make(chan struct{}, 1)}
+			srv := httptest.NewServer(backend)
+			defer srv.Close()
+
+			stream := &fakeStream{
+				in:  make(chan *controlplanev1.ServerMessage, 1),
+				out: make(chan *controlplanev1.NodeMessage, 16),
+			}
+			mem := meminfo{path: writeMeminfo(t, "MemTotal: 16000 kB\nMemAvailable: 9000 kB\n")}
+
+			// Heartbeat and regular polls are an hour apart: only the command refresh
+			// and the fast poll can push a status within this test.
+			done := make(chan error, 1)
+			go func() {
+				done <- runOnce(fakeControl{stream}, llama.New(srv.URL), &controlplanev1.NodeHello{NodeId: "n1"}, mem, 3600, 3600, 3600)
+			}()
+			defer func() {
+				close(stream.in)
+				select {
+				case <-done:
+				case <-time.After(5 * time.Second):
+					t.Error("runOnce did not return after the stream closed")
+				}
+			}()
+
+			next := func(within time.Duration) *controlplanev1.NodeMessage {
+				t.Helper()
+				select {
+				case m := <-stream.out:
+					return m
+				case <-time.After(within):
+					t.Fatalf("no message within %v", within)
+					return nil
+				}
+			}
+			stateOf := func(m *controlplanev1.NodeMessage) controlplanev1.ModelState {
+				t.Helper()
+				st := m.GetStatus()
+				if st == nil {
+					t.Fatalf("got %T, want a status", m.Msg)
+				}
+				for _, r := range st.Models {
+					if r.ModelId == "m" {
+						return r.State
+					}
+				}
+				t.Fatalf("status without model m: %v", st.Models)
+				return 0
+			}
+
+			if m := next(time.Second); m.GetHello() == nil {
+				t.Fatalf("first message %T, want hello", m.Msg)
+			}
+			// runOnce primes /models, then /slots; commands before that would be
+			// part of the first snapshot.
+			select {
+			case <-backend.slots:
+			case <-time.After(time.Second):
+				t.Fatal("agent did not prime its state")
+			}
+
+			stream.in <- tc.cmd
+			ack := next(2 * time.Second).GetAck()
+			if ack == nil || ack.RequestId != "r1" || !ack.Ok {
+				t.Fatalf("ack = %v, want ok for r1", ack)
+			}
+			if got := stateOf(next(time.Second)); got != tc.after {
+				t.Fatalf("state after the command = %v, want %v", got, tc.after)
+			}
+
+			// A change after the command is picked up by the fast poll (500 ms).
+			backend.set("m", tc.nextStatus)
+			start := time.Now()
+			if got := stateOf(next(3 * time.Second)); got != tc.next {
+				t.Fatalf("state after the fast poll = %v, want %v", got, tc.next)
+			}
+			if waited := time.Since(start); waited > fastPollWindow {
+				t.Fatalf("status pushed after %v, want within the fast poll window %v", waited, fastPollWindow)
+			}
+		})
+	}
+}
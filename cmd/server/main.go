@@ -2,73 +2,284 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 
 	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
 	"github.com/mcules/llm-router/internal/activity"
 	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/config"
 	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/debugdump"
 	"github.com/mcules/llm-router/internal/httpx"
+	"github.com/mcules/llm-router/internal/logtail"
+	"github.com/mcules/llm-router/internal/maintenance"
 	"github.com/mcules/llm-router/internal/metrics"
 	"github.com/mcules/llm-router/internal/planner"
 	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/promptlog"
 	"github.com/mcules/llm-router/internal/proxy"
 	"github.com/mcules/llm-router/internal/state"
 	"github.com/mcules/llm-router/internal/ui"
+	"github.com/mcules/llm-router/internal/webhook"
 )
 
 // Comments in this file are intentionally in English.
 
+// shutdownTimeout bounds how long requests in flight may finish after SIGINT/SIGTERM.
+const shutdownTimeout = 15 * time.Second
+
+// metricsPruneInterval is how often stale latency/recency entries are swept.
+const metricsPruneInterval = 10 * time.Minute
+
+// Cached node proxies are swept every proxyPruneInterval and dropped once their node has
+// been offline or moved to another data plane URL for proxyEvictAfter; the 429 records
+// of offline nodes go the same way.
+const (
+	proxyPruneInterval = time.Minute
+	proxyEvictAfter    = 10 * time.Minute
+)
+
+// agentKeepaliveMin is the shortest keepalive interval accepted from agents; agents
+// pinging more often are disconnected by gRPC (GOAWAY "too_many_pings").
+const agentKeepaliveMin = 10 * time.Second
+
+// logBufferLines is how many recent log lines the UI log tail keeps.
+const logBufferLines = 1000
+
 func main() {
+	// Keep the recent log lines for the UI log tail (/ui/logs), in addition to stderr.
+	// Output of the log package goes through the same handler.
+	logs := logtail.New(logBufferLines)
+	slog.SetDefault(slog.New(logtail.NewHandler(logs, os.Stderr)))
+
+	// Configuration: defaults, optional JSON file (CONFIG_FILE), env overrides.
+	cfg, err := config.LoadServer(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
 	// Cluster state shared across gRPC control plane, planner and HTTP API.
 	cluster := state.NewClusterState()
 
 	// In-memory store/logs.
-	dbPath := os.Getenv("POLICIES_DB_PATH")
-	if dbPath == "" {
-		dbPath = "policies.db"
-	}
-	policyStore, err := policy.Open(dbPath)
+	policyStore, err := policy.Open(cfg.PoliciesDBPath)
 	if err != nil {
 		log.Fatalf("failed to open policy store: %v", err)
 	}
 	defer policyStore.Close()
+	policyStore.DegradedAfter = cfg.Store.DegradedAfterErrors
+	policyStore.FailClosed = cfg.Store.FailClosed
 
 	activityLog := activity.New(300)
+
+	// Read-only switch for migrations, toggled by the admin in the UI (/ui/maintenance).
+	maint := maintenance.New()
+
+	// Optional webhook for activity events (unloads, node offline, load failures).
+	if cfg.Webhook.URL != "" {
+		hook := webhook.NewDispatcher(cfg.Webhook.URL, cfg.Webhook.Secret)
+		hook.Timeout = time.Duration(cfg.Webhook.TimeoutSeconds) * time.Second
+		hook.Retries = cfg.Webhook.Retries
+		hook.Events = webhook.ParseEvents(cfg.Webhook.Events)
+		activityLog.Subscribe(hook.Handle)
+		go hook.Run(context.Background())
+	}
+
+	// Optional prompt/response capture for evaluation datasets (opt-in per API key).
+	var promptLog *promptlog.Logger
+	if cfg.PromptLog.Sink != "" {
+		sink, err := promptlog.OpenSink(cfg.PromptLog.Sink, cfg.PromptLog.Path)
+		if err != nil {
+			log.Fatalf("prompt log: %v", err)
+		}
+		promptLog = promptlog.New(sink, cfg.PromptLog.Keys)
+		promptLog.MaxBytes = cfg.PromptLog.MaxKB << 10
+		if promptLog.Redactors, err = promptlog.ParseRedactors(cfg.PromptLog.Redact); err != nil {
+			log.Fatalf("prompt log: %v", err)
+		}
+		go promptLog.Run(context.Background())
+		slog.Warn("prompt logging enabled – requests and responses are stored", "sink", cfg.PromptLog.Sink, "keys", cfg.PromptLog.Keys)
+	}
+
 	authenticator := auth.NewAuthenticator(policyStore)
+	authenticator.MaxKeysPerUser = cfg.Auth.MaxKeysPerUser
+	authenticator.Maintenance = maint
+	if authenticator.Pepper, err = auth.LoadPepper(cfg.Auth.KeyPepper, cfg.Auth.KeyPepperFile); err != nil {
+		log.Fatalf("auth: key pepper: %v", err)
+	}
+	if authenticator.PreviousPepper, err = auth.LoadPepper(cfg.Auth.PreviousKeyPepper, cfg.Auth.PreviousKeyPepperFile); err != nil {
+		log.Fatalf("auth: previous key pepper: %v", err)
+	}
+	if n, err := authenticator.StaleKeyHashes(context.Background()); err == nil && n > 0 {
+		log.Printf("auth: %d API keys are not hashed with the current pepper yet; they are rehashed on their next use", n)
+	}
+	if cfg.Auth.AdminPassword != "" {
+		changed, err := authenticator.ResetAdminPassword(context.Background(), cfg.Auth.AdminPassword)
+		switch {
+		case err != nil:
+			slog.Error("auth: reset admin password", "err", err)
+		case changed:
+			slog.Warn("auth: admin password reset from ADMIN_PASSWORD")
+		}
+	}
+	// The flusher runs until the HTTP server has shut down, then writes the usage of the
+	// last requests (see the end of main).
+	flushCtx, stopFlusher := context.WithCancel(context.Background())
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		authenticator.RunUsageFlusher(flushCtx, time.Duration(cfg.Auth.UsageFlushSeconds)*time.Second)
+	}()
+	if cfg.Auth.BootstrapAdminKey {
+		key, rec, err := authenticator.BootstrapKey(context.Background())
+		switch {
+		case err != nil:
+			slog.Error("auth: create bootstrap admin key", "err", err)
+		case key != "":
+			// Printed exactly once: only the hash is stored. Stderr only, so the key
+			// does not stay in the UI log tail.
+			log.New(os.Stderr, "", log.LstdFlags).Printf("auth: bootstrap admin API key (id=%s, shown only now): %s", rec.ID, key)
+			slog.Info("auth: bootstrap admin API key created (printed to stderr only)", "id", rec.ID)
+		}
+	}
 
 	// Proxy router (API hot path).
 	apiRouter := proxy.NewRouter(cluster, policyStore)
-	apiRouter.NodeOfflineTTL = time.Duration(envOrInt("NODE_OFFLINE_SECONDS", 5)) * time.Second
+	apiRouter.NodeOfflineTTL = time.Duration(cfg.NodeOfflineSeconds) * time.Second
 	apiRouter.Latency = metrics.NewLatencyTracker(0.2)
-
-	// gRPC server (control plane).
-	grpcLis, err := net.Listen("tcp", ":9090")
+	apiRouter.Recency = metrics.NewRecencyTracker()
+	apiRouter.Placement = metrics.NewPlacementTracker(0)
+	apiRouter.ExposeRoutingHeaders = cfg.Proxy.ExposeRoutingHeaders
+	apiRouter.NormalizeModelNames = cfg.Proxy.NormalizeModelNames
+	apiRouter.EmbeddingsRequireReady = cfg.Proxy.EmbeddingsRequireReady
+	apiRouter.EmbeddingsChunkSize = cfg.Proxy.EmbeddingsChunkSize
+	apiRouter.EarlyModelCheck = cfg.Proxy.EarlyModelCheck
+	apiRouter.StreamOverrideHeader = cfg.Proxy.StreamOverrideHeader
+	apiRouter.LoadRetryAfter = time.Duration(cfg.Proxy.LoadRetryAfterSeconds) * time.Second
+	// Per-node upstream connection budget (0 = unlimited).
+	apiRouter.MaxConnsPerNode = cfg.Proxy.MaxConnsPerNode
+	apiRouter.MaxIdleConnsPerNode = cfg.Proxy.MaxIdleConnsPerNode
+	upstreamTLS, err := proxy.NewUpstreamTLS(cfg.Proxy.TLSCAFile, cfg.Proxy.TLSInsecureSkipVerify)
+	if err != nil {
+		log.Fatalf("proxy tls: %v", err)
+	}
+	if cfg.Proxy.TLSInsecureSkipVerify {
+		slog.Warn("proxy: TLS verification of https data plane URLs is disabled")
+	}
+	apiRouter.UpstreamTLS = upstreamTLS
+	apiRouter.UpstreamCredentials, err = proxy.ParseUpstreamCredentials(cfg.Proxy.UpstreamCredentials)
+	if err != nil {
+		log.Fatalf("config: proxy.upstream_credentials: %v", err)
+	}
+	apiRouter.StaticUpstreams, err = proxy.ParseStaticUpstreams(cfg.Proxy.StaticUpstreams)
 	if err != nil {
-		log.Fatalf("grpc listen: %v", err)
+		log.Fatalf("config: proxy.static_upstreams: %v", err)
+	}
+	// Percent by which each model priority point scales load/latency penalties.
+	apiRouter.ModelPriorityWeight = float64(cfg.Proxy.ModelPriorityWeightPercent) / 100
+	apiRouter.PreferLeastModels = cfg.Proxy.PreferLeastModels
+	apiRouter.GenSpeedWeight = int64(cfg.Proxy.GenSpeedWeightMB) << 20
+	apiRouter.OverloadThreshold = float64(cfg.Proxy.OverloadThreshold)
+	apiRouter.PlacementStrategy = cfg.Proxy.PlacementStrategy
+	apiRouter.HashKeyHeader = cfg.Proxy.HashKeyHeader
+	apiRouter.HashPrefixChars = cfg.Proxy.HashPrefixChars
+	apiRouter.Zone = cfg.Proxy.Zone
+	apiRouter.ZoneHeader = cfg.Proxy.ZoneHeader
+	apiRouter.CrossZonePenalty = int64(cfg.Proxy.CrossZonePenaltyMB) << 20
+	apiRouter.MaxBodyBytes = int64(cfg.Proxy.MaxBodyMB) << 20
+	apiRouter.MaxConcurrentRequests = cfg.Proxy.MaxConcurrentRequests
+	apiRouter.PriorityReserve = float64(cfg.Proxy.PriorityReservePercent) / 100
+	apiRouter.BodyReadTimeout = time.Duration(cfg.Proxy.BodyReadTimeoutSeconds) * time.Second
+	apiRouter.ReserveNodes = cfg.Proxy.ReserveNodes
+	apiRouter.ReserveNodeUtil = float64(cfg.Proxy.ReserveNodeUtilPercent) / 100
+	apiRouter.ReserveRAMFraction = float64(cfg.Proxy.ReserveRAMPercent) / 100
+	apiRouter.WarnStructuredOutput = cfg.Proxy.WarnStructuredOutput
+	apiRouter.PromptLog = promptLog
+	apiRouter.Spend = authenticator
+	apiRouter.BudgetWarnOnly = cfg.Auth.BudgetMode == "warn"
+	apiRouter.ExcludeUnknownRAM = cfg.Proxy.ExcludeUnknownRAM
+	apiRouter.StatusStaleAfter = time.Duration(cfg.StatusStaleSeconds) * time.Second
+	apiRouter.StaleNoColdLoads = cfg.Proxy.StaleNoColdLoads
+	apiRouter.RefuseOversizedLoads = cfg.Proxy.RefuseOversizedLoads
+	apiRouter.WaitOnReportedLoads = cfg.Proxy.WaitOnReportedLoads
+	apiRouter.LoaderFailovers = cfg.Proxy.LoaderFailovers
+	apiRouter.InflightTokenUnit = cfg.Proxy.InflightTokenUnit
+	apiRouter.DefaultModerationModel = cfg.Proxy.DefaultModerationModel
+	apiRouter.ModelFields, err = proxy.ParseModelFields(cfg.Proxy.ModelFields)
+	if err != nil {
+		log.Fatalf("config: proxy.model_fields: %v", err)
+	}
+	apiRouter.ThrottleBackoff = time.Duration(cfg.Proxy.UpstreamThrottleBackoffSeconds) * time.Second
+
+	// Development only: reproduce a production state from a debug dump. The restored
+	// nodes are offline, so requests are not routed to them.
+	if cfg.DebugRestoreFile != "" {
+		dump, err := debugdump.Load(cfg.DebugRestoreFile)
+		if err != nil {
+			log.Fatalf("config: debug_restore_file: %v", err)
+		}
+		n := dump.Restore(cluster, apiRouter.Latency, time.Now().Add(-apiRouter.NodeOfflineTTL-time.Second))
+		slog.Warn("restored nodes from debug dump; for development only", "nodes", n, "file", cfg.DebugRestoreFile, "created", dump.CreatedAt.Format(time.RFC3339))
+		if apiRouter.NodeOfflineTTL <= 0 {
+			slog.Warn("node offline TTL is 0, restored nodes count as online and receive requests")
+		}
 	}
 
-	grpcServer := grpc.NewServer()
+	// gRPC server (control plane). Keepalive pings detect agents whose network silently
+	// dropped: their stream ends within about interval + timeout instead of waiting for
+	// TCP timeouts, so the node is detached and goes offline by heartbeat TTL.
+	keepaliveTime := time.Duration(cfg.Control.KeepaliveSeconds) * time.Second
+	keepaliveTimeout := time.Duration(cfg.Control.KeepaliveTimeoutSeconds) * time.Second
+	grpcOpts := []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: agentKeepaliveMin, PermitWithoutStream: true}),
+	}
+	if keepaliveTime > 0 {
+		grpcOpts = append(grpcOpts, grpc.KeepaliveParams(keepalive.ServerParameters{Time: keepaliveTime, Timeout: keepaliveTimeout}))
+	}
+	grpcServer := grpc.NewServer(grpcOpts...)
 	controlSvc := control.NewNodeControlService(cluster, apiRouter)
+	controlSvc.SendRetries = cfg.Control.SendRetries
+	controlSvc.ReconnectGrace = time.Duration(cfg.Control.ReconnectGraceSeconds) * time.Second
+	controlSvc.PingConcurrency = cfg.Control.PingConcurrency
+	controlSvc.PingTimeout = time.Duration(cfg.Control.PingTimeoutSeconds) * time.Second
+	controlSvc.SendTimeout = time.Duration(cfg.Control.SendTimeoutSeconds) * time.Second
+	controlSvc.MaxLoadedAge = time.Duration(cfg.Control.MaxLoadedAgeHours) * time.Hour
+	controlSvc.StatusLogInterval = time.Duration(cfg.Control.StatusLogIntervalSeconds) * time.Second
+	controlSvc.MaxModelsPerNode = cfg.Control.MaxModelsPerNode
+	controlSvc.HelloGrace = time.Duration(cfg.Control.HelloGraceSeconds) * time.Second
+	controlSvc.Activity = activityLog
+	apiRouter.Commands = controlSvc
 	controlplanev1.RegisterNodeControlServer(grpcServer, controlSvc)
 
-	go func() {
-		log.Printf("gRPC listening on :9090")
-		if err := grpcServer.Serve(grpcLis); err != nil {
-			log.Fatalf("grpc serve: %v", err)
+	if !cfg.GRPCOnHTTPPort {
+		grpcLis, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			log.Fatalf("grpc listen: %v", err)
 		}
-	}()
+
+		go func() {
+			log.Printf("gRPC listening on %s", cfg.GRPCAddr)
+			if err := grpcServer.Serve(grpcLis); err != nil {
+				log.Fatalf("grpc serve: %v", err)
+			}
+		}()
+	}
 
 	// Periodic status polling (Server-side heartbeats/pings)
 	go func() {
-		interval := time.Duration(envOrInt("STATUS_POLL_INTERVAL_SECONDS", 10)) * time.Second
+		interval := time.Duration(cfg.StatusPollIntervalSeconds) * time.Second
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 		for {
@@ -79,14 +290,60 @@ func main() {
 		}
 	}()
 
-	// Planner (unload/pressure/ttl automation).
+	// Drop latency/recency entries of nodes and models that are gone, so churning
+	// clusters do not grow these maps forever.
+	if ttl := time.Duration(cfg.MetricsTTLHours) * time.Hour; ttl > 0 {
+		go func() {
+			ticker := time.NewTicker(metricsPruneInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				cutoff := time.Now().Add(-ttl)
+				n := apiRouter.Latency.Prune(cutoff) + apiRouter.Recency.Prune(cutoff) + apiRouter.Placement.Prune(cutoff)
+				if n > 0 {
+					log.Printf("metrics: pruned %d stale entries", n)
+				}
+			}
+		}()
+	}
+
+	// Close the connection pools of nodes that are gone or changed their data plane URL,
+	// and forget the 429s of gone nodes.
+	go func() {
+		ticker := time.NewTicker(proxyPruneInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if n := apiRouter.PruneProxies(time.Now(), proxyEvictAfter); n > 0 {
+				log.Printf("proxy: dropped %d cached node proxies", n)
+			}
+			if n := apiRouter.PruneThrottles(time.Now(), proxyEvictAfter); n > 0 {
+				log.Printf("proxy: dropped the 429 records of %d gone nodes", n)
+			}
+			apiRouter.PruneUnroutable(time.Now())
+		}
+	}()
+
+	// Planner (unload/pressure/ttl automation, warm pool).
+	warmPool, err := planner.ParseWarmPool(cfg.Planner.WarmPool)
+	if err != nil {
+		log.Fatalf("config: planner.warm_pool: %v", err)
+	}
 	pl := &planner.Planner{
 		Cluster:      cluster,
 		Policies:     policyStore,
 		Commands:     controlSvc,
 		Activity:     activityLog,
-		MinFreeBytes: uint64(envOrInt("MIN_FREE_RAM_MB", 2048)) * 1024 * 1024,
-		Interval:     time.Duration(envOrInt("PLANNER_INTERVAL_SECONDS", 2)) * time.Second,
+		MinFreeBytes: uint64(cfg.Planner.MinFreeRAMMB) * 1024 * 1024,
+		Interval:     time.Duration(cfg.Planner.IntervalSeconds) * time.Second,
+		MinResident:  time.Duration(cfg.Planner.MinResidentSeconds) * time.Second,
+		Maintenance:  maint,
+		WarmPool:     warmPool,
+		Loader:       apiRouter,
+
+		ErrorUnloadAfter: time.Duration(cfg.Planner.ErrorUnloadAfterSeconds) * time.Second,
+		UnloadRetries:    cfg.Planner.UnloadRetries,
+
+		NormalizeModelNames: apiRouter.NormalizeModelNames,
+		NodeOfflineTTL:      apiRouter.NodeOfflineTTL,
 	}
 	go pl.Run(context.Background())
 
@@ -95,7 +352,7 @@ func main() {
 
 	// Root redirect to UI.
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		http.Redirect(w, r, "/ui/", http.StatusFound)
+		http.Redirect(w, r, cfg.BasePath+"/ui/", http.StatusFound)
 	})
 
 	// UI.
@@ -104,47 +361,217 @@ func main() {
 		log.Fatalf("ui init: %v", err)
 	}
 	uiHandler.NodeOfflineTTL = apiRouter.NodeOfflineTTL
+	uiHandler.StatusStaleAfter = apiRouter.StatusStaleAfter
+	uiHandler.MaxEventStreams = cfg.UIMaxEventStreams
+	uiHandler.BasePath = cfg.BasePath
+	uiHandler.NormalizeModelNames = apiRouter.NormalizeModelNames
+	uiHandler.Auth = authenticator
+	uiHandler.Recency = apiRouter.Recency
+	uiHandler.Placement = apiRouter.Placement
+	uiHandler.Diagnostics = apiRouter
+	uiHandler.Replicas = apiRouter
+	uiHandler.Gates = apiRouter
+	uiHandler.Connections = controlSvc
+	uiHandler.Logs = logs
+	uiHandler.Maintenance = maint
+	uiHandler.Settings = func() any {
+		return map[string]any{
+			"config": cfg.Redacted(),
+			"router": apiRouter.Settings(),
+			"planner": map[string]any{
+				"min_free_bytes":     pl.MinFreeBytes,
+				"interval":           pl.Interval.String(),
+				"min_resident":       pl.MinResident.String(),
+				"error_unload_after": pl.ErrorUnloadAfter.String(),
+				"unload_retries":     pl.UnloadRetries,
+			},
+		}
+	}
 	uiHandler.Register(mux)
 
+	if cfg.MetricsAddr == "" {
+		mux.Handle("/metrics", metricsHandler(apiRouter, authenticator, true))
+	} else {
+		// Separate listener, so scraping can be firewalled apart from the API port.
+		mux.Handle("/metrics", http.NotFoundHandler())
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metricsHandler(apiRouter, authenticator, false))
+		uiHandler.RegisterProbes(metricsMux)
+		metricsSrv := &http.Server{
+			Addr:              cfg.MetricsAddr,
+			Handler:           metricsMux,
+			ReadHeaderTimeout: 5 * time.Second,
+			IdleTimeout:       120 * time.Second,
+		}
+		go func() {
+			log.Printf("metrics listening on %s", cfg.MetricsAddr)
+			if err := metricsSrv.ListenAndServe(); err != nil {
+				log.Fatalf("metrics serve: %v", err)
+			}
+		}()
+	}
+
 	// API endpoints.
 	modelsHandler := proxy.NewModelsHandler(cluster)
+	modelsHandler.NodeOfflineTTL = apiRouter.NodeOfflineTTL
+	modelsHandler.NormalizeModelNames = apiRouter.NormalizeModelNames
+	modelsHandler.HideLoadingModels = cfg.Proxy.HideLoadingModels
+	modelsHandler.StaticUpstreams = apiRouter.StaticUpstreams
 
-	// Create a sub-mux or just wrap the handlers for API.
-	// For simplicity, we wrap the individual handlers if they need auth.
+	// Register the API mux into the main mux, wrapped with Auth middleware.
 	apiMux := http.NewServeMux()
-	apiMux.HandleFunc("/v1/models", modelsHandler.HandleModels)
-	apiMux.HandleFunc("/v1/chat/completions", apiRouter.HandleChatCompletions)
-	apiMux.HandleFunc("/v1/embeddings", apiRouter.HandleEmbeddings)
-	apiMux.HandleFunc("/v1/completions", apiRouter.HandleCompletions)
+	apiHandler := apiChain(apiRouter, authenticator, apiMux)
+	registerEndpoints(mux, apiMux, apiHandler, []apiEndpoint{
+		{"/v1/models", cfg.Endpoints.Models, modelsHandler.HandleModels, false},
+		{"/v1/models/", cfg.Endpoints.Models, modelsHandler.HandleModelCapabilities, false},
+		{"/v1/models/load", cfg.Endpoints.ModelLoad, apiRouter.HandleModelLoad, true},
+		{"/v1/chat/completions", cfg.Endpoints.ChatCompletions, apiRouter.HandleChatCompletions, true},
+		{"/v1/embeddings", cfg.Endpoints.Embeddings, apiRouter.HandleEmbeddings, true},
+		{"/v1/completions", cfg.Endpoints.Completions, apiRouter.HandleCompletions, true},
+		{"/v1/moderations", cfg.Endpoints.Moderations, apiRouter.HandleModerations, true},
+	}, cfg.Proxy.AcceptTrailingSlash)
+	mux.Handle("/v1/", apiHandler)
 
-	// Register the API mux into the main mux, wrapped with Auth middleware.
-	mux.Handle("/v1/", authenticator.Middleware(apiMux))
+	// Azure OpenAI style paths, rewritten to /v1/ before authentication.
+	if cfg.Proxy.AzurePaths {
+		mux.Handle("/openai/deployments/", proxy.AzurePaths(apiHandler))
+	}
 
-	// Wrap mux with CORS (optional but recommended).
-	handler := httpx.CORS{AllowOrigin: "*"}.Wrap(mux)
+	// Optional public model catalog (more specific pattern than /v1/).
+	if cfg.Auth.AllowAnonymousModels && cfg.Endpoints.Models {
+		mux.Handle("/v1/models", authenticator.OptionalMiddleware(apiRouter.LimitConcurrency(http.HandlerFunc(modelsHandler.HandleModels))))
+	}
+
+	// Wrap mux with CORS (optional but recommended). With a base path, routes are
+	// also reachable below it (gRPC stays at the root, see GRPCMux below).
+	handler := httpx.CORS{AllowOrigin: "*"}.Wrap(httpx.StripBasePath(cfg.BasePath, mux))
 
 	srv := &http.Server{
-		Addr:              ":8080",
+		Addr:              cfg.HTTPAddr,
 		Handler:           handler,
 		ReadHeaderTimeout: 5 * time.Second,
 		// Important: do not set WriteTimeout for streaming responses.
 		IdleTimeout: 120 * time.Second,
 	}
 
-	log.Printf("HTTP listening on :8080")
-	if err := srv.ListenAndServe(); err != nil {
+	if cfg.GRPCOnHTTPPort {
+		// gRPC requires HTTP/2. With TLS it is negotiated via ALPN; without TLS the
+		// agent connects with prior knowledge (unencrypted HTTP/2).
+		srv.Handler = httpx.GRPCMux(grpcServer, handler)
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+		// net/http serves the HTTP/2 connection here, so it sends the keepalive pings.
+		if keepaliveTime > 0 {
+			srv.HTTP2 = &http.HTTP2Config{SendPingTimeout: keepaliveTime, PingTimeout: keepaliveTimeout}
+		}
+		log.Printf("gRPC multiplexed on HTTP port %s", cfg.HTTPAddr)
+	}
+
+	// On SIGINT/SIGTERM stop accepting requests, let those in flight finish (at most
+	// shutdownTimeout) and flush the API key usage before exiting.
+	sigCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-sigCtx.Done()
+		log.Printf("shutting down (waiting up to %s for requests in flight)", shutdownTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Warn("http shutdown", "err", err)
+		}
+	}()
+
+	if cfg.TLSCertFile != "" {
+		log.Printf("HTTPS listening on %s", cfg.HTTPAddr)
+		err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	} else {
+		log.Printf("HTTP listening on %s", cfg.HTTPAddr)
+		err = srv.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("http serve: %v", err)
 	}
+
+	<-shutdownDone
+	stopFlusher()
+	<-flushed
+	log.Printf("usage flushed, exiting")
 }
 
-func envOrInt(k string, def int) int {
-	v := os.Getenv(k)
-	if v == "" {
-		return def
+// metricsHandler serves the Prometheus metrics (placement modes and loader waits per
+// model, limits, node status, proxy cache, throttling). On the API port (public) it
+// requires an API key like /v1/: model and node names are not for every client that
+// reaches the port. A separate METRICS_ADDR listener is firewalled instead.
+func metricsHandler(r *proxy.Router, a *auth.Authenticator, public bool) http.Handler {
+	h := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.Placement.WritePrometheus(w)
+		_ = r.WriteLimitMetrics(w)
+		_ = r.WriteStatusMetrics(w)
+		_ = r.WriteProxyCacheMetrics(w)
+		_ = r.WriteThrottleMetrics(w)
+	})
+	if public {
+		return a.Middleware(h)
 	}
-	n, err := strconv.Atoi(v)
-	if err != nil {
-		return def
+	return h
+}
+
+// apiEndpoint is an API route that can be switched off (see config.Endpoints).
+type apiEndpoint struct {
+	pattern string
+	enabled bool
+	handler http.HandlerFunc
+	slash   bool // also served as pattern+"/" with acceptSlash
+}
+
+// apiChain wraps the API handler next with authentication, the concurrency cap, the
+// budgets and the routing debug header. Only the API goes through it: the UI, probes
+// and metrics stay reachable while the API is saturated. The concurrency cap follows
+// authentication: X-Priority high only counts for admin keys.
+func apiChain(r *proxy.Router, a *auth.Authenticator, next http.Handler) http.Handler {
+	return a.Middleware(r.LimitConcurrency(r.EnforceBudget(r.RouteDebug(next))))
+}
+
+// registerEndpoints registers the enabled endpoints on apiMux, which apiHandler serves
+// behind authentication. Disabled endpoints get a 404 on the main mux (more specific
+// than /v1/), so they answer before authentication. An enabled endpoint below a
+// disabled subtree ("/v1/models/load" below "/v1/models/") is routed to apiHandler on
+// the main mux as well, or the subtree's 404 would shadow it. With acceptSlash the
+// endpoints marked slash are also served as "/v1/.../".
+func registerEndpoints(mux, apiMux *http.ServeMux, apiHandler http.Handler, endpoints []apiEndpoint, acceptSlash bool) {
+	var disabledTrees []string
+	for _, e := range endpoints {
+		if !e.enabled && strings.HasSuffix(e.pattern, "/") {
+			disabledTrees = append(disabledTrees, e.pattern)
+		}
+	}
+
+	for _, e := range endpoints {
+		slash := e.slash && acceptSlash
+		if !e.enabled {
+			mux.Handle(e.pattern, http.NotFoundHandler())
+			if slash {
+				mux.Handle(e.pattern+"/{$}", http.NotFoundHandler())
+			}
+			continue
+		}
+		apiMux.HandleFunc(e.pattern, e.handler)
+		if slash {
+			apiMux.Handle(e.pattern+"/{$}", httpx.TrimTrailingSlash(e.handler))
+		}
+		for _, tree := range disabledTrees {
+			if strings.HasPrefix(e.pattern, tree) {
+				mux.Handle(e.pattern, apiHandler)
+				if slash {
+					mux.Handle(e.pattern+"/{$}", apiHandler)
+				}
+				break
+			}
+		}
 	}
-	return n
 }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/config"
+	"github.com/mcules/llm-router/internal/metrics"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/proxy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestMetricsHandlerAuth(t *testing.T) {
+	store, err := policy.Open(filepath.Join(t.TempDir(), "policies.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	a := auth.NewAuthenticator(store)
+	key, _, err := a.GenerateKey(context.Background(), "prometheus", "admin", "*", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := proxy.NewRouter(state.NewClusterState(), store)
+	r.Placement = metrics.NewPlacementTracker(0)
+	r.Placement.ObservePlacement("qwen3-8b", "cold")
+
+	for _, tc := range []struct {
+		name   string
+		public bool
+		header string
+		want   int
+	}{
+		{"API port without key", true, "", http.StatusUnauthorized},
+		{"API port with invalid key", true, "Bearer sk-invalid", http.StatusUnauthorized},
+		{"API port with key", true, "Bearer " + key, http.StatusOK},
+		{"metrics listener without key", false, "", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			w := httptest.NewRecorder()
+			metricsHandler(r, a, tc.public).ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+			if leaked := strings.Contains(w.Body.String(), "qwen3-8b"); leaked != (tc.want == http.StatusOK) {
+				t.Errorf("model name in body = %v, want %v", leaked, tc.want == http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestRegisterEndpoints(t *testing.T) {
+	serve := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(name + " " + r.URL.Path)) }
+	}
+	build := func(e config.Endpoints, slash bool) http.Handler {
+		mux, apiMux := http.NewServeMux(), http.NewServeMux()
+		// Stands in for authentication: disabled endpoints must answer before it.
+		apiHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			apiMux.ServeHTTP(w, r)
+		})
+		registerEndpoints(mux, apiMux, apiHandler, []apiEndpoint{
+			{"/v1/models", e.Models, serve("models"), false},
+			{"/v1/models/", e.Models, serve("capabilities"), false},
+			{"/v1/models/load", e.ModelLoad, serve("load"), true},
+			{"/v1/chat/completions", e.ChatCompletions, serve("chat"), true},
+			{"/v1/embeddings", e.Embeddings, serve("embeddings"), true},
+		}, slash)
+		mux.Handle("/v1/", apiHandler)
+		return mux
+	}
+	all := config.Endpoints{ChatCompletions: true, Embeddings: true, Models: true, ModelLoad: true}
+
+	for _, tc := range []struct {
+		name       string
+		endpoints  config.Endpoints
+		slash      bool
+		path       string
+		wantCode   int
+		wantBody   string
+		beforeAuth bool // 404 of a disabled endpoint
+	}{
+		{"enabled", all, false, "/v1/chat/completions", http.StatusOK, "chat /v1/chat/completions", false},
+		{"disabled without key", config.Endpoints{Embeddings: true}, false, "/v1/chat/completions", http.StatusNotFound, "", true},
+		{"disabled slash", config.Endpoints{Embeddings: true}, true, "/v1/chat/completions/", http.StatusNotFound, "", true},
+		{"slash enabled", all, true, "/v1/chat/completions/", http.StatusOK, "chat /v1/chat/completions", false},
+		{"slash off", all, false, "/v1/chat/completions/", http.StatusNotFound, "", false},
+		{"catalog disabled", config.Endpoints{ModelLoad: true}, false, "/v1/models", http.StatusNotFound, "", true},
+		{"capabilities disabled", config.Endpoints{ModelLoad: true}, false, "/v1/models/m/capabilities", http.StatusNotFound, "", true},
+		{"load without catalog", config.Endpoints{ModelLoad: true}, false, "/v1/models/load", http.StatusOK, "load /v1/models/load", false},
+		{"load slash without catalog", config.Endpoints{ModelLoad: true}, true, "/v1/models/load/", http.StatusOK, "load /v1/models/load", false},
+		{"catalog without load", config.Endpoints{Models: true}, false, "/v1/models/load", http.StatusNotFound, "", true},
+		{"catalog still served", config.Endpoints{Models: true}, false, "/v1/models/m/capabilities", http.StatusOK, "capabilities /v1/models/m/capabilities", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{"", "Bearer sk-test"} {
+				req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+				if key != "" {
+					req.Header.Set("Authorization", key)
+				}
+				w := httptest.NewRecorder()
+				build(tc.endpoints, tc.slash).ServeHTTP(w, req)
+
+				// Only disabled endpoints answer without a key.
+				want := tc.wantCode
+				if key == "" && !tc.beforeAuth {
+					want = http.StatusUnauthorized
+				}
+				if w.Code != want {
+					t.Fatalf("key %q: status = %d, want %d", key, w.Code, want)
+				}
+				if want == http.StatusOK && w.Body.String() != tc.wantBody {
+					t.Errorf("served %q, want %q", w.Body.String(), tc.wantBody)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIChainLeavesUIReachable(t *testing.T) {
+	store, err := policy.Open(filepath.Join(t.TempDir(), "policies.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	a := auth.NewAuthenticator(store)
+	key, _, err := a.GenerateKey(context.Background(), "client", "alice", "*", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := proxy.NewRouter(state.NewClusterState(), store)
+	r.MaxConcurrentRequests = 1
+	release := make(chan struct{})
+	started := make(chan struct{})
+	api := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", apiChain(r, a, api))
+	mux.HandleFunc("/ui/", func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("ui")) })
+
+	serve := func(path string) int {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serve("/v1/chat/completions")
+	}()
+	<-started
+	t.Cleanup(func() { close(release); <-done })
+
+	if code := serve("/v1/chat/completions"); code != http.StatusServiceUnavailable {
+		t.Errorf("API while saturated = %d, want 503", code)
+	}
+	if code := serve("/ui/"); code != http.StatusOK {
+		t.Errorf("UI while the API is saturated = %d, want 200", code)
+	}
+}
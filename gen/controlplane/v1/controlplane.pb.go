@@ -181,6 +181,8 @@ type ServerMessage struct {
 	//	*ServerMessage_Hello
 	//	*ServerMessage_UnloadModel
 	//	*ServerMessage_Ping
+	//	*ServerMessage_CancelLoad
+	//	*ServerMessage_LoadModel
 	Msg           isServerMessage_Msg `protobuf_oneof:"msg"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -250,6 +252,24 @@ func (x *ServerMessage) GetPing() *Ping {
 	return nil
 }
 
+func (x *ServerMessage) GetCancelLoad() *CancelLoad {
+	if x != nil {
+		if x, ok := x.Msg.(*ServerMessage_CancelLoad); ok {
+			return x.CancelLoad
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetLoadModel() *LoadModel {
+	if x != nil {
+		if x, ok := x.Msg.(*ServerMessage_LoadModel); ok {
+			return x.LoadModel
+		}
+	}
+	return nil
+}
+
 type isServerMessage_Msg interface {
 	isServerMessage_Msg()
 }
@@ -266,18 +286,39 @@ type ServerMessage_Ping struct {
 	Ping *Ping `protobuf:"bytes,3,opt,name=ping,proto3,oneof"`
 }
 
+type ServerMessage_CancelLoad struct {
+	CancelLoad *CancelLoad `protobuf:"bytes,4,opt,name=cancel_load,json=cancelLoad,proto3,oneof"`
+}
+
+type ServerMessage_LoadModel struct {
+	LoadModel *LoadModel `protobuf:"bytes,5,opt,name=load_model,json=loadModel,proto3,oneof"`
+}
+
 func (*ServerMessage_Hello) isServerMessage_Msg() {}
 
 func (*ServerMessage_UnloadModel) isServerMessage_Msg() {}
 
 func (*ServerMessage_Ping) isServerMessage_Msg() {}
 
+func (*ServerMessage_CancelLoad) isServerMessage_Msg() {}
+
+func (*ServerMessage_LoadModel) isServerMessage_Msg() {}
+
 type NodeHello struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
-	LlamaBaseUrl  string                 `protobuf:"bytes,3,opt,name=llama_base_url,json=llamaBaseUrl,proto3" json:"llama_base_url,omitempty"` // agent -> llama (internal), e.g. http://llama:8001
-	DataPlaneUrl  string                 `protobuf:"bytes,4,opt,name=data_plane_url,json=dataPlaneUrl,proto3" json:"data_plane_url,omitempty"` // server -> llama (external), e.g. http://node1:8001
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	NodeId       string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Version      string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	LlamaBaseUrl string                 `protobuf:"bytes,3,opt,name=llama_base_url,json=llamaBaseUrl,proto3" json:"llama_base_url,omitempty"` // agent -> llama (internal), e.g. http://llama:8001
+	DataPlaneUrl string                 `protobuf:"bytes,4,opt,name=data_plane_url,json=dataPlaneUrl,proto3" json:"data_plane_url,omitempty"` // server -> llama (external), e.g. http://node1:8001
+	Zone         string                 `protobuf:"bytes,5,opt,name=zone,proto3" json:"zone,omitempty"`                                       // topology label for zone-aware placement (optional)
+	// TLS of an https data_plane_url (optional): PEM CA bundle the server trusts for this
+	// node in addition to its own roots, or skip certificate verification entirely.
+	DataPlaneCaPem       string `protobuf:"bytes,6,opt,name=data_plane_ca_pem,json=dataPlaneCaPem,proto3" json:"data_plane_ca_pem,omitempty"`
+	DataPlaneTlsInsecure bool   `protobuf:"varint,7,opt,name=data_plane_tls_insecure,json=dataPlaneTlsInsecure,proto3" json:"data_plane_tls_insecure,omitempty"`
+	// Upstream paths for backends that do not serve the OpenAI paths (optional). Keys are
+	// logical endpoints ("chat", "completions", "embeddings", "moderations", "models"),
+	// optionally per model ("chat:<model id>"); values are paths below data_plane_url.
+	EndpointPaths map[string]string `protobuf:"bytes,8,rep,name=endpoint_paths,json=endpointPaths,proto3" json:"endpoint_paths,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -340,6 +381,34 @@ func (x *NodeHello) GetDataPlaneUrl() string {
 	return ""
 }
 
+func (x *NodeHello) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *NodeHello) GetDataPlaneCaPem() string {
+	if x != nil {
+		return x.DataPlaneCaPem
+	}
+	return ""
+}
+
+func (x *NodeHello) GetDataPlaneTlsInsecure() bool {
+	if x != nil {
+		return x.DataPlaneTlsInsecure
+	}
+	return false
+}
+
+func (x *NodeHello) GetEndpointPaths() map[string]string {
+	if x != nil {
+		return x.EndpointPaths
+	}
+	return nil
+}
+
 type NodeStatus struct {
 	state             protoimpl.MessageState `protogen:"open.v1"`
 	TsUnixMs          int64                  `protobuf:"varint,1,opt,name=ts_unix_ms,json=tsUnixMs,proto3" json:"ts_unix_ms,omitempty"`
@@ -347,8 +416,17 @@ type NodeStatus struct {
 	RamAvailableBytes uint64                 `protobuf:"varint,3,opt,name=ram_available_bytes,json=ramAvailableBytes,proto3" json:"ram_available_bytes,omitempty"`
 	InflightRequests  uint32                 `protobuf:"varint,4,opt,name=inflight_requests,json=inflightRequests,proto3" json:"inflight_requests,omitempty"`
 	Models            []*ModelResidency      `protobuf:"bytes,5,rep,name=models,proto3" json:"models,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	// EWMA generation speed of recent requests in tokens/sec (0 = unknown), best-effort.
+	GenTokensPerSecond float64 `protobuf:"fixed64,6,opt,name=gen_tokens_per_second,json=genTokensPerSecond,proto3" json:"gen_tokens_per_second,omitempty"`
+	// Where ram_available_bytes comes from: "memavailable" (accurate), "memfree" (kernel
+	// without MemAvailable, excludes reclaimable cache), "memfree+cache" (estimate from
+	// MemFree, Buffers, Cached and SReclaimable) or "static" (no meminfo). Empty = unknown.
+	RamSource string `protobuf:"bytes,7,opt,name=ram_source,json=ramSource,proto3" json:"ram_source,omitempty"`
+	// Number of llama.cpp slots, i.e. requests the node serves in parallel (0 = unknown,
+	// e.g. /slots disabled).
+	TotalSlots    uint32 `protobuf:"varint,8,opt,name=total_slots,json=totalSlots,proto3" json:"total_slots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *NodeStatus) Reset() {
@@ -416,11 +494,35 @@ func (x *NodeStatus) GetModels() []*ModelResidency {
 	return nil
 }
 
+func (x *NodeStatus) GetGenTokensPerSecond() float64 {
+	if x != nil {
+		return x.GenTokensPerSecond
+	}
+	return 0
+}
+
+func (x *NodeStatus) GetRamSource() string {
+	if x != nil {
+		return x.RamSource
+	}
+	return ""
+}
+
+func (x *NodeStatus) GetTotalSlots() uint32 {
+	if x != nil {
+		return x.TotalSlots
+	}
+	return 0
+}
+
 type ModelResidency struct {
 	state             protoimpl.MessageState `protogen:"open.v1"`
 	ModelId           string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
 	State             ModelState             `protobuf:"varint,2,opt,name=state,proto3,enum=controlplane.v1.ModelState" json:"state,omitempty"`
 	LoadedSinceUnixMs int64                  `protobuf:"varint,3,opt,name=loaded_since_unix_ms,json=loadedSinceUnixMs,proto3" json:"loaded_since_unix_ms,omitempty"`
+	Error             string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`                              // last load error (state ERROR), best-effort
+	Capabilities      []string               `protobuf:"bytes,5,rep,name=capabilities,proto3" json:"capabilities,omitempty"`                // e.g. "chat", "completion", "embeddings", "tools", "streaming" (best-effort)
+	MaxContext        uint32                 `protobuf:"varint,6,opt,name=max_context,json=maxContext,proto3" json:"max_context,omitempty"` // max context length in tokens, 0 = unknown
 	unknownFields     protoimpl.UnknownFields
 	sizeCache         protoimpl.SizeCache
 }
@@ -476,6 +578,27 @@ func (x *ModelResidency) GetLoadedSinceUnixMs() int64 {
 	return 0
 }
 
+func (x *ModelResidency) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ModelResidency) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *ModelResidency) GetMaxContext() uint32 {
+	if x != nil {
+		return x.MaxContext
+	}
+	return 0
+}
+
 type UnloadModel struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
@@ -528,6 +651,114 @@ func (x *UnloadModel) GetModelId() string {
 	return ""
 }
 
+// CancelLoad aborts an in-progress load of a model (unloads it right after the load
+// if the backend cannot abort). The agent acks once the model is no longer loading.
+type CancelLoad struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	ModelId       string                 `protobuf:"bytes,2,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelLoad) Reset() {
+	*x = CancelLoad{}
+	mi := &file_controlplane_v1_controlplane_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelLoad) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelLoad) ProtoMessage() {}
+
+func (x *CancelLoad) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_controlplane_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelLoad.ProtoReflect.Descriptor instead.
+func (*CancelLoad) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_controlplane_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CancelLoad) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *CancelLoad) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+// LoadModel starts loading a model (eager load, POST /v1/models/load). The agent acks
+// once the backend accepted the load; progress is reported with NodeStatus.
+type LoadModel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	ModelId       string                 `protobuf:"bytes,2,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoadModel) Reset() {
+	*x = LoadModel{}
+	mi := &file_controlplane_v1_controlplane_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoadModel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadModel) ProtoMessage() {}
+
+func (x *LoadModel) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_controlplane_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadModel.ProtoReflect.Descriptor instead.
+func (*LoadModel) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_controlplane_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LoadModel) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *LoadModel) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
 type CommandAck struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
@@ -539,7 +770,7 @@ type CommandAck struct {
 
 func (x *CommandAck) Reset() {
 	*x = CommandAck{}
-	mi := &file_controlplane_v1_controlplane_proto_msgTypes[6]
+	mi := &file_controlplane_v1_controlplane_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -551,7 +782,7 @@ func (x *CommandAck) String() string {
 func (*CommandAck) ProtoMessage() {}
 
 func (x *CommandAck) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_controlplane_proto_msgTypes[6]
+	mi := &file_controlplane_v1_controlplane_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -564,7 +795,7 @@ func (x *CommandAck) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CommandAck.ProtoReflect.Descriptor instead.
 func (*CommandAck) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_controlplane_proto_rawDescGZIP(), []int{6}
+	return file_controlplane_v1_controlplane_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *CommandAck) GetRequestId() string {
@@ -597,7 +828,7 @@ type ServerHello struct {
 
 func (x *ServerHello) Reset() {
 	*x = ServerHello{}
-	mi := &file_controlplane_v1_controlplane_proto_msgTypes[7]
+	mi := &file_controlplane_v1_controlplane_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -609,7 +840,7 @@ func (x *ServerHello) String() string {
 func (*ServerHello) ProtoMessage() {}
 
 func (x *ServerHello) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_controlplane_proto_msgTypes[7]
+	mi := &file_controlplane_v1_controlplane_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -622,7 +853,7 @@ func (x *ServerHello) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ServerHello.ProtoReflect.Descriptor instead.
 func (*ServerHello) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_controlplane_proto_rawDescGZIP(), []int{7}
+	return file_controlplane_v1_controlplane_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ServerHello) GetServerVersion() string {
@@ -641,7 +872,7 @@ type Ping struct {
 
 func (x *Ping) Reset() {
 	*x = Ping{}
-	mi := &file_controlplane_v1_controlplane_proto_msgTypes[8]
+	mi := &file_controlplane_v1_controlplane_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -653,7 +884,7 @@ func (x *Ping) String() string {
 func (*Ping) ProtoMessage() {}
 
 func (x *Ping) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_controlplane_proto_msgTypes[8]
+	mi := &file_controlplane_v1_controlplane_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -666,7 +897,7 @@ func (x *Ping) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Ping.ProtoReflect.Descriptor instead.
 func (*Ping) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_controlplane_proto_rawDescGZIP(), []int{8}
+	return file_controlplane_v1_controlplane_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *Ping) GetTsUnixMs() int64 {
@@ -685,17 +916,28 @@ const file_controlplane_v1_controlplane_proto_rawDesc = "" +
 	"\x05hello\x18\x01 \x01(\v2\x1a.controlplane.v1.NodeHelloH\x00R\x05hello\x125\n" +
 	"\x06status\x18\x02 \x01(\v2\x1b.controlplane.v1.NodeStatusH\x00R\x06status\x12/\n" +
 	"\x03ack\x18\x03 \x01(\v2\x1b.controlplane.v1.CommandAckH\x00R\x03ackB\x05\n" +
-	"\x03msg\"\xbc\x01\n" +
+	"\x03msg\"\xb9\x02\n" +
 	"\rServerMessage\x124\n" +
 	"\x05hello\x18\x01 \x01(\v2\x1c.controlplane.v1.ServerHelloH\x00R\x05hello\x12A\n" +
 	"\funload_model\x18\x02 \x01(\v2\x1c.controlplane.v1.UnloadModelH\x00R\vunloadModel\x12+\n" +
-	"\x04ping\x18\x03 \x01(\v2\x15.controlplane.v1.PingH\x00R\x04pingB\x05\n" +
-	"\x03msg\"\x8a\x01\n" +
+	"\x04ping\x18\x03 \x01(\v2\x15.controlplane.v1.PingH\x00R\x04ping\x12>\n" +
+	"\vcancel_load\x18\x04 \x01(\v2\x1b.controlplane.v1.CancelLoadH\x00R\n" +
+	"cancelLoad\x12;\n" +
+	"\n" +
+	"load_model\x18\x05 \x01(\v2\x1a.controlplane.v1.LoadModelH\x00R\tloadModelB\x05\n" +
+	"\x03msg\"\x98\x03\n" +
 	"\tNodeHello\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
 	"\aversion\x18\x02 \x01(\tR\aversion\x12$\n" +
 	"\x0ellama_base_url\x18\x03 \x01(\tR\fllamaBaseUrl\x12$\n" +
-	"\x0edata_plane_url\x18\x04 \x01(\tR\fdataPlaneUrl\"\xe8\x01\n" +
+	"\x0edata_plane_url\x18\x04 \x01(\tR\fdataPlaneUrl\x12\x12\n" +
+	"\x04zone\x18\x05 \x01(\tR\x04zone\x12)\n" +
+	"\x11data_plane_ca_pem\x18\x06 \x01(\tR\x0edataPlaneCaPem\x125\n" +
+	"\x17data_plane_tls_insecure\x18\a \x01(\bR\x14dataPlaneTlsInsecure\x12T\n" +
+	"\x0eendpoint_paths\x18\b \x03(\v2-.controlplane.v1.NodeHello.EndpointPathsEntryR\rendpointPaths\x1a@\n" +
+	"\x12EndpointPathsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xdb\x02\n" +
 	"\n" +
 	"NodeStatus\x12\x1c\n" +
 	"\n" +
@@ -703,14 +945,32 @@ const file_controlplane_v1_controlplane_proto_rawDesc = "" +
 	"\x0fram_total_bytes\x18\x02 \x01(\x04R\rramTotalBytes\x12.\n" +
 	"\x13ram_available_bytes\x18\x03 \x01(\x04R\x11ramAvailableBytes\x12+\n" +
 	"\x11inflight_requests\x18\x04 \x01(\rR\x10inflightRequests\x127\n" +
-	"\x06models\x18\x05 \x03(\v2\x1f.controlplane.v1.ModelResidencyR\x06models\"\x8f\x01\n" +
+	"\x06models\x18\x05 \x03(\v2\x1f.controlplane.v1.ModelResidencyR\x06models\x121\n" +
+	"\x15gen_tokens_per_second\x18\x06 \x01(\x01R\x12genTokensPerSecond\x12\x1d\n" +
+	"\n" +
+	"ram_source\x18\a \x01(\tR\tramSource\x12\x1f\n" +
+	"\vtotal_slots\x18\b \x01(\rR\n" +
+	"totalSlots\"\xea\x01\n" +
 	"\x0eModelResidency\x12\x19\n" +
 	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x121\n" +
 	"\x05state\x18\x02 \x01(\x0e2\x1b.controlplane.v1.ModelStateR\x05state\x12/\n" +
-	"\x14loaded_since_unix_ms\x18\x03 \x01(\x03R\x11loadedSinceUnixMs\"G\n" +
+	"\x14loaded_since_unix_ms\x18\x03 \x01(\x03R\x11loadedSinceUnixMs\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\"\n" +
+	"\fcapabilities\x18\x05 \x03(\tR\fcapabilities\x12\x1f\n" +
+	"\vmax_context\x18\x06 \x01(\rR\n" +
+	"maxContext\"G\n" +
 	"\vUnloadModel\x12\x1d\n" +
 	"\n" +
 	"request_id\x18\x01 \x01(\tR\trequestId\x12\x19\n" +
+	"\bmodel_id\x18\x02 \x01(\tR\amodelId\"F\n" +
+	"\n" +
+	"CancelLoad\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x19\n" +
+	"\bmodel_id\x18\x02 \x01(\tR\amodelId\"E\n" +
+	"\tLoadModel\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x19\n" +
 	"\bmodel_id\x18\x02 \x01(\tR\amodelId\"Q\n" +
 	"\n" +
 	"CommandAck\x12\x1d\n" +
@@ -746,7 +1006,7 @@ func file_controlplane_v1_controlplane_proto_rawDescGZIP() []byte {
 }
 
 var file_controlplane_v1_controlplane_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_controlplane_v1_controlplane_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_controlplane_v1_controlplane_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_controlplane_v1_controlplane_proto_goTypes = []any{
 	(ModelState)(0),        // 0: controlplane.v1.ModelState
 	(*NodeMessage)(nil),    // 1: controlplane.v1.NodeMessage
@@ -755,26 +1015,32 @@ var file_controlplane_v1_controlplane_proto_goTypes = []any{
 	(*NodeStatus)(nil),     // 4: controlplane.v1.NodeStatus
 	(*ModelResidency)(nil), // 5: controlplane.v1.ModelResidency
 	(*UnloadModel)(nil),    // 6: controlplane.v1.UnloadModel
-	(*CommandAck)(nil),     // 7: controlplane.v1.CommandAck
-	(*ServerHello)(nil),    // 8: controlplane.v1.ServerHello
-	(*Ping)(nil),           // 9: controlplane.v1.Ping
+	(*CancelLoad)(nil),     // 7: controlplane.v1.CancelLoad
+	(*LoadModel)(nil),      // 8: controlplane.v1.LoadModel
+	(*CommandAck)(nil),     // 9: controlplane.v1.CommandAck
+	(*ServerHello)(nil),    // 10: controlplane.v1.ServerHello
+	(*Ping)(nil),           // 11: controlplane.v1.Ping
+	nil,                    // 12: controlplane.v1.NodeHello.EndpointPathsEntry
 }
 var file_controlplane_v1_controlplane_proto_depIdxs = []int32{
-	3, // 0: controlplane.v1.NodeMessage.hello:type_name -> controlplane.v1.NodeHello
-	4, // 1: controlplane.v1.NodeMessage.status:type_name -> controlplane.v1.NodeStatus
-	7, // 2: controlplane.v1.NodeMessage.ack:type_name -> controlplane.v1.CommandAck
-	8, // 3: controlplane.v1.ServerMessage.hello:type_name -> controlplane.v1.ServerHello
-	6, // 4: controlplane.v1.ServerMessage.unload_model:type_name -> controlplane.v1.UnloadModel
-	9, // 5: controlplane.v1.ServerMessage.ping:type_name -> controlplane.v1.Ping
-	5, // 6: controlplane.v1.NodeStatus.models:type_name -> controlplane.v1.ModelResidency
-	0, // 7: controlplane.v1.ModelResidency.state:type_name -> controlplane.v1.ModelState
-	1, // 8: controlplane.v1.NodeControl.Stream:input_type -> controlplane.v1.NodeMessage
-	2, // 9: controlplane.v1.NodeControl.Stream:output_type -> controlplane.v1.ServerMessage
-	9, // [9:10] is the sub-list for method output_type
-	8, // [8:9] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	3,  // 0: controlplane.v1.NodeMessage.hello:type_name -> controlplane.v1.NodeHello
+	4,  // 1: controlplane.v1.NodeMessage.status:type_name -> controlplane.v1.NodeStatus
+	9,  // 2: controlplane.v1.NodeMessage.ack:type_name -> controlplane.v1.CommandAck
+	10, // 3: controlplane.v1.ServerMessage.hello:type_name -> controlplane.v1.ServerHello
+	6,  // 4: controlplane.v1.ServerMessage.unload_model:type_name -> controlplane.v1.UnloadModel
+	11, // 5: controlplane.v1.ServerMessage.ping:type_name -> controlplane.v1.Ping
+	7,  // 6: controlplane.v1.ServerMessage.cancel_load:type_name -> controlplane.v1.CancelLoad
+	8,  // 7: controlplane.v1.ServerMessage.load_model:type_name -> controlplane.v1.LoadModel
+	12, // 8: controlplane.v1.NodeHello.endpoint_paths:type_name -> controlplane.v1.NodeHello.EndpointPathsEntry
+	5,  // 9: controlplane.v1.NodeStatus.models:type_name -> controlplane.v1.ModelResidency
+	0,  // 10: controlplane.v1.ModelResidency.state:type_name -> controlplane.v1.ModelState
+	1,  // 11: controlplane.v1.NodeControl.Stream:input_type -> controlplane.v1.NodeMessage
+	2,  // 12: controlplane.v1.NodeControl.Stream:output_type -> controlplane.v1.ServerMessage
+	12, // [12:13] is the sub-list for method output_type
+	11, // [11:12] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_controlplane_v1_controlplane_proto_init() }
@@ -791,6 +1057,8 @@ func file_controlplane_v1_controlplane_proto_init() {
 		(*ServerMessage_Hello)(nil),
 		(*ServerMessage_UnloadModel)(nil),
 		(*ServerMessage_Ping)(nil),
+		(*ServerMessage_CancelLoad)(nil),
+		(*ServerMessage_LoadModel)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -798,7 +1066,7 @@ func file_controlplane_v1_controlplane_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_controlplane_v1_controlplane_proto_rawDesc), len(file_controlplane_v1_controlplane_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   9,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
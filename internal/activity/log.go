@@ -11,6 +11,13 @@ const (
 	EventPressureUnload EventType = "pressure_unload"
 	EventTTLUnload      EventType = "ttl_unload"
 	EventManualUnload   EventType = "manual_unload"
+	EventIdleUnload     EventType = "idle_unload"
+	EventNodeOffline    EventType = "node_offline"
+	EventNodeOnline     EventType = "node_online"
+	EventLoadFailed     EventType = "load_failed"
+	EventLoadCanceled   EventType = "load_canceled"
+	EventWarmLoad       EventType = "warm_load"
+	EventErrorUnload    EventType = "error_unload"
 )
 
 type Event struct {
@@ -26,6 +33,8 @@ type Log struct {
 	buf  []Event
 	next int
 	full bool
+
+	subs []func(Event)
 }
 
 func New(size int) *Log {
@@ -37,16 +46,33 @@ func New(size int) *Log {
 	}
 }
 
-func (l *Log) Add(e Event) {
+// Subscribe registers fn to be called for every added event.
+// fn runs synchronously in Add and must not block.
+func (l *Log) Subscribe(fn func(Event)) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.subs = append(l.subs, fn)
+}
 
+func (l *Log) Add(e Event) {
+	l.mu.Lock()
 	l.buf[l.next] = e
 	l.next++
 	if l.next >= len(l.buf) {
 		l.next = 0
 		l.full = true
 	}
+	subs := l.subs
+	l.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(e)
+	}
+}
+
+// Cap returns how many events the log keeps; older ones are dropped.
+func (l *Log) Cap() int {
+	return len(l.buf)
 }
 
 func (l *Log) List() []Event {
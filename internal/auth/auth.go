@@ -3,22 +3,42 @@ package auth
 import (
 	"context"
 	"crypto/rand"
-	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/mcules/llm-router/internal/maintenance"
 	"github.com/mcules/llm-router/internal/policy"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrKeyLimitReached is returned by GenerateKey when the owner already holds MaxKeysPerUser keys.
+var ErrKeyLimitReached = errors.New("maximum number of API keys reached for this user")
+
 type Authenticator struct {
 	Store *policy.Store
+
+	// MaxKeysPerUser limits how many API keys a single user may own (0 = unlimited).
+	MaxKeysPerUser int
+
+	// Maintenance defers API key usage writes while enabled (nil = never).
+	Maintenance *maintenance.Mode
+
+	// Pepper is the HMAC secret API keys are hashed with (nil = plain SHA-256).
+	// Keys hashed without pepper or with PreviousPepper keep working and are rehashed
+	// with Pepper on their next use (see matchKey).
+	Pepper         []byte
+	PreviousPepper []byte
+
+	seededAdmin bool
+	usage       *usageRecorder
 }
 
 func NewAuthenticator(store *policy.Store) *Authenticator {
+	a := &Authenticator{Store: store, usage: newUsageRecorder()}
+
 	// Sicherstellen, dass der Standard-Admin-User existiert
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -26,19 +46,70 @@ func NewAuthenticator(store *policy.Store) *Authenticator {
 	_, exists, _ := store.GetUser(ctx, "admin")
 	if !exists {
 		hash, _ := bcrypt.GenerateFromPassword([]byte("admin"), bcrypt.DefaultCost)
-		_ = store.CreateUser(ctx, policy.UserRecord{
+		err := store.CreateUser(ctx, policy.UserRecord{
 			Username:      "admin",
 			PasswordHash:  string(hash),
 			AllowedNodes:  "*",
 			AllowedModels: "*",
 		})
+		a.seededAdmin = err == nil
 	}
 
-	return &Authenticator{Store: store}
+	return a
+}
+
+// SeededAdmin reports whether the admin user was created by this process (first run).
+func (a *Authenticator) SeededAdmin() bool {
+	return a.seededAdmin
+}
+
+// BootstrapKey creates an unrestricted API key for the admin if the admin user was just
+// seeded, so automation can use /v1 before anyone opened the UI. It returns "" otherwise.
+// Only the hash is stored: the returned plaintext is the only copy.
+func (a *Authenticator) BootstrapKey(ctx context.Context) (string, policy.APIKeyRecord, error) {
+	if !a.seededAdmin {
+		return "", policy.APIKeyRecord{}, nil
+	}
+	return a.GenerateKey(ctx, "bootstrap", "admin", "*", "*")
+}
+
+// ResetAdminPassword sets the password of the admin user (ADMIN_PASSWORD), so a lost
+// password can be recovered by a restart. It only writes if the password differs and
+// reports whether it did. Other users are never touched.
+func (a *Authenticator) ResetAdminPassword(ctx context.Context, password string) (bool, error) {
+	u, exists, err := a.Store.GetUser(ctx, "admin")
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, errors.New("admin user does not exist")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil {
+		return false, nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return false, err
+	}
+	if err := a.Store.UpdateUserPassword(ctx, "admin", string(hash)); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // GenerateKey erzeugt einen neuen API-Key (Plaintext) und den zugehörigen Record.
-func (a *Authenticator) GenerateKey(ctx context.Context, name string, allowedNodes, allowedModels string) (string, policy.APIKeyRecord, error) {
+// Der Key gehört dem angegebenen Benutzer (owner).
+func (a *Authenticator) GenerateKey(ctx context.Context, name, owner string, allowedNodes, allowedModels string) (string, policy.APIKeyRecord, error) {
+	if a.MaxKeysPerUser > 0 {
+		n, err := a.Store.CountAPIKeysByOwner(ctx, owner)
+		if err != nil {
+			return "", policy.APIKeyRecord{}, err
+		}
+		if n >= a.MaxKeysPerUser {
+			return "", policy.APIKeyRecord{}, ErrKeyLimitReached
+		}
+	}
+
 	raw := make([]byte, 24)
 	if _, err := rand.Read(raw); err != nil {
 		return "", policy.APIKeyRecord{}, err
@@ -48,8 +119,7 @@ func (a *Authenticator) GenerateKey(ctx context.Context, name string, allowedNod
 	id := hex.EncodeToString(raw[:8])
 	prefix := key[:7] // sk-xxxx
 
-	hash := sha256.Sum256([]byte(key))
-	hashedKey := hex.EncodeToString(hash[:])
+	hashedKey := a.hashKey(key)
 
 	record := policy.APIKeyRecord{
 		ID:            id,
@@ -59,6 +129,7 @@ func (a *Authenticator) GenerateKey(ctx context.Context, name string, allowedNod
 		CreatedAt:     time.Now(),
 		AllowedNodes:  allowedNodes,
 		AllowedModels: allowedModels,
+		Owner:         owner,
 	}
 
 	if err := a.Store.CreateAPIKey(ctx, record); err != nil {
@@ -129,6 +200,20 @@ func CheckACL(allowedStr, actualValue string) bool {
 	return false
 }
 
+// CheckACLFold ist wie CheckACL, vergleicht aber ohne Groß-/Kleinschreibung und Leerzeichen.
+func CheckACLFold(allowedStr, actualValue string) bool {
+	if allowedStr == "*" || allowedStr == "" {
+		return true
+	}
+	actualValue = strings.TrimSpace(actualValue)
+	for _, p := range strings.Split(allowedStr, ",") {
+		if strings.EqualFold(strings.TrimSpace(p), actualValue) {
+			return true
+		}
+	}
+	return false
+}
+
 type ctxKeyAuthRecord struct{}
 
 func GetAuthRecord(r *http.Request) *policy.APIKeyRecord {
@@ -138,6 +223,30 @@ func GetAuthRecord(r *http.Request) *policy.APIKeyRecord {
 	return nil
 }
 
+// IsAdmin reports whether username is the built-in admin account. API keys act with
+// their owner's role, so admin-only request features check IsAdmin(rec.Owner).
+func IsAdmin(username string) bool {
+	return username == "admin"
+}
+
+// WithAuthRecord returns r carrying rec as its authenticated API key (see GetAuthRecord).
+func WithAuthRecord(r *http.Request, rec *policy.APIKeyRecord) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), ctxKeyAuthRecord{}, rec))
+}
+
+// OptionalMiddleware lässt Requests ohne Authorization Header anonym durch (kein Record,
+// also keine ACL-Filterung). Mit Header wird wie üblich geprüft, ungültige Keys werden abgelehnt.
+func (a *Authenticator) OptionalMiddleware(next http.Handler) http.Handler {
+	authed := a.Middleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authed.ServeHTTP(w, r)
+	})
+}
+
 // Middleware prüft den Authorization Header.
 func (a *Authenticator) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -154,8 +263,6 @@ func (a *Authenticator) Middleware(next http.Handler) http.Handler {
 		}
 
 		key := parts[1]
-		hash := sha256.Sum256([]byte(key))
-		hashedKey := hex.EncodeToString(hash[:])
 
 		keys, err := a.Store.ListAPIKeys(r.Context())
 		if err != nil {
@@ -163,26 +270,19 @@ func (a *Authenticator) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
-		var found *policy.APIKeyRecord
-		for _, k := range keys {
-			if k.HashedKey == hashedKey {
-				found = &k
-				break
-			}
-		}
-
+		found, stale := a.matchKey(keys, key)
 		if found == nil {
 			http.Error(w, "Invalid API key", http.StatusUnauthorized)
 			return
 		}
+		if stale {
+			a.rehash(r.Context(), found, key)
+		}
 
-		// Update last used (asynchron)
-		go func() {
-			_ = a.Store.UpdateAPIKeyLastUsed(context.Background(), found.ID)
-		}()
+		// Update last used + request count (coalesced, written by RunUsageFlusher)
+		a.usage.record(found.ID, time.Now())
 
 		// Record in context speichern für ACL Checks im Proxy
-		ctx := context.WithValue(r.Context(), ctxKeyAuthRecord{}, found)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, WithAuthRecord(r, found))
 	})
 }
@@ -0,0 +1,32 @@
+package auth
+
+import "testing"
+
+func TestIsAdmin(t *testing.T) {
+	for name, want := range map[string]bool{"admin": true, "Admin": false, "alice": false, "": false} {
+		if got := IsAdmin(name); got != want {
+			t.Errorf("IsAdmin(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCheckACLFold(t *testing.T) {
+	for _, tc := range []struct {
+		allowed, model string
+		exact, fold    bool
+	}{
+		{"*", "Llama-3.1-8B", true, true},
+		{"", "x", true, true},
+		{"llama-3.1-8b", "llama-3.1-8b", true, true},
+		{"llama-3.1-8b", "Llama-3.1-8B", false, true},
+		{"qwen, Llama-3.1-8B ", " llama-3.1-8b", false, true},
+		{"llama-3.1-8b", "llama-3.1-70b", false, false},
+	} {
+		if got := CheckACL(tc.allowed, tc.model); got != tc.exact {
+			t.Errorf("CheckACL(%q, %q) = %v, want %v", tc.allowed, tc.model, got, tc.exact)
+		}
+		if got := CheckACLFold(tc.allowed, tc.model); got != tc.fold {
+			t.Errorf("CheckACLFold(%q, %q) = %v, want %v", tc.allowed, tc.model, got, tc.fold)
+		}
+	}
+}
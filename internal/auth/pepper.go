@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+// pepperedPrefix marks API key hashes made with a pepper: "hmac:<pepper id>:<hex>".
+// Hashes without it are plain SHA-256 hex (keys created before a pepper was set).
+const pepperedPrefix = "hmac:"
+
+// LoadPepper returns the pepper given directly or read from file (trimmed); only one of
+// them may be set. It returns nil if neither is.
+func LoadPepper(value, file string) ([]byte, error) {
+	if value != "" && file != "" {
+		return nil, errors.New("set the pepper or the pepper file, not both")
+	}
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read pepper file: %w", err)
+		}
+		value = strings.TrimSpace(string(b))
+		if value == "" {
+			return nil, fmt.Errorf("pepper file %s is empty", file)
+		}
+	}
+	if value == "" {
+		return nil, nil
+	}
+	return []byte(value), nil
+}
+
+// pepperID identifies a pepper in stored hashes without revealing it.
+func pepperID(pepper []byte) string {
+	sum := sha256.Sum256(pepper)
+	return hex.EncodeToString(sum[:4])
+}
+
+// hashWith hashes an API key for storage: HMAC-SHA256 with the pepper, or plain
+// SHA-256 without one.
+func hashWith(pepper []byte, key string) string {
+	if len(pepper) == 0 {
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(key))
+	return pepperedPrefix + pepperID(pepper) + ":" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// hashKey hashes a new API key with the current pepper.
+func (a *Authenticator) hashKey(key string) string {
+	return hashWith(a.Pepper, key)
+}
+
+// matchKey returns the stored key whose hash matches key. A key hashed without pepper
+// or with PreviousPepper also matches; stale reports that it should be rehashed.
+func (a *Authenticator) matchKey(keys []policy.APIKeyRecord, key string) (found *policy.APIKeyRecord, stale bool) {
+	current := a.hashKey(key)
+	candidates := []string{current}
+	if len(a.Pepper) > 0 {
+		if len(a.PreviousPepper) > 0 {
+			candidates = append(candidates, hashWith(a.PreviousPepper, key))
+		}
+		candidates = append(candidates, hashWith(nil, key))
+	}
+
+	for i := range keys {
+		for _, c := range candidates {
+			if subtle.ConstantTimeCompare([]byte(keys[i].HashedKey), []byte(c)) == 1 {
+				return &keys[i], c != current
+			}
+		}
+	}
+	return nil, false
+}
+
+// rehash stores the hash of key with the current pepper. It is skipped during
+// maintenance (the key keeps working and is rehashed on a later use).
+func (a *Authenticator) rehash(ctx context.Context, rec *policy.APIKeyRecord, key string) {
+	if a.Maintenance.Enabled() {
+		return
+	}
+	hash := a.hashKey(key)
+	if err := a.Store.SetAPIKeyHash(ctx, rec.ID, hash); err != nil {
+		log.Printf("auth: rehash API key %s: %v", rec.ID, err)
+		return
+	}
+	rec.HashedKey = hash
+	log.Printf("auth: API key %s rehashed with the current pepper", rec.ID)
+}
+
+// StaleKeyHashes counts the stored API keys not hashed with the current pepper; they
+// are rehashed on their next use if they match the previous pepper or no pepper.
+func (a *Authenticator) StaleKeyHashes(ctx context.Context) (int, error) {
+	if len(a.Pepper) == 0 {
+		return 0, nil
+	}
+	keys, err := a.Store.ListAPIKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	prefix := pepperedPrefix + pepperID(a.Pepper) + ":"
+	n := 0
+	for _, k := range keys {
+		if !strings.HasPrefix(k.HashedKey, prefix) {
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+// usageWriteTimeout bounds a single last-used write, so a slow database cannot stall the flusher.
+const usageWriteTimeout = 5 * time.Second
+
+// keyUsage is the not yet written usage of one API key.
+type keyUsage struct {
+	lastUsed time.Time
+	requests int64
+	spend    float64 // cost of responses in spendMonth
+	month    string
+}
+
+// modelUsageKey identifies the usage of one key and model on one day.
+type modelUsageKey struct {
+	day, keyID, modelID string
+}
+
+// modelUsage is the not yet written usage of one key and model on one day.
+type modelUsage struct {
+	requests, tokens int64
+	cost             float64
+}
+
+// usageRecorder coalesces API key usage in memory. The request path only updates a map;
+// a single flusher writes the latest timestamp and the request count per key once per
+// interval, so the SQLite connection sees at most one write per key and interval.
+type usageRecorder struct {
+	mu      sync.Mutex
+	pending map[string]*keyUsage
+	models  map[modelUsageKey]*modelUsage
+}
+
+func newUsageRecorder() *usageRecorder {
+	return &usageRecorder{pending: map[string]*keyUsage{}, models: map[modelUsageKey]*modelUsage{}}
+}
+
+func (u *usageRecorder) record(keyID string, at time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	k := u.pending[keyID]
+	if k == nil {
+		k = &keyUsage{}
+		u.pending[keyID] = k
+	}
+	if at.After(k.lastUsed) {
+		k.lastUsed = at
+	}
+	k.requests++
+}
+
+// recordSpend adds the cost of a response. Pending spend of an earlier month is dropped
+// when the month changes before the next flush.
+func (u *usageRecorder) recordSpend(keyID string, at time.Time, amount float64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	k := u.pending[keyID]
+	if k == nil {
+		k = &keyUsage{}
+		u.pending[keyID] = k
+	}
+	if at.After(k.lastUsed) {
+		k.lastUsed = at
+	}
+	if month := policy.MonthOf(at); k.month != month {
+		k.month, k.spend = month, 0
+	}
+	k.spend += amount
+}
+
+// recordModel adds a response to the usage of the key and model on the day of at.
+func (u *usageRecorder) recordModel(keyID, modelID string, at time.Time, tokens int64, cost float64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	key := modelUsageKey{day: policy.DayOf(at), keyID: keyID, modelID: modelID}
+	m := u.models[key]
+	if m == nil {
+		m = &modelUsage{}
+		u.models[key] = m
+	}
+	m.requests++
+	m.tokens += tokens
+	m.cost += cost
+}
+
+// pendingSpend returns the not yet written spend of a key in month.
+func (u *usageRecorder) pendingSpend(keyID, month string) float64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if k := u.pending[keyID]; k != nil && k.month == month {
+		return k.spend
+	}
+	return 0
+}
+
+// take returns the pending usage and starts a new batch.
+func (u *usageRecorder) take() (map[string]*keyUsage, map[modelUsageKey]*modelUsage) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	keys, models := u.pending, u.models
+	u.pending = map[string]*keyUsage{}
+	u.models = map[modelUsageKey]*modelUsage{}
+	return keys, models
+}
+
+// restore merges the key usage k of a failed write back into the pending batch. Spend
+// of a month older than the one pending meanwhile is dropped, as in recordSpend.
+func (u *usageRecorder) restore(keyID string, k *keyUsage) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	cur := u.pending[keyID]
+	if cur == nil {
+		u.pending[keyID] = k
+		return
+	}
+	if k.lastUsed.After(cur.lastUsed) {
+		cur.lastUsed = k.lastUsed
+	}
+	cur.requests += k.requests
+	switch {
+	case cur.month == k.month:
+		cur.spend += k.spend
+	case cur.month == "" || (k.month != "" && k.month > cur.month):
+		cur.month, cur.spend = k.month, k.spend
+	}
+}
+
+// restoreModel merges the key/model usage m of a failed write back into the pending batch.
+func (u *usageRecorder) restoreModel(key modelUsageKey, m *modelUsage) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	cur := u.models[key]
+	if cur == nil {
+		u.models[key] = m
+		return
+	}
+	cur.requests += m.requests
+	cur.tokens += m.tokens
+	cur.cost += m.cost
+}
+
+// RunUsageFlusher writes the recorded API key usage every interval until ctx is done,
+// then flushes once more. Usage of the last interval is lost if the process dies
+// without canceling ctx.
+func (a *Authenticator) RunUsageFlusher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			a.FlushUsage(context.Background())
+			return
+		case <-t.C:
+			a.FlushUsage(ctx)
+		}
+	}
+}
+
+// FlushUsage writes the recorded API key usage one key after another. Usage whose write
+// fails stays pending for the next flush (request counts, spend and daily usage rows
+// alike). In maintenance mode the usage stays pending until a later flush.
+func (a *Authenticator) FlushUsage(ctx context.Context) {
+	if a.Maintenance.Enabled() {
+		return
+	}
+	batch, models := a.usage.take()
+	failed := 0
+	for id, k := range batch {
+		wctx, cancel := context.WithTimeout(ctx, usageWriteTimeout)
+		month := k.month
+		if month == "" {
+			month = policy.MonthOf(k.lastUsed)
+		}
+		err := a.Store.RecordAPIKeyUse(wctx, id, k.lastUsed, k.requests, k.spend, month)
+		cancel()
+		if err != nil {
+			a.usage.restore(id, k)
+			failed++
+		}
+	}
+	if failed > 0 {
+		log.Printf("auth: failed to record usage of %d of %d API keys, retrying with the next flush", failed, len(batch))
+	}
+
+	failed = 0
+	for key, m := range models {
+		wctx, cancel := context.WithTimeout(ctx, usageWriteTimeout)
+		err := a.Store.RecordUsage(wctx, key.day, key.keyID, key.modelID, m.requests, m.tokens, m.cost)
+		cancel()
+		if err != nil {
+			a.usage.restoreModel(key, m)
+			failed++
+		}
+	}
+	if failed > 0 {
+		log.Printf("auth: failed to record usage of %d of %d key/model pairs, retrying with the next flush", failed, len(models))
+	}
+}
+
+// RecordSpend charges amount to the key; it is written with the next usage flush.
+func (a *Authenticator) RecordSpend(keyID string, at time.Time, amount float64) {
+	if amount <= 0 {
+		return
+	}
+	a.usage.recordSpend(keyID, at, amount)
+}
+
+// RecordUsage records a successful response of the key for the usage export and charges
+// its cost (see RecordSpend); it is written with the next usage flush.
+func (a *Authenticator) RecordUsage(keyID, modelID string, at time.Time, tokens int64, cost float64) {
+	a.usage.recordModel(keyID, modelID, at, tokens, cost)
+	a.RecordSpend(keyID, at, cost)
+}
+
+// MonthSpend returns the spend of the key in the month of now: the stored spend plus
+// what was not written yet.
+func (a *Authenticator) MonthSpend(rec *policy.APIKeyRecord, now time.Time) float64 {
+	month := policy.MonthOf(now)
+	return rec.SpendIn(month) + a.usage.pendingSpend(rec.ID, month)
+}
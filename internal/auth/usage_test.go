@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+// newTestAuthenticator returns an authenticator on a fresh store at path.
+func newTestAuthenticator(t testing.TB, path string) *Authenticator {
+	t.Helper()
+	store, err := policy.Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return NewAuthenticator(store)
+}
+
+func TestFlushUsageKeepsFailedWrites(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "policies.db")
+	a := newTestAuthenticator(t, path)
+	_, rec, err := a.GenerateKey(ctx, "team", "admin", "*", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for range 3 {
+		a.usage.record(rec.ID, now)
+	}
+	a.RecordUsage(rec.ID, "m", now, 100, 0.5)
+
+	// Writes fail while the database is closed: nothing may be lost.
+	a.Store.Close()
+	a.FlushUsage(ctx)
+	if got := a.MonthSpend(&rec, now); math.Abs(got-0.5) > 1e-9 {
+		t.Fatalf("pending spend after failed flush = %v, want 0.5", got)
+	}
+
+	a.usage.record(rec.ID, now)
+	a.RecordUsage(rec.ID, "m", now, 50, 0.25)
+
+	store, err := policy.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	a.Store = store
+	a.FlushUsage(ctx)
+
+	got, ok, err := store.GetAPIKey(ctx, rec.ID)
+	if err != nil || !ok {
+		t.Fatalf("get key: ok=%v err=%v", ok, err)
+	}
+	if got.RequestCount != 4 {
+		t.Errorf("request count = %d, want 4", got.RequestCount)
+	}
+	if math.Abs(got.Spend-0.75) > 1e-9 {
+		t.Errorf("spend = %v, want 0.75", got.Spend)
+	}
+
+	var rows []policy.UsageRow
+	day := policy.DayOf(now)
+	if err := store.EachUsage(ctx, day, day, func(r policy.UsageRow) error {
+		rows = append(rows, r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Requests != 2 || rows[0].Tokens != 150 {
+		t.Errorf("usage rows = %+v, want one row with 2 requests and 150 tokens", rows)
+	}
+}
+
+func TestUsageRestoreMonths(t *testing.T) {
+	u := newUsageRecorder()
+	u.recordSpend("k", time.Date(2026, 11, 1, 0, 0, 1, 0, time.Local), 1)
+	// A failed write of October arrives after November started: November is kept.
+	u.restore("k", &keyUsage{requests: 2, spend: 5, month: "2026-10"})
+	if got := u.pendingSpend("k", "2026-11"); got != 1 {
+		t.Errorf("November spend = %v, want 1", got)
+	}
+	u.restore("k", &keyUsage{requests: 1, spend: 2, month: "2026-11"})
+	if got := u.pendingSpend("k", "2026-11"); got != 3 {
+		t.Errorf("November spend = %v, want 3", got)
+	}
+	if got := u.pending["k"].requests; got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+}
+
+// BenchmarkMiddleware measures the authenticated request path, which only records usage
+// in memory (written by the flusher, not per request).
+func BenchmarkMiddleware(b *testing.B) {
+	a := newTestAuthenticator(b, filepath.Join(b.TempDir(), "policies.db"))
+	key, _, err := a.GenerateKey(context.Background(), "bench", "admin", "*", "*")
+	if err != nil {
+		b.Fatal(err)
+	}
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			req.Header.Set("Authorization", "Bearer "+key)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				b.Fatalf("status %d", w.Code)
+			}
+		}
+	})
+}
+
+// BenchmarkUsageRecord measures recording usage of a few keys from many goroutines.
+func BenchmarkUsageRecord(b *testing.B) {
+	u := newUsageRecorder()
+	ids := []string{"k1", "k2", "k3", "k4"}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			u.record(ids[i%len(ids)], time.Now())
+			i++
+		}
+	})
+}
@@ -0,0 +1,646 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Server is the server configuration. Values are resolved in this order:
+// built-in defaults, the JSON config file (optional), environment variables.
+type Server struct {
+	HTTPAddr       string `json:"http_addr"`
+	GRPCAddr       string `json:"grpc_addr"`
+	GRPCOnHTTPPort bool   `json:"grpc_on_http_port"`
+	MetricsAddr    string `json:"metrics_addr"` // /metrics, /health, /ready ("" = on HTTPAddr)
+	TLSCertFile    string `json:"tls_cert_file"`
+	TLSKeyFile     string `json:"tls_key_file"`
+	PoliciesDBPath string `json:"policies_db_path"`
+	// External path prefix when served behind a reverse proxy at a subpath (e.g. "/llm").
+	BasePath string `json:"base_path"`
+
+	// Nodes with heartbeat older than this are considered offline.
+	NodeOfflineSeconds int `json:"node_offline_seconds"`
+	// Interval of server-side pings to all nodes.
+	StatusPollIntervalSeconds int `json:"status_poll_interval_seconds"`
+	// Without any node status update for this long, /ready reports degraded (0 = off).
+	StatusStaleSeconds int `json:"status_stale_seconds"`
+	// Latency and recency entries without observations for this long are pruned (0 = keep).
+	MetricsTTLHours int `json:"metrics_ttl_hours"`
+	// Connected dashboard event streams (/ui/events) before further ones get 503 (0 = no cap).
+	UIMaxEventStreams int `json:"ui_max_event_streams"`
+	// Development only: prime the cluster state from a debug dump at startup.
+	DebugRestoreFile string `json:"debug_restore_file"`
+
+	Control   Control   `json:"control"`
+	Planner   Planner   `json:"planner"`
+	Proxy     Proxy     `json:"proxy"`
+	Auth      Auth      `json:"auth"`
+	Webhook   Webhook   `json:"webhook"`
+	Store     Store     `json:"store"`
+	PromptLog PromptLog `json:"prompt_log"`
+	Endpoints Endpoints `json:"endpoints"`
+}
+
+// Control configures command delivery to node agents.
+type Control struct {
+	SendRetries           int `json:"send_retries"`
+	ReconnectGraceSeconds int `json:"reconnect_grace_seconds"`
+	// Parallel ping sends and per-send timeout for server-side status polling.
+	PingConcurrency    int `json:"ping_concurrency"`
+	PingTimeoutSeconds int `json:"ping_timeout_seconds"`
+	// Deadline of a command send; a ping or command send missing its deadline closes
+	// the stream as wedged (0 = no deadline for commands).
+	SendTimeoutSeconds int `json:"send_timeout_seconds"`
+	// Reported model load times older than this are treated as clock skew (0 = no limit).
+	MaxLoadedAgeHours int `json:"max_loaded_age_hours"`
+	// Unchanged node status is logged at most this often (0 = only on changes).
+	StatusLogIntervalSeconds int `json:"status_log_interval_seconds"`
+	// Models accepted per node status; duplicates are always dropped (0 = no cap).
+	MaxModelsPerNode int `json:"max_models_per_node"`
+	// A status arriving before the node's hello is held this long (0 = close the stream).
+	HelloGraceSeconds int `json:"hello_grace_seconds"`
+	// Keepalive pings on idle control connections and how long to wait for the answer
+	// before the connection counts as dead (0 = no server pings).
+	KeepaliveSeconds        int `json:"keepalive_seconds"`
+	KeepaliveTimeoutSeconds int `json:"keepalive_timeout_seconds"`
+}
+
+// Planner configures unload automation.
+type Planner struct {
+	MinFreeRAMMB    int `json:"min_free_ram_mb"`
+	IntervalSeconds int `json:"interval_seconds"`
+	// Models are never TTL-unloaded within this time after becoming READY.
+	MinResidentSeconds int `json:"min_resident_seconds"`
+	// Models kept loaded on at least one node: "model[@schedule]", comma separated.
+	WarmPool string `json:"warm_pool"`
+	// Models in ERROR this long are unloaded to free their RAM (0 = off).
+	ErrorUnloadAfterSeconds int `json:"error_unload_after_seconds"`
+	// Retries of unloads that could not be sent to the node (0 = off).
+	UnloadRetries int `json:"unload_retries"`
+}
+
+// Proxy configures the API hot path (placement, scoring, upstream connections).
+type Proxy struct {
+	ExposeRoutingHeaders   bool `json:"expose_routing_headers"`
+	NormalizeModelNames    bool `json:"normalize_model_names"`
+	EmbeddingsRequireReady bool `json:"embeddings_require_ready"`
+	WarnStructuredOutput   bool `json:"warn_structured_output"`
+	// Skip nodes reporting RAM total 0 instead of scoring them neutrally.
+	ExcludeUnknownRAM bool `json:"exclude_unknown_ram"`
+	// Refuse cold loads while the node status is stale (see status_stale_seconds).
+	StaleNoColdLoads bool `json:"stale_no_cold_loads"`
+	// Refuse cold loads when no eligible node has the policy's RAM required available.
+	RefuseOversizedLoads bool `json:"refuse_oversized_loads"`
+	// Wait for the load furthest along among all nodes reporting a model LOADING.
+	WaitOnReportedLoads bool `json:"wait_on_reported_loads"`
+	// Times a request waiting for a load is placed again when the loading node goes
+	// offline (0 = the request fails).
+	LoaderFailovers int `json:"loader_failovers"`
+	// max_tokens of a request that counts as one inflight request in placement
+	// (0 = every request counts one).
+	InflightTokenUnit int `json:"inflight_token_unit"`
+	// Retry-After of 503s for models still loading, without a measured load time.
+	LoadRetryAfterSeconds int `json:"load_retry_after_seconds"`
+	// Omit models that are only loading from GET /v1/models.
+	HideLoadingModels   bool `json:"hide_loading_models"`
+	EmbeddingsChunkSize int  `json:"embeddings_chunk_size"`
+	MaxConnsPerNode     int  `json:"max_conns_per_node"`
+	MaxIdleConnsPerNode int  `json:"max_idle_conns_per_node"`
+	// TLS for https data plane URLs: extra CA bundle (PEM file) and disabling verification.
+	TLSCAFile                  string `json:"tls_ca_file"`
+	TLSInsecureSkipVerify      bool   `json:"tls_insecure_skip_verify"`
+	ModelPriorityWeightPercent int    `json:"model_priority_weight_percent"`
+	PreferLeastModels          bool   `json:"prefer_least_models"`
+	// Score bonus in MiB per token/sec of node generation speed (0 = off).
+	GenSpeedWeightMB int `json:"gen_speed_weight_mb"`
+	// Deprioritize nodes whose EWMA latency (ms) times inflight reaches this (0 = off).
+	OverloadThreshold int `json:"overload_threshold"`
+	// Placement among nodes with the model READY: "score" or "consistent_hash".
+	PlacementStrategy string `json:"placement_strategy"`
+	HashKeyHeader     string `json:"hash_key_header"`
+	HashPrefixChars   int    `json:"hash_prefix_chars"`
+	// Zone-aware placement: own zone, client zone header, score penalty for other zones.
+	Zone               string `json:"zone"`
+	ZoneHeader         string `json:"zone_header"`
+	CrossZonePenaltyMB int    `json:"cross_zone_penalty_mb"`
+	// Serve the POST endpoints also with a trailing slash ("/v1/chat/completions/").
+	AcceptTrailingSlash bool `json:"accept_trailing_slash"`
+	// Check the model named in X-Model or ?model= before reading the body.
+	EarlyModelCheck bool `json:"early_model_check"`
+	// Request header forcing "stream" true/false in chat and completion bodies ("" = off).
+	StreamOverrideHeader string `json:"stream_override_header"`
+	// Limits for reading API request bodies (0 = unlimited).
+	MaxBodyMB              int `json:"max_body_mb"`
+	BodyReadTimeoutSeconds int `json:"body_read_timeout_seconds"`
+	// Concurrent API requests the router serves before answering 503 (0 = unlimited).
+	MaxConcurrentRequests int `json:"max_concurrent_requests"`
+	// Share of those slots held back from lower priorities (X-Priority).
+	PriorityReservePercent int `json:"priority_reserve_percent"`
+	// Cluster reserve for cold loads (0 = off): nodes kept below the utilization
+	// threshold and share of total RAM kept available.
+	ReserveNodes           int `json:"reserve_nodes"`
+	ReserveNodeUtilPercent int `json:"reserve_node_util_percent"`
+	ReserveRAMPercent      int `json:"reserve_ram_percent"`
+	// Model used for /v1/moderations requests that omit "model".
+	DefaultModerationModel string `json:"default_moderation_model"`
+	// Body fields holding the model, tried in order (comma separated).
+	ModelFields string `json:"model_fields"`
+	// Serve Azure OpenAI style paths /openai/deployments/{deployment}/...
+	AzurePaths bool `json:"azure_paths"`
+	// Bearer tokens sent to nodes instead of the client's key: "node=token", "*" = default.
+	UpstreamCredentials string `json:"upstream_credentials"`
+	// Models served by endpoints outside the cluster: "model=url", comma separated.
+	StaticUpstreams string `json:"static_upstreams"`
+	// Longest time placement avoids a node whose backend answered 429 (0 = off).
+	UpstreamThrottleBackoffSeconds int `json:"upstream_throttle_backoff_seconds"`
+}
+
+// Auth configures API keys.
+type Auth struct {
+	MaxKeysPerUser int `json:"max_keys_per_user"`
+	// Serve GET /v1/models without API key (full, unfiltered list).
+	AllowAnonymousModels bool `json:"allow_anonymous_models"`
+	// On first run (admin user seeded), create an admin API key and log it once.
+	BootstrapAdminKey bool `json:"bootstrap_admin_key"`
+	// Set the admin user's password at startup if it differs (password recovery).
+	AdminPassword string `json:"admin_password"`
+	// How often API key usage (last used, request count) is written to the database.
+	UsageFlushSeconds int `json:"usage_flush_seconds"`
+	// Keys over their monthly budget: "reject" (402) or "warn" (header only).
+	BudgetMode string `json:"budget_mode"`
+	// HMAC pepper for API key hashes, given directly or as file (one of them), and the
+	// previous pepper during a rotation.
+	KeyPepper             string `json:"key_pepper"`
+	KeyPepperFile         string `json:"key_pepper_file"`
+	PreviousKeyPepper     string `json:"previous_key_pepper"`
+	PreviousKeyPepperFile string `json:"previous_key_pepper_file"`
+}
+
+// Webhook configures event delivery to an external URL (disabled without URL).
+type Webhook struct {
+	URL            string `json:"url"`
+	Secret         string `json:"secret"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+	Retries        int    `json:"retries"`
+	// Events is a comma separated list of event types to deliver (empty = all).
+	Events string `json:"events"`
+}
+
+// PromptLog configures capturing of request/response pairs for evaluation datasets.
+// It is off unless Sink is set, and only covers the listed API keys.
+type PromptLog struct {
+	// Sink is "" (off), "stdout", "file" (JSON lines at Path) or "db" (SQLite at Path).
+	Sink string `json:"sink"`
+	Path string `json:"path"`
+	// Keys is a comma separated list of API key ids to capture ("*" = all keys).
+	Keys string `json:"keys"`
+	// Redact is a comma separated list of built-in redactors ("email", "number").
+	Redact string `json:"redact"`
+	// Captured request and response bodies are cut after this many KiB each.
+	MaxKB int `json:"max_kb"`
+}
+
+// Endpoints enables the API endpoints individually; disabled ones answer 404 before
+// authentication.
+type Endpoints struct {
+	ChatCompletions bool `json:"chat_completions"`
+	Completions     bool `json:"completions"`
+	Embeddings      bool `json:"embeddings"`
+	Moderations     bool `json:"moderations"`
+	// GET /v1/models and /v1/models/{id}.
+	Models bool `json:"models"`
+	// POST /v1/models/load.
+	ModelLoad bool `json:"model_load"`
+}
+
+// Store configures policy store health handling.
+type Store struct {
+	// Consecutive database errors after which the store counts as degraded (0 = never).
+	DegradedAfterErrors int `json:"degraded_after_errors"`
+	// Reject policy/key/user changes while degraded (reads use the policy cache).
+	FailClosed bool `json:"fail_closed"`
+}
+
+// DefaultServer returns the built-in defaults (see config.example.json).
+func DefaultServer() Server {
+	return Server{
+		HTTPAddr:                  ":8080",
+		GRPCAddr:                  ":9090",
+		PoliciesDBPath:            "policies.db",
+		NodeOfflineSeconds:        5,
+		StatusPollIntervalSeconds: 10,
+		MetricsTTLHours:           24,
+		UIMaxEventStreams:         100,
+		Control: Control{
+			SendRetries:              2,
+			ReconnectGraceSeconds:    15,
+			PingConcurrency:          16,
+			PingTimeoutSeconds:       5,
+			SendTimeoutSeconds:       10,
+			MaxLoadedAgeHours:        720,
+			StatusLogIntervalSeconds: 60,
+			MaxModelsPerNode:         1000,
+			HelloGraceSeconds:        10,
+			KeepaliveSeconds:         30,
+			KeepaliveTimeoutSeconds:  10,
+		},
+		Planner: Planner{
+			MinFreeRAMMB:       2048,
+			IntervalSeconds:    2,
+			MinResidentSeconds: 30,
+			UnloadRetries:      3,
+		},
+		Proxy: Proxy{
+			MaxIdleConnsPerNode:    50,
+			EmbeddingsChunkSize:    64,
+			PreferLeastModels:      true,
+			PlacementStrategy:      "score",
+			HashKeyHeader:          "X-Cache-Key",
+			ZoneHeader:             "X-Zone",
+			CrossZonePenaltyMB:     4096,
+			EarlyModelCheck:        true,
+			AcceptTrailingSlash:    true,
+			MaxBodyMB:              32,
+			BodyReadTimeoutSeconds: 30,
+			PriorityReservePercent: 10,
+			ReserveNodeUtilPercent: 80,
+			LoadRetryAfterSeconds:  10,
+			WaitOnReportedLoads:    true,
+			LoaderFailovers:        1,
+			ModelFields:            "model",
+		},
+		Auth: Auth{
+			UsageFlushSeconds: 5,
+			BudgetMode:        "reject",
+		},
+		Webhook: Webhook{
+			TimeoutSeconds: 5,
+			Retries:        3,
+		},
+		Store: Store{
+			DegradedAfterErrors: 3,
+		},
+		PromptLog: PromptLog{
+			MaxKB: 1024,
+		},
+		Endpoints: Endpoints{
+			ChatCompletions: true,
+			Completions:     true,
+			Embeddings:      true,
+			Moderations:     true,
+			Models:          true,
+			ModelLoad:       true,
+		},
+	}
+}
+
+// LoadServer resolves the server configuration. path may be empty (no file).
+func LoadServer(path string) (Server, error) {
+	cfg := DefaultServer()
+
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("read config: %w", err)
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides values with the (backward compatible) environment variables.
+func (c *Server) applyEnv() error {
+	e := &envReader{}
+
+	e.str("HTTP_ADDR", &c.HTTPAddr)
+	e.str("GRPC_ADDR", &c.GRPCAddr)
+	e.str("METRICS_ADDR", &c.MetricsAddr)
+	e.bool("GRPC_ON_HTTP_PORT", &c.GRPCOnHTTPPort)
+	e.str("TLS_CERT_FILE", &c.TLSCertFile)
+	e.str("TLS_KEY_FILE", &c.TLSKeyFile)
+	e.str("POLICIES_DB_PATH", &c.PoliciesDBPath)
+	e.str("BASE_PATH", &c.BasePath)
+	e.int("NODE_OFFLINE_SECONDS", &c.NodeOfflineSeconds)
+	e.int("STATUS_POLL_INTERVAL_SECONDS", &c.StatusPollIntervalSeconds)
+	e.int("STATUS_STALE_SECONDS", &c.StatusStaleSeconds)
+	e.int("METRICS_TTL_HOURS", &c.MetricsTTLHours)
+	e.int("UI_MAX_EVENT_STREAMS", &c.UIMaxEventStreams)
+	e.str("DEBUG_RESTORE_FILE", &c.DebugRestoreFile)
+
+	e.int("CONTROL_SEND_RETRIES", &c.Control.SendRetries)
+	e.int("CONTROL_RECONNECT_GRACE_SECONDS", &c.Control.ReconnectGraceSeconds)
+	e.int("PING_CONCURRENCY", &c.Control.PingConcurrency)
+	e.int("PING_TIMEOUT_SECONDS", &c.Control.PingTimeoutSeconds)
+	e.int("CONTROL_SEND_TIMEOUT_SECONDS", &c.Control.SendTimeoutSeconds)
+	e.int("MAX_LOADED_AGE_HOURS", &c.Control.MaxLoadedAgeHours)
+	e.int("STATUS_LOG_INTERVAL_SECONDS", &c.Control.StatusLogIntervalSeconds)
+	e.int("MAX_MODELS_PER_NODE", &c.Control.MaxModelsPerNode)
+	e.int("CONTROL_HELLO_GRACE_SECONDS", &c.Control.HelloGraceSeconds)
+	e.int("CONTROL_KEEPALIVE_SECONDS", &c.Control.KeepaliveSeconds)
+	e.int("CONTROL_KEEPALIVE_TIMEOUT_SECONDS", &c.Control.KeepaliveTimeoutSeconds)
+
+	e.int("MIN_FREE_RAM_MB", &c.Planner.MinFreeRAMMB)
+	e.int("PLANNER_INTERVAL_SECONDS", &c.Planner.IntervalSeconds)
+	e.int("MIN_RESIDENT_SECONDS", &c.Planner.MinResidentSeconds)
+	e.str("WARM_POOL", &c.Planner.WarmPool)
+	e.int("ERROR_UNLOAD_AFTER_SECONDS", &c.Planner.ErrorUnloadAfterSeconds)
+	e.int("UNLOAD_RETRIES", &c.Planner.UnloadRetries)
+
+	e.bool("EXPOSE_ROUTING_HEADERS", &c.Proxy.ExposeRoutingHeaders)
+	e.bool("NORMALIZE_MODEL_NAMES", &c.Proxy.NormalizeModelNames)
+	e.bool("EMBEDDINGS_REQUIRE_READY", &c.Proxy.EmbeddingsRequireReady)
+	e.bool("WARN_STRUCTURED_OUTPUT", &c.Proxy.WarnStructuredOutput)
+	e.bool("EXCLUDE_UNKNOWN_RAM", &c.Proxy.ExcludeUnknownRAM)
+	e.bool("STALE_NO_COLD_LOADS", &c.Proxy.StaleNoColdLoads)
+	e.bool("REFUSE_OVERSIZED_LOADS", &c.Proxy.RefuseOversizedLoads)
+	e.bool("WAIT_ON_REPORTED_LOADS", &c.Proxy.WaitOnReportedLoads)
+	e.int("LOADER_FAILOVERS", &c.Proxy.LoaderFailovers)
+	e.int("INFLIGHT_TOKEN_UNIT", &c.Proxy.InflightTokenUnit)
+	e.bool("HIDE_LOADING_MODELS", &c.Proxy.HideLoadingModels)
+	e.int("EMBEDDINGS_CHUNK_SIZE", &c.Proxy.EmbeddingsChunkSize)
+	e.bool("EARLY_MODEL_CHECK", &c.Proxy.EarlyModelCheck)
+	e.bool("ACCEPT_TRAILING_SLASH", &c.Proxy.AcceptTrailingSlash)
+	e.str("STREAM_OVERRIDE_HEADER", &c.Proxy.StreamOverrideHeader)
+	e.int("LOAD_RETRY_AFTER_SECONDS", &c.Proxy.LoadRetryAfterSeconds)
+	e.int("PROXY_MAX_CONNS_PER_NODE", &c.Proxy.MaxConnsPerNode)
+	e.int("PROXY_MAX_IDLE_CONNS_PER_NODE", &c.Proxy.MaxIdleConnsPerNode)
+	e.str("PROXY_TLS_CA_FILE", &c.Proxy.TLSCAFile)
+	e.bool("PROXY_TLS_INSECURE_SKIP_VERIFY", &c.Proxy.TLSInsecureSkipVerify)
+	e.str("PROXY_UPSTREAM_CREDENTIALS", &c.Proxy.UpstreamCredentials)
+	e.str("STATIC_UPSTREAMS", &c.Proxy.StaticUpstreams)
+	e.int("PROXY_UPSTREAM_THROTTLE_BACKOFF_SECONDS", &c.Proxy.UpstreamThrottleBackoffSeconds)
+	e.int("MODEL_PRIORITY_WEIGHT_PERCENT", &c.Proxy.ModelPriorityWeightPercent)
+	e.bool("PREFER_LEAST_MODELS", &c.Proxy.PreferLeastModels)
+	e.int("GEN_SPEED_WEIGHT_MB", &c.Proxy.GenSpeedWeightMB)
+	e.int("OVERLOAD_THRESHOLD", &c.Proxy.OverloadThreshold)
+	e.str("PLACEMENT_STRATEGY", &c.Proxy.PlacementStrategy)
+	e.str("HASH_KEY_HEADER", &c.Proxy.HashKeyHeader)
+	e.int("HASH_PREFIX_CHARS", &c.Proxy.HashPrefixChars)
+	e.str("ROUTER_ZONE", &c.Proxy.Zone)
+	e.str("ZONE_HEADER", &c.Proxy.ZoneHeader)
+	e.int("CROSS_ZONE_PENALTY_MB", &c.Proxy.CrossZonePenaltyMB)
+	e.int("MAX_BODY_MB", &c.Proxy.MaxBodyMB)
+	e.int("MAX_CONCURRENT_REQUESTS", &c.Proxy.MaxConcurrentRequests)
+	e.int("PRIORITY_RESERVE_PERCENT", &c.Proxy.PriorityReservePercent)
+	e.int("BODY_READ_TIMEOUT_SECONDS", &c.Proxy.BodyReadTimeoutSeconds)
+	e.int("RESERVE_NODES", &c.Proxy.ReserveNodes)
+	e.int("RESERVE_NODE_UTIL_PERCENT", &c.Proxy.ReserveNodeUtilPercent)
+	e.int("RESERVE_RAM_PERCENT", &c.Proxy.ReserveRAMPercent)
+	e.str("DEFAULT_MODERATION_MODEL", &c.Proxy.DefaultModerationModel)
+	e.str("MODEL_FIELDS", &c.Proxy.ModelFields)
+	e.bool("AZURE_PATHS", &c.Proxy.AzurePaths)
+
+	e.int("MAX_KEYS_PER_USER", &c.Auth.MaxKeysPerUser)
+	e.bool("ALLOW_ANONYMOUS_MODELS", &c.Auth.AllowAnonymousModels)
+	e.bool("BOOTSTRAP_ADMIN_KEY", &c.Auth.BootstrapAdminKey)
+	e.str("ADMIN_PASSWORD", &c.Auth.AdminPassword)
+	e.int("USAGE_FLUSH_SECONDS", &c.Auth.UsageFlushSeconds)
+	e.str("BUDGET_MODE", &c.Auth.BudgetMode)
+	e.str("API_KEY_PEPPER", &c.Auth.KeyPepper)
+	e.str("API_KEY_PEPPER_FILE", &c.Auth.KeyPepperFile)
+	e.str("API_KEY_PEPPER_PREVIOUS", &c.Auth.PreviousKeyPepper)
+	e.str("API_KEY_PEPPER_PREVIOUS_FILE", &c.Auth.PreviousKeyPepperFile)
+
+	e.str("WEBHOOK_URL", &c.Webhook.URL)
+	e.str("WEBHOOK_SECRET", &c.Webhook.Secret)
+	e.int("WEBHOOK_TIMEOUT_SECONDS", &c.Webhook.TimeoutSeconds)
+	e.int("WEBHOOK_RETRIES", &c.Webhook.Retries)
+	e.str("WEBHOOK_EVENTS", &c.Webhook.Events)
+	e.str("PROMPT_LOG_SINK", &c.PromptLog.Sink)
+	e.str("PROMPT_LOG_PATH", &c.PromptLog.Path)
+	e.str("PROMPT_LOG_KEYS", &c.PromptLog.Keys)
+	e.str("PROMPT_LOG_REDACT", &c.PromptLog.Redact)
+	e.int("PROMPT_LOG_MAX_KB", &c.PromptLog.MaxKB)
+
+	e.bool("ENDPOINT_CHAT_COMPLETIONS", &c.Endpoints.ChatCompletions)
+	e.bool("ENDPOINT_COMPLETIONS", &c.Endpoints.Completions)
+	e.bool("ENDPOINT_EMBEDDINGS", &c.Endpoints.Embeddings)
+	e.bool("ENDPOINT_MODERATIONS", &c.Endpoints.Moderations)
+	e.bool("ENDPOINT_MODELS", &c.Endpoints.Models)
+	e.bool("ENDPOINT_MODEL_LOAD", &c.Endpoints.ModelLoad)
+
+	e.int("STORE_DEGRADED_AFTER_ERRORS", &c.Store.DegradedAfterErrors)
+	e.bool("STORE_FAIL_CLOSED", &c.Store.FailClosed)
+
+	return errors.Join(e.errs...)
+}
+
+// Validate checks value ranges and combinations.
+func (c Server) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTPAddr != "", "http_addr must not be empty")
+	check(c.GRPCOnHTTPPort || c.GRPCAddr != "", "grpc_addr must not be empty")
+	check(c.MetricsAddr == "" || c.MetricsAddr != c.HTTPAddr && (c.GRPCOnHTTPPort || c.MetricsAddr != c.GRPCAddr),
+		"metrics_addr must differ from http_addr and grpc_addr")
+	check((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "tls_cert_file and tls_key_file must be set together")
+	check(c.PoliciesDBPath != "", "policies_db_path must not be empty")
+	check(c.BasePath == "" || (strings.HasPrefix(c.BasePath, "/") && !strings.HasSuffix(c.BasePath, "/")),
+		"base_path must start with / and not end with / (e.g. /llm), got %q", c.BasePath)
+	check(c.NodeOfflineSeconds >= 0, "node_offline_seconds must be >= 0 (0 = never offline), got %d", c.NodeOfflineSeconds)
+	check(c.StatusPollIntervalSeconds > 0, "status_poll_interval_seconds must be > 0, got %d", c.StatusPollIntervalSeconds)
+	check(c.StatusStaleSeconds >= 0, "status_stale_seconds must be >= 0 (0 = off), got %d", c.StatusStaleSeconds)
+	check(c.StatusStaleSeconds == 0 || c.StatusStaleSeconds > c.StatusPollIntervalSeconds,
+		"status_stale_seconds must be greater than status_poll_interval_seconds (%d), got %d", c.StatusPollIntervalSeconds, c.StatusStaleSeconds)
+	check(c.MetricsTTLHours >= 0, "metrics_ttl_hours must be >= 0 (0 = keep), got %d", c.MetricsTTLHours)
+	check(c.UIMaxEventStreams >= 0, "ui_max_event_streams must be >= 0 (0 = no cap), got %d", c.UIMaxEventStreams)
+
+	check(c.Control.SendRetries >= 0, "control.send_retries must be >= 0, got %d", c.Control.SendRetries)
+	check(c.Control.ReconnectGraceSeconds >= 0, "control.reconnect_grace_seconds must be >= 0, got %d", c.Control.ReconnectGraceSeconds)
+	check(c.Control.PingConcurrency > 0, "control.ping_concurrency must be > 0, got %d", c.Control.PingConcurrency)
+	check(c.Control.PingTimeoutSeconds > 0, "control.ping_timeout_seconds must be > 0, got %d", c.Control.PingTimeoutSeconds)
+	check(c.Control.SendTimeoutSeconds >= 0, "control.send_timeout_seconds must be >= 0 (0 = no limit), got %d", c.Control.SendTimeoutSeconds)
+	check(c.Control.MaxLoadedAgeHours >= 0, "control.max_loaded_age_hours must be >= 0, got %d", c.Control.MaxLoadedAgeHours)
+	check(c.Control.StatusLogIntervalSeconds >= 0, "control.status_log_interval_seconds must be >= 0, got %d", c.Control.StatusLogIntervalSeconds)
+	check(c.Control.MaxModelsPerNode >= 0, "control.max_models_per_node must be >= 0 (0 = no cap), got %d", c.Control.MaxModelsPerNode)
+	check(c.Control.HelloGraceSeconds >= 0, "control.hello_grace_seconds must be >= 0, got %d", c.Control.HelloGraceSeconds)
+	check(c.Control.KeepaliveSeconds >= 0, "control.keepalive_seconds must be >= 0, got %d", c.Control.KeepaliveSeconds)
+	check(c.Control.KeepaliveSeconds == 0 || c.Control.KeepaliveTimeoutSeconds > 0, "control.keepalive_timeout_seconds must be > 0, got %d", c.Control.KeepaliveTimeoutSeconds)
+
+	check(c.Planner.MinFreeRAMMB >= 0, "planner.min_free_ram_mb must be >= 0, got %d", c.Planner.MinFreeRAMMB)
+	check(c.Planner.IntervalSeconds > 0, "planner.interval_seconds must be > 0, got %d", c.Planner.IntervalSeconds)
+	check(c.Planner.MinResidentSeconds >= 0, "planner.min_resident_seconds must be >= 0, got %d", c.Planner.MinResidentSeconds)
+	check(c.Planner.ErrorUnloadAfterSeconds >= 0, "planner.error_unload_after_seconds must be >= 0, got %d", c.Planner.ErrorUnloadAfterSeconds)
+	check(c.Planner.UnloadRetries >= 0, "planner.unload_retries must be >= 0, got %d", c.Planner.UnloadRetries)
+	check(c.Proxy.UpstreamThrottleBackoffSeconds >= 0, "proxy.upstream_throttle_backoff_seconds must be >= 0, got %d", c.Proxy.UpstreamThrottleBackoffSeconds)
+
+	check(c.Proxy.EmbeddingsChunkSize > 0, "proxy.embeddings_chunk_size must be > 0, got %d", c.Proxy.EmbeddingsChunkSize)
+	check(c.Proxy.MaxConnsPerNode >= 0, "proxy.max_conns_per_node must be >= 0, got %d", c.Proxy.MaxConnsPerNode)
+	check(c.Proxy.MaxIdleConnsPerNode >= 0, "proxy.max_idle_conns_per_node must be >= 0, got %d", c.Proxy.MaxIdleConnsPerNode)
+	check(c.Proxy.PlacementStrategy == "score" || c.Proxy.PlacementStrategy == "consistent_hash",
+		"proxy.placement_strategy must be score or consistent_hash, got %q", c.Proxy.PlacementStrategy)
+	check(c.Proxy.HashPrefixChars >= 0, "proxy.hash_prefix_chars must be >= 0, got %d", c.Proxy.HashPrefixChars)
+	check(c.Proxy.CrossZonePenaltyMB >= 0, "proxy.cross_zone_penalty_mb must be >= 0, got %d", c.Proxy.CrossZonePenaltyMB)
+	check(!c.Proxy.StaleNoColdLoads || c.StatusStaleSeconds > 0, "proxy.stale_no_cold_loads requires status_stale_seconds > 0")
+	check(c.Proxy.LoadRetryAfterSeconds > 0, "proxy.load_retry_after_seconds must be > 0, got %d", c.Proxy.LoadRetryAfterSeconds)
+	check(c.Proxy.LoaderFailovers >= 0, "proxy.loader_failovers must be >= 0, got %d", c.Proxy.LoaderFailovers)
+	check(c.Proxy.InflightTokenUnit >= 0, "proxy.inflight_token_unit must be >= 0, got %d", c.Proxy.InflightTokenUnit)
+	check(c.Proxy.MaxBodyMB >= 0, "proxy.max_body_mb must be >= 0, got %d", c.Proxy.MaxBodyMB)
+	check(c.Proxy.MaxConcurrentRequests >= 0, "proxy.max_concurrent_requests must be >= 0 (0 = unlimited), got %d", c.Proxy.MaxConcurrentRequests)
+	check(c.Proxy.PriorityReservePercent >= 0 && c.Proxy.PriorityReservePercent < 50, "proxy.priority_reserve_percent must be between 0 and 49, got %d", c.Proxy.PriorityReservePercent)
+	check(c.Proxy.BodyReadTimeoutSeconds >= 0, "proxy.body_read_timeout_seconds must be >= 0, got %d", c.Proxy.BodyReadTimeoutSeconds)
+	check(c.Proxy.ReserveNodes >= 0, "proxy.reserve_nodes must be >= 0, got %d", c.Proxy.ReserveNodes)
+	check(c.Proxy.ReserveNodeUtilPercent > 0 && c.Proxy.ReserveNodeUtilPercent <= 100, "proxy.reserve_node_util_percent must be in 1..100, got %d", c.Proxy.ReserveNodeUtilPercent)
+	check(c.Proxy.ReserveRAMPercent >= 0 && c.Proxy.ReserveRAMPercent < 100, "proxy.reserve_ram_percent must be in 0..99, got %d", c.Proxy.ReserveRAMPercent)
+	check(c.Proxy.ModelPriorityWeightPercent >= 0, "proxy.model_priority_weight_percent must be >= 0, got %d", c.Proxy.ModelPriorityWeightPercent)
+	check(c.Proxy.GenSpeedWeightMB >= 0, "proxy.gen_speed_weight_mb must be >= 0, got %d", c.Proxy.GenSpeedWeightMB)
+	check(c.Proxy.OverloadThreshold >= 0, "proxy.overload_threshold must be >= 0, got %d", c.Proxy.OverloadThreshold)
+
+	check(c.Auth.MaxKeysPerUser >= 0, "auth.max_keys_per_user must be >= 0 (0 = unlimited), got %d", c.Auth.MaxKeysPerUser)
+	check(c.Auth.UsageFlushSeconds > 0, "auth.usage_flush_seconds must be > 0, got %d", c.Auth.UsageFlushSeconds)
+	check(c.Auth.BudgetMode == "reject" || c.Auth.BudgetMode == "warn",
+		"auth.budget_mode must be reject or warn, got %q", c.Auth.BudgetMode)
+	check(c.Auth.KeyPepper == "" || c.Auth.KeyPepperFile == "", "auth.key_pepper and auth.key_pepper_file must not be set together")
+	check(c.Auth.PreviousKeyPepper == "" || c.Auth.PreviousKeyPepperFile == "", "auth.previous_key_pepper and auth.previous_key_pepper_file must not be set together")
+	check((c.Auth.PreviousKeyPepper == "" && c.Auth.PreviousKeyPepperFile == "") || c.Auth.KeyPepper != "" || c.Auth.KeyPepperFile != "",
+		"auth.previous_key_pepper requires auth.key_pepper (the pepper rotated to)")
+
+	if c.Webhook.URL != "" {
+		u, err := url.Parse(c.Webhook.URL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "webhook.url must be an http(s) URL, got %q", c.Webhook.URL)
+	}
+	check(c.Webhook.TimeoutSeconds > 0, "webhook.timeout_seconds must be > 0, got %d", c.Webhook.TimeoutSeconds)
+	check(c.Webhook.Retries >= 0, "webhook.retries must be >= 0, got %d", c.Webhook.Retries)
+
+	switch c.PromptLog.Sink {
+	case "", "stdout":
+	case "file", "db":
+		check(c.PromptLog.Path != "", "prompt_log.path must be set for sink %q", c.PromptLog.Sink)
+	default:
+		check(false, "prompt_log.sink must be empty, stdout, file or db, got %q", c.PromptLog.Sink)
+	}
+	check(c.PromptLog.Sink == "" || strings.TrimSpace(c.PromptLog.Keys) != "", "prompt_log.keys must list the API key ids to capture (or *)")
+	check(c.PromptLog.MaxKB >= 0, "prompt_log.max_kb must be >= 0 (0 = unlimited), got %d", c.PromptLog.MaxKB)
+
+	check(c.Store.DegradedAfterErrors >= 0, "store.degraded_after_errors must be >= 0, got %d", c.Store.DegradedAfterErrors)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// redacted replaces secret values in Redacted output.
+const redacted = "<redacted>"
+
+// Redacted returns a copy that is safe to show: the webhook secret, the upstream
+// credentials and the API key peppers are replaced and the webhook URL is reduced to scheme and host (paths and
+// queries often carry tokens). Static upstream URLs keep their path, but not their
+// user info and query.
+func (c Server) Redacted() Server {
+	if c.Webhook.Secret != "" {
+		c.Webhook.Secret = redacted
+	}
+	if c.Proxy.UpstreamCredentials != "" {
+		c.Proxy.UpstreamCredentials = redacted
+	}
+	if c.Auth.KeyPepper != "" {
+		c.Auth.KeyPepper = redacted
+	}
+	if c.Auth.AdminPassword != "" {
+		c.Auth.AdminPassword = redacted
+	}
+	if c.Auth.PreviousKeyPepper != "" {
+		c.Auth.PreviousKeyPepper = redacted
+	}
+	if c.Webhook.URL != "" {
+		if u, err := url.Parse(c.Webhook.URL); err == nil && u.Host != "" {
+			c.Webhook.URL = u.Scheme + "://" + u.Host
+			if u.Path != "" || u.RawQuery != "" || u.User != nil {
+				c.Webhook.URL += "/" + redacted
+			}
+		} else {
+			c.Webhook.URL = redacted
+		}
+	}
+	if c.Proxy.StaticUpstreams != "" {
+		c.Proxy.StaticUpstreams = redactStaticUpstreams(c.Proxy.StaticUpstreams)
+	}
+	return c
+}
+
+// redactStaticUpstreams replaces user info and query of the "model=url" entries.
+func redactStaticUpstreams(s string) string {
+	entries := strings.Split(s, ",")
+	for i, entry := range entries {
+		modelID, raw, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			entries[i] = redacted
+			continue
+		}
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || u.Host == "" {
+			entries[i] = modelID + "=" + redacted
+			continue
+		}
+		out := modelID + "=" + u.Scheme + "://"
+		if u.User != nil {
+			out += redacted + "@"
+		}
+		out += u.Host + u.EscapedPath()
+		if u.RawQuery != "" {
+			out += "?" + redacted
+		}
+		entries[i] = out
+	}
+	return strings.Join(entries, ",")
+}
+
+// envReader applies set environment variables and collects parse errors.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) str(k string, dst *string) {
+	if v, ok := os.LookupEnv(k); ok && v != "" {
+		*dst = v
+	}
+}
+
+func (e *envReader) int(k string, dst *int) {
+	v, ok := os.LookupEnv(k)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("env %s: invalid integer %q", k, v))
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) bool(k string, dst *bool) {
+	v, ok := os.LookupEnv(k)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("env %s: invalid boolean %q", k, v))
+		return
+	}
+	*dst = b
+}
@@ -0,0 +1,91 @@
+package control
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+	"github.com/mcules/llm-router/internal/activity"
+)
+
+// LoadCancelNotifier is optionally implemented by the Notifier. It is called once a node
+// confirmed a canceled load, so placement stops waiting for that loader.
+type LoadCancelNotifier interface {
+	NotifyLoadCanceled(nodeID, modelID string)
+}
+
+// cancelAckTTL is how long a cancel request is remembered without an ack (the agent may
+// wait for a load it cannot abort to finish).
+const cancelAckTTL = 15 * time.Minute
+
+type pendingCancel struct {
+	nodeID  string
+	modelID string
+	sentAt  time.Time
+}
+
+// SendCancelLoad asks the node to abort loading modelID. The ack is handled in handleAck.
+func (s *NodeControlService) SendCancelLoad(nodeID, requestID, modelID string) error {
+	msg := &controlplanev1.ServerMessage{
+		Msg: &controlplanev1.ServerMessage_CancelLoad{
+			CancelLoad: &controlplanev1.CancelLoad{
+				RequestId: requestID,
+				ModelId:   modelID,
+			},
+		},
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for id, pc := range s.cancels {
+		if now.Sub(pc.sentAt) > cancelAckTTL {
+			delete(s.cancels, id)
+		}
+	}
+	s.cancels[requestID] = pendingCancel{nodeID: nodeID, modelID: modelID, sentAt: now}
+	s.mu.Unlock()
+
+	// A queued command (ErrQueued) is still acked once delivered.
+	err := s.sendCommand(nodeID, msg)
+	if err != nil && !errors.Is(err, ErrQueued) {
+		s.mu.Lock()
+		delete(s.cancels, requestID)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// handleAck completes commands that need follow-up on the server (LoadModel and
+// CancelLoad).
+func (s *NodeControlService) handleAck(ack *controlplanev1.CommandAck) {
+	if s.handleLoadAck(ack) {
+		return
+	}
+
+	s.mu.Lock()
+	pc, ok := s.cancels[ack.RequestId]
+	delete(s.cancels, ack.RequestId)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if !ack.Ok {
+		log.Printf("control: cancel load of %s on node %s failed: %s", pc.modelID, pc.nodeID, ack.Error)
+		return
+	}
+
+	if n, ok := s.Notifier.(LoadCancelNotifier); ok {
+		n.NotifyLoadCanceled(pc.nodeID, pc.modelID)
+	}
+	if s.Activity != nil {
+		s.Activity.Add(activity.Event{
+			At:     time.Now(),
+			Type:   activity.EventLoadCanceled,
+			NodeID: pc.nodeID,
+			Model:  pc.modelID,
+			Note:   "canceled by operator",
+		})
+	}
+}
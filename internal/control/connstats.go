@@ -0,0 +1,115 @@
+package control
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flapWindow: a node that re-attaches within this time after its stream ended counts as
+// flapping (e.g. an unstable network or a crash-looping agent).
+const flapWindow = time.Minute
+
+// Disconnect reasons of a node stream.
+const (
+	DisconnectEOF      = "closed by agent"
+	DisconnectReplaced = "replaced by new stream"
+)
+
+// ConnStats is the control stream history of a node since server start.
+type ConnStats struct {
+	Connects       int
+	Disconnects    int
+	Flaps          int // re-attaches within flapWindow after a disconnect
+	LastConnect    time.Time
+	LastDisconnect time.Time
+	LastFlap       time.Time
+	LastReason     string // why the stream last ended ("" = never ended)
+	RemoteAddr     string // address of the current or last stream
+}
+
+// Connected reports whether the node currently has a stream.
+func (c ConnStats) Connected() bool {
+	return c.Connects > c.Disconnects
+}
+
+// FlappingSince reports whether the node flapped after t.
+func (c ConnStats) FlappingSince(t time.Time) bool {
+	return c.LastFlap.After(t)
+}
+
+// connTracker records attach/detach events per node.
+type connTracker struct {
+	mu    sync.Mutex
+	nodes map[string]*ConnStats
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{nodes: map[string]*ConnStats{}}
+}
+
+func (t *connTracker) getLocked(nodeID string) *ConnStats {
+	c := t.nodes[nodeID]
+	if c == nil {
+		c = &ConnStats{}
+		t.nodes[nodeID] = c
+	}
+	return c
+}
+
+func (t *connTracker) connected(nodeID, remoteAddr string, now time.Time) (ConnStats, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.getLocked(nodeID)
+	flap := c.Disconnects > 0 && !c.Connected() && now.Sub(c.LastDisconnect) < flapWindow
+	if flap {
+		c.Flaps++
+		c.LastFlap = now
+	}
+	c.Connects++
+	c.LastConnect = now
+	c.RemoteAddr = remoteAddr
+	return *c, flap
+}
+
+func (t *connTracker) disconnected(nodeID, reason string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.getLocked(nodeID)
+	c.Disconnects++
+	c.LastDisconnect = now
+	c.LastReason = reason
+}
+
+func (t *connTracker) get(nodeID string) (ConnStats, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.nodes[nodeID]
+	if !ok {
+		return ConnStats{}, false
+	}
+	return *c, true
+}
+
+// disconnectReason describes how a node stream ended.
+func disconnectReason(err error) string {
+	if err == nil || errors.Is(err, io.EOF) {
+		return DisconnectEOF
+	}
+	if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+		return st.Code().String() + ": " + st.Message()
+	}
+	return err.Error()
+}
+
+// Connection returns the control stream history of a node.
+func (s *NodeControlService) Connection(nodeID string) (ConnStats, bool) {
+	return s.conns.get(nodeID)
+}
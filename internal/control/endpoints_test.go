@@ -0,0 +1,39 @@
+package control
+
+import (
+	"maps"
+	"testing"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+)
+
+func TestHelloEndpointPaths(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   map[string]string
+		want map[string]string
+	}{
+		{"none", nil, nil},
+		{"valid", map[string]string{"chat": "/generate", "embeddings:e5": "/embed"}, map[string]string{"chat": "/generate", "embeddings:e5": "/embed"}},
+		{"relative path dropped", map[string]string{"chat": "generate", "completions": "/complete"}, map[string]string{"completions": "/complete"}},
+		{"nothing valid", map[string]string{"": "/x", "chat": "http://other/generate"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestService()
+			hello := helloMsg("n1")
+			hello.GetHello().EndpointPaths = tc.in
+			stream := &scriptedStream{fakeStream: newFakeStream(), msgs: []*controlplanev1.NodeMessage{hello, statusMsg()}}
+			if err := s.Stream(stream); err != nil {
+				t.Fatalf("Stream = %v", err)
+			}
+
+			_, got, _, ok := s.Cluster.NodeDataPlane("n1")
+			if !ok {
+				t.Fatal("node not registered")
+			}
+			if !maps.Equal(got, tc.want) || (tc.want == nil) != (got == nil) {
+				t.Errorf("endpoint paths = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package control
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+	"github.com/mcules/llm-router/internal/state"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// scriptedStream is a control stream receiving msgs in order, gap apart, then io.EOF.
+type scriptedStream struct {
+	*fakeStream
+	msgs []*controlplanev1.NodeMessage
+	gap  time.Duration
+	recv int
+}
+
+func (s *scriptedStream) Recv() (*controlplanev1.NodeMessage, error) {
+	if len(s.msgs) == 0 {
+		return nil, io.EOF
+	}
+	if s.recv++; s.recv > 1 {
+		time.Sleep(s.gap)
+	}
+	m := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return m, nil
+}
+
+func helloMsg(nodeID string) *controlplanev1.NodeMessage {
+	return &controlplanev1.NodeMessage{Msg: &controlplanev1.NodeMessage_Hello{
+		Hello: &controlplanev1.NodeHello{NodeId: nodeID, DataPlaneUrl: "http://" + nodeID},
+	}}
+}
+
+func statusMsg(models ...string) *controlplanev1.NodeMessage {
+	st := &controlplanev1.NodeStatus{RamTotalBytes: 64 << 30, RamAvailableBytes: 32 << 30}
+	for _, m := range models {
+		st.Models = append(st.Models, &controlplanev1.ModelResidency{ModelId: m, State: controlplanev1.ModelState_MODEL_STATE_READY})
+	}
+	return &controlplanev1.NodeMessage{Msg: &controlplanev1.NodeMessage_Status{Status: st}}
+}
+
+func TestStatusBeforeHelloIsHeld(t *testing.T) {
+	s := newTestService()
+	stream := &scriptedStream{fakeStream: newFakeStream(), msgs: []*controlplanev1.NodeMessage{
+		statusMsg("old"),
+		statusMsg("m"), // the latest early status wins
+		helloMsg("n1"),
+	}}
+	if err := s.Stream(stream); err != nil {
+		t.Fatalf("Stream = %v, want the stream kept open until EOF", err)
+	}
+
+	n, ok := s.Cluster.Node("n1")
+	if !ok {
+		t.Fatal("node n1 not registered")
+	}
+	if n.Models["m"].State != state.ModelReady {
+		t.Errorf("models = %v, want the early status applied after the hello", n.Models)
+	}
+	if _, ok := n.Models["old"]; ok {
+		t.Error("an older early status was applied")
+	}
+}
+
+func TestStatusBeforeHelloWithoutGrace(t *testing.T) {
+	s := newTestService()
+	s.HelloGrace = 0
+	stream := &scriptedStream{fakeStream: newFakeStream(), msgs: []*controlplanev1.NodeMessage{
+		statusMsg("m"),
+		helloMsg("n1"),
+	}}
+	err := s.Stream(stream)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Stream = %v, want FailedPrecondition", err)
+	}
+	if _, ok := s.Cluster.Node("n1"); ok {
+		t.Error("node registered from a closed stream")
+	}
+}
+
+func TestStatusBeforeHelloPastGrace(t *testing.T) {
+	s := newTestService()
+	s.HelloGrace = 20 * time.Millisecond
+	stream := &scriptedStream{fakeStream: newFakeStream(), gap: 50 * time.Millisecond, msgs: []*controlplanev1.NodeMessage{
+		statusMsg("m"),
+		statusMsg("m"), // still no hello after the grace
+		helloMsg("n1"),
+	}}
+	if err := s.Stream(stream); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Stream = %v, want FailedPrecondition once the grace is over", err)
+	}
+}
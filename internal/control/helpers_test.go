@@ -0,0 +1,59 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+	"github.com/mcules/llm-router/internal/state"
+
+	"google.golang.org/grpc"
+)
+
+// fakeStream is a control stream whose Send fails after failAfter messages (-1 = never).
+type fakeStream struct {
+	grpc.ServerStream
+
+	mu        sync.Mutex
+	sent      []*controlplanev1.ServerMessage
+	failAfter int
+}
+
+func newFakeStream() *fakeStream { return &fakeStream{failAfter: -1} }
+
+func (f *fakeStream) Context() context.Context { return context.Background() }
+
+func (f *fakeStream) Send(m *controlplanev1.ServerMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failAfter >= 0 && len(f.sent) >= f.failAfter {
+		return errors.New("stream broken")
+	}
+	f.sent = append(f.sent, m)
+	return nil
+}
+
+func (f *fakeStream) Recv() (*controlplanev1.NodeMessage, error) {
+	select {}
+}
+
+func (f *fakeStream) unloads() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for _, m := range f.sent {
+		if u := m.GetUnloadModel(); u != nil {
+			out = append(out, u.ModelId)
+		}
+	}
+	return out
+}
+
+// newTestService returns a service without send retry pauses.
+func newTestService() *NodeControlService {
+	s := NewNodeControlService(state.NewClusterState(), nil)
+	s.SendRetries = 0
+	s.SendRetryBackoff = 0
+	return s
+}
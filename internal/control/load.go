@@ -0,0 +1,70 @@
+package control
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// loadAckTTL is how long a load command is remembered without an ack.
+const loadAckTTL = 5 * time.Minute
+
+type pendingLoad struct {
+	nodeID  string
+	modelID string
+	sentAt  time.Time
+}
+
+// SendLoad asks the node to start loading modelID. A failed ack is reported to the
+// Notifier as ERROR, so requests waiting for the load fail fast (see handleAck).
+func (s *NodeControlService) SendLoad(nodeID, requestID, modelID string) error {
+	msg := &controlplanev1.ServerMessage{
+		Msg: &controlplanev1.ServerMessage_LoadModel{
+			LoadModel: &controlplanev1.LoadModel{
+				RequestId: requestID,
+				ModelId:   modelID,
+			},
+		},
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for id, pl := range s.loads {
+		if now.Sub(pl.sentAt) > loadAckTTL {
+			delete(s.loads, id)
+		}
+	}
+	s.loads[requestID] = pendingLoad{nodeID: nodeID, modelID: modelID, sentAt: now}
+	s.mu.Unlock()
+
+	// A queued command (ErrQueued) is still acked once delivered.
+	err := s.sendCommand(nodeID, msg)
+	if err != nil && !errors.Is(err, ErrQueued) {
+		s.mu.Lock()
+		delete(s.loads, requestID)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// handleLoadAck completes a load command; it reports whether requestID was one.
+func (s *NodeControlService) handleLoadAck(ack *controlplanev1.CommandAck) bool {
+	s.mu.Lock()
+	pl, ok := s.loads[ack.RequestId]
+	delete(s.loads, ack.RequestId)
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	if !ack.Ok {
+		log.Printf("control: load of %s on node %s failed: %s", pl.modelID, pl.nodeID, ack.Error)
+		if s.Notifier != nil {
+			s.Notifier.NotifyModelState(pl.nodeID, pl.modelID, state.ModelError)
+		}
+	}
+	return true
+}
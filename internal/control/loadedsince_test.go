@@ -0,0 +1,68 @@
+package control
+
+import (
+	"testing"
+	"time"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestSanitizeLoadedSince(t *testing.T) {
+	s := newTestService()
+	s.MaxLoadedAge = 24 * time.Hour
+	now := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	accepted := now.Add(-time.Hour)
+	prev := &state.NodeSnapshot{Models: map[string]state.ModelResidency{"m": {ModelID: "m", LoadedSince: accepted}}}
+
+	for _, tc := range []struct {
+		name     string
+		reported time.Time
+		prev     *state.NodeSnapshot
+		want     time.Time
+	}{
+		{"plausible", now.Add(-2 * time.Hour), prev, now.Add(-2 * time.Hour)},
+		{"not reported", time.Time{}, prev, time.Time{}},
+		{"future, first status", now.Add(time.Hour), nil, now},
+		{"future, keeps the accepted value", now.Add(time.Hour), prev, accepted},
+		{"far past, first status", now.Add(-48 * time.Hour), nil, now},
+		{"far past, keeps the accepted value", now.Add(-48 * time.Hour), prev, accepted},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := s.sanitizeLoadedSince("n1", "m", tc.reported, tc.prev, now); !got.Equal(tc.want) {
+				t.Errorf("sanitized %s to %s, want %s", tc.reported, got, tc.want)
+			}
+		})
+	}
+
+	s.MaxLoadedAge = 0
+	old := now.Add(-48 * time.Hour)
+	if got := s.sanitizeLoadedSince("n1", "m", old, nil, now); !got.Equal(old) {
+		t.Errorf("without MaxLoadedAge sanitized %s to %s", old, got)
+	}
+}
+
+func TestFutureLoadedSinceStaysPut(t *testing.T) {
+	s := newTestService()
+	stream := newFakeStream()
+	s.Cluster.UpsertNodeHello("n1", "test", "", "http://n1", "", state.DataPlaneTLS{}, nil)
+	future := time.Now().Add(time.Hour).UnixMilli()
+	report := func() time.Time {
+		s.applyStatus(stream, "n1", &controlplanev1.NodeStatus{
+			RamTotalBytes: 64 << 30, RamAvailableBytes: 32 << 30,
+			Models: []*controlplanev1.ModelResidency{{ModelId: "m", State: controlplanev1.ModelState_MODEL_STATE_READY, LoadedSinceUnixMs: future}},
+		})
+		n, _ := s.Cluster.Node("n1")
+		return n.Models["m"].LoadedSince
+	}
+
+	first := report()
+	if first.After(time.Now()) {
+		t.Fatalf("LoadedSince = %s, want no future time", first)
+	}
+	time.Sleep(5 * time.Millisecond)
+	// Repeating the skewed time must not move LoadedSince forward, else the TTL never expires.
+	if second := report(); !second.Equal(first) {
+		t.Errorf("LoadedSince moved from %s to %s", first, second)
+	}
+}
@@ -0,0 +1,78 @@
+package control
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+)
+
+// modelWarnInterval is how often an agent reporting bad model lists is warned about.
+const modelWarnInterval = 5 * time.Minute
+
+// sanitizeModels drops entries without id, duplicate ids (the first entry wins) and
+// everything beyond limit unique models (0 = no cap). It returns the kept entries and
+// the number of duplicates and entries dropped by the cap.
+func sanitizeModels(in []*controlplanev1.ModelResidency, limit int) (out []*controlplanev1.ModelResidency, dups, capped int) {
+	seen := make(map[string]struct{}, len(in))
+	out = make([]*controlplanev1.ModelResidency, 0, len(in))
+	for _, m := range in {
+		if m == nil || m.ModelId == "" {
+			dups++
+			continue
+		}
+		if _, ok := seen[m.ModelId]; ok {
+			dups++
+			continue
+		}
+		if limit > 0 && len(out) >= limit {
+			capped++
+			continue
+		}
+		seen[m.ModelId] = struct{}{}
+		out = append(out, m)
+	}
+	return out, dups, capped
+}
+
+// modelWarner rate-limits warnings about malformed model lists per node.
+type modelWarner struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func (w *modelWarner) warn(nodeID string, now time.Time, reported, dups, capped, limit int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.last == nil {
+		w.last = map[string]time.Time{}
+	}
+	if now.Sub(w.last[nodeID]) < modelWarnInterval {
+		return
+	}
+	w.last[nodeID] = now
+	log.Printf("WARNING: node %s reported %d models: dropped %d duplicate/empty and %d beyond the limit of %d per node",
+		nodeID, reported, dups, capped, limit)
+}
+
+// endpointPaths returns the endpoint map of a hello without entries whose path is not
+// absolute (logged), or nil if empty.
+func endpointPaths(nodeID string, in map[string]string) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for endpoint, path := range in {
+		if endpoint == "" || !strings.HasPrefix(path, "/") {
+			log.Printf("WARNING: node %s reported endpoint path %q=%q, ignoring it (path must start with /)", nodeID, endpoint, path)
+			continue
+		}
+		out[endpoint] = path
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
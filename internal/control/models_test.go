@@ -0,0 +1,64 @@
+package control
+
+import (
+	"fmt"
+	"testing"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func residency(id string, st controlplanev1.ModelState) *controlplanev1.ModelResidency {
+	return &controlplanev1.ModelResidency{ModelId: id, State: st}
+}
+
+func TestSanitizeModels(t *testing.T) {
+	ready, failed := controlplanev1.ModelState_MODEL_STATE_READY, controlplanev1.ModelState_MODEL_STATE_ERROR
+	in := []*controlplanev1.ModelResidency{
+		residency("a", ready),
+		residency("a", failed), // duplicate: the first entry wins
+		residency("", ready),
+		nil,
+		residency("b", ready),
+		residency("c", ready),
+		residency("d", ready),
+		residency("e", ready),
+	}
+
+	out, dups, capped := sanitizeModels(in, 3)
+	var ids []string
+	for _, m := range out {
+		ids = append(ids, m.ModelId)
+	}
+	if fmt.Sprint(ids) != "[a b c]" || out[0].State != ready {
+		t.Errorf("kept %v (a %s), want [a b c] with a READY", ids, out[0].State)
+	}
+	if dups != 3 || capped != 2 {
+		t.Errorf("dups %d, capped %d, want 3 and 2", dups, capped)
+	}
+
+	if out, _, capped := sanitizeModels(in, 0); len(out) != 5 || capped != 0 {
+		t.Errorf("without cap kept %d (capped %d), want 5", len(out), capped)
+	}
+}
+
+func TestStatusWithExcessiveModels(t *testing.T) {
+	s := newTestService()
+	s.MaxModelsPerNode = 100
+	s.Cluster.UpsertNodeHello("n1", "test", "", "http://n1", "", state.DataPlaneTLS{}, nil)
+
+	report := &controlplanev1.NodeStatus{RamTotalBytes: 64 << 30, RamAvailableBytes: 32 << 30}
+	for i := range 5000 {
+		report.Models = append(report.Models, residency(fmt.Sprintf("phantom-%04d", i), controlplanev1.ModelState_MODEL_STATE_UNLOADED))
+		report.Models = append(report.Models, residency(fmt.Sprintf("phantom-%04d", i), controlplanev1.ModelState_MODEL_STATE_READY))
+	}
+	s.applyStatus(newFakeStream(), "n1", report)
+
+	n, _ := s.Cluster.Node("n1")
+	if len(n.Models) != 100 {
+		t.Fatalf("stored %d models, want the cap of 100", len(n.Models))
+	}
+	if m := n.Models["phantom-0000"]; m.State != state.ModelUnloaded {
+		t.Errorf("phantom-0000 = %s, want the first entry (UNLOADED)", m.State)
+	}
+}
@@ -1,12 +1,14 @@
 package control
 
 import (
+	"errors"
 	"io"
 	"log"
 	"sync"
 	"time"
 
 	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+	"github.com/mcules/llm-router/internal/activity"
 	"github.com/mcules/llm-router/internal/state"
 
 	"google.golang.org/grpc/codes"
@@ -18,36 +20,110 @@ type ModelStateNotifier interface {
 	NotifyModelState(nodeID, modelID string, st state.ModelState)
 }
 
+// ErrNodeReconnecting is returned when a command could not be delivered because the
+// node's stream is down, but the node was connected recently and is expected back.
+var ErrNodeReconnecting = status.Error(codes.Unavailable, "node reconnecting, retry later")
+
+// ErrQueued is returned when a command could not be delivered right away and was queued
+// for a recently connected node instead: it is sent when the node re-attaches within
+// ReconnectGrace and dropped otherwise.
+var ErrQueued = errors.New("node reconnecting, command queued until it re-attaches")
+
+// errSendTimeout ends a send that missed its deadline; the stream is closed then.
+var errSendTimeout = errors.New("control stream send timed out")
+
+// maxPendingPerNode bounds the commands queued for a reconnecting node.
+const maxPendingPerNode = 32
+
 type NodeControlService struct {
 	controlplanev1.UnimplementedNodeControlServer
 	Cluster  *state.ClusterState
 	Notifier ModelStateNotifier
-
-	mu      sync.RWMutex
-	streams map[string]*nodeStream
+	// Activity (optional) receives load_failed events for models entering ERROR.
+	Activity *activity.Log
+
+	// SendRetries is the number of additional send attempts for a command.
+	SendRetries int
+	// SendRetryBackoff is the pause between send attempts.
+	SendRetryBackoff time.Duration
+	// ReconnectGrace is how long undeliverable commands are queued for a node whose
+	// stream dropped. Queued commands are flushed when the node re-attaches (0 = no queue).
+	ReconnectGrace time.Duration
+
+	// MaxLoadedAge bounds reported LoadedSince timestamps (see sanitizeLoadedSince; 0 = no bound).
+	MaxLoadedAge time.Duration
+
+	// PingConcurrency bounds parallel sends in BroadcastPing.
+	PingConcurrency int
+	// PingTimeout bounds a single ping send.
+	PingTimeout time.Duration
+	// SendTimeout bounds a single command send (0 = no limit). A ping or command send
+	// missing its deadline closes the stream as wedged (see send).
+	SendTimeout time.Duration
+
+	// StatusLogInterval is how often an unchanged node status is logged (0 = only on changes).
+	StatusLogInterval time.Duration
+
+	// MaxModelsPerNode caps the models accepted from one status message; duplicate ids
+	// are always dropped (0 = no cap).
+	MaxModelsPerNode int
+
+	// HelloGrace is how long a status received before the node's hello is held for it;
+	// a status after the grace closes the stream (0 = close right away).
+	HelloGrace time.Duration
+
+	statusLog   *statusLogger
+	modelWarner modelWarner
+	conns       *connTracker
+
+	mu         sync.RWMutex
+	streams    map[string]*nodeStream
+	detachedAt map[string]time.Time
+	pending    map[string][]pendingCommand
+	cancels    map[string]pendingCancel // by request id, until acked
+	loads      map[string]pendingLoad   // by request id, until acked
 }
 
 type nodeStream struct {
+	nodeID string
 	sendMu sync.Mutex
 	stream controlplanev1.NodeControl_StreamServer
+
+	// abort is closed to end the stream's handler (see Stream; nil = no handler).
+	abort     chan struct{}
+	abortOnce sync.Once
+}
+
+type pendingCommand struct {
+	msg      *controlplanev1.ServerMessage
+	queuedAt time.Time
 }
 
 func NewNodeControlService(cluster *state.ClusterState, notifier ModelStateNotifier) *NodeControlService {
 	return &NodeControlService{
-		Cluster:  cluster,
-		Notifier: notifier,
-		streams:  map[string]*nodeStream{},
+		Cluster:           cluster,
+		Notifier:          notifier,
+		SendRetries:       2,
+		SendRetryBackoff:  250 * time.Millisecond,
+		ReconnectGrace:    15 * time.Second,
+		MaxLoadedAge:      30 * 24 * time.Hour,
+		PingConcurrency:   16,
+		PingTimeout:       5 * time.Second,
+		SendTimeout:       10 * time.Second,
+		StatusLogInterval: 60 * time.Second,
+		MaxModelsPerNode:  1000,
+		HelloGrace:        10 * time.Second,
+		statusLog:         newStatusLogger(),
+		conns:             newConnTracker(),
+		streams:           map[string]*nodeStream{},
+		detachedAt:        map[string]time.Time{},
+		pending:           map[string][]pendingCommand{},
+		cancels:           map[string]pendingCancel{},
+		loads:             map[string]pendingLoad{},
 	}
 }
 
 func (s *NodeControlService) SendUnload(nodeID, requestID, modelID string) error {
-	s.mu.RLock()
-	ns := s.streams[nodeID]
-	s.mu.RUnlock()
-	if ns == nil {
-		return status.Errorf(codes.Unavailable, "node stream not available: %s", nodeID)
-	}
-
 	msg := &controlplanev1.ServerMessage{
 		Msg: &controlplanev1.ServerMessage_UnloadModel{
 			UnloadModel: &controlplanev1.UnloadModel{
@@ -56,16 +132,160 @@ func (s *NodeControlService) SendUnload(nodeID, requestID, modelID string) error
 			},
 		},
 	}
+	return s.sendCommand(nodeID, msg)
+}
+
+// sendCommand delivers msg to the node's current stream. Transient failures are retried
+// (re-resolving the stream, which may have been replaced by a reconnect in the meantime).
+// If delivery still fails for a recently connected node, the command is queued until the
+// node re-attaches and ErrQueued is returned.
+func (s *NodeControlService) sendCommand(nodeID string, msg *controlplanev1.ServerMessage) error {
+	var lastErr error
+	for attempt := 0; attempt <= s.SendRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(s.SendRetryBackoff)
+		}
+
+		s.mu.RLock()
+		ns := s.streams[nodeID]
+		detached, ok := s.detachedAt[nodeID]
+		s.mu.RUnlock()
+		known := ok && time.Since(detached) <= s.ReconnectGrace
+
+		if ns == nil {
+			if !known {
+				// Never connected (or gone longer than the grace): nothing to wait for.
+				return status.Errorf(codes.Unavailable, "node stream not available: %s", nodeID)
+			}
+			lastErr = ErrNodeReconnecting
+			continue
+		}
+
+		ns.sendMu.Lock()
+		err := s.send(ns, msg, s.SendTimeout)
+		ns.sendMu.Unlock()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+
+	if s.enqueue(nodeID, msg) {
+		log.Printf("control: node %s unreachable, command queued until reconnect (last error: %v)", nodeID, lastErr)
+		return ErrQueued
+	}
+	return ErrNodeReconnecting
+}
+
+// enqueue stores msg for delivery on re-attach. Unload commands for the same model replace
+// each other so repeated planner ticks don't pile up duplicates.
+func (s *NodeControlService) enqueue(nodeID string, msg *controlplanev1.ServerMessage) bool {
+	if s.ReconnectGrace <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.pending[nodeID]
+	if u := msg.GetUnloadModel(); u != nil {
+		for i, pc := range q {
+			if pu := pc.msg.GetUnloadModel(); pu != nil && pu.ModelId == u.ModelId {
+				q[i] = pendingCommand{msg: msg, queuedAt: time.Now()}
+				return true
+			}
+		}
+	}
+	if len(q) >= maxPendingPerNode {
+		return false
+	}
+	s.pending[nodeID] = append(q, pendingCommand{msg: msg, queuedAt: time.Now()})
+	return true
+}
+
+// flushPending delivers queued commands that have not expired to a freshly attached stream.
+// If a send fails, the undelivered rest stays queued for the next attach.
+func (s *NodeControlService) flushPending(nodeID string, ns *nodeStream) {
+	s.mu.Lock()
+	q := s.pending[nodeID]
+	delete(s.pending, nodeID)
+	s.mu.Unlock()
+
+	if len(q) == 0 {
+		return
+	}
 
 	ns.sendMu.Lock()
 	defer ns.sendMu.Unlock()
 
-	if err := ns.stream.Send(msg); err != nil {
-		return status.Errorf(codes.Unavailable, "send unload: %v", err)
+	delivered := 0
+	for i, pc := range q {
+		if time.Since(pc.queuedAt) > s.ReconnectGrace {
+			continue
+		}
+		if err := s.send(ns, pc.msg, s.SendTimeout); err != nil {
+			log.Printf("control: flush queued command to node %s failed after %d delivered, keeping %d queued: %v", nodeID, delivered, s.requeue(nodeID, q[i:]), err)
+			return
+		}
+		delivered++
+	}
+	log.Printf("control: delivered %d queued command(s) to node %s", delivered, nodeID)
+}
+
+// requeue puts the undelivered commands q back in front of the node's queue (commands may
+// have been queued meanwhile) and returns how many are queued then. Expired commands are
+// dropped.
+func (s *NodeControlService) requeue(nodeID string, q []pendingCommand) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := make([]pendingCommand, 0, len(q)+len(s.pending[nodeID]))
+	for _, pc := range append(q, s.pending[nodeID]...) {
+		if time.Since(pc.queuedAt) <= s.ReconnectGrace {
+			kept = append(kept, pc)
+		}
+	}
+	if len(kept) > maxPendingPerNode {
+		kept = kept[len(kept)-maxPendingPerNode:]
+	}
+	if len(kept) == 0 {
+		delete(s.pending, nodeID)
+		return 0
+	}
+	s.pending[nodeID] = kept
+	return len(kept)
+}
+
+// send sends msg on ns, whose sendMu the caller holds, waiting at most timeout (0 = no
+// limit). A Send blocked that long means the agent stopped reading (e.g. a half-open
+// connection whose flow control window never opens): the stream is detached, so
+// commands are queued for the reconnect, and closed, which makes the blocked Send
+// return; send then fails with errSendTimeout.
+func (s *NodeControlService) send(ns *nodeStream, msg *controlplanev1.ServerMessage, timeout time.Duration) error {
+	if timeout <= 0 {
+		return ns.stream.Send(msg)
+	}
+	done := make(chan error, 1)
+	go func() { done <- ns.stream.Send(msg) }()
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-t.C:
 	}
-	return nil
+	log.Printf("WARNING: control: send to node %s blocked for %s, closing its stream", ns.nodeID, timeout)
+	s.detach(ns.nodeID, ns.stream, errSendTimeout)
+	if ns.abort != nil {
+		ns.abortOnce.Do(func() { close(ns.abort) })
+	}
+	<-done
+	return errSendTimeout
 }
 
+// BroadcastPing pings all connected nodes with at most PingConcurrency sends in flight.
+// Each send is bounded by PingTimeout. A stream busy with another send is skipped: that
+// send is bounded by SendTimeout, so a wedged stream is closed either way.
 func (s *NodeControlService) BroadcastPing() {
 	s.mu.RLock()
 	// Copy stream pointers to minimize lock hold time
@@ -81,16 +301,56 @@ func (s *NodeControlService) BroadcastPing() {
 		},
 	}
 
+	conc := s.PingConcurrency
+	if conc <= 0 {
+		conc = 1
+	}
+	sem := make(chan struct{}, conc)
+	var wg sync.WaitGroup
 	for _, ns := range streams {
+		sem <- struct{}{}
+		wg.Add(1)
 		go func(n *nodeStream) {
-			n.sendMu.Lock()
-			defer n.sendMu.Unlock()
-			_ = n.stream.Send(msg)
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.ping(n, msg)
 		}(ns)
 	}
+	wg.Wait()
 }
 
+func (s *NodeControlService) ping(n *nodeStream, msg *controlplanev1.ServerMessage) {
+	if !n.sendMu.TryLock() {
+		log.Printf("control: ping skipped for node %s: previous send still in progress", n.nodeID)
+		return
+	}
+	defer n.sendMu.Unlock()
+
+	// Pings are not queued: a reconnecting agent sends a fresh status anyway.
+	if err := s.send(n, msg, s.PingTimeout); err != nil {
+		log.Printf("control: ping send to node %s failed: %v", n.nodeID, err)
+	}
+}
+
+// Stream serves the control stream of a node. Messages are received on another
+// goroutine, so a stream whose sends are wedged can be closed without waiting for the
+// agent (see send): returning ends the stream, which unblocks its Send and Recv.
 func (s *NodeControlService) Stream(stream controlplanev1.NodeControl_StreamServer) error {
+	abort := make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- s.receive(stream, abort) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-abort:
+		return status.Error(codes.Unavailable, errSendTimeout.Error())
+	}
+}
+
+// receive handles the messages of a control stream until it ends; abort is closed when
+// the stream is closed as wedged.
+func (s *NodeControlService) receive(stream controlplanev1.NodeControl_StreamServer, abort chan struct{}) error {
 	_ = stream.Send(&controlplanev1.ServerMessage{
 		Msg: &controlplanev1.ServerMessage_Hello{
 			Hello: &controlplanev1.ServerHello{ServerVersion: "dev"},
@@ -99,14 +359,20 @@ func (s *NodeControlService) Stream(stream controlplanev1.NodeControl_StreamServ
 
 	var nodeID string
 
+	// A status received before the hello, held for HelloGrace.
+	var (
+		early   *controlplanev1.NodeStatus
+		earlyAt time.Time
+	)
+
 	for {
 		in, err := stream.Recv()
 		if err == io.EOF {
-			s.detach(nodeID, stream)
+			s.detach(nodeID, stream, err)
 			return nil
 		}
 		if err != nil {
-			s.detach(nodeID, stream)
+			s.detach(nodeID, stream, err)
 			return status.Errorf(codes.Unavailable, "stream recv: %v", err)
 		}
 
@@ -119,9 +385,25 @@ func (s *NodeControlService) Stream(stream controlplanev1.NodeControl_StreamServ
 				msg.Hello.Version,
 				msg.Hello.LlamaBaseUrl,
 				msg.Hello.DataPlaneUrl,
+				msg.Hello.Zone,
+				state.DataPlaneTLS{
+					CAPEM:              msg.Hello.DataPlaneCaPem,
+					InsecureSkipVerify: msg.Hello.DataPlaneTlsInsecure,
+				},
+				endpointPaths(msg.Hello.NodeId, msg.Hello.EndpointPaths),
 			)
+			if msg.Hello.DataPlaneTlsInsecure {
+				log.Printf("WARNING: node %s disabled TLS verification of its data plane (%s)", nodeID, msg.Hello.DataPlaneUrl)
+			}
+
+			s.attach(nodeID, stream, abort)
+			s.mu.RLock()
+			ns := s.streams[nodeID]
+			s.mu.RUnlock()
+			if ns != nil {
+				s.flushPending(nodeID, ns)
+			}
 
-			s.attach(nodeID, stream)
 			remoteAddr := "unknown"
 			if p, ok := peer.FromContext(stream.Context()); ok {
 				remoteAddr = p.Addr.String()
@@ -129,70 +411,123 @@ func (s *NodeControlService) Stream(stream controlplanev1.NodeControl_StreamServ
 			log.Printf("node hello: id=%s version=%s llama=%s data=%s remote=%s",
 				msg.Hello.NodeId, msg.Hello.Version, msg.Hello.LlamaBaseUrl, msg.Hello.DataPlaneUrl, remoteAddr)
 
+			if early != nil {
+				log.Printf("control: node %s: applying status received %s before its hello", nodeID, time.Since(earlyAt).Truncate(time.Millisecond))
+				s.applyStatus(stream, nodeID, early)
+				early = nil
+			}
+
 		case *controlplanev1.NodeMessage_Status:
 			if nodeID == "" {
 				remoteAddr := "unknown"
 				if p, ok := peer.FromContext(stream.Context()); ok {
 					remoteAddr = p.Addr.String()
 				}
+				// On a lossy reconnect the status may overtake the hello: keep the latest
+				// one for HelloGrace instead of forcing the agent into another reconnect.
+				now := time.Now()
+				if early == nil {
+					earlyAt = now
+				}
+				if s.HelloGrace > 0 && now.Sub(earlyAt) <= s.HelloGrace {
+					if early == nil {
+						log.Printf("WARNING: Received status before hello (remote: %s). Holding it for up to %s.", remoteAddr, s.HelloGrace)
+					}
+					early = msg.Status
+					continue
+				}
 				log.Printf("WARNING: Received status from stream with no nodeID (remote: %s). Closing stream.", remoteAddr)
 				return status.Errorf(codes.FailedPrecondition, "nodeID not established via hello")
 			}
+			s.applyStatus(stream, nodeID, msg.Status)
 
-			models := map[string]state.ModelResidency{}
-			now := time.Now()
+		case *controlplanev1.NodeMessage_Ack:
+			log.Printf("node ack: req=%s ok=%v err=%s", msg.Ack.RequestId, msg.Ack.Ok, msg.Ack.Error)
+			s.handleAck(msg.Ack)
 
-			for _, m := range msg.Status.Models {
-				st := mapModelState(m.State)
+		default:
+			// Ignore unknown messages for forward compatibility.
+		}
+	}
+}
 
-				models[m.ModelId] = state.ModelResidency{
-					ModelID:     m.ModelId,
-					State:       st,
-					LoadedSince: unixMsToTime(m.LoadedSinceUnixMs),
-					LastSeen:    now,
-				}
+// applyStatus updates the cluster state from a node status and notifies the router.
+func (s *NodeControlService) applyStatus(stream controlplanev1.NodeControl_StreamServer, nodeID string, report *controlplanev1.NodeStatus) {
+	models := map[string]state.ModelResidency{}
+	now := time.Now()
+	prev, _ := s.Cluster.Node(nodeID)
 
-				// Notify router gates (READY signals unblock waiting requests).
-				if s.Notifier != nil {
-					s.Notifier.NotifyModelState(nodeID, m.ModelId, st)
-				}
-			}
+	// A buggy or compromised agent must not bloat the cluster state.
+	reported, dups, capped := sanitizeModels(report.Models, s.MaxModelsPerNode)
+	if dups > 0 || capped > 0 {
+		s.modelWarner.warn(nodeID, now, len(report.Models), dups, capped, s.MaxModelsPerNode)
+	}
 
-			remoteAddr := "unknown"
-			if p, ok := peer.FromContext(stream.Context()); ok {
-				remoteAddr = p.Addr.String()
-			}
-			log.Printf("node status: id=%s remote=%s ram_avail=%d inflight=%d models=%d", nodeID, remoteAddr, msg.Status.RamAvailableBytes, msg.Status.InflightRequests, len(msg.Status.Models))
-			s.Cluster.UpdateNodeStatus(nodeID, msg.Status.RamTotalBytes, msg.Status.RamAvailableBytes, msg.Status.InflightRequests, models)
+	for _, m := range reported {
+		st := mapModelState(m.State)
 
-			// Verify if this stream is still the authoritative one for this nodeID.
-			s.mu.RLock()
-			currentStream, ok := s.streams[nodeID]
-			s.mu.RUnlock()
-			if ok && currentStream.stream != stream {
-				remoteAddr := "unknown"
-				if p, ok := peer.FromContext(stream.Context()); ok {
-					remoteAddr = p.Addr.String()
-				}
-				log.Printf("WARNING: Received status from non-authoritative stream for node %s (remote: %s). Possible NODE_ID collision!", nodeID, remoteAddr)
-			}
+		models[m.ModelId] = state.ModelResidency{
+			ModelID:     m.ModelId,
+			State:       st,
+			LoadedSince: s.sanitizeLoadedSince(nodeID, m.ModelId, unixMsToTime(m.LoadedSinceUnixMs), prev, now),
+			LastSeen:    now,
+			Error:       m.Error,
 
-		case *controlplanev1.NodeMessage_Ack:
-			log.Printf("node ack: req=%s ok=%v err=%s", msg.Ack.RequestId, msg.Ack.Ok, msg.Ack.Error)
+			Capabilities: m.Capabilities,
+			MaxContext:   m.MaxContext,
+		}
 
-		default:
-			// Ignore unknown messages for forward compatibility.
+		if st == state.ModelError && s.Activity != nil && !wasError(prev, m.ModelId) {
+			s.Activity.Add(activity.Event{
+				At:     now,
+				Type:   activity.EventLoadFailed,
+				NodeID: nodeID,
+				Model:  m.ModelId,
+				Note:   loadFailedNote(m.Error),
+			})
 		}
 	}
+
+	remoteAddr := "unknown"
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		remoteAddr = p.Addr.String()
+	}
+	if ok, reason := s.statusLog.shouldLog(nodeID, now, s.StatusLogInterval, report.RamAvailableBytes, report.InflightRequests, models); ok {
+		log.Printf("node status: id=%s remote=%s ram_avail=%d inflight=%d models=%d (%s)", nodeID, remoteAddr, report.RamAvailableBytes, report.InflightRequests, len(models), reason)
+	}
+	s.Cluster.UpdateNodeStatus(nodeID, report.RamTotalBytes, report.RamAvailableBytes, report.RamSource, report.InflightRequests, report.TotalSlots, report.GenTokensPerSecond, models)
+
+	// Notify router gates (READY unblocks waiting requests, ERROR makes them fail fast).
+	// This must happen after the cluster state update: a released gate makes new
+	// requests re-run placement, which has to see the model READY already.
+	if s.Notifier != nil {
+		for id, m := range models {
+			s.Notifier.NotifyModelState(nodeID, id, m.State)
+		}
+	}
+
+	// Verify if this stream is still the authoritative one for this nodeID.
+	s.mu.RLock()
+	currentStream, ok := s.streams[nodeID]
+	s.mu.RUnlock()
+	if ok && currentStream.stream != stream {
+		remoteAddr := "unknown"
+		if p, ok := peer.FromContext(stream.Context()); ok {
+			remoteAddr = p.Addr.String()
+		}
+		log.Printf("WARNING: Received status from non-authoritative stream for node %s (remote: %s). Possible NODE_ID collision!", nodeID, remoteAddr)
+	}
 }
 
-func (s *NodeControlService) attach(nodeID string, stream controlplanev1.NodeControl_StreamServer) {
+// attach makes stream the node's current stream; closing abort ends its handler.
+func (s *NodeControlService) attach(nodeID string, stream controlplanev1.NodeControl_StreamServer, abort chan struct{}) {
 	if nodeID == "" {
 		return
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	now := time.Now()
 	remoteAddr := "unknown"
 	if p, ok := peer.FromContext(stream.Context()); ok {
 		remoteAddr = p.Addr.String()
@@ -204,20 +539,85 @@ func (s *NodeControlService) attach(nodeID string, stream controlplanev1.NodeCon
 			oldAddr = p.Addr.String()
 		}
 		log.Printf("WARNING: node %s re-attached from %s (previous was %s). If these are different nodes, ensure unique NODE_IDs!", nodeID, remoteAddr, oldAddr)
+		s.conns.disconnected(nodeID, DisconnectReplaced, now)
+	}
+
+	if c, flap := s.conns.connected(nodeID, remoteAddr, now); flap {
+		log.Printf("WARNING: node %s reconnected %s after its stream ended (%s), %d flaps since start",
+			nodeID, now.Sub(c.LastDisconnect).Truncate(time.Millisecond), c.LastReason, c.Flaps)
 	}
 
-	s.streams[nodeID] = &nodeStream{stream: stream}
+	s.streams[nodeID] = &nodeStream{nodeID: nodeID, stream: stream, abort: abort}
+	delete(s.detachedAt, nodeID)
 }
 
-func (s *NodeControlService) detach(nodeID string, stream controlplanev1.NodeControl_StreamServer) {
+// detach removes the node's stream if it is still the current one; err is the receive
+// error that ended it (io.EOF for a clean close).
+func (s *NodeControlService) detach(nodeID string, stream controlplanev1.NodeControl_StreamServer, err error) {
 	if nodeID == "" {
 		return
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if cur := s.streams[nodeID]; cur != nil && cur.stream == stream {
+		now := time.Now()
 		delete(s.streams, nodeID)
+		s.detachedAt[nodeID] = now
+		s.statusLog.forget(nodeID)
+		s.conns.disconnected(nodeID, disconnectReason(err), now)
+	}
+	s.pruneDetachedLocked(time.Now())
+}
+
+// pruneDetachedLocked forgets nodes detached longer than ReconnectGrace, and the commands
+// still queued for them (they have expired). s.mu must be held.
+func (s *NodeControlService) pruneDetachedLocked(now time.Time) {
+	for id, at := range s.detachedAt {
+		if now.Sub(at) > s.ReconnectGrace {
+			delete(s.detachedAt, id)
+			delete(s.pending, id)
+		}
+	}
+}
+
+// sanitizeLoadedSince protects TTL decisions against clock skew between agent and server.
+// Timestamps in the future or older than MaxLoadedAge are replaced by the previously
+// accepted value for the model, or by now if there is none. Keeping the previous value
+// (instead of now on every status) lets the TTL still expire.
+func (s *NodeControlService) sanitizeLoadedSince(nodeID, modelID string, reported time.Time, prev *state.NodeSnapshot, now time.Time) time.Time {
+	if reported.IsZero() {
+		return reported
+	}
+	future := reported.After(now)
+	tooOld := s.MaxLoadedAge > 0 && now.Sub(reported) > s.MaxLoadedAge
+	if !future && !tooOld {
+		return reported
+	}
+
+	if prev != nil {
+		if m, ok := prev.Models[modelID]; ok && !m.LoadedSince.IsZero() {
+			return m.LoadedSince
+		}
+	}
+	log.Printf("WARNING: node %s reported implausible loaded_since for %s: %s (server time %s), using server time",
+		nodeID, modelID, reported.Format(time.RFC3339), now.Format(time.RFC3339))
+	return now
+}
+
+func loadFailedNote(errText string) string {
+	if errText == "" {
+		return "model reported error state"
+	}
+	return errText
+}
+
+// wasError reports whether the previous snapshot already had the model in ERROR.
+func wasError(prev *state.NodeSnapshot, modelID string) bool {
+	if prev == nil {
+		return false
 	}
+	m, ok := prev.Models[modelID]
+	return ok && m.State == state.ModelError
 }
 
 func mapModelState(st controlplanev1.ModelState) state.ModelState {
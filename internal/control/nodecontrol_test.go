@@ -0,0 +1,93 @@
+package control
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSendCommandQueuesForReconnectingNode(t *testing.T) {
+	s := newTestService()
+	first := newFakeStream()
+	s.attach("n1", first, nil)
+	s.detach("n1", first, errors.New("connection reset"))
+
+	if err := s.SendUnload("n1", "r1", "a"); !errors.Is(err, ErrQueued) {
+		t.Fatalf("SendUnload to reconnecting node = %v, want ErrQueued", err)
+	}
+	if err := s.SendLoad("n1", "r2", "b"); !errors.Is(err, ErrQueued) {
+		t.Fatalf("SendLoad to reconnecting node = %v, want ErrQueued", err)
+	}
+	if _, ok := s.loads["r2"]; !ok {
+		t.Error("queued load is not awaiting its ack")
+	}
+
+	next := newFakeStream()
+	s.attach("n1", next, nil)
+	s.flushPending("n1", s.streams["n1"])
+	if got := len(next.sent); got != 2 {
+		t.Fatalf("flushed %d commands, want 2", got)
+	}
+
+	if err := s.SendUnload("unknown", "r3", "a"); err == nil || errors.Is(err, ErrQueued) {
+		t.Errorf("SendUnload to unknown node = %v, want unavailable", err)
+	}
+}
+
+func TestFlushPendingRequeuesUndelivered(t *testing.T) {
+	s := newTestService()
+	first := newFakeStream()
+	s.attach("n1", first, nil)
+	s.detach("n1", first, errors.New("connection reset"))
+	for _, m := range []string{"a", "b", "c"} {
+		if err := s.SendUnload("n1", "r-"+m, m); !errors.Is(err, ErrQueued) {
+			t.Fatalf("SendUnload %s = %v, want ErrQueued", m, err)
+		}
+	}
+
+	flaky := newFakeStream()
+	flaky.failAfter = 1
+	s.attach("n1", flaky, nil)
+	s.flushPending("n1", s.streams["n1"])
+	if got := flaky.unloads(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("delivered %v, want [a]", got)
+	}
+
+	s.detach("n1", flaky, errors.New("connection reset"))
+	next := newFakeStream()
+	s.attach("n1", next, nil)
+	s.flushPending("n1", s.streams["n1"])
+	if got := next.unloads(); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("delivered after reconnect %v, want [b c]", got)
+	}
+	if len(s.pending["n1"]) != 0 {
+		t.Errorf("%d commands still queued", len(s.pending["n1"]))
+	}
+}
+
+func TestDetachedNodesArePruned(t *testing.T) {
+	s := newTestService()
+	s.ReconnectGrace = time.Minute
+	old := newFakeStream()
+	s.attach("old", old, nil)
+	s.detach("old", old, nil)
+	if err := s.SendUnload("old", "r1", "a"); !errors.Is(err, ErrQueued) {
+		t.Fatalf("SendUnload = %v, want ErrQueued", err)
+	}
+	s.detachedAt["old"] = time.Now().Add(-2 * time.Minute)
+
+	cur := newFakeStream()
+	s.attach("cur", cur, nil)
+	s.detach("cur", cur, nil)
+
+	if _, ok := s.detachedAt["old"]; ok {
+		t.Error("node detached longer than the grace is still tracked")
+	}
+	if _, ok := s.pending["old"]; ok {
+		t.Error("expired commands of a pruned node are still queued")
+	}
+	if _, ok := s.detachedAt["cur"]; !ok {
+		t.Error("recently detached node was pruned")
+	}
+}
@@ -0,0 +1,43 @@
+package control
+
+import (
+	"testing"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// stateCheckNotifier records the cluster's view of a model at the time a state is notified.
+type stateCheckNotifier struct {
+	cluster *state.ClusterState
+	seen    []state.ModelState
+}
+
+func (n *stateCheckNotifier) NotifyModelState(nodeID, modelID string, st state.ModelState) {
+	node, ok := n.cluster.Node(nodeID)
+	if !ok {
+		n.seen = append(n.seen, "")
+		return
+	}
+	n.seen = append(n.seen, node.Models[modelID].State)
+}
+
+func TestNotifyAfterStatusUpdate(t *testing.T) {
+	s := newTestService()
+	notifier := &stateCheckNotifier{cluster: s.Cluster}
+	s.Notifier = notifier
+
+	stream := &scriptedStream{fakeStream: newFakeStream(), msgs: []*controlplanev1.NodeMessage{
+		helloMsg("n1"),
+		statusMsg("m"),
+	}}
+	if err := s.Stream(stream); err != nil {
+		t.Fatalf("Stream = %v", err)
+	}
+
+	// Waiters released by the READY notification place again; they must find the
+	// model READY in the cluster state already.
+	if len(notifier.seen) != 1 || notifier.seen[0] != state.ModelReady {
+		t.Fatalf("cluster state at notification = %q, want READY", notifier.seen)
+	}
+}
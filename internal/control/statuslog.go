@@ -0,0 +1,91 @@
+package control
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// ramChangeLogBytes is the RAM delta that counts as a material status change.
+const ramChangeLogBytes = 512 * 1024 * 1024
+
+// statusLogger decides whether a node status message is worth a log line.
+// It logs on material changes (model set or states, large RAM delta, inflight
+// crossing zero) and otherwise at most once per interval per node.
+type statusLogger struct {
+	mu   sync.Mutex
+	last map[string]loggedStatus
+}
+
+type loggedStatus struct {
+	at       time.Time
+	models   string
+	ramAvail uint64
+	inflight uint32
+}
+
+func newStatusLogger() *statusLogger {
+	return &statusLogger{
+		last: map[string]loggedStatus{},
+	}
+}
+
+// shouldLog records the status and reports whether it should be logged, with the reason.
+func (l *statusLogger) shouldLog(nodeID string, now time.Time, interval time.Duration, ramAvail uint64, inflight uint32, models map[string]state.ModelResidency) (bool, string) {
+	cur := loggedStatus{
+		at:       now,
+		models:   modelsSignature(models),
+		ramAvail: ramAvail,
+		inflight: inflight,
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prev, ok := l.last[nodeID]
+	var reason string
+	switch {
+	case !ok:
+		reason = "first"
+	case prev.models != cur.models:
+		reason = "models changed"
+	case absDiff(prev.ramAvail, cur.ramAvail) >= ramChangeLogBytes:
+		reason = "ram changed"
+	case (prev.inflight == 0) != (cur.inflight == 0):
+		reason = "inflight changed"
+	case interval > 0 && now.Sub(prev.at) >= interval:
+		reason = "periodic"
+	default:
+		return false, ""
+	}
+
+	l.last[nodeID] = cur
+	return true, reason
+}
+
+// forget drops the state of a node (e.g. on disconnect) so its next status is logged.
+func (l *statusLogger) forget(nodeID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.last, nodeID)
+}
+
+// modelsSignature is a stable representation of the model set and their states.
+func modelsSignature(models map[string]state.ModelResidency) string {
+	parts := make([]string, 0, len(models))
+	for id, m := range models {
+		parts = append(parts, id+"="+string(m.State))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,164 @@
+package control
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	controlplanev1 "github.com/mcules/llm-router/gen/controlplane/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// wedgedStream is a control stream whose agent sent a hello and then stopped reading:
+// every send after the server hello blocks until the stream is closed.
+type wedgedStream struct {
+	*fakeStream
+	hello  chan *controlplanev1.NodeMessage
+	closed chan struct{}
+}
+
+func newWedgedStream(nodeID string) *wedgedStream {
+	w := &wedgedStream{
+		fakeStream: newFakeStream(),
+		hello:      make(chan *controlplanev1.NodeMessage, 1),
+		closed:     make(chan struct{}),
+	}
+	w.hello <- &controlplanev1.NodeMessage{Msg: &controlplanev1.NodeMessage_Hello{
+		Hello: &controlplanev1.NodeHello{NodeId: nodeID, DataPlaneUrl: "http://" + nodeID},
+	}}
+	return w
+}
+
+func (w *wedgedStream) Send(m *controlplanev1.ServerMessage) error {
+	if m.GetHello() != nil {
+		return w.fakeStream.Send(m)
+	}
+	<-w.closed
+	return errors.New("stream closed")
+}
+
+func (w *wedgedStream) Recv() (*controlplanev1.NodeMessage, error) {
+	select {
+	case m := <-w.hello:
+		return m, nil
+	case <-w.closed:
+		return nil, status.Error(codes.Canceled, "context canceled")
+	}
+}
+
+// serve runs s.Stream(w) like the gRPC server: the stream is closed once it returns.
+func (w *wedgedStream) serve(s *NodeControlService) <-chan error {
+	out := make(chan error, 1)
+	go func() {
+		err := s.Stream(w)
+		close(w.closed)
+		out <- err
+	}()
+	return out
+}
+
+func waitAttached(t *testing.T, s *NodeControlService, nodeID string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.RLock()
+		ns := s.streams[nodeID]
+		s.mu.RUnlock()
+		if ns != nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("node %s did not attach", nodeID)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWedgedCommandSendClosesStream(t *testing.T) {
+	s := newTestService()
+	s.SendTimeout = 20 * time.Millisecond
+	w := newWedgedStream("n1")
+	served := w.serve(s)
+	waitAttached(t, s, "n1")
+
+	if err := s.SendUnload("n1", "r1", "a"); !errors.Is(err, ErrQueued) {
+		t.Fatalf("SendUnload on wedged stream = %v, want ErrQueued", err)
+	}
+
+	select {
+	case err := <-served:
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("Stream returned %v, want Unavailable", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stream did not return after the send timed out")
+	}
+	if _, attached := s.streams["n1"]; attached {
+		t.Error("wedged stream is still attached")
+	}
+	if got := len(s.pending["n1"]); got != 1 {
+		t.Errorf("%d commands queued for the reconnect, want 1", got)
+	}
+	if c, _ := s.Connection("n1"); c.LastReason != errSendTimeout.Error() {
+		t.Errorf("disconnect reason = %q, want %q", c.LastReason, errSendTimeout.Error())
+	}
+
+	// The reconnected agent gets the queued unload.
+	next := newFakeStream()
+	s.attach("n1", next, nil)
+	s.flushPending("n1", s.streams["n1"])
+	if got := next.unloads(); len(got) != 1 || got[0] != "a" {
+		t.Errorf("flushed unloads = %v, want [a]", got)
+	}
+}
+
+func TestWedgedStreamDetectedWhileCommandBlocksPing(t *testing.T) {
+	s := newTestService()
+	s.SendTimeout = 50 * time.Millisecond
+	s.PingTimeout = time.Hour
+	w := newWedgedStream("n1")
+	served := w.serve(s)
+	waitAttached(t, s, "n1")
+
+	unloaded := make(chan error, 1)
+	go func() { unloaded <- s.SendUnload("n1", "r1", "a") }()
+	time.Sleep(10 * time.Millisecond)
+
+	// The command holds the stream: the ping is skipped instead of waiting for it.
+	pinged := make(chan struct{})
+	go func() { s.BroadcastPing(); close(pinged) }()
+	select {
+	case <-pinged:
+	case <-time.After(time.Second):
+		t.Fatal("ping waited for the blocked command send")
+	}
+
+	if err := <-unloaded; !errors.Is(err, ErrQueued) {
+		t.Errorf("SendUnload = %v, want ErrQueued", err)
+	}
+	select {
+	case <-served:
+	case <-time.After(2 * time.Second):
+		t.Fatal("wedged stream was not closed")
+	}
+}
+
+func TestWedgedPingClosesStream(t *testing.T) {
+	s := newTestService()
+	s.PingTimeout = 20 * time.Millisecond
+	w := newWedgedStream("n1")
+	served := w.serve(s)
+	waitAttached(t, s, "n1")
+
+	s.BroadcastPing()
+	select {
+	case <-served:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stream did not return after the ping timed out")
+	}
+	if _, attached := s.streams["n1"]; attached {
+		t.Error("wedged stream is still attached")
+	}
+}
@@ -0,0 +1,98 @@
+// Package debugdump captures the server's control state as one JSON document for bug
+// reports and primes a development server from it.
+package debugdump
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/mcules/llm-router/internal/activity"
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/maintenance"
+	"github.com/mcules/llm-router/internal/metrics"
+	"github.com/mcules/llm-router/internal/proxy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// Format is the version of the dump layout; Load rejects other versions.
+const Format = 1
+
+// Dump is a snapshot of the control state: nodes with their models, connection
+// history, latencies, placement counters, loader gates, recent activity and the
+// effective settings (secrets redacted).
+type Dump struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Nodes       []state.NodeSnapshot              `json:"nodes"`
+	Connections map[string]control.ConnStats      `json:"connections,omitempty"`
+	Latency     map[string]metrics.NodeLatency    `json:"latency,omitempty"`
+	Placement   map[string]metrics.ModelPlacement `json:"placement,omitempty"`
+	Gates       []proxy.GateState                 `json:"gates,omitempty"`
+	Activity    []activity.Event                  `json:"activity,omitempty"`
+	Maintenance maintenance.Status                `json:"maintenance"`
+	Settings    any                               `json:"settings,omitempty"`
+}
+
+// Redact removes credentials the node URLs may carry (user info, query). The settings
+// are expected to be redacted already.
+func (d *Dump) Redact() {
+	for i := range d.Nodes {
+		d.Nodes[i].DataPlaneURL = redactURL(d.Nodes[i].DataPlaneURL)
+		d.Nodes[i].LlamaBaseURL = redactURL(d.Nodes[i].LlamaBaseURL)
+	}
+}
+
+func redactURL(s string) string {
+	if s == "" {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "(invalid url)"
+	}
+	if u.User != nil {
+		u.User = url.User("redacted")
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = "redacted"
+	}
+	return u.String()
+}
+
+// Load reads a dump written by the debug dump endpoint.
+func Load(path string) (*Dump, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var d Dump
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if d.Format != Format {
+		return nil, fmt.Errorf("%s: unsupported dump format %d (want %d)", path, d.Format, Format)
+	}
+	return &d, nil
+}
+
+// Restore primes cluster and latency (nil = skip) with the dump's nodes. Heartbeats are
+// moved to staleBefore at the latest, so the nodes count as offline and are not routed
+// to until a real agent with the same id connects. It returns the number of nodes.
+func (d *Dump) Restore(cluster *state.ClusterState, latency *metrics.LatencyTracker, staleBefore time.Time) int {
+	for _, n := range d.Nodes {
+		if n.LastHeartbeat.After(staleBefore) {
+			n.LastHeartbeat = staleBefore
+		}
+		cluster.RestoreNode(n)
+	}
+	if latency != nil {
+		for nodeID, l := range d.Latency {
+			latency.Restore(nodeID, l)
+		}
+	}
+	return len(d.Nodes)
+}
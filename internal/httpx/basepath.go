@@ -0,0 +1,27 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// StripBasePath serves next below an external path prefix (e.g. "/llm") by removing the
+// prefix from incoming requests. Requests without the prefix are passed through unchanged,
+// so it works whether or not the fronting reverse proxy already strips it.
+func StripBasePath(prefix string, next http.Handler) http.Handler {
+	if prefix == "" {
+		return next
+	}
+	strip := http.StripPrefix(prefix, next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == prefix:
+			http.Redirect(w, r, prefix+"/", http.StatusFound)
+		case strings.HasPrefix(r.URL.Path, prefix+"/"):
+			strip.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
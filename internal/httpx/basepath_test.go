@@ -0,0 +1,41 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStripBasePath(t *testing.T) {
+	var seen string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { seen = r.URL.Path })
+	h := StripBasePath("/llm", next)
+
+	for _, tc := range []struct {
+		path     string
+		seen     string
+		location string
+	}{
+		{"/llm/ui/models", "/ui/models", ""},
+		{"/llm/", "/", ""},
+		{"/llm", "", "/llm/"},
+		{"/ui/models", "/ui/models", ""}, // the fronting proxy stripped it already
+		{"/llmx/ui/", "/llmx/ui/", ""},
+	} {
+		seen = ""
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if seen != tc.seen {
+			t.Errorf("%s: served %q, want %q", tc.path, seen, tc.seen)
+		}
+		if loc := rec.Header().Get("Location"); loc != tc.location {
+			t.Errorf("%s: redirected to %q, want %q", tc.path, loc, tc.location)
+		}
+	}
+
+	seen = ""
+	StripBasePath("", next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/llm/ui/", nil))
+	if seen != "/llm/ui/" {
+		t.Errorf("without base path served %q, want the path unchanged", seen)
+	}
+}
@@ -0,0 +1,19 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// GRPCMux routes gRPC requests (HTTP/2 + application/grpc content type) to grpcHandler
+// and everything else to next. This allows serving the control plane and the HTTP API
+// on a single port.
+func GRPCMux(grpcHandler, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcHandler.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,22 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// TrimTrailingSlash serves "/path/" as "/path": the slash is removed from the request
+// before next sees it, so path based logic (upstream path mapping) matches.
+func TrimTrailingSlash(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.URL.Path) > 1 && strings.HasSuffix(r.URL.Path, "/") {
+			r2 := *r
+			u := *r.URL
+			u.Path = strings.TrimSuffix(u.Path, "/")
+			u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+			r2.URL = &u
+			r = &r2
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,33 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrimTrailingSlash(t *testing.T) {
+	var path, raw string
+	h := TrimTrailingSlash(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, raw = r.URL.Path, r.URL.RawPath
+	}))
+
+	for _, tc := range []struct {
+		in, path, raw string
+	}{
+		{"/v1/chat/completions/", "/v1/chat/completions", ""},
+		{"/v1/chat/completions", "/v1/chat/completions", ""},
+		{"/", "/", ""},
+		{"/v1/models/a%2Fb/", "/v1/models/a/b", "/v1/models/a%2Fb"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.in, nil)
+		before := req.URL.String()
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if path != tc.path || raw != tc.raw {
+			t.Errorf("%s: served as %q (raw %q), want %q (raw %q)", tc.in, path, raw, tc.path, tc.raw)
+		}
+		if req.URL.String() != before {
+			t.Errorf("%s: the caller's request was modified to %s", tc.in, req.URL)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 )
@@ -31,6 +32,10 @@ type ModelsResponse struct {
 			Failed   bool   `json:"failed"`    // best-effort
 			ExitCode int    `json:"exit_code"` // best-effort
 		} `json:"status"`
+		Capabilities []string `json:"capabilities"` // best-effort, not reported by all versions
+		Meta         struct {
+			NCtxTrain uint32 `json:"n_ctx_train"` // best-effort
+		} `json:"meta"`
 	} `json:"data"`
 }
 
@@ -54,46 +59,112 @@ func (c *Client) GetModels(ctx context.Context) (*ModelsResponse, error) {
 	return &out, nil
 }
 
+// SlotsResponse is the answer of GET /slots: llama.cpp sends a JSON array of slots,
+// some proxies in front of it wrap it as {"slots": [...]}.
 type SlotsResponse struct {
-	Slots []struct {
-		IsProcessing bool `json:"is_processing"`
-	} `json:"slots"`
+	Slots []Slot `json:"slots"`
 }
 
-func (c *Client) GetSlotsInflight(ctx context.Context) (uint32, error) {
+func (s *SlotsResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &s.Slots); err == nil {
+		return nil
+	}
+	type plain SlotsResponse
+	return json.Unmarshal(b, (*plain)(s))
+}
+
+type Slot struct {
+	ID           int           `json:"id"`
+	IDTask       int           `json:"id_task"`
+	IsProcessing bool          `json:"is_processing"`
+	NextToken    SlotNextToken `json:"next_token"` // best-effort
+}
+
+// SlotNextToken holds the generation progress of a slot. Depending on the llama.cpp
+// version "next_token" is an object or an array with one object.
+type SlotNextToken struct {
+	NDecoded int `json:"n_decoded"`
+}
+
+func (t *SlotNextToken) UnmarshalJSON(b []byte) error {
+	type plain SlotNextToken
+	var list []plain
+	if err := json.Unmarshal(b, &list); err == nil {
+		if len(list) > 0 {
+			*t = SlotNextToken(list[0])
+		}
+		return nil
+	}
+	return json.Unmarshal(b, (*plain)(t))
+}
+
+// Inflight counts the slots processing a request.
+func (s *SlotsResponse) Inflight() uint32 {
+	var inflight uint32
+	for _, sl := range s.Slots {
+		if sl.IsProcessing {
+			inflight++
+		}
+	}
+	return inflight
+}
+
+// Total returns the number of slots (0 = unknown: /slots disabled or empty).
+func (s *SlotsResponse) Total() uint32 {
+	return uint32(len(s.Slots))
+}
+
+func (c *Client) GetSlots(ctx context.Context) (*SlotsResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/slots", nil)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	res, err := c.HTTP.Do(req)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer res.Body.Close()
 
-	// If /slots is disabled, llama.cpp may return non-2xx. Treat as 0 inflight.
+	// If /slots is disabled, llama.cpp may return non-2xx. Treat as no slots (0 inflight,
+	// unknown total).
 	if res.StatusCode/100 != 2 {
-		return 0, nil
+		return &SlotsResponse{}, nil
 	}
 
 	var out SlotsResponse
 	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
-		return 0, err
-	}
-
-	var inflight uint32
-	for _, s := range out.Slots {
-		if s.IsProcessing {
-			inflight++
-		}
+		return nil, err
 	}
-	return inflight, nil
+	return &out, nil
 }
 
 type unloadReq struct {
 	Model string `json:"model"`
 }
 
+// LoadModel starts loading a model (llama.cpp router mode, POST /models/load). llama.cpp
+// answers once the load has started; the model reports "loading" until it is ready.
+func (c *Client) LoadModel(ctx context.Context, modelID string) error {
+	body, _ := json.Marshal(unloadReq{Model: modelID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/models/load", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("load status=%d: %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
 func (c *Client) UnloadModel(ctx context.Context, modelID string) error {
 	body, _ := json.Marshal(unloadReq{Model: modelID})
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/models/unload", bytes.NewReader(body))
@@ -113,3 +184,47 @@ func (c *Client) UnloadModel(ctx context.Context, modelID string) error {
 	}
 	return nil
 }
+
+// CancelLoad aborts the load of a model. llama.cpp has no dedicated abort call, so the
+// model is unloaded right away (which stops a loading instance where supported); if that
+// is rejected, the load is awaited and the model unloaded once it finishes.
+// It returns when the model is no longer loading or ctx is done.
+func (c *Client) CancelLoad(ctx context.Context, modelID string) error {
+	if err := c.UnloadModel(ctx, modelID); err == nil {
+		return nil
+	}
+
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cancel load: %w", ctx.Err())
+		case <-t.C:
+		}
+
+		models, err := c.GetModels(ctx)
+		if err != nil {
+			continue
+		}
+		status := ""
+		for _, m := range models.Data {
+			if m.ID == modelID {
+				status = m.Status.Value
+				if m.Status.Failed {
+					status = "failed"
+				}
+				break
+			}
+		}
+		switch status {
+		case "loading":
+			continue
+		case "loaded":
+			return c.UnloadModel(ctx, modelID)
+		default:
+			// Gone, unloaded or failed: nothing left to cancel.
+			return nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package llama
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetSlots(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		status   int
+		body     string
+		total    uint32
+		inflight uint32
+		decoded  int // n_decoded of the first slot
+	}{
+		{
+			name:   "llama.cpp array",
+			status: http.StatusOK,
+			body: `[{"id":0,"id_task":7,"is_processing":true,"next_token":{"n_decoded":42}},
+			        {"id":1,"id_task":-1,"is_processing":false,"next_token":{"n_decoded":0}}]`,
+			total: 2, inflight: 1, decoded: 42,
+		},
+		{
+			name:   "next_token as array",
+			status: http.StatusOK,
+			body:   `[{"id":0,"is_processing":true,"next_token":[{"n_decoded":5}]}]`,
+			total:  1, inflight: 1, decoded: 5,
+		},
+		{
+			name:   "wrapped object",
+			status: http.StatusOK,
+			body:   `{"slots":[{"id":0,"is_processing":false},{"id":1,"is_processing":true},{"id":2}]}`,
+			total:  3, inflight: 1,
+		},
+		{"no slots", http.StatusOK, `[]`, 0, 0, 0},
+		{"slots endpoint disabled", http.StatusNotImplemented, `{"error":{"message":"This server does not support slots endpoint."}}`, 0, 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/slots" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			slots, err := New(srv.URL).GetSlots(context.Background())
+			if err != nil {
+				t.Fatalf("GetSlots: %v", err)
+			}
+			if slots.Total() != tc.total || slots.Inflight() != tc.inflight {
+				t.Errorf("total %d, inflight %d, want %d and %d", slots.Total(), slots.Inflight(), tc.total, tc.inflight)
+			}
+			if tc.decoded > 0 && slots.Slots[0].NextToken.NDecoded != tc.decoded {
+				t.Errorf("n_decoded = %d, want %d", slots.Slots[0].NextToken.NDecoded, tc.decoded)
+			}
+		})
+	}
+}
+
+func TestGetSlotsInvalidBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html>`))
+	}))
+	defer srv.Close()
+	if _, err := New(srv.URL).GetSlots(context.Background()); err == nil {
+		t.Error("GetSlots of an HTML page succeeded")
+	}
+}
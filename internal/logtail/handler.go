@@ -0,0 +1,125 @@
+package logtail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler is the process's slog.Handler: it writes records to out in the standard
+// logger's format ("2006/01/02 15:04:05 message key=value") and keeps them in a Buffer
+// with their level. Installed with slog.SetDefault it also receives the log package's
+// output; those records are all info, so their "ERROR:" / "WARNING:" prefix sets the
+// level instead.
+type Handler struct {
+	buf   *Buffer
+	out   io.Writer
+	outMu *sync.Mutex
+	attrs string // preformatted attributes of WithAttrs
+	group string // key prefix of WithGroup
+}
+
+// NewHandler returns a handler writing to out and buf.
+func NewHandler(buf *Buffer, out io.Writer) *Handler {
+	return &Handler{buf: buf, out: out, outMu: &sync.Mutex{}}
+}
+
+// Enabled drops debug records.
+func (h *Handler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= slog.LevelInfo
+}
+
+// Handle writes the record.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	lvl, prefix := recordLevel(r)
+
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteString(r.Message)
+	sb.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&sb, h.group, a)
+		return true
+	})
+	text := sb.String()
+
+	at := r.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	h.outMu.Lock()
+	_, err := fmt.Fprintf(h.out, "%s %s\n", at.Format("2006/01/02 15:04:05"), text)
+	h.outMu.Unlock()
+	h.buf.add(lvl, at, text)
+	return err
+}
+
+// WithAttrs returns a handler adding attrs to every record.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var sb strings.Builder
+	for _, a := range attrs {
+		appendAttr(&sb, h.group, a)
+	}
+	h2 := *h
+	h2.attrs += sb.String()
+	return &h2
+}
+
+// WithGroup returns a handler qualifying later attribute keys with name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group += name + "."
+	return &h2
+}
+
+// recordLevel returns the level of a record and the prefix its text needs, so warnings
+// and errors read the same whether they were logged via slog or the log package.
+func recordLevel(r slog.Record) (Level, string) {
+	switch {
+	case r.Level >= slog.LevelError:
+		if strings.HasPrefix(r.Message, "ERROR:") {
+			return LevelError, ""
+		}
+		return LevelError, "ERROR: "
+	case r.Level >= slog.LevelWarn:
+		if strings.HasPrefix(r.Message, "WARNING:") {
+			return LevelWarning, ""
+		}
+		return LevelWarning, "WARNING: "
+	case strings.HasPrefix(r.Message, "ERROR:"):
+		return LevelError, ""
+	case strings.HasPrefix(r.Message, "WARNING:"):
+		return LevelWarning, ""
+	default:
+		return LevelInfo, ""
+	}
+}
+
+func appendAttr(sb *strings.Builder, group string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		prefix := group
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(sb, prefix, ga)
+		}
+		return
+	}
+	v := a.Value.String()
+	if v == "" || strings.ContainsAny(v, " \"=") {
+		v = fmt.Sprintf("%q", v)
+	}
+	fmt.Fprintf(sb, " %s%s=%s", group, a.Key, v)
+}
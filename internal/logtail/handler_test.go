@@ -0,0 +1,80 @@
+package logtail
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestHandlerLevels(t *testing.T) {
+	buf := New(10)
+	var out bytes.Buffer
+	logger := slog.New(NewHandler(buf, &out))
+
+	logger.Info("plain", "node", "n1")
+	logger.Warn("slow node", "latency", "2s")
+	logger.Error("ERROR: already prefixed")
+
+	std := log.New(&bridge{logger}, "", 0)
+	std.Printf("WARNING: legacy warning")
+	std.Printf("legacy info mentioning ERROR: in the middle")
+
+	want := []struct {
+		level, text string
+	}{
+		{"info", "plain node=n1"},
+		{"warning", "WARNING: slow node latency=2s"},
+		{"error", "ERROR: already prefixed"},
+		{"warning", "WARNING: legacy warning"},
+		{"info", "legacy info mentioning ERROR: in the middle"},
+	}
+	got := buf.Recent()
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Level != w.level || got[i].Text != w.text {
+			t.Errorf("line %d = %s %q, want %s %q", i, got[i].Level, got[i].Text, w.level, w.text)
+		}
+	}
+	if !strings.Contains(out.String(), " WARNING: slow node latency=2s\n") {
+		t.Errorf("stderr output missing warning line:\n%s", out.String())
+	}
+}
+
+func TestHandlerAttrsAndGroups(t *testing.T) {
+	buf := New(10)
+	logger := slog.New(NewHandler(buf, &bytes.Buffer{})).With("component", "proxy").WithGroup("req")
+	logger.Info("done", "model", "llama 3", slog.Group("node", "id", "n1"))
+
+	got := buf.Recent()[0].Text
+	want := `done component=proxy req.model="llama 3" req.node.id=n1`
+	if got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
+
+func TestBufferRing(t *testing.T) {
+	buf := New(3)
+	logger := slog.New(NewHandler(buf, &bytes.Buffer{}))
+	for _, m := range []string{"a", "b", "c", "d"} {
+		logger.Info(m)
+	}
+	var texts []string
+	for _, l := range buf.Recent() {
+		texts = append(texts, l.Text)
+	}
+	if strings.Join(texts, ",") != "b,c,d" {
+		t.Errorf("recent = %v, want [b c d]", texts)
+	}
+}
+
+// bridge feeds log package output into a slog logger at info, like slog.SetDefault does.
+type bridge struct{ l *slog.Logger }
+
+func (b *bridge) Write(p []byte) (int, error) {
+	b.l.Info(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
@@ -0,0 +1,135 @@
+// Package logtail keeps the most recent log lines of the process in memory, so they can
+// be followed in the UI without shell access.
+package logtail
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level of a log line, taken from its slog record (see Handler).
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelWarning
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelError:
+		return "error"
+	case LevelWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// ParseLevel parses "info", "warning" (or "warn") and "error"; anything else is info.
+func ParseLevel(s string) Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "error":
+		return LevelError
+	case "warning", "warn":
+		return LevelWarning
+	default:
+		return LevelInfo
+	}
+}
+
+// Line is one captured log line.
+type Line struct {
+	Seq   uint64    `json:"seq"`
+	At    time.Time `json:"at"`
+	Level string    `json:"level"`
+	Text  string    `json:"text"`
+
+	level Level
+}
+
+// AtLeast reports whether the line has at least level min.
+func (l Line) AtLeast(min Level) bool {
+	return l.level >= min
+}
+
+// Buffer keeps the last lines logged through a Handler in a ring buffer and fans new
+// lines out to subscribers.
+type Buffer struct {
+	mu    sync.Mutex
+	lines []Line // ring
+	next  int
+	full  bool
+	seq   uint64
+	subs  map[chan Line]struct{}
+}
+
+// New creates a buffer for the last size lines (0 = 1000).
+func New(size int) *Buffer {
+	if size <= 0 {
+		size = 1000
+	}
+	return &Buffer{
+		lines: make([]Line, size),
+		subs:  map[chan Line]struct{}{},
+	}
+}
+
+func (b *Buffer) add(lvl Level, at time.Time, text string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	l := Line{Seq: b.seq, At: at, Level: lvl.String(), Text: text, level: lvl}
+
+	b.lines[b.next] = l
+	b.next++
+	if b.next >= len(b.lines) {
+		b.next = 0
+		b.full = true
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- l:
+		default:
+			// Slow reader: the line is skipped for it (the stream reports gaps by seq).
+		}
+	}
+}
+
+// Recent returns the buffered lines, oldest first.
+func (b *Buffer) Recent() []Line {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.recentLocked()
+}
+
+func (b *Buffer) recentLocked() []Line {
+	var out []Line
+	if b.full {
+		out = make([]Line, 0, len(b.lines))
+		out = append(out, b.lines[b.next:]...)
+	}
+	return append(out, b.lines[:b.next]...)
+}
+
+// Subscribe returns the buffered lines and a channel receiving every later line.
+// Call Unsubscribe when done.
+func (b *Buffer) Subscribe() ([]Line, chan Line) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Line, 256)
+	b.subs[ch] = struct{}{}
+	return b.recentLocked(), ch
+}
+
+// Unsubscribe stops delivering lines to ch.
+func (b *Buffer) Unsubscribe(ch chan Line) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, ch)
+}
@@ -0,0 +1,73 @@
+// Package maintenance holds the server-wide read-only switch used during migrations of
+// the policy database and other maintenance: proxy requests keep being routed from the
+// current state, while mutations and the planner are paused.
+package maintenance
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrReadOnly is returned for mutations while maintenance mode is on.
+var ErrReadOnly = errors.New("server is in maintenance mode (read-only)")
+
+// Status describes the current mode.
+type Status struct {
+	Enabled bool      `json:"enabled"`
+	Since   time.Time `json:"since,omitzero"`
+	By      string    `json:"by,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+// Mode is the maintenance switch. A nil *Mode is never enabled, so components can hold
+// an optional reference.
+type Mode struct {
+	mu     sync.RWMutex
+	status Status
+}
+
+// New returns a mode that is off.
+func New() *Mode {
+	return &Mode{}
+}
+
+// Enabled reports whether mutations are currently rejected.
+func (m *Mode) Enabled() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status.Enabled
+}
+
+// Status returns the current mode.
+func (m *Mode) Status() Status {
+	if m == nil {
+		return Status{}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Set turns maintenance mode on or off; by and reason are kept while it is on.
+// It reports whether the mode changed.
+func (m *Mode) Set(enabled bool, by, reason string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status.Enabled == enabled {
+		if enabled {
+			m.status.Reason = reason
+		}
+		return false
+	}
+	if enabled {
+		m.status = Status{Enabled: true, Since: time.Now(), By: by, Reason: reason}
+	} else {
+		m.status = Status{}
+	}
+	return true
+}
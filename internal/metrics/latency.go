@@ -100,9 +100,32 @@ func (t *LatencyTracker) Snapshot() map[string]NodeLatency {
 	return out
 }
 
+// Restore sets the latency of a node as recorded elsewhere (debug dumps).
+func (t *LatencyTracker) Restore(nodeID string, l NodeLatency) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.nodes[nodeID] = &l
+}
+
 func (t *LatencyTracker) Delete(nodeID string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	delete(t.nodes, nodeID)
 }
+
+// Prune removes nodes without observations since olderThan and returns how many were removed.
+func (t *LatencyTracker) Prune(olderThan time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for id, l := range t.nodes {
+		if l.LastAt.Before(olderThan) {
+			delete(t.nodes, id)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,208 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OtherModel collects the placements of models beyond the tracker's model limit.
+const OtherModel = "_other"
+
+// waitBuckets are the upper bounds (seconds) of the wait duration histogram.
+var waitBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 180}
+
+// ModelPlacement counts how requests for a model were placed and how long they waited
+// for a model load.
+type ModelPlacement struct {
+	Direct     uint64 // routed to a node with the model READY
+	ColdStarts uint64 // triggered a model load themselves
+	Waits      uint64 // waited for a load in progress
+
+	WaitCount    uint64 // observed waits (the wait histogram below)
+	WaitFailures uint64 // waits that ended without the model becoming READY
+	WaitSum      time.Duration
+	WaitMax      time.Duration
+	// WaitBuckets[i] counts waits <= waitBuckets[i] (cumulative, like Prometheus).
+	WaitBuckets []uint64
+
+	LastAt time.Time
+}
+
+// AvgWait returns the mean wait duration.
+func (p ModelPlacement) AvgWait() time.Duration {
+	if p.WaitCount == 0 {
+		return 0
+	}
+	return p.WaitSum / time.Duration(p.WaitCount)
+}
+
+// PlacementTracker records placement modes and loader wait times per model.
+// The number of tracked models is bounded; further models are counted as OtherModel.
+type PlacementTracker struct {
+	mu        sync.RWMutex
+	maxModels int
+	models    map[string]*ModelPlacement
+}
+
+// NewPlacementTracker creates a tracker for at most maxModels models (0 = 500).
+func NewPlacementTracker(maxModels int) *PlacementTracker {
+	if maxModels <= 0 {
+		maxModels = 500
+	}
+	return &PlacementTracker{
+		maxModels: maxModels,
+		models:    map[string]*ModelPlacement{},
+	}
+}
+
+// ObservePlacement counts a placement; mode is "direct", "cold" or "wait".
+func (t *PlacementTracker) ObservePlacement(modelID, mode string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.getOrCreateLocked(modelID)
+	switch mode {
+	case "cold":
+		p.ColdStarts++
+	case "wait":
+		p.Waits++
+	default:
+		p.Direct++
+	}
+}
+
+// ObserveWait records how long a request waited for the model to become READY.
+func (t *PlacementTracker) ObserveWait(modelID string, d time.Duration, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.getOrCreateLocked(modelID)
+	p.WaitCount++
+	if !ok {
+		p.WaitFailures++
+	}
+	p.WaitSum += d
+	if d > p.WaitMax {
+		p.WaitMax = d
+	}
+	for i, le := range waitBuckets {
+		if d.Seconds() <= le {
+			p.WaitBuckets[i]++
+		}
+	}
+}
+
+func (t *PlacementTracker) getOrCreateLocked(modelID string) *ModelPlacement {
+	p, ok := t.models[modelID]
+	if !ok {
+		if len(t.models) >= t.maxModels {
+			modelID = OtherModel
+			p = t.models[modelID]
+		}
+		if p == nil {
+			p = &ModelPlacement{WaitBuckets: make([]uint64, len(waitBuckets))}
+			t.models[modelID] = p
+		}
+	}
+	p.LastAt = time.Now()
+	return p
+}
+
+// Get returns the counters of a model.
+func (t *PlacementTracker) Get(modelID string) (ModelPlacement, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	p, ok := t.models[modelID]
+	if !ok {
+		return ModelPlacement{}, false
+	}
+	return p.copy(), true
+}
+
+// Snapshot returns the counters of all models.
+func (t *PlacementTracker) Snapshot() map[string]ModelPlacement {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	out := make(map[string]ModelPlacement, len(t.models))
+	for k, v := range t.models {
+		out[k] = v.copy()
+	}
+	return out
+}
+
+func (p *ModelPlacement) copy() ModelPlacement {
+	cp := *p
+	cp.WaitBuckets = append([]uint64(nil), p.WaitBuckets...)
+	return cp
+}
+
+// Prune removes models without placements since olderThan and returns how many were removed.
+func (t *PlacementTracker) Prune(olderThan time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for id, p := range t.models {
+		if p.LastAt.Before(olderThan) {
+			delete(t.models, id)
+			n++
+		}
+	}
+	return n
+}
+
+// WritePrometheus writes the counters in the Prometheus text exposition format.
+func (t *PlacementTracker) WritePrometheus(w io.Writer) error {
+	snap := t.Snapshot()
+	ids := make([]string, 0, len(snap))
+	for id := range snap {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var b strings.Builder
+	b.WriteString("# HELP llm_router_placements_total Requests by model and placement mode.\n")
+	b.WriteString("# TYPE llm_router_placements_total counter\n")
+	for _, id := range ids {
+		p := snap[id]
+		m := quoteLabel(id)
+		fmt.Fprintf(&b, "llm_router_placements_total{model=%s,mode=\"direct\"} %d\n", m, p.Direct)
+		fmt.Fprintf(&b, "llm_router_placements_total{model=%s,mode=\"cold\"} %d\n", m, p.ColdStarts)
+		fmt.Fprintf(&b, "llm_router_placements_total{model=%s,mode=\"wait\"} %d\n", m, p.Waits)
+	}
+
+	b.WriteString("# HELP llm_router_load_wait_seconds Time requests waited for a model load.\n")
+	b.WriteString("# TYPE llm_router_load_wait_seconds histogram\n")
+	for _, id := range ids {
+		p := snap[id]
+		m := quoteLabel(id)
+		for i, le := range waitBuckets {
+			fmt.Fprintf(&b, "llm_router_load_wait_seconds_bucket{model=%s,le=\"%s\"} %d\n", m, strconv.FormatFloat(le, 'g', -1, 64), p.WaitBuckets[i])
+		}
+		fmt.Fprintf(&b, "llm_router_load_wait_seconds_bucket{model=%s,le=\"+Inf\"} %d\n", m, p.WaitCount)
+		fmt.Fprintf(&b, "llm_router_load_wait_seconds_sum{model=%s} %g\n", m, p.WaitSum.Seconds())
+		fmt.Fprintf(&b, "llm_router_load_wait_seconds_count{model=%s} %d\n", m, p.WaitCount)
+	}
+
+	b.WriteString("# HELP llm_router_load_wait_failures_total Waits that ended without the model becoming ready.\n")
+	b.WriteString("# TYPE llm_router_load_wait_failures_total counter\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "llm_router_load_wait_failures_total{model=%s} %d\n", quoteLabel(id), snap[id].WaitFailures)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quoteLabel quotes a Prometheus label value.
+func quoteLabel(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(v) + `"`
+}
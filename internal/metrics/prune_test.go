@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyPrune(t *testing.T) {
+	tr := NewLatencyTracker(0.2)
+	now := time.Now()
+	tr.Restore("gone", NodeLatency{LastAt: now.Add(-2 * time.Hour)})
+	tr.ObserveOK("live", 10*time.Millisecond)
+
+	if n := tr.Prune(now.Add(-time.Hour)); n != 1 {
+		t.Fatalf("pruned %d nodes, want 1", n)
+	}
+	if _, ok := tr.Get("gone"); ok {
+		t.Error("stale node was kept")
+	}
+	if _, ok := tr.Get("live"); !ok {
+		t.Error("recently observed node was pruned")
+	}
+}
+
+func TestRecencyPrune(t *testing.T) {
+	tr := NewRecencyTracker()
+	tr.Touch("n1", "old")
+	cutoff := time.Now().Add(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	tr.Touch("n1", "new")
+
+	if n := tr.Prune(cutoff); n != 1 {
+		t.Fatalf("pruned %d entries, want 1", n)
+	}
+	if _, ok := tr.LastUsed("n1", "old"); ok {
+		t.Error("stale entry was kept")
+	}
+	if _, ok := tr.LastUsed("n1", "new"); !ok {
+		t.Error("recent entry was pruned")
+	}
+}
+
+func TestPlacementPrune(t *testing.T) {
+	tr := NewPlacementTracker(0)
+	tr.ObservePlacement("old", "cold")
+	cutoff := time.Now().Add(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	tr.ObserveWait("new", time.Second, true)
+
+	if n := tr.Prune(cutoff); n != 1 {
+		t.Fatalf("pruned %d models, want 1", n)
+	}
+	if _, ok := tr.Get("old"); ok {
+		t.Error("stale model was kept")
+	}
+	if _, ok := tr.Get("new"); !ok {
+		t.Error("recently placed model was pruned")
+	}
+}
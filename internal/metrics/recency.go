@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+type recencyKey struct {
+	nodeID  string
+	modelID string
+}
+
+// RecencyTracker records when a model was last routed to on a node.
+type RecencyTracker struct {
+	mu      sync.RWMutex
+	started time.Time
+	last    map[recencyKey]time.Time
+}
+
+func NewRecencyTracker() *RecencyTracker {
+	return &RecencyTracker{
+		started: time.Now(),
+		last:    map[recencyKey]time.Time{},
+	}
+}
+
+func (t *RecencyTracker) Touch(nodeID, modelID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.last[recencyKey{nodeID: nodeID, modelID: modelID}] = time.Now()
+}
+
+func (t *RecencyTracker) LastUsed(nodeID, modelID string) (time.Time, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	at, ok := t.last[recencyKey{nodeID: nodeID, modelID: modelID}]
+	return at, ok
+}
+
+// IdleSince returns the time since which the model has not been used on the node.
+// Without a recorded request it falls back to the later of loadedSince and the tracker start,
+// since usage before a server restart is unknown.
+func (t *RecencyTracker) IdleSince(nodeID, modelID string, loadedSince time.Time) time.Time {
+	if at, ok := t.LastUsed(nodeID, modelID); ok {
+		return at
+	}
+	if loadedSince.After(t.started) {
+		return loadedSince
+	}
+	return t.started
+}
+
+// Prune removes node/model entries last used before olderThan and returns how many were removed.
+// IdleSince falls back to the load time for pruned entries, which is at least as old.
+func (t *RecencyTracker) Prune(olderThan time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for k, at := range t.last {
+		if at.Before(olderThan) {
+			delete(t.last, k)
+			n++
+		}
+	}
+	return n
+}
@@ -88,3 +88,24 @@ func ErrorRate(st NodeStats) float64 {
 	}
 	return float64(st.Errors) / float64(st.Requests)
 }
+
+// Prune removes nodes without TTFB or error observations since olderThan and returns how many were removed.
+// The server keeps no Store (its node latency is metrics.LatencyTracker, swept by the
+// metrics prune ticker); programs that keep one call Prune from a similar sweep.
+func (s *Store) Prune(olderThan time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for id, st := range s.nodes {
+		last := st.LastTTFB
+		if st.LastError.After(last) {
+			last = st.LastError
+		}
+		if last.Before(olderThan) {
+			delete(s.nodes, id)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,29 @@
+package perf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	s := New(0.2)
+	s.ObserveTTFB("ttfb", 100*time.Millisecond)
+	s.ObserveError("error")
+	start := time.Now()
+
+	if n := s.Prune(start.Add(-time.Minute)); n != 0 {
+		t.Fatalf("pruned %d fresh nodes", n)
+	}
+
+	time.Sleep(time.Millisecond)
+	s.ObserveError("ttfb") // an error keeps a node whose last TTFB is stale
+	if n := s.Prune(start.Add(time.Nanosecond)); n != 1 {
+		t.Fatalf("pruned %d nodes, want 1", n)
+	}
+	if _, ok := s.Snapshot("error"); ok {
+		t.Error("stale node was kept")
+	}
+	if _, ok := s.Snapshot("ttfb"); !ok {
+		t.Error("node with a recent error was pruned")
+	}
+}
@@ -0,0 +1,28 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestUnderPressureIgnoresUnknownRAM(t *testing.T) {
+	p, _, _ := newTestPlanner(t, &fakeSender{})
+	p.MinFreeBytes = 8 << 30
+
+	for _, tc := range []struct {
+		name  string
+		total uint64
+		avail uint64
+		want  bool
+	}{
+		{"plenty free", 64 << 30, 32 << 30, false},
+		{"below the minimum", 64 << 30, 4 << 30, true},
+		{"unknown capacity", 0, 0, false},
+	} {
+		n := &state.NodeSnapshot{NodeID: "n1", RAMTotalBytes: tc.total, RAMAvailBytes: tc.avail}
+		if got := p.underPressure(n); got != tc.want {
+			t.Errorf("%s: underPressure = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package planner
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// fakeSender records unload commands and answers them with err.
+type fakeSender struct {
+	mu   sync.Mutex
+	err  error
+	sent []string // node/model
+}
+
+func (f *fakeSender) SendUnload(nodeID, requestID, modelID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, nodeID+"/"+modelID)
+	return f.err
+}
+
+func (f *fakeSender) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent)
+}
+
+// addNode registers an online node reporting models (model id -> state).
+func addNode(c *state.ClusterState, nodeID string, avail uint64, models map[string]state.ModelState) {
+	c.UpsertNodeHello(nodeID, "test", "", "http://"+nodeID, "", state.DataPlaneTLS{}, nil)
+	res := make(map[string]state.ModelResidency, len(models))
+	for id, st := range models {
+		res[id] = state.ModelResidency{ModelID: id, State: st}
+	}
+	c.UpdateNodeStatus(nodeID, 64<<30, avail, "", 0, 0, 0, res)
+}
+
+// fakeLoader records warm loads.
+type fakeLoader struct {
+	mu     sync.Mutex
+	loaded []string
+}
+
+func (f *fakeLoader) WarmLoad(ctx context.Context, modelID string) (string, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loaded = append(f.loaded, modelID)
+	return "n1", true, nil
+}
+
+// newTestPlanner returns a planner on an empty cluster and a fresh policy store that
+// sends unloads to sender.
+func newTestPlanner(t *testing.T, sender *fakeSender) (*Planner, *state.ClusterState, *policy.Store) {
+	t.Helper()
+	store, err := policy.Open(filepath.Join(t.TempDir(), "policies.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	c := state.NewClusterState()
+	p := &Planner{Cluster: c, Policies: store, Commands: sender, NodeOfflineTTL: time.Hour, sentThisTick: map[string]bool{}}
+	return p, c, store
+}
@@ -2,6 +2,7 @@ package planner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
@@ -9,6 +10,8 @@ import (
 	"time"
 
 	"github.com/mcules/llm-router/internal/activity"
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/maintenance"
 	"github.com/mcules/llm-router/internal/policy"
 	"github.com/mcules/llm-router/internal/state"
 )
@@ -28,18 +31,69 @@ type Planner struct {
 	// Tick frequency.
 	Interval time.Duration
 	Activity *activity.Log
+
+	// NormalizeModelNames looks up policies ignoring case and surrounding whitespace.
+	NormalizeModelNames bool
+
+	// NodeOfflineTTL is used to report node offline/online transitions as activity events.
+	NodeOfflineTTL time.Duration
+
+	// MinResident keeps a model from TTL unloads for this long after it became READY,
+	// however short its TTL. The later of the reported LoadedSince and the time the
+	// planner first saw the model READY counts, so an early or skewed LoadedSince on the
+	// first status after a load cannot fire the TTL right away.
+	MinResident time.Duration
+
+	// Maintenance pauses all unloads and warm loads while enabled (nil = never).
+	Maintenance *maintenance.Mode
+
+	// WarmPool lists models kept READY on at least one node while their schedule is
+	// active: Loader starts missing ones, and the TTL pass keeps their last replica.
+	WarmPool []WarmModel
+	Loader   WarmLoader
+
+	// ErrorUnloadAfter unloads a model that has been in ERROR on a node this long, so
+	// the RAM it may still hold is freed and a later load starts clean (0 = off).
+	ErrorUnloadAfter time.Duration
+
+	// UnloadRetries is how often an unload that could not be sent is retried on later
+	// ticks while its node is online (0 = off, see retryPass).
+	UnloadRetries int
+
+	online    map[string]bool      // last observed online state per node (tick goroutine only)
+	readySeen map[string]time.Time // first tick a model was seen READY, by node and model (tick goroutine only)
+	errorSeen map[string]time.Time // first tick (or last unload) a model was seen ERROR, by node and model (tick goroutine only)
+	warmTried map[string]time.Time // last warm load attempt per model (tick goroutine only)
+	firstSeen time.Time            // first online node after start, for warmStartup (tick goroutine only)
+
+	pending      map[string]*pendingUnload // failed unloads by node and model (tick goroutine only)
+	sentThisTick map[string]bool           // unloads attempted in the current tick, by node and model
 }
 
 func (p *Planner) Run(ctx context.Context) {
 	t := time.NewTicker(p.Interval)
 	defer t.Stop()
 
+	// Until the first tick, check every warmStartupPoll whether the warm pool can be
+	// loaded already (see warmStartup).
+	var startup <-chan time.Time
+	if len(p.WarmPool) > 0 && p.Loader != nil {
+		st := time.NewTicker(warmStartupPoll)
+		defer st.Stop()
+		startup = st.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-t.C:
+			startup = nil
 			p.tick(ctx)
+		case now := <-startup:
+			if p.warmStartup(ctx, now) {
+				startup = nil
+			}
 		}
 	}
 }
@@ -48,53 +102,91 @@ func (p *Planner) tick(ctx context.Context) {
 	nodes := p.Cluster.Snapshot()
 	now := time.Now()
 
+	p.trackOnline(nodes, now)
+	p.trackReady(nodes, now)
+	p.trackError(nodes, now)
+	p.trackPending(nodes)
+	p.sentThisTick = map[string]bool{}
+
+	// Keep tracking, so min-resident and online state are current when maintenance ends.
+	if p.Maintenance.Enabled() {
+		return
+	}
+
+	warm := p.activeWarm(now)
+	ready := p.readyReplicas(nodes, now)
+
+	// 0) Retry unloads that could not be sent on an earlier tick.
+	p.retryPass(ctx, nodes, warm, ready, now)
+
 	// 1) TTL unload pass (cheap and deterministic).
 	for _, n := range nodes {
-		if n.InflightRequests > 0 {
-			continue
-		}
 		for _, m := range n.Models {
-			if m.State != state.ModelReady {
-				continue
-			}
-
-			pol, ok, err := p.Policies.GetPolicy(ctx, m.ModelID)
-			if err != nil {
-				log.Printf("planner: get policy: %v", err)
+			if !p.ttlExpired(ctx, n, m, now) {
 				continue
 			}
-			if !ok || pol.TTLSecs <= 0 || pol.Pinned {
+			// The warm pool keeps one replica; extra ones still expire.
+			if warm[m.ModelID] && ready[m.ModelID] <= 1 {
 				continue
 			}
-
-			loadedAt := m.LoadedSince
-			if loadedAt.IsZero() {
-				// Fallback: treat as recently seen.
-				continue
-			}
-
-			if now.Sub(loadedAt) >= time.Duration(pol.TTLSecs)*time.Second {
-				p.tryUnload(n.NodeID, m.ModelID, "ttl")
-			}
+			p.tryUnload(n.NodeID, m.ModelID, "ttl")
+			ready[m.ModelID]--
 		}
 	}
 
 	// 2) RAM pressure pass.
 	for _, n := range nodes {
-		if p.MinFreeBytes == 0 {
-			continue
-		}
-		if n.RAMAvailBytes >= p.MinFreeBytes {
+		if !p.underPressure(n) {
 			continue
 		}
-		if n.InflightRequests > 0 {
-			// Conservative: avoid unloading while node is busy.
-			continue
-		}
-
 		need := p.MinFreeBytes - n.RAMAvailBytes
 		p.handlePressure(ctx, n, need)
 	}
+
+	// 3) Error recovery pass.
+	p.errorPass(nodes, now)
+
+	// 4) Warm pool pass.
+	p.warmPass(ctx, warm, ready, now)
+}
+
+// ttlExpired reports whether the model m on the idle node n is READY, has a TTL (and is
+// not pinned) and has been loaded longer than that TTL and MinResident.
+func (p *Planner) ttlExpired(ctx context.Context, n *state.NodeSnapshot, m state.ModelResidency, now time.Time) bool {
+	if n.InflightRequests > 0 || m.State != state.ModelReady {
+		return false
+	}
+	pol, ok, err := p.getPolicy(ctx, m.ModelID)
+	if err != nil {
+		log.Printf("planner: get policy: %v", err)
+		return false
+	}
+	if !ok || pol.TTLSecs <= 0 || pol.Pinned {
+		return false
+	}
+
+	loadedAt := m.LoadedSince
+	if loadedAt.IsZero() {
+		// Fallback: treat as recently seen.
+		return false
+	}
+	if p.withinMinResident(n.NodeID, m.ModelID, loadedAt, now) {
+		return false
+	}
+	return now.Sub(loadedAt) >= time.Duration(pol.TTLSecs)*time.Second
+}
+
+// underPressure reports whether the idle node n has less than MinFreeBytes available.
+func (p *Planner) underPressure(n *state.NodeSnapshot) bool {
+	if p.MinFreeBytes == 0 {
+		return false
+	}
+	if n.RAMAvailBytes >= p.MinFreeBytes || !n.CapacityKnown() {
+		// Unknown capacity is no evidence of pressure.
+		return false
+	}
+	// Conservative: avoid unloading while node is busy.
+	return n.InflightRequests == 0
 }
 
 func (p *Planner) handlePressure(ctx context.Context, n *state.NodeSnapshot, needBytes uint64) {
@@ -111,7 +203,7 @@ func (p *Planner) handlePressure(ctx context.Context, n *state.NodeSnapshot, nee
 		if m.State != state.ModelReady {
 			continue
 		}
-		pol, ok, err := p.Policies.GetPolicy(ctx, m.ModelID)
+		pol, ok, err := p.getPolicy(ctx, m.ModelID)
 		if err != nil {
 			log.Printf("planner: get policy: %v", err)
 			continue
@@ -170,22 +262,41 @@ func (p *Planner) handlePressure(ctx context.Context, n *state.NodeSnapshot, nee
 	}
 }
 
+// tryUnload unloads the model unless that was already attempted this tick; a failed
+// send is queued for retryPass. A command the control plane queued for a reconnecting
+// node (control.ErrQueued) is not retried: it is delivered when the node is back.
 func (p *Planner) tryUnload(nodeID, modelID, reason string) {
-	reqID := fmt.Sprintf("unload-%s-%d", reason, time.Now().UnixNano())
-	if err := p.Commands.SendUnload(nodeID, reqID, modelID); err != nil {
-		log.Printf("planner: unload failed node=%s model=%s reason=%s err=%v", nodeID, modelID, reason, err)
+	key := nodeID + "\x00" + modelID
+	if p.sentThisTick[key] {
 		return
 	}
-	if p.Activity != nil {
-		p.Activity.Add(activity.Event{
-			At:     time.Now(),
-			Type:   activity.EventType("ttl_unload"), // or pressure_unload based on reason
-			NodeID: nodeID,
-			Model:  modelID,
-			Note:   reason,
-		})
+	if p.sentThisTick == nil {
+		p.sentThisTick = map[string]bool{}
+	}
+	p.sentThisTick[key] = true
+
+	if err := p.sendUnload(nodeID, modelID, reason); err != nil && !errors.Is(err, control.ErrQueued) {
+		p.queueRetry(nodeID, modelID, reason)
+	}
+}
+
+// sendUnload sends the unload command and records it as activity event. It returns
+// control.ErrQueued (after recording the event) if the command was queued for a
+// reconnecting node.
+func (p *Planner) sendUnload(nodeID, modelID, reason string) error {
+	reqID := fmt.Sprintf("unload-%s-%d", reason, time.Now().UnixNano())
+	note := reason
+	err := p.Commands.SendUnload(nodeID, reqID, modelID)
+	switch {
+	case errors.Is(err, control.ErrQueued):
+		log.Printf("planner: unload queued until node reconnects node=%s model=%s reason=%s", nodeID, modelID, reason)
+		note = reason + " (queued, node reconnecting)"
+	case err != nil:
+		log.Printf("planner: unload failed node=%s model=%s reason=%s err=%v", nodeID, modelID, reason, err)
+		return err
+	default:
+		log.Printf("planner: unload requested node=%s model=%s reason=%s", nodeID, modelID, reason)
 	}
-	log.Printf("planner: unload requested node=%s model=%s reason=%s", nodeID, modelID, reason)
 
 	// Log activity event (optional).
 	if p.Activity != nil {
@@ -195,6 +306,8 @@ func (p *Planner) tryUnload(nodeID, modelID, reason string) {
 			et = activity.EventTTLUnload
 		case "pressure":
 			et = activity.EventPressureUnload
+		case "error":
+			et = activity.EventErrorUnload
 		default:
 			et = activity.EventType(reason)
 		}
@@ -203,7 +316,84 @@ func (p *Planner) tryUnload(nodeID, modelID, reason string) {
 			Type:   et,
 			NodeID: nodeID,
 			Model:  modelID,
-			Note:   reason,
+			Note:   note,
 		})
 	}
+	return err
+}
+
+// trackReady records when each node/model pair was first seen READY and forgets pairs
+// that are no longer READY.
+func (p *Planner) trackReady(nodes []*state.NodeSnapshot, now time.Time) {
+	if p.readySeen == nil {
+		p.readySeen = map[string]time.Time{}
+	}
+	ready := make(map[string]bool, len(p.readySeen))
+	for _, n := range nodes {
+		for _, m := range n.Models {
+			if m.State != state.ModelReady {
+				continue
+			}
+			key := n.NodeID + "\x00" + m.ModelID
+			ready[key] = true
+			if _, ok := p.readySeen[key]; !ok {
+				p.readySeen[key] = now
+			}
+		}
+	}
+	for key := range p.readySeen {
+		if !ready[key] {
+			delete(p.readySeen, key)
+		}
+	}
+}
+
+// withinMinResident reports whether the model became READY less than MinResident ago.
+func (p *Planner) withinMinResident(nodeID, modelID string, loadedAt, now time.Time) bool {
+	if p.MinResident <= 0 {
+		return false
+	}
+	since := loadedAt
+	if seen, ok := p.readySeen[nodeID+"\x00"+modelID]; ok && seen.After(since) {
+		since = seen
+	}
+	return now.Sub(since) < p.MinResident
+}
+
+// trackOnline emits an activity event whenever a node crosses the offline TTL.
+func (p *Planner) trackOnline(nodes []*state.NodeSnapshot, now time.Time) {
+	if p.Activity == nil || p.NodeOfflineTTL <= 0 {
+		return
+	}
+	if p.online == nil {
+		p.online = map[string]bool{}
+	}
+
+	for _, n := range nodes {
+		online := n.IsOnline(now, p.NodeOfflineTTL)
+		prev, seen := p.online[n.NodeID]
+		p.online[n.NodeID] = online
+		if !seen || prev == online {
+			continue
+		}
+
+		et, note := activity.EventNodeOffline, fmt.Sprintf("no heartbeat for %s", now.Sub(n.LastHeartbeat).Truncate(time.Second))
+		if online {
+			et, note = activity.EventNodeOnline, "heartbeat resumed"
+		}
+		log.Printf("planner: node %s %s", n.NodeID, et)
+		p.Activity.Add(activity.Event{
+			At:     now,
+			Type:   et,
+			NodeID: n.NodeID,
+			Note:   note,
+		})
+	}
+}
+
+func (p *Planner) getPolicy(ctx context.Context, modelID string) (policy.ModelPolicy, bool, error) {
+	if p.NormalizeModelNames {
+		return p.Policies.GetPolicyFold(ctx, modelID)
+	}
+	return p.Policies.GetPolicy(ctx, modelID)
 }
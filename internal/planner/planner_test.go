@@ -0,0 +1,30 @@
+package planner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestTryUnloadQueuedIsNotRetried(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		err       error
+		wantRetry bool
+	}{
+		{"sent", nil, false},
+		{"queued", control.ErrQueued, false},
+		{"failed", errors.New("node stream not available"), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Planner{Cluster: state.NewClusterState(), Commands: &fakeSender{err: tc.err}, UnloadRetries: 3}
+			p.sentThisTick = map[string]bool{}
+			p.tryUnload("n1", "m", "pressure")
+			if _, retry := p.pending["n1\x00m"]; retry != tc.wantRetry {
+				t.Errorf("queued for retry = %v, want %v", retry, tc.wantRetry)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package planner
+
+import (
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// trackError records when each node/model pair was first seen in ERROR and forgets
+// pairs that left it (unloaded, reloaded or gone).
+func (p *Planner) trackError(nodes []*state.NodeSnapshot, now time.Time) {
+	if p.errorSeen == nil {
+		p.errorSeen = map[string]time.Time{}
+	}
+	failed := make(map[string]bool, len(p.errorSeen))
+	for _, n := range nodes {
+		for _, m := range n.Models {
+			if m.State != state.ModelError {
+				continue
+			}
+			key := n.NodeID + "\x00" + m.ModelID
+			failed[key] = true
+			if _, ok := p.errorSeen[key]; !ok {
+				p.errorSeen[key] = now
+			}
+		}
+	}
+	for key := range p.errorSeen {
+		if !failed[key] {
+			delete(p.errorSeen, key)
+		}
+	}
+}
+
+// errorPass unloads models stuck in ERROR for ErrorUnloadAfter on online nodes. The
+// planner ignores ERROR models otherwise, but a failed load may still hold RAM. A model
+// still in ERROR after the unload is retried after another ErrorUnloadAfter.
+func (p *Planner) errorPass(nodes []*state.NodeSnapshot, now time.Time) {
+	if p.ErrorUnloadAfter <= 0 {
+		return
+	}
+	for _, n := range nodes {
+		if !n.IsOnline(now, p.NodeOfflineTTL) {
+			continue
+		}
+		for _, m := range n.Models {
+			if m.State != state.ModelError {
+				continue
+			}
+			key := n.NodeID + "\x00" + m.ModelID
+			if now.Sub(p.errorSeen[key]) < p.ErrorUnloadAfter {
+				continue
+			}
+			p.errorSeen[key] = now
+			p.tryUnload(n.NodeID, m.ModelID, "error")
+		}
+	}
+}
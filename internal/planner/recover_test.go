@@ -0,0 +1,106 @@
+package planner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/activity"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestErrorPassUnloadsStuckModels(t *testing.T) {
+	sender := &fakeSender{}
+	p, c, _ := newTestPlanner(t, sender)
+	p.ErrorUnloadAfter = 5 * time.Minute
+	p.Activity = activity.New(10)
+	addNode(c, "n1", 32<<30, map[string]state.ModelState{"bad": state.ModelError, "ok": state.ModelReady})
+
+	t0 := time.Now()
+	pass := func(at time.Duration) {
+		now := t0.Add(at)
+		nodes := c.Snapshot()
+		p.sentThisTick = map[string]bool{}
+		p.trackError(nodes, now)
+		p.errorPass(nodes, now)
+	}
+
+	pass(0)
+	pass(4 * time.Minute)
+	if n := sender.count(); n != 0 {
+		t.Fatalf("%d unloads before the threshold, want none", n)
+	}
+
+	pass(5 * time.Minute)
+	if sender.count() != 1 || sender.sent[0] != "n1/bad" {
+		t.Fatalf("unloads = %v, want n1/bad", sender.sent)
+	}
+	events := p.Activity.List()
+	if len(events) != 1 || events[0].Type != activity.EventErrorUnload || events[0].Model != "bad" {
+		t.Errorf("activity = %+v, want one error-recovery unload of bad", events)
+	}
+
+	// Still in ERROR: retried only after another threshold.
+	pass(9 * time.Minute)
+	if n := sender.count(); n != 1 {
+		t.Errorf("%d unloads, want no retry before another threshold", n)
+	}
+	pass(10 * time.Minute)
+	if n := sender.count(); n != 2 {
+		t.Errorf("%d unloads, want a retry after another threshold", n)
+	}
+}
+
+func TestErrorPassRestartsAfterRecovery(t *testing.T) {
+	sender := &fakeSender{}
+	p, c, _ := newTestPlanner(t, sender)
+	p.ErrorUnloadAfter = 5 * time.Minute
+
+	t0 := time.Now()
+	pass := func(at time.Duration, st state.ModelState) {
+		addNode(c, "n1", 32<<30, map[string]state.ModelState{"m": st})
+		now := t0.Add(at)
+		p.sentThisTick = map[string]bool{}
+		p.trackError(c.Snapshot(), now)
+		p.errorPass(c.Snapshot(), now)
+	}
+
+	pass(0, state.ModelError)
+	pass(3*time.Minute, state.ModelReady) // reloaded fine
+	pass(4*time.Minute, state.ModelError) // failed again: a new error
+	pass(6*time.Minute, state.ModelError)
+	if n := sender.count(); n != 0 {
+		t.Fatalf("%d unloads 2 minutes into the new error, want none", n)
+	}
+	pass(9*time.Minute, state.ModelError)
+	if n := sender.count(); n != 1 {
+		t.Errorf("%d unloads, want one 5 minutes into the new error", n)
+	}
+}
+
+func TestErrorPassSkips(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		after   time.Duration
+		offline bool
+	}{
+		{"disabled", 0, false},
+		{"node offline", time.Minute, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := &fakeSender{}
+			p, c, _ := newTestPlanner(t, sender)
+			p.ErrorUnloadAfter = tc.after
+			addNode(c, "n1", 32<<30, map[string]state.ModelState{"m": state.ModelError})
+
+			now := time.Now()
+			p.trackError(c.Snapshot(), now.Add(-time.Hour))
+			if tc.offline {
+				now = now.Add(2 * p.NodeOfflineTTL)
+			}
+			p.errorPass(c.Snapshot(), now)
+			if n := sender.count(); n != 0 {
+				t.Errorf("%d unloads, want none", n)
+			}
+		})
+	}
+}
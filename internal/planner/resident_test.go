@@ -0,0 +1,62 @@
+package planner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestMinResidentHoldsShortTTL(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name        string
+		minResident time.Duration
+		loadedAgo   time.Duration // LoadedSince reported on the first status
+		after       time.Duration // time since the planner first saw the model READY
+		want        bool
+	}{
+		{"fresh load", 30 * time.Second, 0, 6 * time.Second, false},
+		{"skewed LoadedSince", 30 * time.Second, time.Hour, 6 * time.Second, false},
+		{"grace over", 30 * time.Second, 0, 31 * time.Second, true},
+		{"grace off", 0, 6 * time.Second, 0, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, _, store := newTestPlanner(t, &fakeSender{})
+			p.MinResident = tc.minResident
+			if err := store.UpsertPolicy(ctx, policy.ModelPolicy{ModelID: "m", TTLSecs: 5}); err != nil {
+				t.Fatal(err)
+			}
+
+			seen := time.Now()
+			m := state.ModelResidency{ModelID: "m", State: state.ModelReady, LoadedSince: seen.Add(-tc.loadedAgo)}
+			n := &state.NodeSnapshot{NodeID: "n1", Models: map[string]state.ModelResidency{"m": m}}
+			p.trackReady([]*state.NodeSnapshot{n}, seen)
+
+			if got := p.ttlExpired(ctx, n, m, seen.Add(tc.after)); got != tc.want {
+				t.Errorf("ttlExpired = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMinResidentRestartsAfterUnload(t *testing.T) {
+	p, _, _ := newTestPlanner(t, &fakeSender{})
+	p.MinResident = 30 * time.Second
+	t0 := time.Now()
+	ready := &state.NodeSnapshot{NodeID: "n1", Models: map[string]state.ModelResidency{
+		"m": {ModelID: "m", State: state.ModelReady},
+	}}
+	gone := &state.NodeSnapshot{NodeID: "n1"}
+
+	p.trackReady([]*state.NodeSnapshot{ready}, t0)
+	p.trackReady([]*state.NodeSnapshot{gone}, t0.Add(time.Minute))
+	p.trackReady([]*state.NodeSnapshot{ready}, t0.Add(2*time.Minute))
+
+	// Loaded again: the grace counts from the reload, not the first load.
+	if !p.withinMinResident("n1", "m", t0, t0.Add(2*time.Minute+10*time.Second)) {
+		t.Error("reloaded model is not within its minimum resident time")
+	}
+}
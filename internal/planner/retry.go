@@ -0,0 +1,137 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// pendingUnload is an unload the planner decided on but could not send (e.g. the
+// node's control stream was down). It is kept until the model is gone from the node.
+type pendingUnload struct {
+	nodeID, modelID, reason string
+	retries                 int  // failed retries so far
+	sent                    bool // a retry got through; waiting for the model to go
+}
+
+// queueRetry records a failed unload for retryPass (with UnloadRetries > 0).
+func (p *Planner) queueRetry(nodeID, modelID, reason string) {
+	if p.UnloadRetries <= 0 {
+		return
+	}
+	if p.pending == nil {
+		p.pending = map[string]*pendingUnload{}
+	}
+	key := nodeID + "\x00" + modelID
+	if pu, ok := p.pending[key]; ok {
+		pu.reason, pu.sent = reason, false
+		return
+	}
+	p.pending[key] = &pendingUnload{nodeID: nodeID, modelID: modelID, reason: reason}
+}
+
+// trackPending forgets pending unloads whose model is gone from the node (unloaded,
+// no longer reported or the node removed).
+func (p *Planner) trackPending(nodes []*state.NodeSnapshot) {
+	if len(p.pending) == 0 {
+		return
+	}
+	byID := make(map[string]*state.NodeSnapshot, len(nodes))
+	for _, n := range nodes {
+		byID[n.NodeID] = n
+	}
+	for key, pu := range p.pending {
+		n, ok := byID[pu.nodeID]
+		if !ok {
+			delete(p.pending, key)
+			continue
+		}
+		if m, ok := n.Models[pu.modelID]; !ok || m.State == state.ModelUnloaded {
+			delete(p.pending, key)
+		}
+	}
+}
+
+// retryPass resends the pending unloads of online nodes, so a short control stream
+// outage does not leave RAM pressure unresolved until the condition recurs. Offline
+// nodes are retried once they are back; each failed retry on an online node counts
+// against UnloadRetries. An unload whose reason no longer holds (see stillDue) is
+// dropped instead of resent.
+//
+// Retries and the control plane's reconnect queue do not overlap: an unload the control
+// plane queued for a reconnecting node (control.ErrQueued) is never queued here, and a
+// retry it queues counts as sent, as it is delivered when the node re-attaches. If the
+// node stays away longer than the control plane's grace, the queued command expires;
+// the TTL and pressure passes send a new one once the node is back and the condition
+// still holds.
+func (p *Planner) retryPass(ctx context.Context, nodes []*state.NodeSnapshot, warm map[string]bool, ready map[string]int, now time.Time) {
+	if len(p.pending) == 0 {
+		return
+	}
+	byID := make(map[string]*state.NodeSnapshot, len(nodes))
+	for _, n := range nodes {
+		if n.IsOnline(now, p.NodeOfflineTTL) {
+			byID[n.NodeID] = n
+		}
+	}
+
+	keys := make([]string, 0, len(p.pending))
+	for key, pu := range p.pending {
+		if _, online := byID[pu.nodeID]; !pu.sent && online {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		pu := p.pending[key]
+		if !p.stillDue(ctx, byID[pu.nodeID], pu, warm, ready, now) {
+			log.Printf("planner: dropping unload retry node=%s model=%s reason=%s: no longer due", pu.nodeID, pu.modelID, pu.reason)
+			delete(p.pending, key)
+			continue
+		}
+		p.sentThisTick[key] = true
+		if pu.reason == "ttl" {
+			ready[pu.modelID]--
+		}
+		if err := p.sendUnload(pu.nodeID, pu.modelID, pu.reason); err != nil && !errors.Is(err, control.ErrQueued) {
+			pu.retries++
+			if pu.retries >= p.UnloadRetries {
+				log.Printf("planner: giving up unload node=%s model=%s reason=%s after %d retries", pu.nodeID, pu.modelID, pu.reason, pu.retries)
+				delete(p.pending, key)
+			}
+			continue
+		}
+		pu.sent = true
+	}
+}
+
+// stillDue reports whether the reason of the pending unload pu still holds on node n:
+// the TTL still expired (and the warm pool has another replica), the node still under
+// RAM pressure with the model READY and not pinned, or the model still in ERROR.
+// Unloads for other reasons stay due.
+func (p *Planner) stillDue(ctx context.Context, n *state.NodeSnapshot, pu *pendingUnload, warm map[string]bool, ready map[string]int, now time.Time) bool {
+	m, ok := n.Models[pu.modelID]
+	if !ok {
+		return false
+	}
+	switch pu.reason {
+	case "ttl":
+		return p.ttlExpired(ctx, n, m, now) && (!warm[pu.modelID] || ready[pu.modelID] > 1)
+	case "pressure":
+		if m.State != state.ModelReady || !p.underPressure(n) {
+			return false
+		}
+		pol, ok, err := p.getPolicy(ctx, pu.modelID)
+		return err == nil && !(ok && pol.Pinned)
+	case "error":
+		return m.State == state.ModelError
+	default:
+		return true
+	}
+}
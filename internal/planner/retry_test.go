@@ -0,0 +1,98 @@
+package planner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestRetryPassReevaluatesReason(t *testing.T) {
+	ready := map[string]state.ModelState{"m": state.ModelReady}
+	for _, tc := range []struct {
+		name     string
+		reason   string
+		avail    uint64
+		models   map[string]state.ModelState
+		pinned   bool
+		wantSent bool
+	}{
+		{"pressure persists", "pressure", 1 << 30, ready, false, true},
+		{"pressure resolved", "pressure", 32 << 30, ready, false, false},
+		{"pressure, model pinned meanwhile", "pressure", 1 << 30, ready, true, false},
+		{"pressure, model unloading", "pressure", 1 << 30, map[string]state.ModelState{"m": state.ModelLoading}, false, false},
+		{"error persists", "error", 32 << 30, map[string]state.ModelState{"m": state.ModelError}, false, true},
+		{"error recovered", "error", 32 << 30, ready, false, false},
+		{"ttl, model reloading", "ttl", 32 << 30, map[string]state.ModelState{"m": state.ModelLoading}, false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := &fakeSender{}
+			p, c, store := newTestPlanner(t, sender)
+			p.MinFreeBytes = 8 << 30
+			p.UnloadRetries = 3
+			if tc.pinned {
+				if err := store.UpsertPolicy(context.Background(), policy.ModelPolicy{ModelID: "m", Pinned: true}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			addNode(c, "n1", tc.avail, tc.models)
+			p.queueRetry("n1", "m", tc.reason)
+
+			p.retryPass(context.Background(), c.Snapshot(), nil, map[string]int{}, time.Now())
+			if sent := sender.count() == 1; sent != tc.wantSent {
+				t.Fatalf("unload resent = %v, want %v", sent, tc.wantSent)
+			}
+			pu, pending := p.pending["n1\x00m"]
+			if tc.wantSent && (!pending || !pu.sent) {
+				t.Error("resent unload is not marked sent")
+			}
+			if !tc.wantSent && pending {
+				t.Error("unload that is no longer due is still pending")
+			}
+		})
+	}
+}
+
+func TestRetryPassTTLStillExpired(t *testing.T) {
+	sender := &fakeSender{}
+	p, c, store := newTestPlanner(t, sender)
+	p.UnloadRetries = 3
+	if err := store.UpsertPolicy(context.Background(), policy.ModelPolicy{ModelID: "m", TTLSecs: 60}); err != nil {
+		t.Fatal(err)
+	}
+	c.UpsertNodeHello("n1", "test", "", "http://n1", "", state.DataPlaneTLS{}, nil)
+	c.UpdateNodeStatus("n1", 64<<30, 32<<30, "", 0, 0, 0, map[string]state.ModelResidency{
+		"m": {ModelID: "m", State: state.ModelReady, LoadedSince: time.Now().Add(-time.Hour)},
+	})
+	p.queueRetry("n1", "m", "ttl")
+
+	// The last warm-pool replica is kept.
+	now := time.Now()
+	p.retryPass(context.Background(), c.Snapshot(), map[string]bool{"m": true}, map[string]int{"m": 1}, now)
+	if sender.count() != 0 {
+		t.Fatal("unloaded the last warm-pool replica")
+	}
+
+	p.queueRetry("n1", "m", "ttl")
+	p.retryPass(context.Background(), c.Snapshot(), nil, map[string]int{"m": 1}, now)
+	if sender.count() != 1 {
+		t.Fatal("expired TTL unload was not resent")
+	}
+}
+
+func TestRetryPassQueuedCountsAsSent(t *testing.T) {
+	sender := &fakeSender{err: control.ErrQueued}
+	p, c, _ := newTestPlanner(t, sender)
+	p.UnloadRetries = 1
+	addNode(c, "n1", 32<<30, map[string]state.ModelState{"m": state.ModelError})
+	p.queueRetry("n1", "m", "error")
+
+	p.retryPass(context.Background(), c.Snapshot(), nil, map[string]int{}, time.Now())
+	pu, ok := p.pending["n1\x00m"]
+	if !ok || !pu.sent || pu.retries != 0 {
+		t.Fatalf("pending = %+v, want sent without a failed retry", pu)
+	}
+}
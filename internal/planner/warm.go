@@ -0,0 +1,249 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mcules/llm-router/internal/activity"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// WarmLoader starts the load of a model on the node placement picks for a cold load
+// (see proxy.Router.WarmLoad). started is false if the model is already READY or
+// loading somewhere; nodeID is that node then.
+type WarmLoader interface {
+	WarmLoad(ctx context.Context, modelID string) (nodeID string, started bool, err error)
+}
+
+// warmRetry is the minimum time between two load attempts of the same warm-pool model.
+const warmRetry = time.Minute
+
+// After a start the warm pool is checked every warmStartupPoll until a node is online,
+// and loaded warmStartupSettle later, when the other nodes have had time to report
+// what they have loaded (see warmStartup).
+const (
+	warmStartupPoll   = time.Second
+	warmStartupSettle = 5 * time.Second
+)
+
+// WarmModel is a warm-pool entry: a model kept READY on at least one node while its
+// schedule is active.
+type WarmModel struct {
+	ModelID  string
+	Schedule *Schedule // nil = always
+}
+
+// Schedule is a daily time window on a range of weekdays, in local time.
+type Schedule struct {
+	FromDay, ToDay time.Weekday  // inclusive, may wrap (Sat-Mon)
+	From, To       time.Duration // since midnight; To <= From spans midnight
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWarmPool parses a comma separated list of "model" or "model@schedule" entries,
+// where schedule is "[Day[-Day] ]HH:MM-HH:MM" (e.g. "qwen3@Mon-Fri 08:00-18:00").
+func ParseWarmPool(s string) ([]WarmModel, error) {
+	var out []WarmModel
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		modelID, sched, hasSched := strings.Cut(entry, "@")
+		modelID = strings.TrimSpace(modelID)
+		if modelID == "" {
+			return nil, fmt.Errorf("warm pool entry %q: missing model id", entry)
+		}
+		if seen[modelID] {
+			return nil, fmt.Errorf("warm pool entry %q: model listed twice", entry)
+		}
+		seen[modelID] = true
+
+		wm := WarmModel{ModelID: modelID}
+		if hasSched {
+			sc, err := ParseSchedule(sched)
+			if err != nil {
+				return nil, fmt.Errorf("warm pool entry %q: %w", entry, err)
+			}
+			wm.Schedule = &sc
+		}
+		out = append(out, wm)
+	}
+	return out, nil
+}
+
+// ParseSchedule parses "[Day[-Day] ]HH:MM-HH:MM"; without days the window is daily.
+func ParseSchedule(s string) (Schedule, error) {
+	fields := strings.Fields(s)
+	sc := Schedule{FromDay: time.Sunday, ToDay: time.Saturday}
+	switch len(fields) {
+	case 1:
+	case 2:
+		from, to, isRange := strings.Cut(fields[0], "-")
+		if !isRange {
+			to = from
+		}
+		var ok1, ok2 bool
+		sc.FromDay, ok1 = weekdays[strings.ToLower(from)]
+		sc.ToDay, ok2 = weekdays[strings.ToLower(to)]
+		if !ok1 || !ok2 {
+			return Schedule{}, fmt.Errorf("invalid days %q (e.g. Mon-Fri)", fields[0])
+		}
+	default:
+		return Schedule{}, fmt.Errorf("invalid schedule %q (e.g. Mon-Fri 08:00-18:00)", s)
+	}
+
+	times := fields[len(fields)-1]
+	from, to, ok := strings.Cut(times, "-")
+	if !ok {
+		return Schedule{}, fmt.Errorf("invalid time range %q (e.g. 08:00-18:00)", times)
+	}
+	var err error
+	if sc.From, err = parseClock(from); err != nil {
+		return Schedule{}, err
+	}
+	if sc.To, err = parseClock(to); err != nil {
+		return Schedule{}, err
+	}
+	if sc.From == sc.To {
+		return Schedule{}, fmt.Errorf("empty time range %q", times)
+	}
+	return sc, nil
+}
+
+// parseClock parses "HH:MM" (24:00 allowed as end of day).
+func parseClock(s string) (time.Duration, error) {
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || n != 2 || len(s) != 5 ||
+		h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q (HH:MM)", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// Active reports whether t (in its location) falls into the window. A window spanning
+// midnight belongs to the day it starts on.
+func (sc *Schedule) Active(t time.Time) bool {
+	if sc == nil {
+		return true
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	clock := t.Sub(midnight)
+	if sc.From < sc.To {
+		return sc.onDay(t.Weekday()) && clock >= sc.From && clock < sc.To
+	}
+	// Overnight: the evening part today, or the morning part of yesterday's window.
+	if clock >= sc.From {
+		return sc.onDay(t.Weekday())
+	}
+	return clock < sc.To && sc.onDay((t.Weekday()+6)%7)
+}
+
+func (sc *Schedule) onDay(d time.Weekday) bool {
+	if sc.FromDay <= sc.ToDay {
+		return d >= sc.FromDay && d <= sc.ToDay
+	}
+	return d >= sc.FromDay || d <= sc.ToDay
+}
+
+// activeWarm returns the warm-pool models whose schedule is active at now.
+func (p *Planner) activeWarm(now time.Time) map[string]bool {
+	if len(p.WarmPool) == 0 {
+		return nil
+	}
+	active := make(map[string]bool, len(p.WarmPool))
+	for _, wm := range p.WarmPool {
+		if wm.Schedule.Active(now) {
+			active[wm.ModelID] = true
+		}
+	}
+	return active
+}
+
+// readyReplicas counts the online nodes that report each model READY.
+func (p *Planner) readyReplicas(nodes []*state.NodeSnapshot, now time.Time) map[string]int {
+	out := map[string]int{}
+	for _, n := range nodes {
+		if !n.IsOnline(now, p.NodeOfflineTTL) {
+			continue
+		}
+		for _, m := range n.Models {
+			if m.State == state.ModelReady {
+				out[m.ModelID]++
+			}
+		}
+	}
+	return out
+}
+
+// warmStartup runs the warm pass once right after the start, so the pool does not wait
+// for the first tick: warmStartupSettle after the first node was seen online. It
+// reports whether it ran (or there is nothing left to do before the first tick).
+func (p *Planner) warmStartup(ctx context.Context, now time.Time) bool {
+	nodes := p.Cluster.Snapshot()
+	if p.firstSeen.IsZero() {
+		for _, n := range nodes {
+			if n.IsOnline(now, p.NodeOfflineTTL) {
+				p.firstSeen = now
+				break
+			}
+		}
+		return false
+	}
+	if now.Sub(p.firstSeen) < warmStartupSettle {
+		return false
+	}
+	if p.Maintenance.Enabled() {
+		return true
+	}
+	p.warmPass(ctx, p.activeWarm(now), p.readyReplicas(nodes, now), now)
+	return true
+}
+
+// warmPass starts a load of every active warm-pool model without READY replicas
+// (see readyReplicas). Placement (eligibility, reserve, loads already in progress) is
+// left to the Loader; a model is retried at most every warmRetry.
+func (p *Planner) warmPass(ctx context.Context, active map[string]bool, ready map[string]int, now time.Time) {
+	if p.Loader == nil || len(active) == 0 {
+		return
+	}
+	if p.warmTried == nil {
+		p.warmTried = map[string]time.Time{}
+	}
+	for _, wm := range p.WarmPool {
+		if !active[wm.ModelID] || ready[wm.ModelID] > 0 {
+			continue
+		}
+		if last, ok := p.warmTried[wm.ModelID]; ok && now.Sub(last) < warmRetry {
+			continue
+		}
+		p.warmTried[wm.ModelID] = now
+
+		nodeID, started, err := p.Loader.WarmLoad(ctx, wm.ModelID)
+		if err != nil {
+			log.Printf("planner: warm load failed model=%s err=%v", wm.ModelID, err)
+			continue
+		}
+		if !started {
+			continue
+		}
+		log.Printf("planner: warm load requested node=%s model=%s", nodeID, wm.ModelID)
+		if p.Activity != nil {
+			p.Activity.Add(activity.Event{
+				At:     now,
+				Type:   activity.EventWarmLoad,
+				NodeID: nodeID,
+				Model:  wm.ModelID,
+				Note:   "warm pool",
+			})
+		}
+	}
+}
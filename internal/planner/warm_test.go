@@ -0,0 +1,45 @@
+package planner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestWarmStartup(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		models map[string]state.ModelState
+		want   int
+	}{
+		{"missing", nil, 1},
+		{"ready", map[string]state.ModelState{"m": state.ModelReady}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := state.NewClusterState()
+			loader := &fakeLoader{}
+			p := &Planner{Cluster: c, Loader: loader, NodeOfflineTTL: time.Hour, WarmPool: []WarmModel{{ModelID: "m"}}}
+			ctx := context.Background()
+			start := time.Now()
+
+			if p.warmStartup(ctx, start) {
+				t.Fatal("ran without nodes")
+			}
+			addNode(c, "n1", 32<<30, tc.models)
+			if p.warmStartup(ctx, start.Add(time.Second)) {
+				t.Fatal("ran as soon as the first node was seen")
+			}
+			if p.warmStartup(ctx, start.Add(time.Second+warmStartupSettle/2)) {
+				t.Fatal("ran before the settle time")
+			}
+			if !p.warmStartup(ctx, start.Add(time.Second+warmStartupSettle)) {
+				t.Fatal("did not run after the settle time")
+			}
+			if got := len(loader.loaded); got != tc.want {
+				t.Errorf("warm loads = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
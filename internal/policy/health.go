@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrStoreDegraded is returned by mutations while the store is degraded and FailClosed is set.
+var ErrStoreDegraded = errors.New("policy store degraded, changes are rejected")
+
+// Health describes the state of the underlying database.
+type Health struct {
+	Healthy           bool      `json:"healthy"`
+	ConsecutiveErrors int       `json:"consecutive_errors"`
+	LastError         string    `json:"last_error,omitempty"`
+	LastErrorAt       time.Time `json:"last_error_at,omitempty"`
+}
+
+// Health returns the current store health.
+func (s *Store) Health() Health {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	h := Health{
+		Healthy:           !s.degradedLocked(),
+		ConsecutiveErrors: s.consecErrs,
+		LastErrorAt:       s.lastErrAt,
+	}
+	if s.lastErr != nil {
+		h.LastError = s.lastErr.Error()
+	}
+	return h
+}
+
+func (s *Store) degradedLocked() bool {
+	return s.DegradedAfter > 0 && s.consecErrs >= s.DegradedAfter
+}
+
+// observe records the outcome of a database operation and passes err through.
+// sql.ErrNoRows is a regular result, not a failure.
+func (s *Store) observe(err error) error {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		if s.degradedLocked() {
+			log.Printf("policy store: recovered after %d consecutive errors", s.consecErrs)
+		}
+		s.consecErrs = 0
+		return err
+	}
+
+	s.consecErrs++
+	s.lastErr = err
+	s.lastErrAt = time.Now()
+	if s.DegradedAfter > 0 && s.consecErrs == s.DegradedAfter {
+		log.Printf("ERROR: policy store degraded after %d consecutive errors (fail_closed=%v): %v", s.consecErrs, s.FailClosed, err)
+	}
+	return err
+}
+
+// writable rejects mutations while degraded if FailClosed is set.
+func (s *Store) writable() error {
+	if !s.FailClosed {
+		return nil
+	}
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	if s.degradedLocked() {
+		return ErrStoreDegraded
+	}
+	return nil
+}
+
+func (s *Store) cachePut(p ModelPolicy) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	s.cache[p.ModelID] = p
+}
+
+func (s *Store) cacheDelete(modelID string) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	delete(s.cache, modelID)
+}
+
+func (s *Store) cacheReplace(list []ModelPolicy) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	s.cache = make(map[string]ModelPolicy, len(list))
+	for _, p := range list {
+		s.cache[p.ModelID] = p
+	}
+}
+
+// cacheGet returns the last known policy, optionally matching folded model ids.
+func (s *Store) cacheGet(modelID string, fold bool) (ModelPolicy, bool) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	if p, ok := s.cache[modelID]; ok || !fold {
+		return p, ok
+	}
+	want := strings.ToLower(strings.TrimSpace(modelID))
+	var (
+		best  ModelPolicy
+		found bool
+	)
+	for id, p := range s.cache {
+		if strings.ToLower(strings.TrimSpace(id)) == want && (!found || id < best.ModelID) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+func (s *Store) cacheList() []ModelPolicy {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	out := make([]ModelPolicy, 0, len(s.cache))
+	for _, p := range s.cache {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModelID < out[j].ModelID })
+	return out
+}
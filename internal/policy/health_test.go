@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStoreDegradesAndServesCache(t *testing.T) {
+	s := openTestStore(t)
+	s.DegradedAfter = 2
+	s.FailClosed = true
+	ctx := context.Background()
+	if err := s.UpsertPolicy(ctx, ModelPolicy{ModelID: "m", TTLSecs: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ListPolicies(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a broken database (e.g. the file was removed).
+	s.db.Close()
+
+	p, ok, err := s.GetPolicy(ctx, "m")
+	if err != nil || !ok || p.TTLSecs != 60 {
+		t.Fatalf("GetPolicy = %+v, %v, %v, want the cached policy", p, ok, err)
+	}
+	if h := s.Health(); !h.Healthy || h.ConsecutiveErrors != 1 || h.LastError == "" {
+		t.Errorf("health after one error = %+v, want healthy with the error recorded", h)
+	}
+	if _, _, err := s.GetPolicy(ctx, "unknown"); err == nil {
+		t.Error("GetPolicy of an uncached model succeeded on a broken database")
+	}
+	if h := s.Health(); h.Healthy {
+		t.Errorf("health after %d errors = %+v, want degraded", h.ConsecutiveErrors, h)
+	}
+
+	list, err := s.ListPolicies(ctx)
+	if err != nil || len(list) != 1 || list[0].ModelID != "m" {
+		t.Errorf("ListPolicies = %v, %v, want the cached policies", list, err)
+	}
+	if err := s.UpsertPolicy(ctx, ModelPolicy{ModelID: "n"}); !errors.Is(err, ErrStoreDegraded) {
+		t.Errorf("UpsertPolicy while degraded = %v, want %v", err, ErrStoreDegraded)
+	}
+	if err := s.Delete(ctx, "m"); !errors.Is(err, ErrStoreDegraded) {
+		t.Errorf("Delete while degraded = %v, want %v", err, ErrStoreDegraded)
+	}
+}
+
+func TestStoreFailsOpenByDefault(t *testing.T) {
+	s := openTestStore(t)
+	s.DegradedAfter = 1
+	s.db.Close()
+	ctx := context.Background()
+
+	s.GetPolicy(ctx, "m")
+	if s.Health().Healthy {
+		t.Fatal("store healthy after an error with DegradedAfter 1")
+	}
+	// Without FailClosed the write is attempted (and fails with the database error).
+	if err := s.UpsertPolicy(ctx, ModelPolicy{ModelID: "m"}); err == nil || errors.Is(err, ErrStoreDegraded) {
+		t.Errorf("UpsertPolicy = %v, want the database error", err)
+	}
+}
+
+func TestStoreRecovers(t *testing.T) {
+	s := openTestStore(t)
+	s.DegradedAfter = 1
+	s.observe(errors.New("disk I/O error"))
+	if s.Health().Healthy {
+		t.Fatal("store healthy after an error")
+	}
+	if _, _, err := s.GetPolicy(context.Background(), "m"); err != nil {
+		t.Fatal(err)
+	}
+	if h := s.Health(); !h.Healthy || h.ConsecutiveErrors != 0 {
+		t.Errorf("health after a successful query = %+v, want healthy", h)
+	}
+}
@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ParamOverrides are the server-side request parameters of a model policy.
+type ParamOverrides struct {
+	// Defaults are set when the request omits the key (or sends null).
+	Defaults map[string]json.RawMessage
+	// Max clamps numeric values the request sends. Requests without the key are not
+	// changed; add a default as well to limit them.
+	Max map[string]float64
+}
+
+// Empty reports whether there is nothing to apply.
+func (o ParamOverrides) Empty() bool {
+	return len(o.Defaults) == 0 && len(o.Max) == 0
+}
+
+// ParamOverrides parses ParamDefaults and ParamMax.
+func (p ModelPolicy) ParamOverrides() (ParamOverrides, error) {
+	var o ParamOverrides
+	if s := strings.TrimSpace(p.ParamDefaults); s != "" {
+		if err := json.Unmarshal([]byte(s), &o.Defaults); err != nil {
+			return ParamOverrides{}, fmt.Errorf("parameter defaults must be a JSON object: %w", err)
+		}
+	}
+	if s := strings.TrimSpace(p.ParamMax); s != "" {
+		if err := json.Unmarshal([]byte(s), &o.Max); err != nil {
+			return ParamOverrides{}, fmt.Errorf("parameter limits must be a JSON object of numbers: %w", err)
+		}
+	}
+	for k := range o.Defaults {
+		if k == "model" {
+			return ParamOverrides{}, fmt.Errorf("parameter defaults must not set %q", k)
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,55 @@
+package policy
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParamOverrides(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		defaults  string
+		max       string
+		wantErr   bool
+		wantEmpty bool
+	}{
+		{name: "none", wantEmpty: true},
+		{name: "blank", defaults: "  ", max: "\n", wantEmpty: true},
+		{name: "defaults and limits", defaults: `{"temperature":0.2,"stop":["\n"]}`, max: `{"max_tokens":512}`},
+		{name: "defaults not an object", defaults: `[1]`, wantErr: true},
+		{name: "limit not a number", max: `{"max_tokens":"512"}`, wantErr: true},
+		{name: "default for the model", defaults: `{"model":"other"}`, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := ModelPolicy{ModelID: "m", ParamDefaults: tc.defaults, ParamMax: tc.max}.ParamOverrides()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("overrides = %+v, want an error", o)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.Empty() != tc.wantEmpty {
+				t.Errorf("Empty = %v, want %v", o.Empty(), tc.wantEmpty)
+			}
+		})
+	}
+}
+
+func TestParamOverridesStored(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	in := ModelPolicy{ModelID: "m", ParamDefaults: `{"temperature":0.2}`, ParamMax: `{"max_tokens":512}`}
+	if err := s.UpsertPolicy(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	p, ok, err := s.GetPolicy(ctx, "m")
+	if err != nil || !ok {
+		t.Fatalf("GetPolicy = %v, %v", ok, err)
+	}
+	if p.ParamDefaults != in.ParamDefaults || p.ParamMax != in.ParamMax {
+		t.Errorf("stored overrides = %q / %q, want %q / %q", p.ParamDefaults, p.ParamMax, in.ParamDefaults, in.ParamMax)
+	}
+}
@@ -3,6 +3,9 @@ package policy
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -10,6 +13,18 @@ import (
 
 type Store struct {
 	db *sql.DB
+
+	// DegradedAfter is the number of consecutive database errors after which the store
+	// reports itself degraded (0 = never).
+	DegradedAfter int
+	// FailClosed rejects mutations with ErrStoreDegraded while degraded.
+	FailClosed bool
+
+	healthMu   sync.Mutex
+	consecErrs int
+	lastErr    error
+	lastErrAt  time.Time
+	cache      map[string]ModelPolicy // last known policies, served while the database fails
 }
 
 func Open(path string) (*Store, error) {
@@ -21,11 +36,16 @@ func Open(path string) (*Store, error) {
 	db.SetMaxOpenConns(1)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	s := &Store{db: db}
+	s := &Store{db: db, DegradedAfter: 3, cache: map[string]ModelPolicy{}}
 	if err := s.migrate(); err != nil {
 		_ = db.Close()
 		return nil, err
 	}
+	// Warm the policy cache used while the database is unavailable.
+	if _, err := s.ListPolicies(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 	return s, nil
 }
 
@@ -37,8 +57,14 @@ func (s *Store) Delete(ctx context.Context, modelID string) error {
 	if s.db == nil {
 		return nil
 	}
+	if err := s.writable(); err != nil {
+		return err
+	}
 	_, err := s.db.ExecContext(ctx, "DELETE FROM model_policies WHERE model_id=?;", modelID)
-	return err
+	if err == nil {
+		s.cacheDelete(modelID)
+	}
+	return s.observe(err)
 }
 
 func (s *Store) ListAll(ctx context.Context) ([]ModelPolicy, error) {
@@ -70,7 +96,9 @@ CREATE TABLE IF NOT EXISTS api_keys (
   created_at DATETIME NOT NULL,
   last_used_at DATETIME,
   allowed_nodes TEXT NOT NULL DEFAULT '',
-  allowed_models TEXT NOT NULL DEFAULT ''
+  allowed_models TEXT NOT NULL DEFAULT '',
+  owner TEXT NOT NULL DEFAULT 'admin',
+  request_count INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS users (
@@ -79,7 +107,88 @@ CREATE TABLE IF NOT EXISTS users (
   allowed_nodes TEXT NOT NULL DEFAULT '',
   allowed_models TEXT NOT NULL DEFAULT ''
 );
+
+CREATE TABLE IF NOT EXISTS usage_daily (
+  day TEXT NOT NULL,
+  key_id TEXT NOT NULL,
+  model_id TEXT NOT NULL,
+  requests INTEGER NOT NULL DEFAULT 0,
+  tokens INTEGER NOT NULL DEFAULT 0,
+  cost REAL NOT NULL DEFAULT 0,
+  PRIMARY KEY (day, key_id, model_id)
+);
 `)
+	if err != nil {
+		return err
+	}
+
+	// Columns added after the initial schema. Existing keys default to the admin owner.
+	if err := s.addColumnIfMissing("api_keys", "owner", "TEXT NOT NULL DEFAULT 'admin'"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("api_keys", "request_count", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("model_policies", "param_defaults", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("model_policies", "param_max", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("model_policies", "cost_per_1k_tokens", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("model_policies", "verify_ready", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("model_policies", "max_nodes", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("model_policies", "version", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("api_keys", "monthly_budget", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("api_keys", "spend", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("api_keys", "spend_month", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	return s.addColumnIfMissing("api_keys", "force_stream", "TEXT NOT NULL DEFAULT ''")
+}
+
+// addColumnIfMissing adds a column to an existing table (SQLite has no ADD COLUMN IF NOT EXISTS).
+func (s *Store) addColumnIfMissing(table, column, decl string) error {
+	rows, err := s.db.Query("PRAGMA table_info(" + table + ");")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid     int
+			name    string
+			typ     string
+			notNull int
+			dflt    sql.NullString
+			pk      int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_ = rows.Close()
+
+	_, err = s.db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + decl + ";")
 	return err
 }
 
@@ -92,6 +201,37 @@ type APIKeyRecord struct {
 	LastUsedAt    *time.Time
 	AllowedNodes  string
 	AllowedModels string
+	Owner         string // username that created the key
+	RequestCount  int64
+
+	// MonthlyBudget caps the spend per calendar month (0 = unlimited). Spend is the
+	// cost accumulated in SpendMonth ("2006-01"); an older month counts as zero.
+	MonthlyBudget float64
+	Spend         float64
+	SpendMonth    string
+
+	// ForceStream sets "stream" in chat and completion requests of the key: ForceStreamOn,
+	// ForceStreamOff or "" (the client decides).
+	ForceStream string
+}
+
+// Values of APIKeyRecord.ForceStream.
+const (
+	ForceStreamOn  = "on"
+	ForceStreamOff = "off"
+)
+
+// MonthOf returns the spend month of t ("2006-01", server local time).
+func MonthOf(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+// SpendIn returns the spend recorded for month ("2006-01").
+func (r APIKeyRecord) SpendIn(month string) float64 {
+	if r.SpendMonth != month {
+		return 0
+	}
+	return r.Spend
 }
 
 type UserRecord struct {
@@ -105,11 +245,14 @@ func (s *Store) CreateAPIKey(ctx context.Context, record APIKeyRecord) error {
 	if s.db == nil {
 		return nil
 	}
+	if err := s.writable(); err != nil {
+		return err
+	}
 	_, err := s.db.ExecContext(ctx, `
-INSERT INTO api_keys(key_id, name, prefix, hashed_key, created_at, allowed_nodes, allowed_models)
-VALUES(?, ?, ?, ?, ?, ?, ?);
-`, record.ID, record.Name, record.Prefix, record.HashedKey, record.CreatedAt, record.AllowedNodes, record.AllowedModels)
-	return err
+INSERT INTO api_keys(key_id, name, prefix, hashed_key, created_at, allowed_nodes, allowed_models, owner, monthly_budget)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?);
+`, record.ID, record.Name, record.Prefix, record.HashedKey, record.CreatedAt, record.AllowedNodes, record.AllowedModels, record.Owner, record.MonthlyBudget)
+	return s.observe(err)
 }
 
 func (s *Store) ListAPIKeys(ctx context.Context) ([]APIKeyRecord, error) {
@@ -117,9 +260,33 @@ func (s *Store) ListAPIKeys(ctx context.Context) ([]APIKeyRecord, error) {
 		return nil, nil
 	}
 	rows, err := s.db.QueryContext(ctx, `
-SELECT key_id, name, prefix, hashed_key, created_at, last_used_at, allowed_nodes, allowed_models
+SELECT key_id, name, prefix, hashed_key, created_at, last_used_at, allowed_nodes, allowed_models, owner, request_count, monthly_budget, spend, spend_month, force_stream
 FROM api_keys ORDER BY created_at DESC;
 `)
+	if err != nil {
+		return nil, s.observe(err)
+	}
+	defer rows.Close()
+
+	var out []APIKeyRecord
+	for rows.Next() {
+		var r APIKeyRecord
+		if err := rows.Scan(&r.ID, &r.Name, &r.Prefix, &r.HashedKey, &r.CreatedAt, &r.LastUsedAt, &r.AllowedNodes, &r.AllowedModels, &r.Owner, &r.RequestCount, &r.MonthlyBudget, &r.Spend, &r.SpendMonth, &r.ForceStream); err != nil {
+			return nil, s.observe(err)
+		}
+		out = append(out, r)
+	}
+	return out, s.observe(rows.Err())
+}
+
+func (s *Store) ListAPIKeysByOwner(ctx context.Context, owner string) ([]APIKeyRecord, error) {
+	if s.db == nil {
+		return nil, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT key_id, name, prefix, hashed_key, created_at, last_used_at, allowed_nodes, allowed_models, owner, request_count, monthly_budget, spend, spend_month, force_stream
+FROM api_keys WHERE owner=? ORDER BY created_at DESC;
+`, owner)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +295,7 @@ FROM api_keys ORDER BY created_at DESC;
 	var out []APIKeyRecord
 	for rows.Next() {
 		var r APIKeyRecord
-		if err := rows.Scan(&r.ID, &r.Name, &r.Prefix, &r.HashedKey, &r.CreatedAt, &r.LastUsedAt, &r.AllowedNodes, &r.AllowedModels); err != nil {
+		if err := rows.Scan(&r.ID, &r.Name, &r.Prefix, &r.HashedKey, &r.CreatedAt, &r.LastUsedAt, &r.AllowedNodes, &r.AllowedModels, &r.Owner, &r.RequestCount, &r.MonthlyBudget, &r.Spend, &r.SpendMonth, &r.ForceStream); err != nil {
 			return nil, err
 		}
 		out = append(out, r)
@@ -136,16 +303,25 @@ FROM api_keys ORDER BY created_at DESC;
 	return out, nil
 }
 
+func (s *Store) CountAPIKeysByOwner(ctx context.Context, owner string) (int, error) {
+	if s.db == nil {
+		return 0, nil
+	}
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM api_keys WHERE owner=?;", owner).Scan(&n)
+	return n, err
+}
+
 func (s *Store) GetAPIKey(ctx context.Context, id string) (APIKeyRecord, bool, error) {
 	if s.db == nil {
 		return APIKeyRecord{}, false, nil
 	}
 	row := s.db.QueryRowContext(ctx, `
-SELECT key_id, name, prefix, hashed_key, created_at, last_used_at, allowed_nodes, allowed_models
+SELECT key_id, name, prefix, hashed_key, created_at, last_used_at, allowed_nodes, allowed_models, owner, request_count, monthly_budget, spend, spend_month, force_stream
 FROM api_keys WHERE key_id=?;
 `, id)
 	var r APIKeyRecord
-	err := row.Scan(&r.ID, &r.Name, &r.Prefix, &r.HashedKey, &r.CreatedAt, &r.LastUsedAt, &r.AllowedNodes, &r.AllowedModels)
+	err := row.Scan(&r.ID, &r.Name, &r.Prefix, &r.HashedKey, &r.CreatedAt, &r.LastUsedAt, &r.AllowedNodes, &r.AllowedModels, &r.Owner, &r.RequestCount, &r.MonthlyBudget, &r.Spend, &r.SpendMonth, &r.ForceStream)
 	if err == sql.ErrNoRows {
 		return APIKeyRecord{}, false, nil
 	}
@@ -159,8 +335,11 @@ func (s *Store) DeleteAPIKey(ctx context.Context, id string) error {
 	if s.db == nil {
 		return nil
 	}
+	if err := s.writable(); err != nil {
+		return err
+	}
 	_, err := s.db.ExecContext(ctx, "DELETE FROM api_keys WHERE key_id=?;", id)
-	return err
+	return s.observe(err)
 }
 
 func (s *Store) UpdateAPIKeyLastUsed(ctx context.Context, id string) error {
@@ -168,18 +347,78 @@ func (s *Store) UpdateAPIKeyLastUsed(ctx context.Context, id string) error {
 		return nil
 	}
 	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at=? WHERE key_id=?;", time.Now(), id)
-	return err
+	return s.observe(err)
+}
+
+// RecordAPIKeyUse sets last_used_at, increments the request counter and adds spend to
+// the spend of month in one write. Spend of an older month is reset first.
+func (s *Store) RecordAPIKeyUse(ctx context.Context, id string, at time.Time, requests int64, spend float64, month string) error {
+	if s.db == nil {
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx, `
+UPDATE api_keys SET
+  last_used_at=?,
+  request_count=request_count+?,
+  spend=CASE WHEN spend_month=? THEN spend+? ELSE ? END,
+  spend_month=?
+WHERE key_id=?;
+`, at, requests, month, spend, spend, month, id)
+	return s.observe(err)
+}
+
+// SetAPIKeyHash replaces the stored hash of a key (rehashing with a new pepper).
+func (s *Store) SetAPIKeyHash(ctx context.Context, id, hash string) error {
+	if s.db == nil {
+		return nil
+	}
+	if err := s.writable(); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET hashed_key=? WHERE key_id=?;", hash, id)
+	return s.observe(err)
+}
+
+// SetAPIKeyBudget sets the monthly budget of a key (0 = unlimited).
+func (s *Store) SetAPIKeyBudget(ctx context.Context, id string, budget float64) error {
+	if s.db == nil {
+		return nil
+	}
+	if err := s.writable(); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET monthly_budget=? WHERE key_id=?;", budget, id)
+	return s.observe(err)
+}
+
+// SetAPIKeyForceStream sets the stream override of a key (ForceStreamOn, ForceStreamOff
+// or "" = none).
+func (s *Store) SetAPIKeyForceStream(ctx context.Context, id, mode string) error {
+	if s.db == nil {
+		return nil
+	}
+	if mode != "" && mode != ForceStreamOn && mode != ForceStreamOff {
+		return fmt.Errorf("invalid stream override %q", mode)
+	}
+	if err := s.writable(); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET force_stream=? WHERE key_id=?;", mode, id)
+	return s.observe(err)
 }
 
 func (s *Store) CreateUser(ctx context.Context, u UserRecord) error {
 	if s.db == nil {
 		return nil
 	}
+	if err := s.writable(); err != nil {
+		return err
+	}
 	_, err := s.db.ExecContext(ctx, `
 INSERT INTO users(username, password_hash, allowed_nodes, allowed_models)
 VALUES(?, ?, ?, ?);
 `, u.Username, u.PasswordHash, u.AllowedNodes, u.AllowedModels)
-	return err
+	return s.observe(err)
 }
 
 func (s *Store) GetUser(ctx context.Context, username string) (UserRecord, bool, error) {
@@ -188,7 +427,7 @@ func (s *Store) GetUser(ctx context.Context, username string) (UserRecord, bool,
 	}
 	row := s.db.QueryRowContext(ctx, "SELECT username, password_hash, allowed_nodes, allowed_models FROM users WHERE username=?;", username)
 	var u UserRecord
-	err := row.Scan(&u.Username, &u.PasswordHash, &u.AllowedNodes, &u.AllowedModels)
+	err := s.observe(row.Scan(&u.Username, &u.PasswordHash, &u.AllowedNodes, &u.AllowedModels))
 	if err == sql.ErrNoRows {
 		return UserRecord{}, false, nil
 	}
@@ -222,41 +461,97 @@ func (s *Store) DeleteUser(ctx context.Context, username string) error {
 	if s.db == nil {
 		return nil
 	}
+	if err := s.writable(); err != nil {
+		return err
+	}
 	_, err := s.db.ExecContext(ctx, "DELETE FROM users WHERE username=?;", username)
-	return err
+	return s.observe(err)
 }
 
 func (s *Store) UpdateUser(ctx context.Context, u UserRecord) error {
 	if s.db == nil {
 		return nil
 	}
+	if err := s.writable(); err != nil {
+		return err
+	}
 	_, err := s.db.ExecContext(ctx, `
 UPDATE users SET allowed_nodes=?, allowed_models=? WHERE username=?;
 `, u.AllowedNodes, u.AllowedModels, u.Username)
-	return err
+	return s.observe(err)
 }
 
 func (s *Store) UpdateUserPassword(ctx context.Context, username, passwordHash string) error {
 	if s.db == nil {
 		return nil
 	}
+	if err := s.writable(); err != nil {
+		return err
+	}
 	_, err := s.db.ExecContext(ctx, "UPDATE users SET password_hash=? WHERE username=?;", passwordHash, username)
-	return err
+	return s.observe(err)
 }
 
+// ErrConflict is returned by UpsertPolicy when the policy changed since the version
+// the write is based on (another admin saved it in between).
+var ErrConflict = errors.New("policy changed, please reload")
+
+// UpsertPolicy creates or replaces the policy of p.ModelID. With p.Version > 0 the
+// write is based on that version of the policy: it fails with ErrConflict if the policy
+// was changed or deleted since. p.Version 0 writes unconditionally. Policies failing
+// Validate are rejected.
 func (s *Store) UpsertPolicy(ctx context.Context, p ModelPolicy) error {
 	if s.db == nil {
 		return nil
 	}
-	_, err := s.db.ExecContext(ctx, `
-INSERT INTO model_policies(model_id, ram_required_bytes, ttl_secs, pinned, priority)
-VALUES(?, ?, ?, ?, ?)
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("invalid policy: %w", err)
+	}
+	if err := s.writable(); err != nil {
+		return err
+	}
+	var row *sql.Row
+	if p.Version > 0 {
+		row = s.db.QueryRowContext(ctx, `
+UPDATE model_policies SET
+  ram_required_bytes=?,
+  ttl_secs=?,
+  pinned=?,
+  priority=?,
+  param_defaults=?,
+  param_max=?,
+  cost_per_1k_tokens=?,
+  verify_ready=?,
+  max_nodes=?,
+  version=version+1
+WHERE model_id=? AND version=?
+RETURNING version;
+`, p.RAMRequiredBytes, p.TTLSecs, boolToInt(p.Pinned), p.Priority, p.ParamDefaults, p.ParamMax, p.CostPer1KTokens, boolToInt(p.VerifyReady), p.MaxNodes, p.ModelID, p.Version)
+	} else {
+		row = s.db.QueryRowContext(ctx, `
+INSERT INTO model_policies(model_id, ram_required_bytes, ttl_secs, pinned, priority, param_defaults, param_max, cost_per_1k_tokens, verify_ready, max_nodes, version)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
 ON CONFLICT(model_id) DO UPDATE SET
   ram_required_bytes=excluded.ram_required_bytes,
   ttl_secs=excluded.ttl_secs,
   pinned=excluded.pinned,
-  priority=excluded.priority;
-`, p.ModelID, p.RAMRequiredBytes, p.TTLSecs, boolToInt(p.Pinned), p.Priority)
+  priority=excluded.priority,
+  param_defaults=excluded.param_defaults,
+  param_max=excluded.param_max,
+  cost_per_1k_tokens=excluded.cost_per_1k_tokens,
+  verify_ready=excluded.verify_ready,
+  max_nodes=excluded.max_nodes,
+  version=model_policies.version+1
+RETURNING version;
+`, p.ModelID, p.RAMRequiredBytes, p.TTLSecs, boolToInt(p.Pinned), p.Priority, p.ParamDefaults, p.ParamMax, p.CostPer1KTokens, boolToInt(p.VerifyReady), p.MaxNodes)
+	}
+	err := s.observe(row.Scan(&p.Version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrConflict
+	}
+	if err == nil {
+		s.cachePut(p)
+	}
 	return err
 }
 
@@ -265,20 +560,61 @@ func (s *Store) GetPolicy(ctx context.Context, modelID string) (ModelPolicy, boo
 		return ModelPolicy{}, false, nil
 	}
 	row := s.db.QueryRowContext(ctx, `
-SELECT model_id, ram_required_bytes, ttl_secs, pinned, priority
+SELECT model_id, ram_required_bytes, ttl_secs, pinned, priority, param_defaults, param_max, cost_per_1k_tokens, verify_ready, max_nodes, version
 FROM model_policies WHERE model_id=?;
 `, modelID)
 
 	var p ModelPolicy
-	var pinnedInt int
-	err := row.Scan(&p.ModelID, &p.RAMRequiredBytes, &p.TTLSecs, &pinnedInt, &p.Priority)
+	var pinnedInt, verifyInt int
+	err := s.observe(row.Scan(&p.ModelID, &p.RAMRequiredBytes, &p.TTLSecs, &pinnedInt, &p.Priority, &p.ParamDefaults, &p.ParamMax, &p.CostPer1KTokens, &verifyInt, &p.MaxNodes, &p.Version))
 	if err == sql.ErrNoRows {
+		s.cacheDelete(modelID)
 		return ModelPolicy{}, false, nil
 	}
 	if err != nil {
+		// Serve the last known policy while the database fails.
+		if c, ok := s.cacheGet(modelID, false); ok {
+			return c, true, nil
+		}
 		return ModelPolicy{}, false, err
 	}
 	p.Pinned = pinnedInt != 0
+	p.VerifyReady = verifyInt != 0
+	p.clamp()
+	s.cachePut(p)
+	return p, true, nil
+}
+
+// GetPolicyFold looks up a policy ignoring case and surrounding whitespace of the model id.
+// An exact match is preferred over a folded one.
+func (s *Store) GetPolicyFold(ctx context.Context, modelID string) (ModelPolicy, bool, error) {
+	if s.db == nil {
+		return ModelPolicy{}, false, nil
+	}
+	row := s.db.QueryRowContext(ctx, `
+SELECT model_id, ram_required_bytes, ttl_secs, pinned, priority, param_defaults, param_max, cost_per_1k_tokens, verify_ready, max_nodes, version
+FROM model_policies
+WHERE model_id=? OR lower(trim(model_id))=lower(trim(?))
+ORDER BY model_id=? DESC, model_id ASC
+LIMIT 1;
+`, modelID, modelID, modelID)
+
+	var p ModelPolicy
+	var pinnedInt, verifyInt int
+	err := s.observe(row.Scan(&p.ModelID, &p.RAMRequiredBytes, &p.TTLSecs, &pinnedInt, &p.Priority, &p.ParamDefaults, &p.ParamMax, &p.CostPer1KTokens, &verifyInt, &p.MaxNodes, &p.Version))
+	if err == sql.ErrNoRows {
+		return ModelPolicy{}, false, nil
+	}
+	if err != nil {
+		if c, ok := s.cacheGet(modelID, true); ok {
+			return c, true, nil
+		}
+		return ModelPolicy{}, false, err
+	}
+	p.Pinned = pinnedInt != 0
+	p.VerifyReady = verifyInt != 0
+	p.clamp()
+	s.cachePut(p)
 	return p, true, nil
 }
 
@@ -286,8 +622,21 @@ func (s *Store) ListPolicies(ctx context.Context) ([]ModelPolicy, error) {
 	if s.db == nil {
 		return nil, nil
 	}
+	out, err := s.listPolicies(ctx)
+	if s.observe(err) != nil {
+		// Serve the last known policies while the database fails.
+		if cached := s.cacheList(); len(cached) > 0 {
+			return cached, nil
+		}
+		return nil, err
+	}
+	s.cacheReplace(out)
+	return out, nil
+}
+
+func (s *Store) listPolicies(ctx context.Context) ([]ModelPolicy, error) {
 	rows, err := s.db.QueryContext(ctx, `
-SELECT model_id, ram_required_bytes, ttl_secs, pinned, priority
+SELECT model_id, ram_required_bytes, ttl_secs, pinned, priority, param_defaults, param_max, cost_per_1k_tokens, verify_ready, max_nodes, version
 FROM model_policies
 ORDER BY model_id ASC;
 `)
@@ -299,11 +648,13 @@ ORDER BY model_id ASC;
 	var out []ModelPolicy
 	for rows.Next() {
 		var p ModelPolicy
-		var pinnedInt int
-		if err := rows.Scan(&p.ModelID, &p.RAMRequiredBytes, &p.TTLSecs, &pinnedInt, &p.Priority); err != nil {
+		var pinnedInt, verifyInt int
+		if err := rows.Scan(&p.ModelID, &p.RAMRequiredBytes, &p.TTLSecs, &pinnedInt, &p.Priority, &p.ParamDefaults, &p.ParamMax, &p.CostPer1KTokens, &verifyInt, &p.MaxNodes, &p.Version); err != nil {
 			return nil, err
 		}
 		p.Pinned = pinnedInt != 0
+		p.VerifyReady = verifyInt != 0
+		p.clamp()
 		out = append(out, p)
 	}
 	return out, rows.Err()
@@ -0,0 +1,143 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "policies.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestUpsertPolicyConcurrentEditConflict(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	if err := s.UpsertPolicy(ctx, ModelPolicy{ModelID: "m", TTLSecs: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two admins open the same policy.
+	first, _, _ := s.GetPolicy(ctx, "m")
+	second, _, _ := s.GetPolicy(ctx, "m")
+	if first.Version != 1 {
+		t.Fatalf("version = %d, want 1", first.Version)
+	}
+
+	first.TTLSecs = 120
+	if err := s.UpsertPolicy(ctx, first); err != nil {
+		t.Fatalf("first save: %v", err)
+	}
+	second.Priority = 5
+	if err := s.UpsertPolicy(ctx, second); !errors.Is(err, ErrConflict) {
+		t.Fatalf("stale save = %v, want ErrConflict", err)
+	}
+
+	got, _, _ := s.GetPolicy(ctx, "m")
+	if got.TTLSecs != 120 || got.Priority != 0 || got.Version != 2 {
+		t.Errorf("stored %+v, want the first save at version 2", got)
+	}
+
+	// After reloading, the second admin's save goes through.
+	second, _, _ = s.GetPolicy(ctx, "m")
+	second.Priority = 5
+	if err := s.UpsertPolicy(ctx, second); err != nil {
+		t.Fatalf("save after reload: %v", err)
+	}
+
+	// A policy deleted meanwhile is not recreated by a stale save.
+	stale, _, _ := s.GetPolicy(ctx, "m")
+	if err := s.Delete(ctx, "m"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpsertPolicy(ctx, stale); !errors.Is(err, ErrConflict) {
+		t.Errorf("save of deleted policy = %v, want ErrConflict", err)
+	}
+}
+
+func TestUpsertPolicyConcurrentWritersOneWins(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	if err := s.UpsertPolicy(ctx, ModelPolicy{ModelID: "m"}); err != nil {
+		t.Fatal(err)
+	}
+	base, _, _ := s.GetPolicy(ctx, "m")
+
+	const writers = 8
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		ok        int
+		conflicts int
+	)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := base
+			p.Priority = i + 1
+			err := s.UpsertPolicy(ctx, p)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				ok++
+			case errors.Is(err, ErrConflict):
+				conflicts++
+			default:
+				t.Errorf("save: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if ok != 1 || conflicts != writers-1 {
+		t.Errorf("%d saves succeeded and %d conflicted, want 1 and %d", ok, conflicts, writers-1)
+	}
+	if got, _, _ := s.GetPolicy(ctx, "m"); got.Version != 2 {
+		t.Errorf("version = %d, want 2", got.Version)
+	}
+}
+
+func TestGetPolicyFold(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	for _, p := range []ModelPolicy{
+		{ModelID: "Llama-3.1-8B", TTLSecs: 1},
+		{ModelID: "llama-3.1-8b", TTLSecs: 2},
+		{ModelID: "Qwen", TTLSecs: 3},
+	} {
+		if err := s.UpsertPolicy(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		model string
+		ttl   int64 // 0 = not found
+	}{
+		{"llama-3.1-8b", 2}, // exact match wins
+		{"Llama-3.1-8B", 1},
+		{"LLAMA-3.1-8B", 1}, // folded: the smallest id
+		{" qwen ", 3},
+		{"mistral", 0},
+	} {
+		p, ok, err := s.GetPolicyFold(ctx, tc.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != (tc.ttl != 0) || p.TTLSecs != tc.ttl {
+			t.Errorf("GetPolicyFold(%q) = %s ttl %d (found %v), want ttl %d", tc.model, p.ModelID, p.TTLSecs, ok, tc.ttl)
+		}
+	}
+	if _, ok, _ := s.GetPolicy(ctx, "LLAMA-3.1-8B"); ok {
+		t.Error("GetPolicy folded the model id")
+	}
+}
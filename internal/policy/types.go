@@ -1,9 +1,75 @@
 package policy
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
 type ModelPolicy struct {
 	ModelID          string
 	RAMRequiredBytes uint64
 	TTLSecs          int64
 	Pinned           bool
 	Priority         int // higher = keep longer
+
+	// Request parameter overrides as JSON objects ("" = none), see ParamOverrides.
+	ParamDefaults string // values set when the request omits the key
+	ParamMax      string // numeric ceilings for values the request sends
+
+	// CostPer1KTokens is charged to the API key per 1000 tokens (prompt and completion)
+	// of a response; 0 = free.
+	CostPer1KTokens float64
+
+	// VerifyReady probes a READY node (is the model still loaded?) before a request is
+	// routed to it, so a model unloaded since the last status is not hit.
+	VerifyReady bool
+
+	// MaxNodes caps the number of nodes the model is READY or LOADING on: placement
+	// does not cold-load it on another node once it has that many (0 = unlimited).
+	MaxNodes int
+
+	// Version is incremented by every write (optimistic locking, see UpsertPolicy).
+	Version int64
+}
+
+// Limits of the policy values accepted by Validate.
+const (
+	MaxPriority         = 100
+	MaxTTLSecs          = 10 * 365 * 24 * 3600 // 10 years
+	MaxRAMRequiredBytes = 1 << 50              // 1 PiB
+)
+
+// Validate reports every value of the policy that makes no sense, so the planner and
+// placement never see them. UpsertPolicy rejects such policies.
+func (p ModelPolicy) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(strings.TrimSpace(p.ModelID) != "", "model id is required")
+	check(p.TTLSecs >= 0 && p.TTLSecs <= MaxTTLSecs, "ttl must be between 0 and %d seconds, got %d", MaxTTLSecs, p.TTLSecs)
+	check(p.RAMRequiredBytes <= MaxRAMRequiredBytes, "required RAM must be at most %d bytes, got %d", uint64(MaxRAMRequiredBytes), p.RAMRequiredBytes)
+	check(p.Priority >= -MaxPriority && p.Priority <= MaxPriority, "priority must be between %d and %d, got %d", -MaxPriority, MaxPriority, p.Priority)
+	check(p.MaxNodes >= 0, "max nodes must not be negative, got %d", p.MaxNodes)
+	check(p.CostPer1KTokens >= 0 && !math.IsInf(p.CostPer1KTokens, 0) && !math.IsNaN(p.CostPer1KTokens), "cost per 1k tokens must be a non-negative number, got %v", p.CostPer1KTokens)
+	if _, err := p.ParamOverrides(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// clamp brings the values of a stored policy into the range Validate accepts, for
+// policies written before validation existed.
+func (p *ModelPolicy) clamp() {
+	p.TTLSecs = min(max(p.TTLSecs, 0), MaxTTLSecs)
+	p.RAMRequiredBytes = min(p.RAMRequiredBytes, MaxRAMRequiredBytes)
+	p.Priority = min(max(p.Priority, -MaxPriority), MaxPriority)
+	p.MaxNodes = max(p.MaxNodes, 0)
+	if !(p.CostPer1KTokens >= 0) || math.IsInf(p.CostPer1KTokens, 0) {
+		p.CostPer1KTokens = 0
+	}
 }
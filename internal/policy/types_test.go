@@ -0,0 +1,54 @@
+package policy
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := ModelPolicy{ModelID: "m", TTLSecs: 3600, Priority: 5, MaxNodes: 2, CostPer1KTokens: 0.5,
+		ParamDefaults: `{"temperature":0.2}`, ParamMax: `{"max_tokens":4096}`}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid policy: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		modify func(p *ModelPolicy)
+		want   string
+	}{
+		{"model id", func(p *ModelPolicy) { p.ModelID = "  " }, "model id is required"},
+		{"negative ttl", func(p *ModelPolicy) { p.TTLSecs = -1 }, "ttl must be between"},
+		{"ttl too long", func(p *ModelPolicy) { p.TTLSecs = MaxTTLSecs + 1 }, "ttl must be between"},
+		{"ram", func(p *ModelPolicy) { p.RAMRequiredBytes = MaxRAMRequiredBytes + 1 }, "required RAM must be at most"},
+		{"priority too low", func(p *ModelPolicy) { p.Priority = -MaxPriority - 1 }, "priority must be between"},
+		{"priority too high", func(p *ModelPolicy) { p.Priority = MaxPriority + 1 }, "priority must be between"},
+		{"max nodes", func(p *ModelPolicy) { p.MaxNodes = -1 }, "max nodes must not be negative"},
+		{"negative cost", func(p *ModelPolicy) { p.CostPer1KTokens = -0.01 }, "cost per 1k tokens"},
+		{"infinite cost", func(p *ModelPolicy) { p.CostPer1KTokens = math.Inf(1) }, "cost per 1k tokens"},
+		{"NaN cost", func(p *ModelPolicy) { p.CostPer1KTokens = math.NaN() }, "cost per 1k tokens"},
+		{"param defaults", func(p *ModelPolicy) { p.ParamDefaults = `[1]` }, "parameter defaults must be a JSON object"},
+		{"param defaults model", func(p *ModelPolicy) { p.ParamDefaults = `{"model":"x"}` }, `must not set "model"`},
+		{"param max", func(p *ModelPolicy) { p.ParamMax = `{"max_tokens":"many"}` }, "parameter limits must be a JSON object of numbers"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := valid
+			tc.modify(&p)
+			err := p.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("Validate = %v, want %q", err, tc.want)
+			}
+		})
+	}
+
+	// Every invalid value is reported, not only the first.
+	p := ModelPolicy{TTLSecs: -1, MaxNodes: -1}
+	err := p.Validate()
+	for _, want := range []string{"model id", "ttl", "max nodes"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate = %v, want it to mention %s", err, want)
+		}
+	}
+}
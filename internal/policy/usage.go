@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"context"
+	"time"
+)
+
+// usagePageSize is the number of usage rows EachUsage reads per query.
+const usagePageSize = 500
+
+// UsageRow is the usage of one API key and model on one day.
+type UsageRow struct {
+	Day      string // "2006-01-02", server local time
+	KeyID    string
+	KeyName  string // "" once the key is deleted
+	Owner    string
+	ModelID  string
+	Requests int64 // successful responses
+	Tokens   int64 // as reported in the responses' usage
+	Cost     float64
+}
+
+// DayOf returns the usage day of t ("2006-01-02", server local time).
+func DayOf(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// RecordUsage adds requests, tokens and cost to the usage of a key and model on day.
+func (s *Store) RecordUsage(ctx context.Context, day, keyID, modelID string, requests, tokens int64, cost float64) error {
+	if s.db == nil {
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO usage_daily(day, key_id, model_id, requests, tokens, cost)
+VALUES(?, ?, ?, ?, ?, ?)
+ON CONFLICT(day, key_id, model_id) DO UPDATE SET
+  requests=requests+excluded.requests,
+  tokens=tokens+excluded.tokens,
+  cost=cost+excluded.cost;
+`, day, keyID, modelID, requests, tokens, cost)
+	return s.observe(err)
+}
+
+// EachUsage calls fn for the usage of the days from to to ("2006-01-02", inclusive),
+// ordered by day, key and model. The rows are read in pages, so the database is not
+// held while fn is slow (e.g. writing to a client); an error of fn stops the iteration.
+func (s *Store) EachUsage(ctx context.Context, from, to string, fn func(UsageRow) error) error {
+	if s.db == nil {
+		return nil
+	}
+	var after UsageRow // last row of the previous page
+	for {
+		page, err := s.usagePage(ctx, from, to, after)
+		if err != nil {
+			return err
+		}
+		for _, u := range page {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+		if len(page) < usagePageSize {
+			return nil
+		}
+		after = page[len(page)-1]
+	}
+}
+
+func (s *Store) usagePage(ctx context.Context, from, to string, after UsageRow) ([]UsageRow, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT u.day, u.key_id, COALESCE(k.name, ''), COALESCE(k.owner, ''), u.model_id, u.requests, u.tokens, u.cost
+FROM usage_daily u LEFT JOIN api_keys k ON k.key_id = u.key_id
+WHERE u.day >= ? AND u.day <= ? AND (u.day, u.key_id, u.model_id) > (?, ?, ?)
+ORDER BY u.day, u.key_id, u.model_id
+LIMIT ?;
+`, from, to, after.Day, after.KeyID, after.ModelID, usagePageSize)
+	if s.observe(err) != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []UsageRow
+	for rows.Next() {
+		var u UsageRow
+		if err := rows.Scan(&u.Day, &u.KeyID, &u.KeyName, &u.Owner, &u.ModelID, &u.Requests, &u.Tokens, &u.Cost); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,170 @@
+// Package promptlog captures request/response pairs of opted-in API keys for building
+// evaluation datasets. Capturing is off unless a sink and the keys are configured.
+package promptlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// queueSize bounds the records waiting for the sink. Further records are dropped.
+const queueSize = 1024
+
+// Record is one captured request/response pair.
+type Record struct {
+	At         time.Time `json:"at"`
+	KeyID      string    `json:"key_id"`
+	Owner      string    `json:"owner,omitempty"`
+	Endpoint   string    `json:"endpoint"`
+	Model      string    `json:"model"`
+	NodeID     string    `json:"node_id"`
+	Status     int       `json:"status"`
+	Stream     bool      `json:"stream"`
+	DurationMs int64     `json:"duration_ms"`
+	Request    string    `json:"request"`
+	// Response is the response body; for streams the concatenated generated text.
+	Response  string `json:"response"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// Sink stores records. Write is called from a single goroutine.
+type Sink interface {
+	Write(ctx context.Context, rec Record) error
+	Close() error
+}
+
+// Redactor rewrites captured text before it reaches the sink (e.g. PII removal).
+type Redactor func(string) string
+
+var (
+	emailRe  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	numberRe = regexp.MustCompile(`\+?\d[\d \-]{6,}\d`)
+)
+
+// redactors are the built-in redactors by name.
+var redactors = map[string]Redactor{
+	// email replaces e-mail addresses.
+	"email": func(s string) string { return emailRe.ReplaceAllString(s, "[email]") },
+	// number replaces digit runs of 8+ characters (phone, card and account numbers).
+	"number": func(s string) string { return numberRe.ReplaceAllString(s, "[number]") },
+}
+
+// ParseRedactors resolves a comma separated list of built-in redactor names.
+func ParseRedactors(list string) ([]Redactor, error) {
+	var out []Redactor
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		r, ok := redactors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redactor %q", name)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// Logger queues records of opted-in keys and writes them to the sink in the background,
+// so capturing never adds latency to a request.
+type Logger struct {
+	Sink Sink
+	// Redactors are applied to request and response text in order.
+	Redactors []Redactor
+	// MaxBytes caps the captured request and response body each (0 = unlimited).
+	MaxBytes int
+
+	keys  map[string]bool // key ids, "*" = all keys
+	queue chan Record
+}
+
+// New creates a logger for the comma separated key ids ("*" = all keys).
+func New(sink Sink, keys string) *Logger {
+	l := &Logger{
+		Sink:     sink,
+		MaxBytes: 1 << 20,
+		keys:     map[string]bool{},
+		queue:    make(chan Record, queueSize),
+	}
+	for _, k := range strings.Split(keys, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			l.keys[k] = true
+		}
+	}
+	return l
+}
+
+// Enabled reports whether requests of the key are captured. A nil logger captures nothing.
+func (l *Logger) Enabled(keyID string) bool {
+	if l == nil || keyID == "" {
+		return false
+	}
+	return l.keys["*"] || l.keys[keyID]
+}
+
+// Submit enqueues a record. It never blocks.
+func (l *Logger) Submit(rec Record) {
+	select {
+	case l.queue <- rec:
+	default:
+		log.Printf("promptlog: queue full, dropping record key=%s model=%s", rec.KeyID, rec.Model)
+	}
+}
+
+// Run writes queued records until ctx is done, then closes the sink.
+func (l *Logger) Run(ctx context.Context) {
+	defer l.Sink.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case rec := <-l.queue:
+			for _, r := range l.Redactors {
+				rec.Request = r(rec.Request)
+				rec.Response = r(rec.Response)
+			}
+			if err := l.Sink.Write(ctx, rec); err != nil {
+				log.Printf("promptlog: write: %v", err)
+			}
+		}
+	}
+}
+
+// StreamText concatenates the generated text of an OpenAI-style SSE stream
+// (choices[].delta.content for chat, choices[].text for completions).
+func StreamText(sse []byte) string {
+	var b strings.Builder
+	for _, line := range bytes.Split(sse, []byte("\n")) {
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 || string(data) == "[DONE]" {
+			continue
+		}
+		var chunk struct {
+			Choices []struct {
+				Text  string `json:"text"`
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if json.Unmarshal(data, &chunk) != nil {
+			continue
+		}
+		for _, c := range chunk.Choices {
+			b.WriteString(c.Delta.Content)
+			b.WriteString(c.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package promptlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStreamText(t *testing.T) {
+	sse := "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+		": keep-alive\n\n" +
+		"data: {\"choices\":[{\"text\":\"lo\"}]}\n\n" +
+		"data: not json\n\n" +
+		"data: [DONE]\n\n"
+	if got := StreamText([]byte(sse)); got != "Hello" {
+		t.Errorf("StreamText = %q, want Hello", got)
+	}
+}
+
+func TestRedactors(t *testing.T) {
+	rs, err := ParseRedactors("email, number")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := "mail jane.doe@example.com or call +49 170 1234567, order 42"
+	for _, r := range rs {
+		s = r(s)
+	}
+	if want := "mail [email] or call [number], order 42"; s != want {
+		t.Errorf("redacted to %q, want %q", s, want)
+	}
+	if _, err := ParseRedactors("email,ssn"); err == nil {
+		t.Error("unknown redactor accepted")
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	var off *Logger
+	if off.Enabled("k1") {
+		t.Error("nil logger enabled")
+	}
+	l := New(nil, " k1 ,k2")
+	if !l.Enabled("k1") || !l.Enabled("k2") || l.Enabled("k3") || l.Enabled("") {
+		t.Error("key list not honored")
+	}
+	if all := New(nil, "*"); !all.Enabled("any") || all.Enabled("") {
+		t.Error("* does not enable every key")
+	}
+}
+
+func TestFileSinkRedacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.jsonl")
+	sink, err := OpenSink(SinkFile, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(sink, "k1")
+	l.Redactors, _ = ParseRedactors("email")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { l.Run(ctx); close(done) }()
+	l.Submit(Record{At: time.Now(), KeyID: "k1", Model: "m", Request: "from a@b.de", Response: "ok"})
+
+	var got Record
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if data, _ := os.ReadFile(path); bytes.HasSuffix(data, []byte("\n")) {
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("record not written")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got.KeyID != "k1" || got.Request != "from [email]" || got.Response != "ok" {
+		t.Errorf("written %+v", got)
+	}
+}
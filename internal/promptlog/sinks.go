@@ -0,0 +1,101 @@
+package promptlog
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	_ "modernc.org/sqlite"
+)
+
+// Sink kinds for OpenSink.
+const (
+	SinkStdout = "stdout"
+	SinkFile   = "file"
+	SinkDB     = "db"
+)
+
+// OpenSink opens a sink: "stdout", "file" (JSON lines appended to path) or "db"
+// (SQLite database at path, table prompt_logs).
+func OpenSink(kind, path string) (Sink, error) {
+	switch kind {
+	case SinkStdout:
+		return &jsonSink{w: os.Stdout}, nil
+	case SinkFile:
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSink{w: f, c: f}, nil
+	case SinkDB:
+		return openDBSink(path)
+	default:
+		return nil, fmt.Errorf("unknown prompt log sink %q", kind)
+	}
+}
+
+// jsonSink writes one JSON object per line.
+type jsonSink struct {
+	w io.Writer
+	c io.Closer
+}
+
+func (s *jsonSink) Write(_ context.Context, rec Record) error {
+	return json.NewEncoder(s.w).Encode(rec)
+}
+
+func (s *jsonSink) Close() error {
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
+
+// dbSink stores records in a SQLite table.
+type dbSink struct {
+	db *sql.DB
+}
+
+func openDBSink(path string) (*dbSink, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec(`
+CREATE TABLE IF NOT EXISTS prompt_logs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  at DATETIME NOT NULL,
+  key_id TEXT NOT NULL,
+  owner TEXT NOT NULL DEFAULT '',
+  endpoint TEXT NOT NULL,
+  model TEXT NOT NULL,
+  node_id TEXT NOT NULL,
+  status INTEGER NOT NULL,
+  stream INTEGER NOT NULL,
+  duration_ms INTEGER NOT NULL,
+  request TEXT NOT NULL,
+  response TEXT NOT NULL,
+  truncated INTEGER NOT NULL DEFAULT 0
+);
+`)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &dbSink{db: db}, nil
+}
+
+func (s *dbSink) Write(ctx context.Context, rec Record) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO prompt_logs(at, key_id, owner, endpoint, model, node_id, status, stream, duration_ms, request, response, truncated)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+`, rec.At, rec.KeyID, rec.Owner, rec.Endpoint, rec.Model, rec.NodeID, rec.Status, rec.Stream, rec.DurationMs, rec.Request, rec.Response, rec.Truncated)
+	return err
+}
+
+func (s *dbSink) Close() error {
+	return s.db.Close()
+}
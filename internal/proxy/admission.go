@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// modelHintHeader lets clients name the model of a request before its body is read.
+const modelHintHeader = "X-Model"
+
+// admitEarly checks the model a client names in the X-Model header or the "model"
+// query parameter before the body is read (with EarlyModelCheck): a model the API key
+// may not use gets 403, a model no online node reports gets 404, so large bodies of
+// such requests are never read. It returns the named model ("" = none) and false if
+// the request was answered. The body's model must match it (see checkModelHint).
+func (r *Router) admitEarly(w http.ResponseWriter, req *http.Request) (string, bool) {
+	if !r.EarlyModelCheck {
+		return "", true
+	}
+	hint := strings.TrimSpace(req.Header.Get(modelHintHeader))
+	if hint == "" {
+		hint = strings.TrimSpace(req.URL.Query().Get("model"))
+	}
+	if hint == "" {
+		return "", true
+	}
+	modelID := r.resolveModelID(hint)
+
+	if rec := auth.GetAuthRecord(req); rec != nil && !r.modelAllowed(rec.AllowedModels, modelID) {
+		http.Error(w, "access to model denied by ACL", http.StatusForbidden)
+		return "", false
+	}
+	if _, static := r.StaticUpstreams[modelID]; static {
+		return modelID, true
+	}
+	if known, anyOnline := r.modelReported(modelID); anyOnline && !known {
+		http.Error(w, fmt.Sprintf("model %s is not available on any node", modelID), http.StatusNotFound)
+		return "", false
+	}
+	return modelID, true
+}
+
+// checkModelHint rejects a request whose body names another model than admitEarly
+// admitted, so the early check cannot be bypassed. It reports whether to go on.
+func (r *Router) checkModelHint(w http.ResponseWriter, hint, modelID string) bool {
+	if hint == "" || hint == modelID {
+		return true
+	}
+	if r.NormalizeModelNames && state.NormalizeModelID(hint) == state.NormalizeModelID(modelID) {
+		return true
+	}
+	http.Error(w, fmt.Sprintf("model %q in the body does not match %s %q", modelID, modelHintHeader, hint), http.StatusBadRequest)
+	return false
+}
+
+// modelReported reports whether an online node reports modelID in any state, and
+// whether any node is online at all (without, existence cannot be judged).
+func (r *Router) modelReported(modelID string) (known, anyOnline bool) {
+	for _, n := range r.Cluster.SnapshotOnline(time.Now(), r.NodeOfflineTTL) {
+		anyOnline = true
+		if _, ok := n.Models[modelID]; ok {
+			return true, true
+		}
+	}
+	return false, anyOnline
+}
@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// countingBody counts the bytes read from a request body.
+type countingBody struct {
+	r    io.Reader
+	read int
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.read += n
+	return n, err
+}
+
+func (b *countingBody) Close() error { return nil }
+
+func TestAdmitEarly(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		header   string
+		query    string
+		body     string
+		key      *policy.APIKeyRecord
+		status   int
+		readBody bool
+	}{
+		{name: "header names a forbidden model", header: "m", key: &policy.APIKeyRecord{AllowedModels: "other"}, status: http.StatusForbidden},
+		{name: "query names a forbidden model", query: "m", key: &policy.APIKeyRecord{AllowedModels: "other"}, status: http.StatusForbidden},
+		{name: "header names an unknown model", header: "nope", status: http.StatusNotFound},
+		{name: "body names another model", header: "m", body: `{"model":"other"}`, status: http.StatusBadRequest, readBody: true},
+		{name: "header matches the body", header: "m", body: `{"model":"m"}`, status: http.StatusOK, readBody: true},
+		{name: "no hint", body: `{"model":"m"}`, key: &policy.APIKeyRecord{AllowedModels: "other"}, status: http.StatusForbidden, readBody: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.EarlyModelCheck = true
+			var completions atomic.Int32
+			srv := fakeLlama(t, "m", "loaded", &completions)
+			addNode(c, testNode{id: "a", url: srv.URL, models: map[string]state.ModelState{"m": state.ModelReady}})
+
+			body := tc.body
+			if body == "" {
+				body = `{"model":"m","messages":[]}`
+			}
+			cb := &countingBody{r: strings.NewReader(body)}
+			target := "/v1/chat/completions"
+			if tc.query != "" {
+				target += "?model=" + tc.query
+			}
+			req := httptest.NewRequest(http.MethodPost, target, nil)
+			req.Body = cb
+			if tc.header != "" {
+				req.Header.Set(modelHintHeader, tc.header)
+			}
+			if tc.key != nil {
+				req = auth.WithAuthRecord(req, tc.key)
+			}
+			rec := httptest.NewRecorder()
+			r.HandleChatCompletions(rec, req)
+
+			if rec.Code != tc.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+			if got := cb.read > 0; got != tc.readBody {
+				t.Errorf("body read = %v, want %v", got, tc.readBody)
+			}
+		})
+	}
+}
+
+func TestAdmitEarlyOff(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelReady}})
+
+	cb := &countingBody{r: strings.NewReader(`{"model":"nope"}`)}
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Body = cb
+	req.Header.Set(modelHintHeader, "nope")
+	req = auth.WithAuthRecord(req, &policy.APIKeyRecord{AllowedModels: "m"})
+	r.HandleChatCompletions(httptest.NewRecorder(), req)
+	if cb.read == 0 {
+		t.Error("body not read, want the hint ignored without EarlyModelCheck")
+	}
+}
+
+func TestBodyCapBeforeParsing(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.MaxBodyBytes = 1 << 10
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelReady}})
+
+	cb := &countingBody{r: strings.NewReader(`{"model":"m","prompt":"` + strings.Repeat("x", 1<<20) + `"}`)}
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Body = cb
+	rec := httptest.NewRecorder()
+	r.HandleChatCompletions(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", rec.Code)
+	}
+	if cb.read > 64<<10 {
+		t.Errorf("read %d bytes of a 1 MiB body with a 1 KiB cap", cb.read)
+	}
+}
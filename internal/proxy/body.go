@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// guardBody limits the size of req.Body to MaxBodyBytes and the time to read it to
+// BodyReadTimeout, so clients dribbling a body cannot hold a goroutine forever.
+// The returned func clears the read deadline again; call it once the body is read.
+func (r *Router) guardBody(w http.ResponseWriter, req *http.Request) (done func()) {
+	if r.MaxBodyBytes > 0 {
+		req.Body = http.MaxBytesReader(w, req.Body, r.MaxBodyBytes)
+	}
+	if r.BodyReadTimeout <= 0 {
+		return func() {}
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Now().Add(r.BodyReadTimeout)); err != nil {
+		return func() {}
+	}
+	body := &trackedBody{ReadCloser: req.Body}
+	req.Body = body
+	return func() {
+		// After a failed read the connection is closed; keeping the deadline lets the
+		// server give up on the rest of the body instead of waiting for it.
+		if body.err == nil || body.err == io.EOF {
+			_ = rc.SetReadDeadline(time.Time{})
+		}
+	}
+}
+
+// trackedBody remembers the last read error of a request body.
+type trackedBody struct {
+	io.ReadCloser
+	err error
+}
+
+func (b *trackedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.err = err
+	}
+	return n, err
+}
+
+// writeBodyError answers a request whose body could not be read or parsed:
+// 408 for too slow bodies, 413 for too large ones, 400 otherwise.
+func writeBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		w.Header().Set("Connection", "close")
+		http.Error(w, "request body read timeout", http.StatusRequestTimeout)
+	case errors.As(err, &tooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
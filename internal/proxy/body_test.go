@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestSlowBodyTimesOut(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.BodyReadTimeout = 200 * time.Millisecond
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelReady}})
+	srv := httptest.NewServer(http.HandlerFunc(r.HandleChatCompletions))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "POST /v1/chat/completions HTTP/1.1\r\nHost: x\r\nContent-Type: application/json\r\nContent-Length: 1000\r\n\r\n{\"model\":")
+
+	// Dribble one byte every 50ms; the body would take 50s.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(50 * time.Millisecond):
+				if _, err := conn.Write([]byte(" ")); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	start := time.Now()
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("no response to a slow body: %v", err)
+	}
+	if res.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("status = %d, want 408", res.StatusCode)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("answered after %s, want about the 200ms read timeout", d)
+	}
+}
+
+func TestBodyDeadlineClearedAfterRead(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.BodyReadTimeout = 100 * time.Millisecond
+	var completions atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		completions.Add(1)
+		time.Sleep(300 * time.Millisecond) // answering takes longer than the body timeout
+		w.Write([]byte(`{"choices":[]}`))
+	}))
+	defer slow.Close()
+	addNode(c, testNode{id: "a", url: slow.URL, models: map[string]state.ModelState{"m": state.ModelReady}})
+	srv := httptest.NewServer(http.HandlerFunc(r.HandleChatCompletions))
+	defer srv.Close()
+
+	// Two requests on one keep-alive connection: the deadline must not hit the second.
+	client := srv.Client()
+	for i := range 2 {
+		res, err := client.Post(srv.URL+"/v1/chat/completions", "application/json", strings.NewReader(`{"model":"m"}`))
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i, res.StatusCode)
+		}
+	}
+	if n := completions.Load(); n != 2 {
+		t.Errorf("%d upstream requests, want 2", n)
+	}
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+// SpendAccounter keeps the usage and spend of API keys (see auth.Authenticator).
+type SpendAccounter interface {
+	RecordUsage(keyID, modelID string, at time.Time, tokens int64, cost float64)
+	MonthSpend(rec *policy.APIKeyRecord, now time.Time) float64
+}
+
+// usageTailBytes is how much of a response body is kept to find its "usage" object,
+// which OpenAI-style responses and the final stream chunk carry at the end.
+const usageTailBytes = 16 << 10
+
+// EnforceBudget rejects inference requests (POST) of API keys that spent their monthly
+// budget with 402. With BudgetWarnOnly they pass and the response carries
+// X-Budget-Exceeded instead. It must run inside the auth middleware.
+func (r *Router) EnforceBudget(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rec := auth.GetAuthRecord(req)
+		if r.Spend == nil || rec == nil || rec.MonthlyBudget <= 0 || req.Method != http.MethodPost {
+			next.ServeHTTP(w, req)
+			return
+		}
+		spent := r.Spend.MonthSpend(rec, time.Now())
+		if spent < rec.MonthlyBudget {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if r.BudgetWarnOnly {
+			w.Header().Set("X-Budget-Exceeded", fmt.Sprintf("%.4f/%.4f", spent, rec.MonthlyBudget))
+			next.ServeHTTP(w, req)
+			return
+		}
+		http.Error(w, fmt.Sprintf("monthly budget of API key %s exceeded (spent %.4f of %.4f)", rec.Name, spent, rec.MonthlyBudget), http.StatusPaymentRequired)
+	})
+}
+
+// requestStreamUsage makes a streaming chat or completion request of an API key with a
+// monthly budget ask for usage in its final chunk (stream_options.include_usage), so
+// accountResponse can charge the stream. The client receives that extra chunk (empty
+// "choices") as with the option set itself. Other bodies are returned unchanged.
+func (r *Router) requestStreamUsage(req *http.Request, body []byte) []byte {
+	rec := auth.GetAuthRecord(req)
+	if r.Spend == nil || rec == nil || rec.MonthlyBudget <= 0 {
+		return body
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return body
+	}
+	var stream bool
+	if json.Unmarshal(fields["stream"], &stream) != nil || !stream {
+		return body
+	}
+	opts := map[string]json.RawMessage{}
+	if raw, ok := fields["stream_options"]; ok && json.Unmarshal(raw, &opts) != nil {
+		return body // not an object: leave it to the backend to reject
+	}
+	var include bool
+	if json.Unmarshal(opts["include_usage"], &include) == nil && include {
+		return body
+	}
+	opts["include_usage"] = json.RawMessage("true")
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		return body
+	}
+	fields["stream_options"] = raw
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// accountResponse records a successful response for the API key and model and charges
+// its tokens at the model's CostPer1KTokens. The body is passed through unchanged; the
+// "usage" object is read from its tail once the body is done. Streams of budgeted keys
+// ask for it (see requestStreamUsage); responses still without usage count with 0
+// tokens and are not charged.
+func (r *Router) accountResponse(resp *http.Response) {
+	if r.Spend == nil || resp.Request == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return
+	}
+	rec := auth.GetAuthRecord(resp.Request)
+	info, ok := resp.Request.Context().Value(ctxKeyRoute{}).(routeInfo)
+	if rec == nil || !ok {
+		return
+	}
+	pol, _, _ := r.getPolicy(resp.Request.Context(), info.ModelID)
+	resp.Body = &usageBody{ReadCloser: resp.Body, done: func(tokens int64) {
+		r.Spend.RecordUsage(rec.ID, info.ModelID, time.Now(), tokens, float64(tokens)*pol.CostPer1KTokens/1000)
+	}}
+}
+
+// usageBody keeps the tail of a response body and reports its token usage once (0 =
+// none found).
+type usageBody struct {
+	io.ReadCloser
+	done func(tokens int64)
+	tail []byte
+	once sync.Once
+}
+
+func (b *usageBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.tail = append(b.tail, p[:n]...)
+		if over := len(b.tail) - usageTailBytes; over > 0 {
+			b.tail = append(b.tail[:0], b.tail[over:]...)
+		}
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *usageBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *usageBody) finish() {
+	b.once.Do(func() {
+		b.done(usageTokens(b.tail))
+	})
+}
+
+// usageTokens returns the total tokens of the last "usage" object in data (0 = none).
+func usageTokens(data []byte) int64 {
+	i := bytes.LastIndex(data, []byte(`"usage"`))
+	if i < 0 {
+		return 0
+	}
+	rest := bytes.TrimLeft(data[i+len(`"usage"`):], " \t\r\n")
+	rest, ok := bytes.CutPrefix(rest, []byte(":"))
+	if !ok {
+		return 0
+	}
+	var u struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+		TotalTokens      int64 `json:"total_tokens"`
+	}
+	if json.NewDecoder(bytes.NewReader(rest)).Decode(&u) != nil {
+		return 0
+	}
+	if u.TotalTokens > 0 {
+		return u.TotalTokens
+	}
+	return u.PromptTokens + u.CompletionTokens
+}
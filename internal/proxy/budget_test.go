@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+// fakeSpend is a SpendAccounter with a fixed month spend that records charges.
+type fakeSpend struct {
+	mu     sync.Mutex
+	spent  float64
+	tokens int64
+	cost   float64
+	calls  int
+}
+
+func (f *fakeSpend) RecordUsage(keyID, modelID string, at time.Time, tokens int64, cost float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	f.tokens += tokens
+	f.cost += cost
+}
+
+func (f *fakeSpend) MonthSpend(rec *policy.APIKeyRecord, now time.Time) float64 {
+	return f.spent
+}
+
+func TestEnforceBudget(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		budget   float64
+		spent    float64
+		method   string
+		warnOnly bool
+		want     int
+		header   bool
+	}{
+		{"unlimited", 0, 100, http.MethodPost, false, http.StatusOK, false},
+		{"under budget", 10, 9.99, http.MethodPost, false, http.StatusOK, false},
+		{"budget reached", 10, 10, http.MethodPost, false, http.StatusPaymentRequired, false},
+		{"over budget", 10, 12.5, http.MethodPost, false, http.StatusPaymentRequired, false},
+		{"over budget, GET", 10, 12.5, http.MethodGet, false, http.StatusOK, false},
+		{"over budget, warn only", 10, 12.5, http.MethodPost, true, http.StatusOK, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, _ := newTestRouter(t)
+			r.Spend = &fakeSpend{spent: tc.spent}
+			r.BudgetWarnOnly = tc.warnOnly
+			h := r.EnforceBudget(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+			req := httptest.NewRequest(tc.method, "/v1/chat/completions", nil)
+			req = auth.WithAuthRecord(req, &policy.APIKeyRecord{ID: "k1", Name: "team", MonthlyBudget: tc.budget})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+			if w.Code == http.StatusPaymentRequired && !strings.Contains(w.Body.String(), "monthly budget of API key team exceeded") {
+				t.Errorf("body = %q, want the budget message", w.Body.String())
+			}
+			if got := w.Header().Get("X-Budget-Exceeded") != ""; got != tc.header {
+				t.Errorf("X-Budget-Exceeded set = %v, want %v", got, tc.header)
+			}
+		})
+	}
+}
+
+func TestRequestStreamUsage(t *testing.T) {
+	budgeted := &policy.APIKeyRecord{ID: "k1", MonthlyBudget: 5}
+	for _, tc := range []struct {
+		name string
+		rec  *policy.APIKeyRecord
+		body string
+		want bool // include_usage set
+	}{
+		{"stream", budgeted, `{"model":"m","stream":true}`, true},
+		{"stream with options", budgeted, `{"model":"m","stream":true,"stream_options":{"include_usage":false}}`, true},
+		{"no stream", budgeted, `{"model":"m"}`, false},
+		{"no budget", &policy.APIKeyRecord{ID: "k2"}, `{"model":"m","stream":true}`, false},
+		{"anonymous", nil, `{"model":"m","stream":true}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, _ := newTestRouter(t)
+			r.Spend = &fakeSpend{}
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			if tc.rec != nil {
+				req = auth.WithAuthRecord(req, tc.rec)
+			}
+			out := r.requestStreamUsage(req, []byte(tc.body))
+
+			var got struct {
+				StreamOptions struct {
+					IncludeUsage bool `json:"include_usage"`
+				} `json:"stream_options"`
+			}
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatalf("body %s: %v", out, err)
+			}
+			if got.StreamOptions.IncludeUsage != tc.want {
+				t.Errorf("include_usage = %v, want %v (body %s)", got.StreamOptions.IncludeUsage, tc.want, out)
+			}
+		})
+	}
+}
+
+func TestAccountResponseChargesStreamUsage(t *testing.T) {
+	r, _, store := newTestRouter(t)
+	if err := store.UpsertPolicy(context.Background(), policy.ModelPolicy{ModelID: "m", CostPer1KTokens: 2}); err != nil {
+		t.Fatal(err)
+	}
+	spend := &fakeSpend{}
+	r.Spend = spend
+
+	stream := "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":300,\"completion_tokens\":200,\"total_tokens\":500}}\n\n" +
+		"data: [DONE]\n\n"
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req = auth.WithAuthRecord(req, &policy.APIKeyRecord{ID: "k1", MonthlyBudget: 5})
+	req = withRouteInfo(req, "m", PlacementResult{})
+	resp := &http.Response{StatusCode: http.StatusOK, Request: req, Body: io.NopCloser(strings.NewReader(stream))}
+
+	r.accountResponse(resp)
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if spend.calls != 1 || spend.tokens != 500 {
+		t.Fatalf("recorded %d call(s) with %d tokens, want 1 with 500", spend.calls, spend.tokens)
+	}
+	if math.Abs(spend.cost-1) > 1e-9 {
+		t.Errorf("cost = %v, want 1 (500 tokens at 2 per 1k)", spend.cost)
+	}
+}
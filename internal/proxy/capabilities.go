@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+type modelCapabilitiesResponse struct {
+	ID     string `json:"id"`
+	Object string `json:"object"`
+	// Capabilities is the intersection across all nodes serving the model, so every
+	// listed capability holds no matter which node a request is routed to.
+	Capabilities []string `json:"capabilities"`
+	// AnyNode is the union: capabilities offered by at least one node.
+	AnyNode []string `json:"any_node"`
+	// MaxContext is the smallest known max context across nodes (0 = unknown).
+	MaxContext uint32 `json:"max_context"`
+	Nodes      int    `json:"nodes"`
+}
+
+// HandleModelCapabilities serves GET /v1/models/{id}/capabilities.
+// Model ids may contain slashes, so the path is parsed manually.
+func (h *ModelsHandler) HandleModelCapabilities(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/v1/models/")
+	modelID, ok := strings.CutSuffix(rest, "/capabilities")
+	if !ok || modelID == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	authRecord := auth.GetAuthRecord(r)
+	allowed := auth.CheckACL
+	if h.NormalizeModelNames {
+		allowed = auth.CheckACLFold
+	}
+	if authRecord != nil && !allowed(authRecord.AllowedModels, modelID) {
+		http.Error(w, "access to model denied by ACL", http.StatusForbidden)
+		return
+	}
+
+	var (
+		nodes      int
+		inter      map[string]bool
+		union      = map[string]bool{}
+		maxContext uint32
+	)
+	for _, n := range h.Cluster.SnapshotOnline(time.Now(), h.NodeOfflineTTL) {
+		if authRecord != nil && !auth.CheckACL(authRecord.AllowedNodes, n.NodeID) {
+			continue
+		}
+		m, ok := h.lookupModel(n, modelID)
+		if !ok || m.State == state.ModelError {
+			continue
+		}
+		nodes++
+
+		caps := map[string]bool{}
+		for _, c := range m.Capabilities {
+			caps[c] = true
+			union[c] = true
+		}
+		if inter == nil {
+			inter = caps
+		} else {
+			for c := range inter {
+				if !caps[c] {
+					delete(inter, c)
+				}
+			}
+		}
+
+		if m.MaxContext > 0 && (maxContext == 0 || m.MaxContext < maxContext) {
+			maxContext = m.MaxContext
+		}
+	}
+
+	if nodes == 0 {
+		http.Error(w, "model not found", http.StatusNotFound)
+		return
+	}
+
+	out := modelCapabilitiesResponse{
+		ID:           modelID,
+		Object:       "model.capabilities",
+		Capabilities: sortedKeys(inter),
+		AnyNode:      sortedKeys(union),
+		MaxContext:   maxContext,
+		Nodes:        nodes,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// lookupModel finds the model on a node, ignoring case/whitespace if normalization is on.
+func (h *ModelsHandler) lookupModel(n *state.NodeSnapshot, modelID string) (state.ModelResidency, bool) {
+	if m, ok := n.Models[modelID]; ok || !h.NormalizeModelNames {
+		return m, ok
+	}
+	want := state.NormalizeModelID(modelID)
+	for id, m := range n.Models {
+		if state.NormalizeModelID(id) == want {
+			return m, true
+		}
+	}
+	return state.ModelResidency{}, false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// addUnknownRAM registers an online node reporting RAM total 0, as an agent does that
+// could not read its memory.
+func addUnknownRAM(c *state.ClusterState, id string) {
+	c.UpsertNodeHello(id, "test", "", "http://"+id, "", state.DataPlaneTLS{}, nil)
+	c.UpdateNodeStatus(id, 0, 0, "", 0, 0, 0, nil)
+}
+
+func TestUnknownRAMScoredWithMean(t *testing.T) {
+	const gib = 1 << 30
+	node := func(id string, total, avail uint64) *state.NodeSnapshot {
+		return &state.NodeSnapshot{NodeID: id, RAMTotalBytes: total, RAMAvailBytes: avail}
+	}
+	lo, hi, unknown := node("lo", 64*gib, 10*gib), node("hi", 64*gib, 40*gib), node("unknown", 0, 0)
+	p := policy.ModelPolicy{ModelID: "m"}
+
+	// The mean of the known nodes (25 GiB) ranks the unknown node between them.
+	if best := pickBestByScore([]*state.NodeSnapshot{unknown, lo, hi}, nil, p, scoreOpts{}); best != hi {
+		t.Errorf("picked %s, want the node with the most RAM", best.NodeID)
+	}
+	o := scoreOpts{NeutralRAM: neutralRAM([]*state.NodeSnapshot{unknown, lo, hi})}
+	if o.NeutralRAM != 25*gib {
+		t.Errorf("neutral RAM = %d GiB, want 25", o.NeutralRAM/gib)
+	}
+	if scoreNode(unknown, nil, p, o) <= scoreNode(lo, nil, p, o) {
+		t.Error("node without RAM data ranks behind a node below the mean")
+	}
+
+	// The OOM penalty needs a known size: the unknown node wins over nodes too small.
+	p.RAMRequiredBytes = 48 * gib
+	if best := pickBestByScore([]*state.NodeSnapshot{lo, hi, unknown}, nil, p, scoreOpts{}); best != unknown {
+		t.Errorf("picked %s, want the node that cannot be ruled out", best.NodeID)
+	}
+	if !fitsRAM(unknown, p) {
+		t.Error("node with unknown RAM does not fit, want no OOM refusal")
+	}
+}
+
+func TestExcludeUnknownRAM(t *testing.T) {
+	for _, exclude := range []bool{false, true} {
+		r, c, _ := newTestRouter(t)
+		r.ExcludeUnknownRAM = exclude
+		addUnknownRAM(c, "a")
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		res, err := r.pickNodeForModel(req, "m")
+		if exclude {
+			if err == nil || res.Reason != ReasonNoNodes {
+				t.Errorf("excluded: placed on %s (reason %s), want %s", res.NodeID, res.Reason, ReasonNoNodes)
+			}
+			continue
+		}
+		if err != nil || res.NodeID != "a" || res.Mode != pickCold {
+			t.Errorf("placed on %q (%s, err %v), want a cold load on a", res.NodeID, res.Mode, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/promptlog"
+)
+
+type ctxKeyCapture struct{}
+
+// withPromptCapture marks a request for prompt logging if PromptLog is enabled for its
+// API key. The body is copied; what is sent upstream is not touched.
+func (r *Router) withPromptCapture(req *http.Request, body []byte) *http.Request {
+	rec := auth.GetAuthRecord(req)
+	if rec == nil || !r.PromptLog.Enabled(rec.ID) {
+		return req
+	}
+	c := &promptlog.Record{
+		At:       time.Now(),
+		KeyID:    rec.ID,
+		Owner:    rec.Owner,
+		Endpoint: req.URL.Path,
+	}
+	if limit := r.PromptLog.MaxBytes; limit > 0 && len(body) > limit {
+		body, c.Truncated = body[:limit], true
+	}
+	c.Request = string(body)
+	return req.WithContext(context.WithValue(req.Context(), ctxKeyCapture{}, c))
+}
+
+// captureResponse tees the response body of a marked request. The record is submitted
+// asynchronously once the client has read the body or the proxy closed it; the bytes
+// the client receives are unchanged.
+func (r *Router) captureResponse(resp *http.Response, nodeID string) {
+	if resp.Request == nil {
+		return
+	}
+	c, _ := resp.Request.Context().Value(ctxKeyCapture{}).(*promptlog.Record)
+	if c == nil {
+		return
+	}
+	rec := *c
+	rec.NodeID = nodeID
+	rec.Status = resp.StatusCode
+	rec.Stream = strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+	if info, ok := resp.Request.Context().Value(ctxKeyRoute{}).(routeInfo); ok {
+		rec.Model = info.ModelID
+	}
+	resp.Body = &captureBody{ReadCloser: resp.Body, log: r.PromptLog, rec: rec}
+}
+
+// captureBody copies what is read from the upstream body (up to MaxBytes).
+type captureBody struct {
+	io.ReadCloser
+	log  *promptlog.Logger
+	rec  promptlog.Record
+	buf  bytes.Buffer
+	once sync.Once
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		chunk := p[:n]
+		if limit := b.log.MaxBytes; limit > 0 && b.buf.Len()+n > limit {
+			chunk = chunk[:limit-b.buf.Len()]
+			b.rec.Truncated = true
+		}
+		b.buf.Write(chunk)
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *captureBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *captureBody) finish() {
+	b.once.Do(func() {
+		b.rec.DurationMs = time.Since(b.rec.At).Milliseconds()
+		if b.rec.Stream {
+			b.rec.Response = promptlog.StreamText(b.buf.Bytes())
+		} else {
+			b.rec.Response = b.buf.String()
+		}
+		b.log.Submit(b.rec)
+	})
+}
@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/promptlog"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// memSink hands written records to a channel.
+type memSink chan promptlog.Record
+
+func (s memSink) Write(_ context.Context, rec promptlog.Record) error { s <- rec; return nil }
+func (s memSink) Close() error                                        { return nil }
+
+func TestPromptCaptureKeepsResponse(t *testing.T) {
+	const (
+		jsonBody = `{"choices":[{"message":{"content":"hello"}}]}`
+		sseBody  = "data: {\"choices\":[{\"delta\":{\"content\":\"hel\"}}]}\n\ndata: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\ndata: [DONE]\n\n"
+	)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.URL.RawQuery, "stream") {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte(sseBody))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(jsonBody))
+	}))
+	defer upstream.Close()
+
+	for _, tc := range []struct {
+		name, query, body, text string
+	}{
+		{"json", "", jsonBody, jsonBody},
+		{"stream", "?stream", sseBody, "hello"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			serve := func(capture bool) (*httptest.ResponseRecorder, memSink) {
+				r, c, _ := newTestRouter(t)
+				addNode(c, testNode{id: "a", url: upstream.URL, models: map[string]state.ModelState{"m": state.ModelReady}})
+				sink := make(memSink, 1)
+				if capture {
+					r.PromptLog = promptlog.New(sink, "k1")
+					ctx, cancel := context.WithCancel(context.Background())
+					t.Cleanup(cancel)
+					go r.PromptLog.Run(ctx)
+				}
+				req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions"+tc.query, strings.NewReader(`{"model":"m","messages":[{"role":"user","content":"hi"}]}`))
+				req = auth.WithAuthRecord(req, &policy.APIKeyRecord{ID: "k1", Owner: "alice"})
+				rec := httptest.NewRecorder()
+				r.HandleChatCompletions(rec, req)
+				return rec, sink
+			}
+
+			plain, _ := serve(false)
+			captured, sink := serve(true)
+			if captured.Code != plain.Code || captured.Body.String() != plain.Body.String() || captured.Body.String() != tc.body {
+				t.Errorf("captured response %d %q, want %d %q as without capture", captured.Code, captured.Body, plain.Code, plain.Body)
+			}
+			if got, want := captured.Header().Get("Content-Type"), plain.Header().Get("Content-Type"); got != want {
+				t.Errorf("Content-Type = %q, want %q", got, want)
+			}
+
+			select {
+			case rec := <-sink:
+				if rec.KeyID != "k1" || rec.Model != "m" || rec.NodeID != "a" || rec.Response != tc.text || !strings.Contains(rec.Request, `"hi"`) {
+					t.Errorf("record = %+v", rec)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("no record written")
+			}
+		})
+	}
+}
+
+func TestPromptCaptureOnlyOptedInKeys(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	r.PromptLog = promptlog.New(make(memSink), "k1")
+	for _, tc := range []struct {
+		key  *policy.APIKeyRecord
+		want bool
+	}{
+		{&policy.APIKeyRecord{ID: "k1"}, true},
+		{&policy.APIKeyRecord{ID: "k2"}, false},
+		{nil, false},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		if tc.key != nil {
+			req = auth.WithAuthRecord(req, tc.key)
+		}
+		req = r.withPromptCapture(req, []byte(`{}`))
+		if got := req.Context().Value(ctxKeyCapture{}) != nil; got != tc.want {
+			t.Errorf("key %+v captured = %v, want %v", tc.key, got, tc.want)
+		}
+	}
+}
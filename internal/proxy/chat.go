@@ -5,36 +5,53 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"time"
 )
 
 // HandleChatCompletions proxies POST /v1/chat/completions to the selected node.
 // It supports both non-stream and stream responses by passing through the response body as-is.
 func (r *Router) HandleChatCompletions(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
-		http.NotFound(w, req)
+		methodNotAllowed(w, http.MethodPost)
 		return
 	}
 
-	modelID, body, err := extractModelAndBody(req)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	hint, ok := r.admitEarly(w, req)
+	if !ok {
 		return
 	}
 
-	node, mode, err := r.pickNodeForModel(req, modelID)
+	done := r.guardBody(w, req)
+	modelID, body, err := r.extractModelAndBody(req)
+	done()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		writeBodyError(w, err)
+		return
+	}
+	modelID, body = r.canonicalizeModel(modelID, body)
+	if !r.checkModelHint(w, hint, modelID) {
 		return
 	}
+	body = r.applyParamOverrides(modelID, body)
+	body = r.applyStreamOverride(req, body)
+	body = r.requestStreamUsage(req, body)
+	req = r.withCacheKey(req, body)
+	req = r.withPromptCapture(req, body)
 
+	res, err := r.pickNodeForModel(req, modelID)
+	if err != nil {
+		r.writePlacementError(w, res, err)
+		return
+	}
 	// Wait path: block until READY or timeout.
-	if mode == pickWait {
-		if err := r.waitModelReady(modelID, node.NodeID, 180*time.Second); err != nil {
-			http.Error(w, "model is still loading (timeout)", http.StatusServiceUnavailable)
+	if res.Mode == pickWait {
+		if res, err = r.awaitLoad(req, modelID, res); err != nil {
+			r.writeWaitError(w, modelID, err)
 			return
 		}
 	}
+	node := res.node()
+
+	r.warnStructuredOutput(modelID, node.NodeID, body)
 
 	target, err := url.Parse(node.DataPlaneURL)
 	if err != nil {
@@ -46,5 +63,7 @@ func (r *Router) HandleChatCompletions(w http.ResponseWriter, req *http.Request)
 	req.Body = io.NopCloser(bytes.NewReader(body))
 	req.ContentLength = int64(len(body))
 
+	req = withRouteInfo(req, modelID, res)
+	defer r.trackInflight(node.NodeID, body)()
 	r.reverseProxy(node.NodeID, target).ServeHTTP(w, req)
 }
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestConcurrentColdRequestsCoalesce(t *testing.T) {
+	const gib = 1 << 30
+	const requests = 50
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "a", avail: 8 * gib})
+	addNode(c, testNode{id: "b", avail: 40 * gib})
+
+	type result struct {
+		first PlacementResult
+		final PlacementResult
+		err   error
+	}
+	results := make(chan result, requests)
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			res, err := r.pickNodeForModel(req, "m")
+			out := result{first: res, final: res, err: err}
+			if err == nil && res.Mode == pickWait {
+				out.final, out.err = r.awaitLoad(req, "m", res)
+			}
+			results <- out
+		}()
+	}
+
+	// Everyone but the loader waits; then the loader reports READY.
+	waitFor(t, func() bool { return gateWaiting(r, "m") == requests-1 })
+	setModels(c, testNode{id: "b", avail: 40 * gib, models: map[string]state.ModelState{"m": state.ModelReady}})
+	r.NotifyModelReady("b", "m")
+	wg.Wait()
+	close(results)
+
+	cold := 0
+	for res := range results {
+		if res.err != nil {
+			t.Fatalf("request failed: %v", res.err)
+		}
+		if res.first.Mode == pickCold {
+			cold++
+		}
+		if res.first.NodeID != "b" || res.final.NodeID != "b" {
+			t.Errorf("placed on %s, routed to %s, want both on the loader b", res.first.NodeID, res.final.NodeID)
+		}
+	}
+	if cold != 1 {
+		t.Errorf("%d cold loads, want 1", cold)
+	}
+}
@@ -0,0 +1,46 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ParseUpstreamCredentials parses comma separated "node=token" entries; the node "*"
+// sets the token of all nodes without an own entry. Tokens are sent to the node as
+// "Authorization: Bearer <token>" (e.g. llama-server --api-key).
+func ParseUpstreamCredentials(s string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		nodeID, token, ok := strings.Cut(entry, "=")
+		nodeID, token = strings.TrimSpace(nodeID), strings.TrimSpace(token)
+		if !ok || nodeID == "" || token == "" {
+			// Never echo the entry: it contains the token.
+			return nil, fmt.Errorf("entry %d: expected node=token", len(out)+1)
+		}
+		if _, dup := out[nodeID]; dup {
+			return nil, fmt.Errorf("node %s listed twice", nodeID)
+		}
+		out[nodeID] = token
+	}
+	return out, nil
+}
+
+// setUpstreamAuth replaces the client's Authorization header, which holds the router
+// API key and is of no use to the node, with the node's credential (if configured).
+// Static upstreams only get their own entry: the nodes' "*" token must not leave the
+// cluster.
+func (r *Router) setUpstreamAuth(req *http.Request, nodeID string) {
+	req.Header.Del("Authorization")
+	token, ok := r.UpstreamCredentials[nodeID]
+	if !ok && !isStaticNode(nodeID) {
+		token, ok = r.UpstreamCredentials["*"]
+	}
+	if ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// authEcho returns an upstream recording the Authorization header of each request.
+func authEcho(t *testing.T, got *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		*got = append(*got, req.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUpstreamAuthorization(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		creds map[string]string
+		want  string
+	}{
+		{"stripped", nil, ""},
+		{"node credential", map[string]string{"a": "node-a", "*": "all"}, "Bearer node-a"},
+		{"wildcard credential", map[string]string{"b": "node-b", "*": "all"}, "Bearer all"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.UpstreamCredentials = tc.creds
+			var got []string
+			srv := authEcho(t, &got)
+			addNode(c, testNode{id: "a", url: srv.URL, models: map[string]state.ModelState{"m": state.ModelReady}})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"m"}`))
+			req.Header.Set("Authorization", "Bearer router-key")
+			rec := httptest.NewRecorder()
+			r.HandleChatCompletions(rec, req)
+
+			if rec.Code != http.StatusOK || len(got) != 1 {
+				t.Fatalf("status %d, %d upstream requests: %s", rec.Code, len(got), rec.Body)
+			}
+			if got[0] != tc.want {
+				t.Errorf("upstream Authorization = %q, want %q", got[0], tc.want)
+			}
+		})
+	}
+}
+
+func TestStaticUpstreamSkipsWildcardCredential(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	var got []string
+	srv := authEcho(t, &got)
+	r.StaticUpstreams = map[string]string{"hosted": srv.URL, "own": srv.URL}
+	r.UpstreamCredentials = map[string]string{"*": "cluster", "static:own": "own-token"}
+
+	for _, model := range []string{"hosted", "own"} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"`+model+`"}`))
+		req.Header.Set("Authorization", "Bearer router-key")
+		r.HandleChatCompletions(httptest.NewRecorder(), req)
+	}
+	if len(got) != 2 || got[0] != "" || got[1] != "Bearer own-token" {
+		t.Errorf("upstream Authorization = %q, want no header for hosted and own-token for own", got)
+	}
+}
+
+func TestParseUpstreamCredentials(t *testing.T) {
+	got, err := ParseUpstreamCredentials(" a = t1 ,*=t2,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"a": "t1", "*": "t2"}; !maps.Equal(got, want) {
+		t.Errorf("parsed %v, want %v", got, want)
+	}
+
+	for _, in := range []string{"a", "a=", "=secret", "a=x,a=y"} {
+		_, err := ParseUpstreamCredentials(in)
+		if err == nil {
+			t.Errorf("%q: no error", in)
+			continue
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("%q: error %q echoes the token", in, err)
+		}
+	}
+}
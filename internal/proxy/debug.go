@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/metrics"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+type ctxKeyDecision struct{}
+
+// placementDecision is a lightweight record of why a node was picked.
+// It is only collected for requests with X-Debug-Route (admin keys).
+type placementDecision struct {
+	Node       string
+	Mode       string
+	Candidates int // online, ACL-permitted nodes
+	Ready      int // candidates reporting the model READY
+	// Unroutable are candidates reporting the model READY without a data plane URL.
+	Unroutable []string
+	Affinity   bool
+	// LatencyDecisive is true if the pick differs from the one without latency penalties.
+	LatencyDecisive bool
+	// CrossZone is true if the picked node is outside the preferred zone.
+	CrossZone bool
+	Code      PlacementReason
+	Reason    string
+}
+
+func (d *placementDecision) String() string {
+	out := fmt.Sprintf("node=%s; mode=%s; candidates=%d; ready=%d; affinity=%t; latency_decisive=%t; cross_zone=%t; code=%s; reason=%s",
+		d.Node, d.Mode, d.Candidates, d.Ready, d.Affinity, d.LatencyDecisive, d.CrossZone, d.Code, d.Reason)
+	if len(d.Unroutable) > 0 {
+		out += "; unroutable=" + strings.Join(d.Unroutable, ",")
+	}
+	return out
+}
+
+func decisionFrom(req *http.Request) *placementDecision {
+	d, _ := req.Context().Value(ctxKeyDecision{}).(*placementDecision)
+	return d
+}
+
+// set records mode, reason code and detail (no-op if no decision is collected).
+func (d *placementDecision) set(mode pickMode, code PlacementReason, reason string) {
+	if d == nil {
+		return
+	}
+	d.Mode = mode.String()
+	d.Code = code
+	d.Reason = reason
+}
+
+// recordPick fills the decision (if collected) for a pick among nodes.
+func (d *placementDecision) recordPick(modelID string, best *state.NodeSnapshot, nodes []*state.NodeSnapshot, lat *metrics.LatencyTracker, p policy.ModelPolicy, o scoreOpts) {
+	if d == nil || best == nil {
+		return
+	}
+	d.Node = best.NodeID
+	_, d.Affinity = best.Models[modelID]
+	d.CrossZone = o.Zone != "" && best.Zone != o.Zone
+	if lat != nil {
+		if alt := pickBestByScore(nodes, nil, p, o); alt != nil && alt.NodeID != best.NodeID {
+			d.LatencyDecisive = true
+		}
+	}
+}
+
+// RouteDebug collects placement decisions for admin requests carrying X-Debug-Route
+// and returns them in the X-Route-Decision response header. It must run inside the
+// auth middleware.
+func (r *Router) RouteDebug(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Debug-Route") == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		// Do not forward the debug header upstream.
+		req.Header.Del("X-Debug-Route")
+
+		rec := auth.GetAuthRecord(req)
+		if rec == nil || !auth.IsAdmin(rec.Owner) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		d := &placementDecision{}
+		req = req.WithContext(context.WithValue(req.Context(), ctxKeyDecision{}, d))
+		next.ServeHTTP(&decisionWriter{ResponseWriter: w, d: d}, req)
+	})
+}
+
+// decisionWriter adds the decision header right before the response headers are sent.
+type decisionWriter struct {
+	http.ResponseWriter
+	d           *placementDecision
+	wroteHeader bool
+}
+
+func (w *decisionWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if w.d.Reason != "" {
+			w.Header().Set("X-Route-Decision", strings.TrimSpace(w.d.String()))
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *decisionWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *decisionWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *decisionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+func TestRouteDebugAdminOnly(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	h := r.RouteDebug(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Debug-Route") != "" {
+			t.Error("X-Debug-Route forwarded upstream")
+		}
+		decisionFrom(req).set(pickDirect, ReasonReadyDirect, "model ready")
+		w.Write([]byte("ok"))
+	}))
+
+	cases := []struct {
+		name   string
+		debug  bool
+		key    *policy.APIKeyRecord
+		header bool
+	}{
+		{"admin key", true, &policy.APIKeyRecord{ID: "k1", Owner: "admin"}, true},
+		{"user key", true, &policy.APIKeyRecord{ID: "k2", Owner: "alice"}, false},
+		{"no key", true, nil, false},
+		{"admin without header", false, &policy.APIKeyRecord{ID: "k1", Owner: "admin"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			if tc.debug {
+				req.Header.Set("X-Debug-Route", "1")
+			}
+			if tc.key != nil {
+				req = auth.WithAuthRecord(req, tc.key)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			got := rec.Header().Get("X-Route-Decision")
+			if tc.header != (got != "") {
+				t.Fatalf("X-Route-Decision = %q, want present %v", got, tc.header)
+			}
+			if tc.header && !strings.Contains(got, "code="+string(ReasonReadyDirect)) {
+				t.Errorf("X-Route-Decision = %q, want the reason code", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+// DiagnosticResult describes a synthetic request sent to a node through the proxy path.
+type DiagnosticResult struct {
+	NodeID     string `json:"node_id"`
+	Target     string `json:"target"`
+	Path       string `json:"path"`
+	OK         bool   `json:"ok"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMs  int64  `json:"latency_ms"`
+	ModelCount int    `json:"model_count,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Diagnose sends a synthetic GET /v1/models to the node's DataPlaneURL using the same
+// target resolution and reverse proxy as real API traffic. It catches DATA_PLANE_URL
+// misconfigurations that the agent's own (local) view cannot detect.
+func (r *Router) Diagnose(ctx context.Context, nodeID string) DiagnosticResult {
+	const path = "/v1/models"
+	res := DiagnosticResult{NodeID: nodeID, Path: path}
+
+	var node *pickedNode
+	for _, n := range r.Cluster.Snapshot() {
+		if n.NodeID == nodeID {
+			node = &pickedNode{NodeID: n.NodeID, DataPlaneURL: n.DataPlaneURL}
+			break
+		}
+	}
+	if node == nil {
+		res.Error = "unknown node"
+		return res
+	}
+	if node.DataPlaneURL == "" {
+		res.Error = "node has no data plane url"
+		return res
+	}
+
+	target, err := r.buildTarget(*node)
+	if err != nil {
+		res.Error = fmt.Sprintf("bad data plane url: %v", err)
+		return res
+	}
+	res.Target = target.String()
+
+	ctx, cancel := context.WithTimeout(internalContext(ctx), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	r.reverseProxy(node.NodeID, target).ServeHTTP(rec, req)
+	res.LatencyMs = time.Since(start).Milliseconds()
+	res.StatusCode = rec.Code
+
+	if rec.Code != http.StatusOK {
+		res.Error = fmt.Sprintf("unexpected status %d", rec.Code)
+		return res
+	}
+
+	var body struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		res.Error = fmt.Sprintf("invalid /v1/models response: %v", err)
+		return res
+	}
+	res.ModelCount = len(body.Data)
+	res.OK = true
+	return res
+}
@@ -5,35 +5,49 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"time"
 )
 
 // HandleEmbeddings proxies POST /v1/embeddings to the selected node.
-// Response is passed through as-is (JSON).
+// Response is passed through as-is (JSON), unless the client accepts application/x-ndjson.
 func (r *Router) HandleEmbeddings(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
-		http.NotFound(w, req)
+		methodNotAllowed(w, http.MethodPost)
 		return
 	}
 
-	modelID, body, err := extractModelAndBody(req)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	hint, ok := r.admitEarly(w, req)
+	if !ok {
 		return
 	}
 
-	node, mode, err := r.pickNodeForModel(req, modelID)
+	done := r.guardBody(w, req)
+	modelID, body, err := r.extractModelAndBody(req)
+	done()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		writeBodyError(w, err)
+		return
+	}
+	modelID, body = r.canonicalizeModel(modelID, body)
+	if !r.checkModelHint(w, hint, modelID) {
 		return
 	}
 
-	if mode == pickWait {
-		if err := r.waitModelReady(modelID, node.NodeID, 180*time.Second); err != nil {
-			http.Error(w, "model is still loading (timeout)", http.StatusServiceUnavailable)
+	pick := r.pickNodeForModel
+	if r.EmbeddingsRequireReady {
+		pick = r.pickReadyNodeForModel
+	}
+	res, err := pick(req, modelID)
+	if err != nil {
+		r.writePlacementError(w, res, err)
+		return
+	}
+	if res.Mode == pickWait {
+		if res, err = r.awaitLoad(req, modelID, res); err != nil {
+			r.writeWaitError(w, modelID, err)
 			return
 		}
 	}
+	node := res.node()
 
 	target, err := url.Parse(node.DataPlaneURL)
 	if err != nil {
@@ -44,5 +58,19 @@ func (r *Router) HandleEmbeddings(w http.ResponseWriter, req *http.Request) {
 	req.Body = io.NopCloser(bytes.NewReader(body))
 	req.ContentLength = int64(len(body))
 
+	req = withRouteInfo(req, modelID, res)
+	defer r.trackInflight(node.NodeID, body)()
+
+	// Opt-in incremental results for large batches.
+	if wantsNDJSON(req) {
+		chunks, err := embeddingsChunks(body, r.EmbeddingsChunkSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.streamEmbeddings(w, req, node.NodeID, target, chunks)
+		return
+	}
+
 	r.reverseProxy(node.NodeID, target).ServeHTTP(w, req)
 }
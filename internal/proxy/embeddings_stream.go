@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+)
+
+// wantsNDJSON reports whether the client asked for incremental embeddings results.
+func wantsNDJSON(req *http.Request) bool {
+	for _, v := range strings.Split(req.Header.Get("Accept"), ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(v))
+		if err == nil && mt == "application/x-ndjson" {
+			return true
+		}
+	}
+	return false
+}
+
+// embeddingsChunks splits the "input" batch of an embeddings body into bodies of at most
+// size entries each. A single input (string or one token array) yields the body unchanged.
+func embeddingsChunks(body []byte, size int) ([][]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON body: %w", err)
+	}
+
+	var input []json.RawMessage
+	if err := json.Unmarshal(fields["input"], &input); err != nil || len(input) == 0 {
+		return [][]byte{body}, nil
+	}
+	// An array of numbers is a single tokenized input, not a batch.
+	if first := bytes.TrimSpace(input[0]); len(first) == 0 || (first[0] != '"' && first[0] != '[') {
+		return [][]byte{body}, nil
+	}
+	if size <= 0 || len(input) <= size {
+		return [][]byte{body}, nil
+	}
+
+	var out [][]byte
+	for start := 0; start < len(input); start += size {
+		end := min(start+size, len(input))
+		v, err := json.Marshal(input[start:end])
+		if err != nil {
+			return nil, err
+		}
+		fields["input"] = v
+		chunk, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk)
+	}
+	return out, nil
+}
+
+// streamEmbeddings sends the chunks one after another to the node and writes each result
+// as one NDJSON line as soon as it is complete. Lines are written in input order and
+// data[].index refers to the position in the original input. An error line ends the stream.
+func (r *Router) streamEmbeddings(w http.ResponseWriter, req *http.Request, nodeID string, target *url.URL, chunks [][]byte) {
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	offset := 0
+	for i, chunk := range chunks {
+		creq := req.Clone(req.Context())
+		creq.Body = io.NopCloser(bytes.NewReader(chunk))
+		creq.ContentLength = int64(len(chunk))
+		creq.Header.Set("Accept", "application/json")
+
+		rec := httptest.NewRecorder()
+		r.reverseProxy(nodeID, target).ServeHTTP(rec, creq)
+
+		if i == 0 && rec.Code != http.StatusOK {
+			// Nothing was written yet: pass the upstream error through unchanged.
+			for k, vv := range rec.Header() {
+				w.Header()[k] = vv
+			}
+			w.WriteHeader(rec.Code)
+			_, _ = w.Write(rec.Body.Bytes())
+			return
+		}
+
+		line, n, err := shiftEmbeddingIndexes(rec, offset)
+		if i == 0 {
+			for k, vv := range rec.Header() {
+				if k != "Content-Type" && k != "Content-Length" {
+					w.Header()[k] = vv
+				}
+			}
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		if err != nil {
+			_ = enc.Encode(map[string]any{
+				"error": map[string]any{"message": err.Error(), "chunk": i, "offset": offset},
+			})
+			return
+		}
+		_ = enc.Encode(line)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		offset += n
+	}
+}
+
+// shiftEmbeddingIndexes decodes a chunk response and moves its data indexes by offset.
+// It returns the rewritten response and the number of embeddings it contains.
+func shiftEmbeddingIndexes(rec *httptest.ResponseRecorder, offset int) (map[string]json.RawMessage, int, error) {
+	if rec.Code != http.StatusOK {
+		return nil, 0, fmt.Errorf("upstream status %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		return nil, 0, fmt.Errorf("invalid upstream response: %w", err)
+	}
+	var data []map[string]json.RawMessage
+	if err := json.Unmarshal(resp["data"], &data); err != nil {
+		return nil, 0, fmt.Errorf("invalid upstream data: %w", err)
+	}
+
+	for pos, item := range data {
+		idx := pos
+		if raw, ok := item["index"]; ok {
+			_ = json.Unmarshal(raw, &idx)
+		}
+		v, _ := json.Marshal(idx + offset)
+		item["index"] = v
+	}
+	v, err := json.Marshal(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp["data"] = v
+	return resp, len(data), nil
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestEmbeddingsRequireReady(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		requireReady bool
+		model        state.ModelState // on the node, "" = not reported
+		status       int
+		upstream     int
+		loader       bool
+	}{
+		{"ready", true, state.ModelReady, http.StatusOK, 1, false},
+		{"not loaded, refused", true, "", http.StatusServiceUnavailable, 0, false},
+		{"loading, refused", true, state.ModelLoading, http.StatusServiceUnavailable, 0, false},
+		{"not loaded, loaded without the option", false, "", http.StatusOK, 1, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.EmbeddingsRequireReady = tc.requireReady
+			r.ExposeRoutingHeaders = true
+			var got []string
+			srv := authEcho(t, &got)
+			n := testNode{id: "a", url: srv.URL}
+			if tc.model != "" {
+				n.models = map[string]state.ModelState{"m": tc.model}
+			}
+			addNode(c, n)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/embeddings", strings.NewReader(`{"model":"m","input":"x"}`))
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			r.HandleEmbeddings(rec, req)
+
+			if rec.Code != tc.status || len(got) != tc.upstream {
+				t.Fatalf("status %d with %d upstream requests, want %d with %d: %s", rec.Code, len(got), tc.status, tc.upstream, rec.Body)
+			}
+			if tc.status != http.StatusOK {
+				if reason := rec.Header().Get("X-Route-Reason"); reason != string(ReasonNotReady) {
+					t.Errorf("X-Route-Reason = %q, want %s", reason, ReasonNotReady)
+				}
+				if d := time.Since(start); d > time.Second {
+					t.Errorf("refused after %s, want at once", d)
+				}
+			}
+			g := r.getGate("m")
+			if loader := g.loadingNode != ""; loader != tc.loader {
+				t.Errorf("loader %q assigned, want %v", g.loadingNode, tc.loader)
+			}
+		})
+	}
+}
+
+func TestEmbeddingsRequireReadyKeepsChatLoading(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.EmbeddingsRequireReady = true
+	var got []string
+	srv := authEcho(t, &got)
+	addNode(c, testNode{id: "a", url: srv.URL})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"m"}`))
+	rec := httptest.NewRecorder()
+	r.HandleChatCompletions(rec, req)
+	if rec.Code != http.StatusOK || len(got) != 1 {
+		t.Fatalf("chat status %d with %d upstream requests, want a cold load on a", rec.Code, len(got))
+	}
+}
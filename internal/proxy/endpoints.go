@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"net/http"
+)
+
+// endpointByPath names the API paths nodes may map to other upstream paths
+// (NodeHello.endpoint_paths).
+var endpointByPath = map[string]string{
+	"/v1/chat/completions": "chat",
+	"/v1/completions":      "completions",
+	"/v1/embeddings":       "embeddings",
+	"/v1/moderations":      "moderations",
+	"/v1/models":           "models",
+}
+
+// upstreamPath returns the path to request on a node for an incoming API path: the
+// node's mapping for "<endpoint>:<model>", else for "<endpoint>", else path unchanged.
+func upstreamPath(paths map[string]string, path, modelID string) string {
+	endpoint, ok := endpointByPath[path]
+	if !ok {
+		return path
+	}
+	if modelID != "" {
+		if p, ok := paths[endpoint+":"+modelID]; ok {
+			return p
+		}
+	}
+	if p, ok := paths[endpoint]; ok {
+		return p
+	}
+	return path
+}
+
+// methodNotAllowed answers a request to an API endpoint with the wrong method: 405
+// with the Allow header, unlike unknown paths (404).
+func methodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/httpx"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestWrongMethod(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	m := NewModelsHandler(c)
+
+	for _, tc := range []struct {
+		path    string
+		handler http.HandlerFunc
+		method  string
+		allow   string
+	}{
+		{"/v1/chat/completions", r.HandleChatCompletions, http.MethodGet, "POST"},
+		{"/v1/completions", r.HandleCompletions, http.MethodGet, "POST"},
+		{"/v1/embeddings", r.HandleEmbeddings, http.MethodPut, "POST"},
+		{"/v1/moderations", r.HandleModerations, http.MethodGet, "POST"},
+		{"/v1/models/load", r.HandleModelLoad, http.MethodGet, "POST"},
+		{"/v1/models", m.HandleModels, http.MethodPost, "GET"},
+		{"/v1/models/m/capabilities", m.HandleModelCapabilities, http.MethodDelete, "GET"},
+	} {
+		w := httptest.NewRecorder()
+		tc.handler(w, httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"model":"m"}`)))
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != tc.allow {
+			t.Errorf("%s %s: status %d, Allow %q, want 405 with Allow %s", tc.method, tc.path, w.Code, w.Header().Get("Allow"), tc.allow)
+		}
+	}
+
+	// Unknown paths below /v1/models/ stay 404 whatever the method.
+	w := httptest.NewRecorder()
+	m.HandleModelCapabilities(w, httptest.NewRequest(http.MethodDelete, "/v1/models/m", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("DELETE /v1/models/m: status %d, want 404", w.Code)
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	var served []string
+	r, c, _ := newTestRouter(t)
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		served = append(served, req.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(node.Close)
+	addNode(c, testNode{id: "a", url: node.URL, models: map[string]state.ModelState{"m": state.ModelReady}})
+
+	// Registered like cmd/server does with proxy.accept_trailing_slash.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chat/completions", r.HandleChatCompletions)
+	mux.Handle("/v1/chat/completions/{$}", httpx.TrimTrailingSlash(http.HandlerFunc(r.HandleChatCompletions)))
+
+	for _, tc := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, "/v1/chat/completions/", http.StatusOK},
+		{http.MethodGet, "/v1/chat/completions/", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/v1/chat/completions/extra", http.StatusNotFound},
+		{http.MethodPost, "/v1/chat/completion", http.StatusNotFound},
+	} {
+		served = nil
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"model":"m","messages":[]}`)))
+		if w.Code != tc.want {
+			t.Errorf("%s %s: status %d, want %d", tc.method, tc.path, w.Code, tc.want)
+		}
+		if tc.want == http.StatusOK && (len(served) != 1 || served[0] != "/v1/chat/completions") {
+			t.Errorf("%s %s: node served %q, want the path without slash", tc.method, tc.path, served)
+		}
+	}
+}
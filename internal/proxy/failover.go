@@ -0,0 +1,36 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// loadWaitTimeout bounds how long a request waits for a model load, failovers included.
+const loadWaitTimeout = 180 * time.Second
+
+// awaitLoad waits for the load the pickWait placement res waits for. If the loading
+// node goes offline meanwhile, placement runs again without it (at most LoaderFailovers
+// times), so the request waits for or starts a load on another node instead of
+// failing; the returned result is where the request goes then.
+func (r *Router) awaitLoad(req *http.Request, modelID string, res PlacementResult) (PlacementResult, error) {
+	deadline := time.Now().Add(loadWaitTimeout)
+	for failovers := 0; ; failovers++ {
+		err := r.waitModelReady(modelID, res.NodeID, time.Until(deadline))
+		if !errors.Is(err, errLoaderOffline) || failovers >= r.LoaderFailovers {
+			return res, err
+		}
+
+		log.Printf("placement: loader %s of %s went offline, placing again (failover %d/%d)", res.NodeID, modelID, failovers+1, r.LoaderFailovers)
+		req = withExcludedNode(req, res.NodeID)
+		res, err = r.pickNodeForModel(req, modelID)
+		if err != nil {
+			return res, fmt.Errorf("%w, placing again failed: %v", errLoaderOffline, err)
+		}
+		if res.Mode != pickWait {
+			return res, nil
+		}
+	}
+}
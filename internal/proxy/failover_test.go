@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestAwaitLoadFailsOverWhenLoaderGoesOffline(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		failovers int
+		want      int
+	}{
+		{"placed again", 1, http.StatusOK},
+		{"failover disabled", 0, http.StatusServiceUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.LoaderFailovers = tc.failovers
+
+			var onA, onB atomic.Int32
+			a := fakeLlama(t, "m", "loading", &onA)
+			b := fakeLlama(t, "m", "loaded", &onB)
+			addNode(c, testNode{id: "a", url: a.URL, models: map[string]state.ModelState{"m": state.ModelLoading}})
+			addNode(c, testNode{id: "b", url: b.URL})
+			g := r.getGate("m")
+			g.mu.Lock()
+			g.loadingNode, g.loadingSince = "a", time.Now()
+			g.mu.Unlock()
+
+			w := httptest.NewRecorder()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"m","messages":[]}`))
+				r.HandleChatCompletions(w, req)
+			}()
+			waitFor(t, func() bool { return gateWaiting(r, "m") == 1 })
+
+			// The loader stops sending heartbeats mid-wait.
+			n, _ := c.Node("a")
+			n.LastHeartbeat = time.Now().Add(-time.Hour)
+			c.RestoreNode(*n)
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("request still waiting after the loader went offline")
+			}
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tc.want, w.Body.String())
+			}
+			wantB := int32(0)
+			if tc.want == http.StatusOK {
+				wantB = 1
+			}
+			if onA.Load() != 0 || onB.Load() != wantB {
+				t.Errorf("completions on a=%d b=%d, want %d on b", onA.Load(), onB.Load(), wantB)
+			}
+		})
+	}
+}
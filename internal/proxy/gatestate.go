@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"maps"
+	"sort"
+	"time"
+)
+
+// GateState is the loader coordination state of a model (see modelGate).
+type GateState struct {
+	ModelID      string    `json:"model_id"`
+	LoadingNode  string    `json:"loading_node,omitempty"`
+	LoadingSince time.Time `json:"loading_since,omitzero"` // zero without LoadingNode
+	ReadyNode    string    `json:"ready_node,omitempty"`
+	// Nodes seen reporting the model LOADING, with the time first seen.
+	ReportedLoads map[string]time.Time `json:"reported_loads,omitempty"`
+	LastLoadMs    int64                `json:"last_load_ms,omitempty"` // last measured load time
+	Canceled      uint64               `json:"canceled,omitempty"`     // loads canceled since start
+	Waiting       int                  `json:"waiting,omitempty"`      // requests waiting for a load
+}
+
+// GateStates returns the gate state of every model the router has placed, by model id.
+func (r *Router) GateStates() []GateState {
+	r.gatesMu.Lock()
+	gates := make(map[string]*modelGate, len(r.gates))
+	for id, g := range r.gates {
+		gates[id] = g
+	}
+	r.gatesMu.Unlock()
+
+	out := make([]GateState, 0, len(gates))
+	for id, g := range gates {
+		g.mu.Lock()
+		var since time.Time
+		if g.loadingNode != "" {
+			since = g.loadingSince
+		}
+		out = append(out, GateState{
+			ModelID:       id,
+			LoadingNode:   g.loadingNode,
+			LoadingSince:  since,
+			ReadyNode:     g.readyNode,
+			ReportedLoads: maps.Clone(g.loadsSeen),
+			LastLoadMs:    g.lastLoad.Milliseconds(),
+			Canceled:      g.canceled,
+			Waiting:       g.waiting,
+		})
+		g.mu.Unlock()
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModelID < out[j].ModelID })
+	return out
+}
@@ -0,0 +1,22 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGateStatesLoadingSinceOnlyWithLoader(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	g := r.getGate("m")
+	g.mu.Lock()
+	g.loadingNode, g.loadingSince = "a", time.Now()
+	g.mu.Unlock()
+
+	if gs := r.GateStates(); len(gs) != 1 || gs[0].LoadingNode != "a" || gs[0].LoadingSince.IsZero() {
+		t.Fatalf("gate states = %+v, want loader a with its start", gs)
+	}
+	r.clearLoader("m", "a")
+	if gs := r.GateStates(); gs[0].LoadingNode != "" || !gs[0].LoadingSince.IsZero() {
+		t.Errorf("gate state after the load = %+v, want no loader and no start", gs[0])
+	}
+}
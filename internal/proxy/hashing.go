@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"strings"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// Placement strategies among nodes that have the model READY.
+const (
+	// PlacementScore picks the best node by score (RAM, load, latency).
+	PlacementScore = "score"
+	// PlacementConsistentHash maps a request key onto the same node while the set of
+	// READY nodes is stable, so prompt-prefix caches of the node are reused.
+	PlacementConsistentHash = "consistent_hash"
+)
+
+type ctxKeyCacheKey struct{}
+
+// withCacheKey derives the consistent hashing key from the request body (prompt prefix)
+// if consistent hashing is enabled and the client did not send the key header.
+func (r *Router) withCacheKey(req *http.Request, body []byte) *http.Request {
+	if r.PlacementStrategy != PlacementConsistentHash || r.HashPrefixChars <= 0 {
+		return req
+	}
+	if r.HashKeyHeader != "" && req.Header.Get(r.HashKeyHeader) != "" {
+		return req
+	}
+	prefix := promptPrefix(body, r.HashPrefixChars)
+	if prefix == "" {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), ctxKeyCacheKey{}, prefix))
+}
+
+// cacheKey returns the consistent hashing key of a request ("" = none).
+func (r *Router) cacheKey(req *http.Request) string {
+	if r.HashKeyHeader != "" {
+		if v := strings.TrimSpace(req.Header.Get(r.HashKeyHeader)); v != "" {
+			return v
+		}
+	}
+	key, _ := req.Context().Value(ctxKeyCacheKey{}).(string)
+	return key
+}
+
+// promptPrefix returns the first n characters of the prompt: the text contents of
+// "messages" (chat) or "prompt" (completions).
+func promptPrefix(body []byte, n int) string {
+	var tmp struct {
+		Prompt   json.RawMessage `json:"prompt"`
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &tmp); err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	var s string
+	if json.Unmarshal(tmp.Prompt, &s) == nil {
+		b.WriteString(s)
+	}
+	for _, m := range tmp.Messages {
+		if b.Len() >= n {
+			break
+		}
+		b.WriteString(m.Role)
+		b.WriteByte(':')
+		if json.Unmarshal(m.Content, &s) == nil {
+			b.WriteString(s)
+		} else {
+			// Multimodal content parts: use the raw JSON, it is stable for equal prompts.
+			b.Write(m.Content)
+		}
+		b.WriteByte('\n')
+	}
+
+	out := []rune(b.String())
+	if len(out) > n {
+		out = out[:n]
+	}
+	return string(out)
+}
+
+// pickByHash selects a node by rendezvous (highest random weight) hashing: every node gets
+// a hash of key and node id, the highest wins. Adding or removing a node only moves the
+// keys that node wins or won.
+func pickByHash(nodes []*state.NodeSnapshot, key string) *state.NodeSnapshot {
+	var (
+		best  *state.NodeSnapshot
+		bestH uint64
+	)
+	for _, n := range nodes {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(n.NodeID))
+		v := mix64(h.Sum64())
+		if best == nil || v > bestH || (v == bestH && n.NodeID < best.NodeID) {
+			best, bestH = n, v
+		}
+	}
+	return best
+}
+
+// mix64 spreads FNV output over all bits (splitmix64 finalizer), so similar node ids
+// do not produce correlated weights.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func hashNodes(ids ...string) []*state.NodeSnapshot {
+	out := make([]*state.NodeSnapshot, len(ids))
+	for i, id := range ids {
+		out[i] = &state.NodeSnapshot{NodeID: id}
+	}
+	return out
+}
+
+func TestPickByHashStable(t *testing.T) {
+	nodes := hashNodes("a", "b", "c", "d")
+	reversed := slices.Clone(nodes)
+	slices.Reverse(reversed)
+
+	used := map[string]int{}
+	for i := range 400 {
+		key := fmt.Sprintf("key-%d", i)
+		first := pickByHash(nodes, key).NodeID
+		if again := pickByHash(reversed, key).NodeID; again != first {
+			t.Fatalf("%s: picked %s, then %s for the same nodes in another order", key, first, again)
+		}
+		used[first]++
+	}
+	// Keys spread over all replicas (expected 100 each).
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if used[id] < 50 {
+			t.Errorf("node %s got %d of 400 keys: %v", id, used[id], used)
+		}
+	}
+}
+
+func TestPickByHashMovesFewKeys(t *testing.T) {
+	before := hashNodes("a", "b", "c", "d")
+	after := hashNodes("a", "b", "c", "d", "e")
+
+	moved := 0
+	for i := range 1000 {
+		key := fmt.Sprintf("key-%d", i)
+		from, to := pickByHash(before, key).NodeID, pickByHash(after, key).NodeID
+		if from != to {
+			if to != "e" {
+				t.Fatalf("%s moved from %s to %s, not to the new node", key, from, to)
+			}
+			moved++
+		}
+	}
+	// A fifth node should take about a fifth of the keys.
+	if moved < 100 || moved > 300 {
+		t.Errorf("%d of 1000 keys moved to the new node, want about 200", moved)
+	}
+
+	// Removing a node only moves the keys it had.
+	removed := hashNodes("a", "c", "d")
+	for i := range 1000 {
+		key := fmt.Sprintf("key-%d", i)
+		if from := pickByHash(before, key).NodeID; from != "b" && pickByHash(removed, key).NodeID != from {
+			t.Fatalf("%s moved off %s although only b left", key, from)
+		}
+	}
+}
+
+func TestPromptPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		body string
+		n    int
+		want string
+	}{
+		{`{"prompt":"hello world"}`, 5, "hello"},
+		{`{"messages":[{"role":"system","content":"be brief"},{"role":"user","content":"hi"}]}`, 100, "system:be brief\nuser:hi\n"},
+		{`{"messages":[{"role":"user","content":[{"type":"text","text":"x"}]}]}`, 100, `user:[{"type":"text","text":"x"}]` + "\n"},
+		{`{"prompt":"äöü"}`, 2, "äö"},
+		{`not json`, 10, ""},
+	} {
+		if got := promptPrefix([]byte(tc.body), tc.n); got != tc.want {
+			t.Errorf("promptPrefix(%s, %d) = %q, want %q", tc.body, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestConsistentHashPlacement(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.PlacementStrategy = PlacementConsistentHash
+	r.HashKeyHeader = "X-Cache-Key"
+	r.HashPrefixChars = 64
+	for _, id := range []string{"a", "b", "c"} {
+		addNode(c, testNode{id: id, models: map[string]state.ModelState{"m": state.ModelReady}})
+	}
+	place := func(header, body string) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		if header != "" {
+			req.Header.Set("X-Cache-Key", header)
+		}
+		req = r.withCacheKey(req, []byte(body))
+		res, err := r.pickNodeForModel(req, "m")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.NodeID
+	}
+
+	for i := range 20 {
+		key := fmt.Sprintf("session-%d", i)
+		want := pickByHash(hashNodes("a", "b", "c"), key).NodeID
+		for range 3 {
+			if got := place(key, `{"model":"m"}`); got != want {
+				t.Fatalf("%s placed on %s, want %s every time", key, got, want)
+			}
+		}
+	}
+
+	body := `{"model":"m","messages":[{"role":"user","content":"same prefix"}]}`
+	first := place("", body)
+	for range 5 {
+		if got := place("", body); got != first {
+			t.Fatalf("equal prompts placed on %s and %s", first, got)
+		}
+	}
+}
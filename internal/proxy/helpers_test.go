@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// newTestRouter returns a router on an empty cluster and a fresh policy store.
+func newTestRouter(t *testing.T) (*Router, *state.ClusterState, *policy.Store) {
+	t.Helper()
+	store, err := policy.Open(filepath.Join(t.TempDir(), "policies.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cluster := state.NewClusterState()
+	r := NewRouter(cluster, store)
+	r.NodeOfflineTTL = time.Minute
+	return r, cluster, store
+}
+
+// testNode is the status a test node reports.
+type testNode struct {
+	id       string
+	url      string // data plane URL (default http://<id>)
+	total    uint64 // RAM total (default 64 GiB)
+	avail    uint64 // RAM available (default 32 GiB)
+	inflight uint32
+	slots    uint32
+	models   map[string]state.ModelState
+}
+
+// addNode registers an online node with the status n.
+func addNode(c *state.ClusterState, n testNode) {
+	if n.url == "" {
+		n.url = "http://" + n.id
+	}
+	if n.total == 0 {
+		n.total = 64 << 30
+	}
+	if n.avail == 0 {
+		n.avail = 32 << 30
+	}
+	c.UpsertNodeHello(n.id, "test", "", n.url, "", state.DataPlaneTLS{}, nil)
+	setModels(c, n)
+}
+
+// setModels reports a new status of an added node.
+func setModels(c *state.ClusterState, n testNode) {
+	if n.total == 0 {
+		n.total = 64 << 30
+	}
+	if n.avail == 0 {
+		n.avail = 32 << 30
+	}
+	res := make(map[string]state.ModelResidency, len(n.models))
+	for id, st := range n.models {
+		res[id] = state.ModelResidency{ModelID: id, State: st}
+	}
+	c.UpdateNodeStatus(n.id, n.total, n.avail, "", n.inflight, n.slots, 0, res)
+}
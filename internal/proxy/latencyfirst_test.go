@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/metrics"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestLatencyFirstScoring(t *testing.T) {
+	const gib = 1 << 30
+	r, c, _ := newTestRouter(t)
+	r.Latency = metrics.NewLatencyTracker(1)
+	ready := map[string]state.ModelState{"m": state.ModelReady}
+	addNode(c, testNode{id: "roomy", avail: 48 * gib, models: ready})
+	addNode(c, testNode{id: "fast", avail: 4 * gib, models: ready})
+	addNode(c, testNode{id: "idle", avail: 60 * gib})
+	r.Latency.ObserveOK("roomy", 400*time.Millisecond)
+	r.Latency.ObserveOK("fast", 50*time.Millisecond)
+
+	for _, tc := range []struct {
+		header string
+		model  string
+		want   string
+	}{
+		{"", "m", "roomy"},
+		{"latency", "m", "fast"},
+		{" Latency ", "m", "fast"},
+		{"ram", "m", "roomy"},
+		// Cold loads keep the RAM-aware score.
+		{"latency", "cold", "idle"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		if tc.header != "" {
+			req.Header.Set("X-Scoring", tc.header)
+		}
+		res, err := r.pickNodeForModel(req, tc.model)
+		if err != nil {
+			t.Fatalf("X-Scoring %q, %s: %v", tc.header, tc.model, err)
+		}
+		if res.NodeID != tc.want {
+			t.Errorf("X-Scoring %q, %s: placed on %s, want %s", tc.header, tc.model, res.NodeID, tc.want)
+		}
+	}
+}
+
+func TestLatencyFirstOOMCheck(t *testing.T) {
+	o := scoreOpts{LatencyFirst: true}
+	lat := metrics.NewLatencyTracker(1)
+	lat.ObserveOK("small", 10*time.Millisecond)
+	lat.ObserveOK("big", 300*time.Millisecond)
+	nodes := []*state.NodeSnapshot{
+		{NodeID: "small", RAMTotalBytes: 16 << 30, RAMAvailBytes: 2 << 30},
+		{NodeID: "big", RAMTotalBytes: 64 << 30, RAMAvailBytes: 40 << 30},
+	}
+	if got := pickBestByScore(nodes, lat, policy.ModelPolicy{ModelID: "m", RAMRequiredBytes: 8 << 30}, o); got.NodeID != "big" {
+		t.Errorf("picked %s, want the node that fits the model", got.NodeID)
+	}
+	if got := pickBestByScore(nodes, lat, policy.ModelPolicy{ModelID: "m"}, o); got.NodeID != "small" {
+		t.Errorf("picked %s, want the fastest node", got.NodeID)
+	}
+}
@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// concurrencyLimit is the router-wide in-flight request counter (see LimitConcurrency).
+type concurrencyLimit struct {
+	max     int
+	reserve int // slots normal requests leave free (low requests twice as many)
+
+	mu        sync.Mutex
+	inflight  int
+	saturated atomic.Uint64
+}
+
+// acquire takes a slot for a request of priority prio unless the slots left for it are
+// in use.
+func (l *concurrencyLimit) acquire(prio int) bool {
+	limit := l.max
+	switch {
+	case prio <= PriorityLow:
+		limit -= 2 * l.reserve
+	case prio == PriorityNormal:
+		limit -= l.reserve
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= limit {
+		return false
+	}
+	l.inflight++
+	return true
+}
+
+func (l *concurrencyLimit) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+}
+
+func (l *concurrencyLimit) current() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+// LimitConcurrency caps the requests the wrapped handler serves at once to
+// MaxConcurrentRequests and answers further ones with 503 right away. It protects the
+// router process itself (goroutines, file descriptors), independent of node capacity.
+// The share PriorityReserve of the slots is held back from lower priorities, so low
+// priority requests are refused first under contention; the handler has to run after
+// authentication for high priority to count. All handlers wrapped by one router share
+// the limit; 0 disables it.
+func (r *Router) LimitConcurrency(next http.Handler) http.Handler {
+	if r.MaxConcurrentRequests <= 0 {
+		return next
+	}
+	r.limitOnce.Do(func() {
+		r.limit = &concurrencyLimit{
+			max:     r.MaxConcurrentRequests,
+			reserve: int(float64(r.MaxConcurrentRequests) * r.PriorityReserve),
+		}
+	})
+	l := r.limit
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !l.acquire(requestPriority(req)) {
+			l.saturated.Add(1)
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "server is saturated, retry later", http.StatusServiceUnavailable)
+			return
+		}
+		defer l.release()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// WriteLimitMetrics writes the in-flight gauge and the saturation counter in the
+// Prometheus text format (nothing without a limit).
+func (r *Router) WriteLimitMetrics(w io.Writer) error {
+	l := r.limit
+	if l == nil {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "# HELP llm_router_inflight_requests API requests currently served by the router.\n"+
+		"# TYPE llm_router_inflight_requests gauge\n"+
+		"llm_router_inflight_requests %d\n"+
+		"# HELP llm_router_inflight_limit Maximum concurrent API requests (MAX_CONCURRENT_REQUESTS).\n"+
+		"# TYPE llm_router_inflight_limit gauge\n"+
+		"llm_router_inflight_limit %d\n"+
+		"# HELP llm_router_saturated_total API requests rejected with 503 because the router was saturated.\n"+
+		"# TYPE llm_router_saturated_total counter\n"+
+		"llm_router_saturated_total %d\n",
+		l.current(), l.max, l.saturated.Load())
+	return err
+}
@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+// blockingHandler answers 200 once released: a send on release finishes one request,
+// closing it finishes all.
+type blockingHandler struct {
+	release chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{release: make(chan struct{})}
+}
+
+func (b *blockingHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	<-b.release
+}
+
+// hold starts a request with priority header prio ("" = none) on h that stays in
+// flight until b is released.
+func (b *blockingHandler) hold(h http.Handler, prio string) {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		h.ServeHTTP(httptest.NewRecorder(), limitRequest(prio))
+	}()
+}
+
+// limitRequest returns an API request of an admin key with priority header prio.
+func limitRequest(prio string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if prio != "" {
+		req.Header.Set("X-Priority", prio)
+	}
+	return auth.WithAuthRecord(req, &policy.APIKeyRecord{ID: "k", Owner: "admin"})
+}
+
+func serveLimited(h http.Handler, prio string) int {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, limitRequest(prio))
+	return w.Code
+}
+
+func TestLimitConcurrencyReject(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	r.MaxConcurrentRequests = 3
+	b := newBlockingHandler()
+	h := r.LimitConcurrency(b)
+	t.Cleanup(func() { close(b.release); b.wg.Wait() })
+
+	for range 3 {
+		b.hold(h, "")
+	}
+	waitFor(t, func() bool { return r.limit.current() == 3 })
+
+	// Request N+1 is refused right away.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, limitRequest(""))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("request beyond the limit = %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if got := r.limit.saturated.Load(); got != 1 {
+		t.Errorf("saturated = %d, want 1", got)
+	}
+
+	// A finished request frees its slot for the next one.
+	b.release <- struct{}{}
+	waitFor(t, func() bool { return r.limit.current() == 2 })
+	b.hold(h, "")
+	waitFor(t, func() bool { return r.limit.current() == 3 })
+
+	var metrics strings.Builder
+	if err := r.WriteLimitMetrics(&metrics); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"llm_router_inflight_requests 3\n", "llm_router_inflight_limit 3\n", "llm_router_saturated_total 1\n"} {
+		if !strings.Contains(metrics.String(), want) {
+			t.Errorf("metrics lack %q:\n%s", want, metrics.String())
+		}
+	}
+}
+
+func TestLimitConcurrencyPriority(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	r.MaxConcurrentRequests = 10
+	r.PriorityReserve = 0.1 // low requests up to 8, normal up to 9, high up to 10
+	b := newBlockingHandler()
+	h := r.LimitConcurrency(b)
+	t.Cleanup(func() { close(b.release); b.wg.Wait() })
+
+	for range 8 {
+		b.hold(h, "")
+	}
+	waitFor(t, func() bool { return r.limit.current() == 8 })
+
+	// Under contention a low-priority request yields, higher ones are admitted.
+	if code := serveLimited(h, "low"); code != http.StatusServiceUnavailable {
+		t.Errorf("low priority with 8 of 10 in flight = %d, want 503", code)
+	}
+	b.hold(h, "")
+	waitFor(t, func() bool { return r.limit.current() == 9 })
+	if code := serveLimited(h, ""); code != http.StatusServiceUnavailable {
+		t.Errorf("normal priority with 9 of 10 in flight = %d, want 503", code)
+	}
+	b.hold(h, "high")
+	waitFor(t, func() bool { return r.limit.current() == 10 })
+	if code := serveLimited(h, "high"); code != http.StatusServiceUnavailable {
+		t.Errorf("high priority at the limit = %d, want 503", code)
+	}
+	if got := r.limit.saturated.Load(); got != 3 {
+		t.Errorf("saturated = %d, want 3", got)
+	}
+}
+
+func TestLimitConcurrencyHighNeedsAdmin(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	r.MaxConcurrentRequests = 4
+	r.PriorityReserve = 0.25 // normal requests up to 3
+	b := newBlockingHandler()
+	h := r.LimitConcurrency(b)
+	t.Cleanup(func() { close(b.release); b.wg.Wait() })
+
+	for range 3 {
+		b.hold(h, "")
+	}
+	waitFor(t, func() bool { return r.limit.current() == 3 })
+
+	// X-Priority high of a regular key counts as normal.
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("X-Priority", "high")
+	req = auth.WithAuthRecord(req, &policy.APIKeyRecord{ID: "k2", Owner: "alice"})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("high priority of a regular key = %d, want 503", w.Code)
+	}
+}
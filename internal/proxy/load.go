@@ -0,0 +1,183 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// LoadCommander sends load commands to nodes through the control plane
+// (implemented by control.NodeControlService).
+type LoadCommander interface {
+	SendLoad(nodeID, requestID, modelID string) error
+}
+
+// loadRequest is the body of POST /v1/models/load.
+type loadRequest struct {
+	Model  string `json:"model"`
+	NodeID string `json:"node_id"` // optional; placement picks the node without it
+}
+
+// loadResponse answers POST /v1/models/load.
+type loadResponse struct {
+	Model  string `json:"model"`
+	NodeID string `json:"node_id"`
+	Status string `json:"status"` // "loading" (started or in progress), "queued" or "ready"
+}
+
+// HandleModelLoad serves POST /v1/models/load: it loads a model ahead of its first
+// request. Without node_id the node is chosen like for a cold request (a load already
+// in progress or a READY node is reported instead of starting another load). The node
+// becomes the model's loader, so requests arriving meanwhile wait for it. It answers
+// 202 when the model is loading and 200 when it is READY already. If the node's control
+// stream is reconnecting, the command is queued (status "queued", 202) and the node
+// stays the loader; the load starts once the node is back.
+// Any API key may start loads, not only admin keys: a load is what the key's first
+// request for the model would trigger anyway, and the same model and node ACLs, RAM
+// checks and ModelPolicy.MaxNodes apply. ENDPOINT_MODEL_LOAD switches the endpoint off.
+func (r *Router) HandleModelLoad(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	done := r.guardBody(w, req)
+	raw, err := io.ReadAll(req.Body)
+	done()
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	var in loadRequest
+	if err := json.Unmarshal(raw, &in); err != nil {
+		http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
+		return
+	}
+	if in.Model == "" {
+		http.Error(w, "model is required", http.StatusBadRequest)
+		return
+	}
+	modelID := r.resolveModelID(in.Model)
+
+	var (
+		res    PlacementResult
+		status int
+	)
+	if in.NodeID == "" {
+		res, err = r.pickNodeForModel(req, modelID)
+		if err != nil {
+			r.writePlacementError(w, res, err)
+			return
+		}
+	} else {
+		res, status, err = r.claimLoader(req, modelID, in.NodeID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+
+	out := loadResponse{Model: modelID, NodeID: res.NodeID, Status: "loading"}
+	switch res.Mode {
+	case pickDirect:
+		out.Status = "ready"
+	case pickCold:
+		err := r.startLoad(req.Context(), res.node(), modelID)
+		switch {
+		case errors.Is(err, control.ErrQueued):
+			out.Status = "queued"
+			log.Printf("proxy: load of %s queued for reconnecting node %s", modelID, res.NodeID)
+		case err != nil:
+			r.clearLoader(modelID, res.NodeID)
+			http.Error(w, fmt.Sprintf("load on node %s: %v", res.NodeID, err), http.StatusBadGateway)
+			return
+		default:
+			log.Printf("proxy: load of %s requested on node %s", modelID, res.NodeID)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if out.Status == "ready" {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// claimLoader makes nodeID the loader of modelID for an explicit load request. The
+// mode of the result is pickDirect if the model is READY there, pickWait if it is
+// loading there already and pickCold if the caller has to start the load. It fails
+// with the HTTP status to answer if the key may not use the model or node, the node is
+// not online, or the model is loading elsewhere or already on ModelPolicy.MaxNodes
+// nodes.
+func (r *Router) claimLoader(req *http.Request, modelID, nodeID string) (PlacementResult, int, error) {
+	if rec := auth.GetAuthRecord(req); rec != nil {
+		if !r.modelAllowed(rec.AllowedModels, modelID) {
+			return PlacementResult{}, http.StatusForbidden, errors.New("access to model denied by ACL")
+		}
+		if !auth.CheckACL(rec.AllowedNodes, nodeID) {
+			return PlacementResult{}, http.StatusForbidden, errors.New("access to node denied by ACL")
+		}
+	}
+
+	now := time.Now()
+	online := r.Cluster.SnapshotOnline(now, r.NodeOfflineTTL)
+	var node *state.NodeSnapshot
+	for _, n := range online {
+		if n.NodeID == nodeID && n.DataPlaneURL != "" {
+			node = n
+			break
+		}
+	}
+	if node == nil {
+		return PlacementResult{}, http.StatusNotFound, fmt.Errorf("node %s is not online", nodeID)
+	}
+	res := PlacementResult{NodeID: node.NodeID, DataPlaneURL: node.DataPlaneURL, Mode: pickCold, Reason: ReasonColdLoad}
+	switch node.Models[modelID].State {
+	case state.ModelReady:
+		res.Mode, res.Reason = pickDirect, ReasonReadyDirect
+		return res, 0, nil
+	case state.ModelLoading:
+		res.Mode, res.Reason = pickWait, ReasonLoaderWait
+		return res, 0, nil
+	}
+
+	pol, _, _ := r.getPolicy(context.Background(), modelID)
+
+	g := r.getGate(modelID)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch {
+	case g.loadingNode == nodeID:
+		res.Mode, res.Reason = pickWait, ReasonLoaderWait
+		return res, 0, nil
+	case g.loadingNode != "":
+		return PlacementResult{}, http.StatusConflict, fmt.Errorf("model %s is already loading on node %s", modelID, g.loadingNode)
+	case pol.MaxNodes > 0 && g.occupiedLocked(online, modelID) >= pol.MaxNodes:
+		return PlacementResult{}, http.StatusConflict, fmt.Errorf("model %s is already on its maximum of %d nodes", modelID, pol.MaxNodes)
+	case !fitsRAM(node, pol):
+		return PlacementResult{}, http.StatusConflict, fmt.Errorf("node %s does not have the %d MiB RAM model %s requires available", nodeID, pol.RAMRequiredBytes>>20, modelID)
+	}
+	g.loadingNode = nodeID
+	g.loadingSince = now
+	return res, 0, nil
+}
+
+// startLoad asks the node to load modelID: through the control plane (LoadModel
+// command) if Commands is set, else directly on its data plane (see sendLoad).
+func (r *Router) startLoad(ctx context.Context, node pickedNode, modelID string) error {
+	if r.Commands == nil {
+		return r.sendLoad(ctx, node, modelID)
+	}
+	return r.Commands.SendLoad(node.NodeID, fmt.Sprintf("load-%d", time.Now().UnixNano()), modelID)
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestWaitFailsFastOnLoadError(t *testing.T) {
+	for _, notify := range []bool{true, false} {
+		r, c, _ := newTestRouter(t)
+		addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelLoading}})
+		g := r.getGate("m")
+		g.loadingNode, g.loadingSince = "a", time.Now()
+
+		done := make(chan error, 1)
+		start := time.Now()
+		go func() { done <- r.waitModelReady("m", "a", 10*time.Second) }()
+		waitFor(t, func() bool { return gateWaiting(r, "m") == 1 })
+
+		// The node reports the load failed; without the notification the waiter still
+		// sees it on its next status check.
+		c.UpdateNodeStatus("a", 64<<30, 32<<30, "", 0, 0, 0, map[string]state.ModelResidency{
+			"m": {ModelID: "m", State: state.ModelError, Error: "out of memory"},
+		})
+		if notify {
+			r.NotifyModelFailed("a", "m")
+		}
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, errModelLoadFailed) || !strings.Contains(err.Error(), "out of memory") {
+				t.Errorf("notify %v: wait = %v, want %v with the node's error", notify, err, errModelLoadFailed)
+			}
+			if d := time.Since(start); d > 2*time.Second {
+				t.Errorf("notify %v: failed after %v, want promptly", notify, d)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("notify %v: still waiting after the load failed", notify)
+		}
+
+		g.mu.Lock()
+		loader := g.loadingNode
+		g.mu.Unlock()
+		if loader != "" {
+			t.Errorf("notify %v: loader = %q, want the slot cleared", notify, loader)
+		}
+	}
+}
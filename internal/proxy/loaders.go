@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// noteReportedLoad records the status transitions of modelID on nodeID: the first
+// status reporting it LOADING starts a reported load, any other state ends it. Loads
+// started outside the router (e.g. by an admin on the node) are tracked the same way
+// as its own.
+func (r *Router) noteReportedLoad(nodeID, modelID string, loading bool, now time.Time) {
+	if !loading {
+		r.gatesMu.Lock()
+		g := r.gates[modelID]
+		r.gatesMu.Unlock()
+		if g == nil {
+			return
+		}
+		g.mu.Lock()
+		delete(g.loadsSeen, nodeID)
+		g.mu.Unlock()
+		return
+	}
+
+	g := r.getGate(modelID)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.loadsSeen[nodeID]; ok {
+		return
+	}
+	if g.loadsSeen == nil {
+		g.loadsSeen = map[string]time.Time{}
+	}
+	g.loadsSeen[nodeID] = now
+}
+
+// trackLoadsLocked forgets reported loads of nodes that are offline or no longer report
+// modelID LOADING (e.g. the model vanished from their status), and records loads not
+// seen by noteReportedLoad (no status notifications) as starting now. g.mu must be held.
+func (g *modelGate) trackLoadsLocked(online []*state.NodeSnapshot, modelID string, now time.Time) {
+	seen := make(map[string]time.Time, len(g.loadsSeen))
+	for _, n := range online {
+		if m, ok := n.Models[modelID]; !ok || m.State != state.ModelLoading {
+			continue
+		}
+		first, ok := g.loadsSeen[n.NodeID]
+		if !ok {
+			first = now
+		}
+		seen[n.NodeID] = first
+	}
+	g.loadsSeen = seen
+}
+
+// furthestLoaderLocked returns the node among nodes whose load of the model is
+// furthest along: the gate's loader and every node reporting the model LOADING, ranked
+// by how long the load has been running (llama.cpp reports no progress). Ties go to
+// the gate's loader, then to the lower node id. It returns nil if no load is in
+// progress on a routable node. g.mu must be held.
+func (g *modelGate) furthestLoaderLocked(nodes []*state.NodeSnapshot) *state.NodeSnapshot {
+	var (
+		best      *state.NodeSnapshot
+		bestSince time.Time
+	)
+	for _, n := range nodes {
+		if n.DataPlaneURL == "" {
+			continue
+		}
+		since, reported := g.loadsSeen[n.NodeID]
+		if n.NodeID == g.loadingNode {
+			if !reported || (!g.loadingSince.IsZero() && g.loadingSince.Before(since)) {
+				since = g.loadingSince
+			}
+		} else if !reported {
+			continue
+		}
+		if best == nil || since.Before(bestSince) ||
+			(since.Equal(bestSince) && g.preferLoader(n.NodeID, best.NodeID)) {
+			best, bestSince = n, since
+		}
+	}
+	return best
+}
+
+// preferLoader breaks a tie between two loads running equally long.
+func (g *modelGate) preferLoader(a, b string) bool {
+	if a == g.loadingNode || b == g.loadingNode {
+		return a == g.loadingNode
+	}
+	return a < b
+}
@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestReportedLoadsFromStatusUpdates(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	loading := map[string]state.ModelState{"m": state.ModelLoading}
+	addNode(c, testNode{id: "b", models: map[string]state.ModelState{}})
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{}})
+
+	// b starts loading first, a later; no request is placed in between.
+	start := time.Now()
+	setModels(c, testNode{id: "b", models: loading})
+	r.noteReportedLoad("b", "m", true, start)
+	setModels(c, testNode{id: "a", models: loading})
+	r.noteReportedLoad("a", "m", true, start.Add(20*time.Second))
+	// Repeated statuses keep the first time.
+	r.NotifyModelState("b", "m", state.ModelLoading)
+	r.NotifyModelState("a", "m", state.ModelLoading)
+
+	states := r.GateStates()
+	if len(states) != 1 {
+		t.Fatalf("gate states = %+v", states)
+	}
+	got := states[0].ReportedLoads
+	if !got["b"].Equal(start) || !got["a"].Equal(start.Add(20*time.Second)) {
+		t.Fatalf("reported loads = %v, want b at start and a 20s later", got)
+	}
+
+	// A request waits for the load that has been running longer.
+	r.WaitOnReportedLoads = true
+	res, err := r.pickNodeForModel(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil), "m")
+	if err != nil || res.Mode != pickWait || res.NodeID != "b" {
+		t.Fatalf("placement = %+v, %v, want waiting for b", res, err)
+	}
+
+	// b finishes: a is the only load left.
+	setModels(c, testNode{id: "b", models: map[string]state.ModelState{"m": state.ModelReady}})
+	r.NotifyModelState("b", "m", state.ModelReady)
+	got = r.GateStates()[0].ReportedLoads
+	if _, ok := got["b"]; ok || len(got) != 1 {
+		t.Errorf("reported loads after b is READY = %v, want only a", got)
+	}
+
+	// a's load is aborted (model unloaded).
+	r.NotifyModelState("a", "m", state.ModelUnloaded)
+	if got := r.GateStates()[0].ReportedLoads; len(got) != 0 {
+		t.Errorf("reported loads after a unloaded = %v, want none", got)
+	}
+}
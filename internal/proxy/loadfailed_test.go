@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// reportError makes a node report modelID in ERROR with errText.
+func reportError(c *state.ClusterState, nodeID, modelID, errText string, at time.Time) {
+	c.UpdateNodeStatus(nodeID, 64<<30, 32<<30, "", 0, 0, 0, map[string]state.ModelResidency{
+		modelID: {ModelID: modelID, State: state.ModelError, Error: errText, LastSeen: at},
+	})
+}
+
+func TestModelFailedOnSingleNode(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	var got []string
+	srv := authEcho(t, &got)
+	addNode(c, testNode{id: "a", url: srv.URL})
+	reportError(c, "a", "m", "out of memory", time.Now())
+
+	rec := httptest.NewRecorder()
+	r.HandleChatCompletions(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"m"}`)))
+
+	if rec.Code != http.StatusServiceUnavailable || len(got) != 0 {
+		t.Fatalf("status %d with %d upstream requests, want 503 without routing", rec.Code, len(got))
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "failed to load on all nodes") || !strings.Contains(body, "a: out of memory") {
+		t.Errorf("body = %q, want the failure with the node's last error", body)
+	}
+}
+
+func TestFailedEverywhere(t *testing.T) {
+	now := time.Now()
+	node := func(id string, st state.ModelState, errText string, at time.Time) *state.NodeSnapshot {
+		return &state.NodeSnapshot{NodeID: id, Models: map[string]state.ModelResidency{
+			"m": {ModelID: "m", State: st, Error: errText, LastSeen: at},
+		}}
+	}
+	for _, tc := range []struct {
+		name  string
+		nodes []*state.NodeSnapshot
+		want  string // "" = no error
+	}{
+		{"not reported", []*state.NodeSnapshot{{NodeID: "a"}}, ""},
+		{"error and unloaded", []*state.NodeSnapshot{node("a", state.ModelError, "boom", now), node("b", state.ModelUnloaded, "", now)}, ""},
+		{"error without text", []*state.NodeSnapshot{node("a", state.ModelError, "", now)}, "model failed to load on all nodes"},
+		{"latest error wins", []*state.NodeSnapshot{
+			node("a", state.ModelError, "old", now.Add(-time.Minute)),
+			node("b", state.ModelError, "new", now),
+			{NodeID: "c"},
+		}, "model failed to load on all nodes (last error: b: new)"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			if err := failedEverywhere(tc.nodes, "m"); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Errorf("error = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package proxy
+
+import (
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// occupiedLocked returns the number of online nodes the model is READY or LOADING on,
+// counting the gate's loader before its first LOADING status. g.mu must be held.
+func (g *modelGate) occupiedLocked(online []*state.NodeSnapshot, modelID string) int {
+	count := 0
+	for _, n := range online {
+		m, ok := n.Models[modelID]
+		switch {
+		case ok && (m.State == state.ModelReady || m.State == state.ModelLoading):
+			count++
+		case n.NodeID == g.loadingNode:
+			count++
+		}
+	}
+	return count
+}
+
+// loadingNodeOf returns the routable node with the lowest id among nodes reporting the
+// model LOADING, or nil.
+func loadingNodeOf(nodes []*state.NodeSnapshot, modelID string) *state.NodeSnapshot {
+	var best *state.NodeSnapshot
+	for _, n := range nodes {
+		if n.DataPlaneURL == "" {
+			continue
+		}
+		if m, ok := n.Models[modelID]; !ok || m.State != state.ModelLoading {
+			continue
+		}
+		if best == nil || n.NodeID < best.NodeID {
+			best = n
+		}
+	}
+	return best
+}
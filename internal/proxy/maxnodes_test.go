@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestMaxNodesBoundary(t *testing.T) {
+	ready := map[string]state.ModelState{"m": state.ModelReady}
+	loading := map[string]state.ModelState{"m": state.ModelLoading}
+
+	for _, tc := range []struct {
+		name     string
+		maxNodes int
+		nodes    []testNode
+		offline  string // node that stopped sending heartbeats
+		allowed  string // key's node ACL ("" = all)
+		reason   PlacementReason
+		node     string
+	}{
+		{
+			name:     "below the maximum a new node is loaded",
+			maxNodes: 2,
+			nodes:    []testNode{{id: "a", models: ready}, {id: "b"}},
+			allowed:  "b",
+			reason:   ReasonColdLoad, node: "b",
+		},
+		{
+			name:     "at the maximum no further node is loaded",
+			maxNodes: 2,
+			nodes:    []testNode{{id: "a", models: ready}, {id: "b", models: loading}, {id: "c"}},
+			allowed:  "c",
+			reason:   ReasonMaxNodes,
+		},
+		{
+			name:     "at the maximum requests wait for a load in progress",
+			maxNodes: 2,
+			nodes:    []testNode{{id: "a", models: loading}, {id: "b", models: loading}, {id: "c"}},
+			reason:   ReasonLoaderWait, node: "a",
+		},
+		{
+			name:     "at the maximum READY nodes are used",
+			maxNodes: 1,
+			nodes:    []testNode{{id: "a", models: ready}, {id: "b"}},
+			reason:   ReasonReadyDirect, node: "a",
+		},
+		{
+			name:     "offline nodes do not count",
+			maxNodes: 1,
+			nodes:    []testNode{{id: "a", models: ready}, {id: "b"}},
+			offline:  "a",
+			reason:   ReasonColdLoad, node: "b",
+		},
+		{
+			name:     "zero is unlimited",
+			maxNodes: 0,
+			nodes:    []testNode{{id: "a", models: ready}, {id: "b", models: ready}, {id: "c"}},
+			allowed:  "c",
+			reason:   ReasonColdLoad, node: "c",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, s := newTestRouter(t)
+			upsertPolicy(t, s, policy.ModelPolicy{ModelID: "m", MaxNodes: tc.maxNodes})
+			for _, n := range tc.nodes {
+				addNode(c, n)
+			}
+			if tc.offline != "" {
+				n, _ := c.Node(tc.offline)
+				n.LastHeartbeat = time.Now().Add(-time.Hour)
+				c.RestoreNode(*n)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			if tc.allowed != "" {
+				req = auth.WithAuthRecord(req, &policy.APIKeyRecord{AllowedNodes: tc.allowed, AllowedModels: "*"})
+			}
+			res, err := r.pickNodeForModel(req, "m")
+			if res.Reason != tc.reason {
+				t.Fatalf("reason = %s (err %v), want %s", res.Reason, err, tc.reason)
+			}
+			if tc.node == "" {
+				if err == nil {
+					t.Fatalf("placed on %s, want a refusal", res.NodeID)
+				}
+				return
+			}
+			if err != nil || res.NodeID != tc.node {
+				t.Errorf("placed on %s (err %v), want %s", res.NodeID, err, tc.node)
+			}
+		})
+	}
+}
+
+func TestMaxNodesCountsTheLoader(t *testing.T) {
+	r, c, s := newTestRouter(t)
+	upsertPolicy(t, s, policy.ModelPolicy{ModelID: "m", MaxNodes: 1})
+	addNode(c, testNode{id: "a"})
+	addNode(c, testNode{id: "b"})
+
+	// The first request makes a the loader before it reports LOADING.
+	res, err := r.pickNodeForModel(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil), "m")
+	if err != nil || res.Mode != pickCold {
+		t.Fatalf("first placement = %+v (%v), want a cold load", res, err)
+	}
+	// An explicit load on the other node would be the second of one.
+	req := httptest.NewRequest(http.MethodPost, "/v1/models/load", nil)
+	other := "a"
+	if res.NodeID == "a" {
+		other = "b"
+	}
+	if _, status, err := r.claimLoader(req, "m", other); status != http.StatusConflict {
+		t.Errorf("claimLoader on %s = %d (%v), want 409", other, status, err)
+	}
+	if _, status, err := r.claimLoader(req, "m", res.NodeID); err != nil {
+		t.Errorf("claimLoader on the loader = %d (%v), want it to join the load", status, err)
+	}
+}
@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type ctxKeyDeployment struct{}
+
+// azureOperations maps the operation of an Azure OpenAI path to the API path serving it.
+var azureOperations = map[string]string{
+	"chat/completions": "/v1/chat/completions",
+	"completions":      "/v1/completions",
+	"embeddings":       "/v1/embeddings",
+}
+
+// AzurePaths serves Azure OpenAI style requests (/openai/deployments/{deployment}/
+// chat/completions, completions, embeddings) with next, the handler of the /v1/ API:
+// the path is rewritten to its /v1/ form and the deployment becomes the request's
+// model (see modelOf). An "api-key" header is accepted in place of Authorization.
+// Unknown operations get 404.
+func AzurePaths(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rest := strings.TrimPrefix(req.URL.Path, "/openai/deployments/")
+		deployment, op, _ := strings.Cut(rest, "/")
+		path, ok := azureOperations[strings.TrimSuffix(op, "/")]
+		if deployment == "" || !ok {
+			http.NotFound(w, req)
+			return
+		}
+
+		req = req.WithContext(context.WithValue(req.Context(), ctxKeyDeployment{}, deployment))
+		u := *req.URL
+		u.Path, u.RawPath = path, ""
+		req.URL = &u
+		// The key must not reach the node (see setUpstreamAuth).
+		if key := req.Header.Get("api-key"); key != "" {
+			if req.Header.Get("Authorization") == "" {
+				req.Header.Set("Authorization", "Bearer "+key)
+			}
+			req.Header.Del("api-key")
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// ParseModelFields parses a comma separated list of body fields holding the model.
+func ParseModelFields(s string) ([]string, error) {
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no field names")
+	}
+	return out, nil
+}
+
+// modelOf returns the model of an API request: the Azure deployment of its path (see
+// AzurePaths), else the first of ModelFields (default "model") the body sets to a
+// non-empty string. field is the body field it was taken from ("" = path or none).
+func (r *Router) modelOf(req *http.Request, body []byte) (modelID, field string, err error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return "", "", fmt.Errorf("invalid json: %w", err)
+	}
+	if d, ok := req.Context().Value(ctxKeyDeployment{}).(string); ok {
+		return d, "", nil
+	}
+
+	names := r.ModelFields
+	if len(names) == 0 {
+		names = []string{"model"}
+	}
+	for _, name := range names {
+		var v string
+		if json.Unmarshal(fields[name], &v) == nil && v != "" {
+			return v, name, nil
+		}
+	}
+	return "", "", nil
+}
+
+// ensureBodyModel writes modelID into the body's "model" field unless it was taken
+// from there, so nodes always find the model where the OpenAI API has it.
+func ensureBodyModel(body []byte, modelID, field string) ([]byte, error) {
+	if field == "model" {
+		return body, nil
+	}
+	return setBodyModel(body, modelID)
+}
@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestParseModelFields(t *testing.T) {
+	got, err := ParseModelFields(" model , model_name,,deployment ")
+	if err != nil || strings.Join(got, "|") != "model|model_name|deployment" {
+		t.Errorf("ParseModelFields = %q, %v", got, err)
+	}
+	if _, err := ParseModelFields(" , "); err == nil {
+		t.Error("ParseModelFields of no names succeeded")
+	}
+}
+
+func TestExtractModelAlternateFields(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		fields []string
+		body   string
+		want   string
+	}{
+		{"default field", nil, `{"model":"a"}`, "a"},
+		{"default ignores others", nil, `{"model_name":"a"}`, ""},
+		{"alternate field", []string{"model", "model_name"}, `{"model_name":"b"}`, "b"},
+		{"first set field wins", []string{"deployment", "model"}, `{"model":"a","deployment":"c"}`, "c"},
+		{"empty value skipped", []string{"model", "model_name"}, `{"model":"","model_name":"b"}`, "b"},
+		{"non-string value skipped", []string{"model_name", "model"}, `{"model_name":42,"model":"a"}`, "a"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, _ := newTestRouter(t)
+			r.ModelFields = tc.fields
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(tc.body))
+
+			modelID, body, err := r.extractModelAndBody(req)
+			if tc.want == "" {
+				if err == nil {
+					t.Fatalf("extracted %q, want a missing model error", modelID)
+				}
+				return
+			}
+			if err != nil || modelID != tc.want {
+				t.Fatalf("extractModelAndBody = %q, %v, want %q", modelID, err, tc.want)
+			}
+			// Nodes always find the model in "model".
+			var out struct{ Model string }
+			if err := json.Unmarshal(body, &out); err != nil || out.Model != tc.want {
+				t.Errorf("body model = %q (%v), want %q", out.Model, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAzurePaths(t *testing.T) {
+	var gotPath, gotAuth, gotKey, gotModel string
+	r, _, _ := newTestRouter(t)
+	h := AzurePaths(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotPath, gotAuth, gotKey = req.URL.Path, req.Header.Get("Authorization"), req.Header.Get("api-key")
+		gotModel, _, _ = r.extractModelAndBody(req)
+	}))
+
+	for _, tc := range []struct {
+		path     string
+		wantPath string
+	}{
+		{"/openai/deployments/gpt-x/chat/completions", "/v1/chat/completions"},
+		{"/openai/deployments/gpt-x/completions", "/v1/completions"},
+		{"/openai/deployments/gpt-x/embeddings/", "/v1/embeddings"},
+	} {
+		gotPath, gotModel = "", ""
+		req := httptest.NewRequest(http.MethodPost, tc.path+"?api-version=2024-06-01", strings.NewReader(`{"model":"ignored","messages":[]}`))
+		req.Header.Set("api-key", "sk-test")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if gotPath != tc.wantPath || gotModel != "gpt-x" {
+			t.Errorf("%s: served %s for model %q, want %s for gpt-x", tc.path, gotPath, gotModel, tc.wantPath)
+		}
+		if gotAuth != "Bearer sk-test" || gotKey != "" {
+			t.Errorf("%s: Authorization %q, api-key %q, want the key moved to Authorization", tc.path, gotAuth, gotKey)
+		}
+	}
+
+	for _, path := range []string{"/openai/deployments/gpt-x/images", "/openai/deployments//chat/completions"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`)))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", path, w.Code)
+		}
+	}
+}
+
+func TestAzurePathReachesNode(t *testing.T) {
+	var body string
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"choices":[]}`)
+	}))
+	t.Cleanup(node.Close)
+
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "a", url: node.URL, models: map[string]state.ModelState{"gpt-x": state.ModelReady}})
+	h := AzurePaths(http.HandlerFunc(r.HandleChatCompletions))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/openai/deployments/gpt-x/chat/completions", strings.NewReader(`{"messages":[]}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(body, `"model":"gpt-x"`) {
+		t.Errorf("node got body %s, want the deployment as model", body)
+	}
+}
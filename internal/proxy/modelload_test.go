@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// TestModelLoadAccess checks that any API key may start loads, limited by its model
+// and node ACLs like its requests.
+func TestModelLoadAccess(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		owner         string
+		models, nodes string
+		body          string
+		wantCode      int
+		wantLoad      string // node/model of the load command, "" = none
+	}{
+		{"admin", "admin", "*", "*", `{"model":"m","node_id":"b"}`, http.StatusAccepted, "b/m"},
+		{"regular key", "alice", "*", "*", `{"model":"m","node_id":"b"}`, http.StatusAccepted, "b/m"},
+		{"regular key picks node", "alice", "m", "a", `{"model":"m"}`, http.StatusAccepted, "a/m"},
+		{"model denied", "alice", "other", "*", `{"model":"m","node_id":"b"}`, http.StatusForbidden, ""},
+		{"model denied without node", "alice", "other", "*", `{"model":"m"}`, http.StatusForbidden, ""},
+		{"node denied", "alice", "*", "a", `{"model":"m","node_id":"b"}`, http.StatusForbidden, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			cmds := &fakeCommands{}
+			r.Commands = cmds
+			unloaded := map[string]state.ModelState{"m": state.ModelUnloaded}
+			addNode(c, testNode{id: "a", models: unloaded})
+			addNode(c, testNode{id: "b", models: unloaded})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/models/load", strings.NewReader(tc.body))
+			req = auth.WithAuthRecord(req, &policy.APIKeyRecord{ID: "k", Owner: tc.owner, AllowedModels: tc.models, AllowedNodes: tc.nodes})
+			w := httptest.NewRecorder()
+			r.HandleModelLoad(w, req)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("status = %d (%s), want %d", w.Code, strings.TrimSpace(w.Body.String()), tc.wantCode)
+			}
+			var loads []string
+			if tc.wantLoad != "" {
+				loads = []string{tc.wantLoad}
+			}
+			if strings.Join(cmds.loads, ",") != strings.Join(loads, ",") {
+				t.Errorf("load commands = %v, want %v", cmds.loads, loads)
+			}
+		})
+	}
+}
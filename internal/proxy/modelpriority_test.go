@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/metrics"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestModelPriorityWeight(t *testing.T) {
+	const gib = 1 << 30
+	// roomy has 6 GiB more available but is busy and slow; quick is idle and fast.
+	roomy := &state.NodeSnapshot{NodeID: "roomy", RAMTotalBytes: 64 * gib, RAMAvailBytes: 40 * gib, InflightRequests: 4}
+	quick := &state.NodeSnapshot{NodeID: "quick", RAMTotalBytes: 64 * gib, RAMAvailBytes: 34 * gib}
+	nodes := []*state.NodeSnapshot{roomy, quick}
+	lat := metrics.NewLatencyTracker(1)
+	lat.ObserveOK("roomy", 300*time.Millisecond)
+	lat.ObserveOK("quick", 50*time.Millisecond)
+
+	for _, tc := range []struct {
+		name     string
+		weight   float64
+		priority int
+		want     *state.NodeSnapshot
+	}{
+		{"neutral model", 1, 0, roomy},
+		{"important model", 1, 1, quick},
+		{"unimportant model", 1, -1, roomy},
+		{"weight off", 0, 1, roomy},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := policy.ModelPolicy{ModelID: "m", Priority: tc.priority}
+			o := scoreOpts{ModelPriorityWeight: tc.weight}
+			if got := pickBestByScore(nodes, lat, p, o); got != tc.want {
+				t.Errorf("picked %s, want %s", got.NodeID, tc.want.NodeID)
+			}
+		})
+	}
+
+	// A negative priority accepts load: with factor 0 only RAM counts.
+	low := policy.ModelPolicy{ModelID: "m", Priority: -1}
+	if got := scoreNode(roomy, lat, low, scoreOpts{ModelPriorityWeight: 1}); got != 40*gib {
+		t.Errorf("low-priority score of the busy node = %d, want its available RAM", got)
+	}
+
+	// Weight 0 leaves the scores as they are without a priority.
+	neutral := policy.ModelPolicy{ModelID: "m"}
+	for _, n := range nodes {
+		for _, prio := range []int{-2, 3} {
+			prioritized := policy.ModelPolicy{ModelID: "m", Priority: prio}
+			if a, b := scoreNode(n, lat, prioritized, scoreOpts{}), scoreNode(n, lat, neutral, scoreOpts{}); a != b {
+				t.Errorf("%s with priority %d and weight 0: score %d, want %d", n.NodeID, prio, a, b)
+			}
+		}
+	}
+}
@@ -13,6 +13,19 @@ import (
 
 type ModelsHandler struct {
 	Cluster *state.ClusterState
+
+	// Nodes with heartbeat older than this TTL are ignored for capabilities (0 = all nodes).
+	NodeOfflineTTL time.Duration
+
+	// NormalizeModelNames lists model ids that differ only in case/whitespace once.
+	NormalizeModelNames bool
+
+	// HideLoadingModels omits models that are only LOADING (not READY anywhere, no node
+	// with it available on disk) from the list.
+	HideLoadingModels bool
+
+	// StaticUpstreams are listed as ready (see Router.StaticUpstreams).
+	StaticUpstreams map[string]string
 }
 
 func NewModelsHandler(cluster *state.ClusterState) *ModelsHandler {
@@ -29,35 +42,104 @@ type openAIModel struct {
 	Object  string `json:"object"`
 	OwnedBy string `json:"owned_by"`
 	Created int64  `json:"created"`
+	// Status is "ready" (READY on a node), "loading" (only being loaded, requests wait
+	// for the load) or "available" (loaded on demand).
+	Status string `json:"status"`
+}
+
+// Model list status values, in increasing order of usability.
+const (
+	modelStatusLoading   = "loading"
+	modelStatusAvailable = "available"
+	modelStatusReady     = "ready"
+)
+
+// modelStatusRank orders the list status of the replicas of a model.
+func modelStatusRank(s string) int {
+	switch s {
+	case modelStatusReady:
+		return 2
+	case modelStatusAvailable:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// listStatus maps the residency state of one replica to its model list status.
+func listStatus(st state.ModelState) string {
+	switch st {
+	case state.ModelReady:
+		return modelStatusReady
+	case state.ModelLoading:
+		return modelStatusLoading
+	default:
+		return modelStatusAvailable
+	}
 }
 
+// HandleModels lists the models known to the cluster. With an API key only models the
+// key may use on nodes it may reach are listed; anonymous requests see the full list.
 func (h *ModelsHandler) HandleModels(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.NotFound(w, r)
+		methodNotAllowed(w, http.MethodGet)
 		return
 	}
 
 	authRecord := auth.GetAuthRecord(r)
 
 	// Aggregate model IDs across all nodes.
+	// With normalization, case/whitespace variants collapse into one entry carrying the
+	// lexically smallest variant, the same id placement resolves such requests to.
 	snap := h.Cluster.Snapshot()
-	set := map[string]struct{}{}
+	set := map[string]string{}
+	status := map[string]string{}
 
 	for _, n := range snap {
 		if authRecord != nil && !auth.CheckACL(authRecord.AllowedNodes, n.NodeID) {
 			continue
 		}
-		for modelID := range n.Models {
-			if authRecord != nil && !auth.CheckACL(authRecord.AllowedModels, modelID) {
+		for modelID, m := range n.Models {
+			key := modelID
+			allowed := auth.CheckACL
+			if h.NormalizeModelNames {
+				key = state.NormalizeModelID(modelID)
+				allowed = auth.CheckACLFold
+			}
+			if authRecord != nil && !allowed(authRecord.AllowedModels, modelID) {
 				continue
 			}
-			set[modelID] = struct{}{}
+			if cur, ok := set[key]; !ok || modelID < cur {
+				set[key] = modelID
+			}
+			if st := listStatus(m.State); modelStatusRank(st) >= modelStatusRank(status[key]) {
+				status[key] = st
+			}
 		}
 	}
 
+	for modelID := range h.StaticUpstreams {
+		key := modelID
+		if h.NormalizeModelNames {
+			key = state.NormalizeModelID(modelID)
+		}
+		if authRecord != nil && (!auth.CheckACL(authRecord.AllowedModels, modelID) || !auth.CheckACL(authRecord.AllowedNodes, staticNodePrefix+modelID)) {
+			continue
+		}
+		if _, ok := set[key]; !ok {
+			set[key] = modelID
+		}
+		status[key] = modelStatusReady
+	}
+
 	modelIDs := make([]string, 0, len(set))
-	for id := range set {
+	statusOf := make(map[string]string, len(set))
+	for key, id := range set {
+		if h.HideLoadingModels && status[key] == modelStatusLoading {
+			continue
+		}
 		modelIDs = append(modelIDs, id)
+		statusOf[id] = status[key]
 	}
 	sort.Slice(modelIDs, func(i, j int) bool {
 		return strings.ToLower(modelIDs[i]) < strings.ToLower(modelIDs[j])
@@ -75,6 +157,7 @@ func (h *ModelsHandler) HandleModels(w http.ResponseWriter, r *http.Request) {
 			Object:  "model",
 			OwnedBy: "llm-router",
 			Created: now,
+			Status:  statusOf[id],
 		})
 	}
 
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// listModels returns model id -> list status of GET /v1/models.
+func listModels(t *testing.T, h *ModelsHandler, key *policy.APIKeyRecord) map[string]string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	if key != nil {
+		req = auth.WithAuthRecord(req, key)
+	}
+	rec := httptest.NewRecorder()
+	h.HandleModels(rec, req)
+	var out openAIModelsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	models := map[string]string{}
+	for _, m := range out.Data {
+		models[m.ID] = m.Status
+	}
+	return models
+}
+
+func TestModelsCollapsesCaseVariants(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"llama3": state.ModelReady}})
+	addNode(c, testNode{id: "b", models: map[string]state.ModelState{"Llama3 ": state.ModelLoading, "qwen": state.ModelUnloaded}})
+	h := NewModelsHandler(c)
+
+	if got := listModels(t, h, nil); len(got) != 3 {
+		t.Errorf("without normalization listed %v, want all 3 ids", got)
+	}
+
+	h.NormalizeModelNames = true
+	r.NormalizeModelNames = true
+	got := listModels(t, h, nil)
+	if len(got) != 2 || got["Llama3 "] != modelStatusReady || got["qwen"] != modelStatusAvailable {
+		t.Fatalf("listed %v, want Llama3 (ready on a) and qwen", got)
+	}
+	// The listed id is the one placement resolves the variants to.
+	if id := r.resolveModelID("LLAMA3"); id != "Llama3 " {
+		t.Errorf("LLAMA3 resolves to %q, want the listed Llama3", id)
+	}
+
+	if got := listModels(t, h, &policy.APIKeyRecord{AllowedModels: "LLAMA3"}); len(got) != 1 {
+		t.Errorf("with a folded model ACL listed %v, want the one llama3 entry", got)
+	}
+}
+
+func TestCapabilitiesFoldsModelID(t *testing.T) {
+	_, c, _ := newTestRouter(t)
+	c.UpsertNodeHello("a", "test", "", "http://a", "", state.DataPlaneTLS{}, nil)
+	c.UpdateNodeStatus("a", 64<<30, 32<<30, "", 0, 0, 0, map[string]state.ModelResidency{
+		"llama3": {ModelID: "llama3", State: state.ModelReady, Capabilities: []string{"chat"}},
+	})
+	h := NewModelsHandler(c)
+
+	get := func() int {
+		rec := httptest.NewRecorder()
+		h.HandleModelCapabilities(rec, httptest.NewRequest(http.MethodGet, "/v1/models/Llama3/capabilities", nil))
+		return rec.Code
+	}
+	if code := get(); code != http.StatusNotFound {
+		t.Errorf("without normalization = %d, want 404", code)
+	}
+	h.NormalizeModelNames = true
+	if code := get(); code != http.StatusOK {
+		t.Errorf("with normalization = %d, want 200", code)
+	}
+}
+
+func TestModelsRestrictedKey(t *testing.T) {
+	c := state.NewClusterState()
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m1": state.ModelReady, "m2": state.ModelLoading}})
+	addNode(c, testNode{id: "b", models: map[string]state.ModelState{"m2": state.ModelReady, "m3": state.ModelUnloaded}})
+	h := NewModelsHandler(c)
+	h.StaticUpstreams = map[string]string{"ext": "https://api.example.com/v1"}
+
+	for _, tc := range []struct {
+		name string
+		key  *policy.APIKeyRecord
+		want map[string]string
+	}{
+		{"anonymous", nil, map[string]string{"m1": "ready", "m2": "ready", "m3": "available", "ext": "ready"}},
+		{"unrestricted key", &policy.APIKeyRecord{AllowedModels: "*", AllowedNodes: "*"},
+			map[string]string{"m1": "ready", "m2": "ready", "m3": "available", "ext": "ready"}},
+		{"allowed models", &policy.APIKeyRecord{AllowedModels: "m1, m3"}, map[string]string{"m1": "ready", "m3": "available"}},
+		// Only what the permitted node reports: m2 is loading there, m3 not listed.
+		{"allowed nodes", &policy.APIKeyRecord{AllowedNodes: "a"}, map[string]string{"m1": "ready", "m2": "loading"}},
+		{"both", &policy.APIKeyRecord{AllowedModels: "m2,m3", AllowedNodes: "b"}, map[string]string{"m2": "ready", "m3": "available"}},
+		{"static upstream by its node", &policy.APIKeyRecord{AllowedModels: "ext", AllowedNodes: staticNodePrefix + "ext"}, map[string]string{"ext": "ready"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := listModels(t, h, tc.key); !maps.Equal(got, tc.want) {
+				t.Errorf("models = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
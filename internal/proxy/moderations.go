@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// HandleModerations proxies POST /v1/moderations to the selected node.
+// The "model" field is optional in the OpenAI API; if it is missing, DefaultModerationModel
+// is used and written into the body so the node knows which model to run.
+func (r *Router) HandleModerations(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	hint, ok := r.admitEarly(w, req)
+	if !ok {
+		return
+	}
+
+	done := r.guardBody(w, req)
+	body, err := io.ReadAll(req.Body)
+	done()
+	if err != nil {
+		writeBodyError(w, fmt.Errorf("read body: %w", err))
+		return
+	}
+	_ = req.Body.Close()
+
+	modelID, field, err := r.modelOf(req, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if modelID == "" {
+		if r.DefaultModerationModel == "" {
+			http.Error(w, "missing model field (no default moderation model configured)", http.StatusBadRequest)
+			return
+		}
+		modelID = r.DefaultModerationModel
+	}
+	if body, err = ensureBodyModel(body, modelID, field); err != nil {
+		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	modelID, body = r.canonicalizeModel(modelID, body)
+	if !r.checkModelHint(w, hint, modelID) {
+		return
+	}
+
+	res, err := r.pickNodeForModel(req, modelID)
+	if err != nil {
+		r.writePlacementError(w, res, err)
+		return
+	}
+	if res.Mode == pickWait {
+		if res, err = r.awaitLoad(req, modelID, res); err != nil {
+			r.writeWaitError(w, modelID, err)
+			return
+		}
+	}
+	node := res.node()
+
+	target, err := url.Parse(node.DataPlaneURL)
+	if err != nil {
+		http.Error(w, "invalid node data plane url", http.StatusBadGateway)
+		return
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	req = withRouteInfo(req, modelID, res)
+	defer r.trackInflight(node.NodeID, body)()
+	r.reverseProxy(node.NodeID, target).ServeHTTP(w, req)
+}
@@ -0,0 +1,86 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// resolveModelID maps the requested model id to the id reported by the nodes.
+// Without normalization the id is returned unchanged. With normalization an exact match
+// wins; otherwise the (lexically smallest) node-reported id that matches after folding
+// case and whitespace is used, so both sides of the comparison are normalized.
+func (r *Router) resolveModelID(modelID string) string {
+	if !r.NormalizeModelNames {
+		return modelID
+	}
+
+	want := state.NormalizeModelID(modelID)
+	var match string
+	for _, n := range r.Cluster.Snapshot() {
+		for id := range n.Models {
+			if id == modelID {
+				return id
+			}
+			if state.NormalizeModelID(id) == want && (match == "" || id < match) {
+				match = id
+			}
+		}
+	}
+	if match == "" {
+		return strings.TrimSpace(modelID)
+	}
+	return match
+}
+
+// canonicalizeModel resolves the requested model and, if the canonical id differs,
+// rewrites the "model" field of the body so the node receives an id it knows.
+func (r *Router) canonicalizeModel(modelID string, body []byte) (string, []byte) {
+	canonical := r.resolveModelID(modelID)
+	if canonical == modelID {
+		return modelID, body
+	}
+
+	out, err := setBodyModel(body, canonical)
+	if err != nil {
+		return modelID, body
+	}
+	return canonical, out
+}
+
+// setBodyModel sets the "model" field of a JSON object body, keeping all other fields.
+func setBodyModel(body []byte, modelID string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		fields = map[string]json.RawMessage{}
+	}
+	v, err := json.Marshal(modelID)
+	if err != nil {
+		return nil, err
+	}
+	fields["model"] = v
+	return json.Marshal(fields)
+}
+
+// modelAllowed checks a model ACL, honoring model name normalization.
+func (r *Router) modelAllowed(allowed, modelID string) bool {
+	if r.NormalizeModelNames {
+		return auth.CheckACLFold(allowed, modelID)
+	}
+	return auth.CheckACL(allowed, modelID)
+}
+
+// getPolicy loads the model policy, honoring model name normalization.
+func (r *Router) getPolicy(ctx context.Context, modelID string) (policy.ModelPolicy, bool, error) {
+	if r.NormalizeModelNames {
+		return r.Policies.GetPolicyFold(ctx, modelID)
+	}
+	return r.Policies.GetPolicy(ctx, modelID)
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestResolveModelID(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"llama-3.1-8b": state.ModelReady, "Qwen-7B": state.ModelUnloaded}})
+
+	for _, tc := range []struct {
+		in, off, on string
+	}{
+		{"llama-3.1-8b", "llama-3.1-8b", "llama-3.1-8b"},
+		{"Llama-3.1-8B", "Llama-3.1-8B", "llama-3.1-8b"},
+		{" qwen-7b ", " qwen-7b ", "Qwen-7B"},
+		{" unknown ", " unknown ", "unknown"},
+	} {
+		r.NormalizeModelNames = false
+		if got := r.resolveModelID(tc.in); got != tc.off {
+			t.Errorf("off: resolveModelID(%q) = %q, want %q", tc.in, got, tc.off)
+		}
+		r.NormalizeModelNames = true
+		if got := r.resolveModelID(tc.in); got != tc.on {
+			t.Errorf("on: resolveModelID(%q) = %q, want %q", tc.in, got, tc.on)
+		}
+	}
+}
+
+func TestCanonicalizeModelRewritesBody(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.NormalizeModelNames = true
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"llama-3.1-8b": state.ModelReady}})
+
+	modelID, body := r.canonicalizeModel("Llama-3.1-8B", []byte(`{"model":"Llama-3.1-8B","temperature":0.2}`))
+	if modelID != "llama-3.1-8b" {
+		t.Fatalf("model = %q, want llama-3.1-8b", modelID)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["model"] != "llama-3.1-8b" || fields["temperature"] != 0.2 {
+		t.Errorf("body = %s, want the node's model id and the other fields kept", body)
+	}
+}
+
+func TestNormalizedPlacement(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		normalize bool
+		key       *policy.APIKeyRecord
+		mode      pickMode
+		denied    bool
+	}{
+		{name: "off: requested id not reported, loaded cold", mode: pickCold},
+		{name: "on: routed to the ready node", normalize: true, mode: pickDirect},
+		{name: "on: ACL folded", normalize: true, key: &policy.APIKeyRecord{AllowedModels: "LLAMA-3.1-8B"}, mode: pickDirect},
+		{name: "off: ACL exact", key: &policy.APIKeyRecord{AllowedModels: "llama-3.1-8b"}, denied: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.NormalizeModelNames = tc.normalize
+			addNode(c, testNode{id: "a", models: map[string]state.ModelState{"llama-3.1-8b": state.ModelReady}})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			if tc.key != nil {
+				req = auth.WithAuthRecord(req, tc.key)
+			}
+			res, err := r.pickNodeForModel(req, r.resolveModelID("Llama-3.1-8B"))
+			if tc.denied {
+				if res.Reason != ReasonACLDenied {
+					t.Fatalf("reason = %s (%v), want %s", res.Reason, err, ReasonACLDenied)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Mode != tc.mode {
+				t.Errorf("mode = %s, want %s", res.Mode, tc.mode)
+			}
+		})
+	}
+}
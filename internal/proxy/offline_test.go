@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// takeOffline makes a node miss its heartbeats, keeping its last reported models.
+func takeOffline(t *testing.T, c *state.ClusterState, nodeID string) {
+	t.Helper()
+	n, ok := c.Node(nodeID)
+	if !ok {
+		t.Fatalf("node %s unknown", nodeID)
+	}
+	n.LastHeartbeat = time.Now().Add(-time.Hour)
+	c.RestoreNode(*n)
+}
+
+func TestStaleReadyOnOfflineNode(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelReady}})
+	addNode(c, testNode{id: "b"})
+	takeOffline(t, c, "a")
+
+	res, err := r.pickNodeForModel(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil), "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.NodeID != "b" || res.Mode != pickCold {
+		t.Errorf("placed %s on %s, want a cold load on b", res.Mode, res.NodeID)
+	}
+
+	if err := r.waitModelReady("m", "a", time.Second); !errors.Is(err, errLoaderOffline) {
+		t.Errorf("waiting on the offline node = %v, want %v", err, errLoaderOffline)
+	}
+}
+
+func TestOfflineLoaderReleased(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelLoading}})
+	addNode(c, testNode{id: "b"})
+	g := r.getGate("m")
+	g.loadingNode, g.loadingSince = "a", time.Now()
+	takeOffline(t, c, "a")
+
+	res, err := r.pickNodeForModel(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil), "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.NodeID != "b" || res.Mode != pickCold {
+		t.Errorf("placed %s on %s, want a cold load on b", res.Mode, res.NodeID)
+	}
+	if g.loadingNode != "b" {
+		t.Errorf("loader = %q, want b", g.loadingNode)
+	}
+}
+
+func TestWaitEndsWhenLoaderGoesOffline(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelLoading}})
+	g := r.getGate("m")
+	g.loadingNode, g.loadingSince = "a", time.Now()
+
+	done := make(chan error, 1)
+	go func() { done <- r.waitModelReady("m", "a", 10*time.Second) }()
+	waitFor(t, func() bool { return gateWaiting(r, "m") == 1 })
+	takeOffline(t, c, "a")
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errLoaderOffline) {
+			t.Fatalf("wait = %v, want %v", err, errLoaderOffline)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait did not end after the loader went offline")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.loadingNode != "" {
+		t.Errorf("loader = %q, want it released", g.loadingNode)
+	}
+}
@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"log"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// overloadPenaltyBytes is subtracted from the score of an overloaded node: more than
+// any RAM difference, so it only wins when every candidate is overloaded.
+const overloadPenaltyBytes = 1 << 40 // 1 TiB
+
+// overloadedNodes returns the nodes among nodes whose load (EWMA latency in ms times
+// inflight requests, weighted like in scoring if inflight lists the node) reached
+// OverloadThreshold. A node stays overloaded until its load drops below half the
+// threshold, so placement does not flap around the threshold.
+// It returns nil with OverloadThreshold 0 or without latency data.
+func (r *Router) overloadedNodes(nodes []*state.NodeSnapshot, inflight map[string]float64) map[string]bool {
+	if r.OverloadThreshold <= 0 || r.Latency == nil {
+		return nil
+	}
+	r.overloadMu.Lock()
+	defer r.overloadMu.Unlock()
+	if r.overloaded == nil {
+		r.overloaded = map[string]bool{}
+	}
+
+	var out map[string]bool
+	for _, n := range nodes {
+		var load float64
+		if l, ok := r.Latency.Get(n.NodeID); ok {
+			load = l.EWMAms * scoreOpts{Inflight: inflight}.inflightOf(n)
+		}
+		was := r.overloaded[n.NodeID]
+		over := load >= r.OverloadThreshold || (was && load >= r.OverloadThreshold/2)
+		if over != was {
+			if over {
+				log.Printf("placement: node %s overloaded (latency x inflight %.0f >= %.0f), deprioritized", n.NodeID, load, r.OverloadThreshold)
+				r.overloaded[n.NodeID] = true
+			} else {
+				log.Printf("placement: node %s recovered from overload (latency x inflight %.0f)", n.NodeID, load)
+				delete(r.overloaded, n.NodeID)
+			}
+		}
+		if over {
+			if out == nil {
+				out = map[string]bool{}
+			}
+			out[n.NodeID] = true
+		}
+	}
+	return out
+}
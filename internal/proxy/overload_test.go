@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/metrics"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestOverloadedNodesHysteresis(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.OverloadThreshold = 1000
+	r.Latency = metrics.NewLatencyTracker(1)
+	r.Latency.ObserveOK("a", 100*time.Millisecond)
+
+	for _, step := range []struct {
+		inflight uint32
+		want     bool
+	}{
+		{9, false}, // 900
+		{10, true}, // 1000 reaches the threshold
+		{6, true},  // 600 stays above half of it
+		{5, true},  // 500 is not below half yet
+		{4, false}, // 400 recovered
+		{9, false}, // 900 is below the threshold again
+	} {
+		addNode(c, testNode{id: "a", inflight: step.inflight})
+		got := r.overloadedNodes(c.Snapshot(), nil)["a"]
+		if got != step.want {
+			t.Fatalf("inflight %d: overloaded = %v, want %v", step.inflight, got, step.want)
+		}
+	}
+}
+
+func TestOverloadedNodesOff(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "a", inflight: 100})
+
+	r.Latency = metrics.NewLatencyTracker(1)
+	r.Latency.ObserveOK("a", time.Second)
+	if got := r.overloadedNodes(c.Snapshot(), nil); got != nil {
+		t.Errorf("threshold 0: overloaded = %v, want none", got)
+	}
+
+	r.OverloadThreshold = 1000
+	r.Latency = nil
+	if got := r.overloadedNodes(c.Snapshot(), nil); got != nil {
+		t.Errorf("without latency tracker: overloaded = %v, want none", got)
+	}
+
+	// A node without latency data has no load to compare.
+	r.Latency = metrics.NewLatencyTracker(1)
+	if got := r.overloadedNodes(c.Snapshot(), nil); got != nil {
+		t.Errorf("without latency data: overloaded = %v, want none", got)
+	}
+}
+
+func TestOverloadDeprioritizesNode(t *testing.T) {
+	const gib = 1 << 30
+	ready := map[string]state.ModelState{"m": state.ModelReady}
+	for _, tc := range []struct {
+		name string
+		latB time.Duration
+		want string
+	}{
+		// a has far more RAM free, but 2 s x 10 requests overloads it.
+		{"other node preferred", 10 * time.Millisecond, "b"},
+		// All candidates overloaded: the best of them is used.
+		{"all overloaded", 2 * time.Second, "a"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.OverloadThreshold = 20000
+			r.Latency = metrics.NewLatencyTracker(1)
+			r.Latency.ObserveOK("a", 2*time.Second)
+			r.Latency.ObserveOK("b", tc.latB)
+			addNode(c, testNode{id: "a", avail: 60 * gib, inflight: 10, models: ready})
+			addNode(c, testNode{id: "b", avail: 4 * gib, inflight: 10, models: ready})
+
+			res, err := r.pickNodeForModel(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil), "m")
+			if err != nil || res.NodeID != tc.want {
+				t.Errorf("placed on %s (%v), want %s", res.NodeID, err, tc.want)
+			}
+		})
+	}
+}
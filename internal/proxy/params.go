@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+// applyParamOverrides applies the parameter defaults and ceilings of the model policy to a
+// chat or completion body. Bodies of models without overrides, and bodies that need no
+// change, are returned unchanged (byte for byte).
+func (r *Router) applyParamOverrides(modelID string, body []byte) []byte {
+	if r.Policies == nil {
+		return body
+	}
+	pol, ok, _ := r.getPolicy(context.Background(), modelID)
+	if !ok {
+		return body
+	}
+	o, err := pol.ParamOverrides()
+	if err != nil {
+		log.Printf("WARNING: policy %s: %v", pol.ModelID, err)
+		return body
+	}
+	if o.Empty() {
+		return body
+	}
+
+	out, err := overrideParams(body, o)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// overrideParams sets missing (or null) default keys and clamps numeric values above their
+// ceiling. All other fields keep their values.
+func overrideParams(body []byte, o policy.ParamOverrides) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for k, v := range o.Defaults {
+		if cur, ok := fields[k]; !ok || bytes.Equal(bytes.TrimSpace(cur), []byte("null")) {
+			fields[k] = v
+			changed = true
+		}
+	}
+	for k, limit := range o.Max {
+		var n float64
+		if json.Unmarshal(fields[k], &n) != nil || n <= limit {
+			continue
+		}
+		v, err := json.Marshal(limit)
+		if err != nil {
+			return nil, err
+		}
+		fields[k] = v
+		changed = true
+	}
+
+	if !changed {
+		return body, nil
+	}
+	return json.Marshal(fields)
+}
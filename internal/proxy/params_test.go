@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+func TestApplyParamOverrides(t *testing.T) {
+	r, _, s := newTestRouter(t)
+	upsertPolicy(t, s, policy.ModelPolicy{
+		ModelID:       "m",
+		ParamDefaults: `{"temperature":0.2,"max_tokens":256}`,
+		ParamMax:      `{"max_tokens":512,"top_k":40}`,
+	})
+	upsertPolicy(t, s, policy.ModelPolicy{ModelID: "plain"})
+
+	for _, tc := range []struct {
+		name  string
+		model string
+		body  string
+		want  map[string]any // nil: body unchanged byte for byte
+	}{
+		{
+			name: "defaults fill missing keys", model: "m",
+			body: `{"model":"m","messages":[]}`,
+			want: map[string]any{"model": "m", "messages": []any{}, "temperature": 0.2, "max_tokens": 256.0},
+		},
+		{
+			name: "null counts as missing", model: "m",
+			body: `{"model":"m","temperature":null,"max_tokens":100}`,
+			want: map[string]any{"model": "m", "temperature": 0.2, "max_tokens": 100.0},
+		},
+		{
+			name: "values above the limit are clamped", model: "m",
+			body: `{"model":"m","temperature":0.9,"max_tokens":4096,"top_k":100}`,
+			want: map[string]any{"model": "m", "temperature": 0.9, "max_tokens": 512.0, "top_k": 40.0},
+		},
+		{
+			name: "unrelated fields untouched", model: "m",
+			body: `{"model":"m","temperature":1,"max_tokens":10,"top_k":"many","tools":[{"type":"function","function":{"name":"f"}}],"stream":true}`,
+			want: map[string]any{
+				"model": "m", "temperature": 1.0, "max_tokens": 10.0, "top_k": "many", "stream": true,
+				"tools": []any{map[string]any{"type": "function", "function": map[string]any{"name": "f"}}},
+			},
+		},
+		{name: "nothing to change", model: "m", body: `{"model":"m", "temperature":0.5,"max_tokens":10}`},
+		{name: "policy without overrides", model: "plain", body: `{"model":"plain","max_tokens":99999}`},
+		{name: "no policy", model: "other", body: `{"model":"other"}`},
+		{name: "invalid body", model: "m", body: `not json`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := r.applyParamOverrides(tc.model, []byte(tc.body))
+			if tc.want == nil {
+				if !bytes.Equal(got, []byte(tc.body)) {
+					t.Errorf("body = %s, want it unchanged", got)
+				}
+				return
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(got, &fields); err != nil {
+				t.Fatalf("body %s: %v", got, err)
+			}
+			gotJSON, _ := json.Marshal(fields)
+			wantJSON, _ := json.Marshal(tc.want)
+			if !bytes.Equal(gotJSON, wantJSON) {
+				t.Errorf("body = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
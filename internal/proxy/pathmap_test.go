@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestUpstreamPath(t *testing.T) {
+	paths := map[string]string{"chat": "/generate", "chat:big": "/big/generate", "embeddings:e5": "/embed"}
+	for _, tc := range []struct {
+		path, model, want string
+	}{
+		{"/v1/chat/completions", "m", "/generate"},
+		{"/v1/chat/completions", "big", "/big/generate"},
+		{"/v1/chat/completions", "", "/generate"},
+		{"/v1/embeddings", "e5", "/embed"},
+		{"/v1/embeddings", "other", "/v1/embeddings"},
+		{"/v1/completions", "m", "/v1/completions"},
+		{"/health", "m", "/health"},
+	} {
+		if got := upstreamPath(paths, tc.path, tc.model); got != tc.want {
+			t.Errorf("upstreamPath(%s, %q) = %s, want %s", tc.path, tc.model, got, tc.want)
+		}
+	}
+	if got := upstreamPath(nil, "/v1/chat/completions", "m"); got != "/v1/chat/completions" {
+		t.Errorf("without a map = %s, want the path unchanged", got)
+	}
+}
+
+func TestNodeEndpointPaths(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		served = map[string][]string{}
+	)
+	backend := func(id string) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			served[id] = append(served[id], req.URL.Path)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	r, c, _ := newTestRouter(t)
+	ready := map[string]state.ModelState{"m": state.ModelReady, "e5": state.ModelReady}
+	// A vLLM-style node behind a path prefix that serves chat at /generate and one
+	// embedding model at /embed; a llama.cpp node with the default paths.
+	vllm := backend("vllm")
+	c.UpsertNodeHello("vllm", "test", "", vllm.URL+"/prefix", "", state.DataPlaneTLS{},
+		map[string]string{"chat": "/generate", "embeddings:e5": "/embed"})
+	setModels(c, testNode{id: "vllm", models: ready})
+
+	for _, tc := range []struct {
+		handler http.HandlerFunc
+		path    string
+		model   string
+		want    string
+	}{
+		{r.HandleChatCompletions, "/v1/chat/completions", "m", "/prefix/generate"},
+		{r.HandleEmbeddings, "/v1/embeddings", "e5", "/prefix/embed"},
+		{r.HandleCompletions, "/v1/completions", "m", "/prefix/v1/completions"},
+	} {
+		w := httptest.NewRecorder()
+		tc.handler(w, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(`{"model":"`+tc.model+`","input":"x","prompt":"x"}`)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tc.path, w.Code, w.Body)
+		}
+		mu.Lock()
+		got := served["vllm"]
+		served["vllm"] = nil
+		mu.Unlock()
+		if len(got) != 1 || got[0] != tc.want {
+			t.Errorf("%s for %s: node served %q, want %s", tc.path, tc.model, got, tc.want)
+		}
+	}
+
+	// The same router keeps the identity mapping for a node without a map.
+	llama := backend("llama")
+	addNode(c, testNode{id: "llama", url: llama.URL, models: map[string]state.ModelState{"only-llama": state.ModelReady}})
+	w := httptest.NewRecorder()
+	r.HandleChatCompletions(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"only-llama"}`)))
+	if got := served["llama"]; w.Code != http.StatusOK || len(got) != 1 || got[0] != "/v1/chat/completions" {
+		t.Errorf("default node: status %d, served %q, want /v1/chat/completions", w.Code, got)
+	}
+}
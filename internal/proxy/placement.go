@@ -3,35 +3,150 @@ package proxy
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
 	"github.com/mcules/llm-router/internal/state"
 )
 
-// PlacementResult describes the outcome of a placement decision.
+// PlacementReason is the machine-readable outcome of a placement decision.
+type PlacementReason string
+
+const (
+	ReasonReadyDirect  PlacementReason = "ready-direct"       // model READY on the picked node
+	ReasonLoaderWait   PlacementReason = "loader-wait"        // wait for a load in progress
+	ReasonColdLoad     PlacementReason = "cold-load-assigned" // the request loads the model
+	ReasonACLDenied    PlacementReason = "acl-denied"         // the API key may not use the model
+	ReasonOOMExcluded  PlacementReason = "oom-excluded-all"   // no node has the policy's RAM available
+	ReasonNoNodes      PlacementReason = "no-nodes"           // no online, eligible node
+	ReasonNotReady     PlacementReason = "not-ready"          // ready-only placement without READY node
+	ReasonLoadFailed   PlacementReason = "load-failed"        // the model failed on every node having it
+	ReasonStatusStale  PlacementReason = "status-stale"       // cold loads refused, node status stale
+	ReasonReserveLimit PlacementReason = "reserve-exhausted"  // every cold load would break the reserve
+	ReasonUnroutable   PlacementReason = "ready-unroutable"   // READY only on nodes without data plane URL
+	ReasonMaxNodes     PlacementReason = "max-nodes"          // the model is on its policy's maximum of nodes
+	ReasonStatic       PlacementReason = "static-upstream"    // no node has the model READY, static upstream
+)
+
+// PlacementResult describes the outcome of a placement decision. Reason is set for
+// failed placements too.
 type PlacementResult struct {
 	NodeID       string
 	DataPlaneURL string
 	Mode         pickMode
+	Reason       PlacementReason
 }
 
+func (p PlacementResult) node() pickedNode {
+	return pickedNode{NodeID: p.NodeID, DataPlaneURL: p.DataPlaneURL}
+}
+
+// placed returns the result of a successful placement on n and records it in the
+// decision (if collected).
+func placed(dec *placementDecision, n *state.NodeSnapshot, mode pickMode, reason PlacementReason, detail string) (PlacementResult, error) {
+	if dec != nil {
+		dec.Node = n.NodeID
+	}
+	dec.set(mode, reason, detail)
+	return PlacementResult{NodeID: n.NodeID, DataPlaneURL: n.DataPlaneURL, Mode: mode, Reason: reason}, nil
+}
+
+// refused returns a failed placement and records it in the decision (if collected).
+func refused(dec *placementDecision, reason PlacementReason, detail string, err error) (PlacementResult, error) {
+	dec.set(pickDirect, reason, detail)
+	return PlacementResult{Mode: pickDirect, Reason: reason}, err
+}
+
+// placementStatus is the HTTP status of a failed placement.
+func placementStatus(reason PlacementReason) int {
+	if reason == ReasonACLDenied {
+		return http.StatusForbidden
+	}
+	return http.StatusServiceUnavailable
+}
+
+// writePlacementError answers a request whose placement failed.
+func (r *Router) writePlacementError(w http.ResponseWriter, res PlacementResult, err error) {
+	if r.ExposeRoutingHeaders {
+		w.Header().Set("X-Route-Reason", string(res.Reason))
+	}
+	http.Error(w, err.Error(), placementStatus(res.Reason))
+}
+
+// errNoReadyNode is returned by ready-only placement when no node has the model loaded.
+var errNoReadyNode = errors.New("model is not loaded on any node")
+
 // pickNodeForModel is the high-level placement entry point.
 // It is intentionally kept small and deterministic.
-func (r *Router) pickNodeForModel(req *http.Request, modelID string) (pickedNode, pickMode, error) {
+func (r *Router) pickNodeForModel(req *http.Request, modelID string) (PlacementResult, error) {
+	res, err := r.pickVerified(req, modelID, false)
+	r.observePlacement(modelID, res.Mode, err)
+	return res, err
+}
+
+// pickReadyNodeForModel only considers nodes that already report the model READY.
+// It never assigns a loader or waits for one.
+func (r *Router) pickReadyNodeForModel(req *http.Request, modelID string) (PlacementResult, error) {
+	res, err := r.pickVerified(req, modelID, true)
+	r.observePlacement(modelID, res.Mode, err)
+	return res, err
+}
+
+// observePlacement counts successful placements per model and mode.
+func (r *Router) observePlacement(modelID string, mode pickMode, err error) {
+	if r.Placement == nil || err != nil {
+		return
+	}
+	r.Placement.ObservePlacement(modelID, mode.String())
+}
+
+func (r *Router) pickNode(req *http.Request, modelID string, readyOnly bool) (PlacementResult, error) {
 	now := time.Now()
+	opts := scoreOpts{
+		Priority:            requestPriority(req),
+		ModelPriorityWeight: r.ModelPriorityWeight,
+		PreferLeastModels:   r.PreferLeastModels,
+		GenSpeedWeight:      r.GenSpeedWeight,
+		Zone:                r.preferredZone(req),
+		CrossZonePenalty:    r.CrossZonePenalty,
+	}
+	dec := decisionFrom(req)
 
 	// 0) ACL Check
 	authRecord := auth.GetAuthRecord(req)
 	if authRecord != nil {
-		if !auth.CheckACL(authRecord.AllowedModels, modelID) {
-			return pickedNode{}, pickDirect, errors.New("access to model denied by ACL")
+		if !r.modelAllowed(authRecord.AllowedModels, modelID) {
+			return refused(dec, ReasonACLDenied, "model denied by ACL", errors.New("access to model denied by ACL"))
 		}
 	}
 
-	// Only consider online nodes.
+	// Only consider online nodes: models reported by offline nodes are stale
+	// (e.g. crashed agent) and must not count as READY or loading.
 	snap := r.Cluster.SnapshotOnline(now, r.NodeOfflineTTL)
+	if r.ExcludeUnknownRAM {
+		known := snap[:0]
+		for _, n := range snap {
+			if n.CapacityKnown() {
+				known = append(known, n)
+			}
+		}
+		snap = known
+	}
+	online := snap
+
+	// Nodes that failed a ready check for this request (see pickVerified).
+	if ex := excludedNodes(req); len(ex) > 0 {
+		kept := make([]*state.NodeSnapshot, 0, len(snap))
+		for _, n := range snap {
+			if !ex[n.NodeID] {
+				kept = append(kept, n)
+			}
+		}
+		snap = kept
+	}
 
 	// Filter nodes by ACL
 	if authRecord != nil {
@@ -43,59 +158,206 @@ func (r *Router) pickNodeForModel(req *http.Request, modelID string) (pickedNode
 		}
 		snap = filtered
 	}
+	opts.Inflight = r.weightedInflight(snap)
+	opts.Overloaded = r.overloadedNodes(snap, opts.Inflight)
 
 	// 1) If any node reports READY for this model, route to the best one among them.
 	var readyNodes []*state.NodeSnapshot
+	var unroutable []string // READY, but without data plane URL (agent misconfigured)
 	for _, n := range snap {
+		m, ok := n.Models[modelID]
+		if !ok || m.State != state.ModelReady {
+			continue
+		}
 		if n.DataPlaneURL == "" {
+			unroutable = append(unroutable, n.NodeID)
+			r.warnUnroutable(n.NodeID, modelID)
 			continue
 		}
-		if m, ok := n.Models[modelID]; ok && m.State == state.ModelReady {
-			readyNodes = append(readyNodes, n)
+		readyNodes = append(readyNodes, n)
+	}
+
+	if dec != nil {
+		dec.Candidates = len(snap)
+		dec.Ready = len(readyNodes)
+		dec.Unroutable = unroutable
+	}
+	readyNodes = r.avoidThrottled(readyNodes, now)
+
+	if len(readyNodes) > 0 && r.PlacementStrategy == PlacementConsistentHash {
+		if key := r.cacheKey(req); key != "" {
+			return placed(dec, pickByHash(readyNodes, key), pickDirect, ReasonReadyDirect, "consistent hash")
 		}
 	}
 
 	if len(readyNodes) > 0 {
-		pol, _, _ := r.Policies.GetPolicy(context.Background(), modelID)
-		best := pickBestByScore(readyNodes, r.Latency, pol)
+		pol, _, _ := r.getPolicy(context.Background(), modelID)
+		// Latency-first only applies among READY nodes; cold loads keep the RAM-aware score.
+		readyOpts := opts
+		readyOpts.LatencyFirst = latencyFirst(req)
+		best := pickBestByScore(readyNodes, r.Latency, pol, readyOpts)
 		if best != nil {
-			return pickedNode{NodeID: best.NodeID, DataPlaneURL: best.DataPlaneURL}, pickDirect, nil
+			dec.recordPick(modelID, best, readyNodes, r.Latency, pol, readyOpts)
+			return placed(dec, best, pickDirect, ReasonReadyDirect, "model ready")
 		}
 	}
 
+	// Models mapped to an endpoint outside the cluster go there while no node has them
+	// READY, without loading them on a node.
+	if n, ok := r.staticUpstream(modelID, authRecord); ok {
+		return placed(dec, n, pickDirect, ReasonStatic, "static upstream")
+	}
+
+	if readyOnly {
+		if len(unroutable) > 0 {
+			return refused(dec, ReasonUnroutable, "ready nodes without data plane url", errReadyUnroutable(modelID, unroutable))
+		}
+		return refused(dec, ReasonNotReady, "no ready node (ready-only)", errNoReadyNode)
+	}
+
+	// Loading a model that failed on every node that has it would fail again.
+	if err := failedEverywhere(snap, modelID); err != nil {
+		return refused(dec, ReasonLoadFailed, "failed on all nodes", err)
+	}
+
 	// 2) Gate-based loader coordination.
 	g := r.getGate(modelID)
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	// If a loader is in progress, callers can wait.
-	if g.loadingNode != "" {
+	// If a loader is in progress, callers can wait. With WaitOnReportedLoads they wait
+	// for the load furthest along, including loads the router did not start.
+	if r.WaitOnReportedLoads {
+		g.trackLoadsLocked(online, modelID, now)
+		if n := g.furthestLoaderLocked(snap); n != nil {
+			return placed(dec, n, pickWait, ReasonLoaderWait, "load in progress")
+		}
+		// Loader node went away.
+		g.loadingNode = ""
+	} else if g.loadingNode != "" {
 		for _, n := range snap {
 			if n.NodeID == g.loadingNode && n.DataPlaneURL != "" {
-				return pickedNode{NodeID: n.NodeID, DataPlaneURL: n.DataPlaneURL}, pickWait, nil
+				return placed(dec, n, pickWait, ReasonLoaderWait, "load in progress")
 			}
 		}
 		// Loader node went away.
 		g.loadingNode = ""
 	}
 
+	// The load may have finished after snap was taken. Route to the node that became
+	// READY instead of starting a second load elsewhere.
+	if g.readyNode != "" {
+		for _, n := range snap {
+			if n.NodeID != g.readyNode || n.DataPlaneURL == "" {
+				continue
+			}
+			if m, online := r.modelStateOnNode(modelID, n.NodeID); online && m.State == state.ModelReady {
+				return placed(dec, n, pickDirect, ReasonReadyDirect, "model ready (load finished)")
+			}
+		}
+	}
+
+	pol, _, _ := r.getPolicy(context.Background(), modelID)
+
+	// A model on its policy's maximum of nodes is not loaded on another one: requests
+	// use those nodes (READY ones were handled above) or are refused.
+	if pol.MaxNodes > 0 && g.occupiedLocked(online, modelID) >= pol.MaxNodes {
+		if n := loadingNodeOf(snap, modelID); n != nil {
+			return placed(dec, n, pickWait, ReasonLoaderWait, "max nodes reached, load in progress")
+		}
+		return refused(dec, ReasonMaxNodes, "max nodes reached",
+			fmt.Errorf("model %s is already on its maximum of %d nodes, none of them usable", modelID, pol.MaxNodes))
+	}
+
+	// A wedged control plane leaves RAM and residency data at old values.
+	if r.StaleNoColdLoads {
+		if _, stale := r.Cluster.StatusStale(now, r.StatusStaleAfter); stale {
+			return refused(dec, ReasonStatusStale, "node status stale", errStatusStale)
+		}
+	}
+
 	// 3) Choose best online eligible node by score (RAM - inflight - latency penalty).
 	eligible := make([]*state.NodeSnapshot, 0, len(snap))
 	for _, n := range snap {
-		if n.DataPlaneURL != "" {
-			eligible = append(eligible, n)
+		if n.DataPlaneURL == "" {
+			continue
 		}
+		if m, ok := n.Models[modelID]; ok && m.State == state.ModelError {
+			continue
+		}
+		eligible = append(eligible, n)
 	}
+	eligible = r.avoidThrottled(eligible, now)
 
-	pol, _, _ := r.Policies.GetPolicy(context.Background(), modelID)
+	// The reserve is cluster-wide, so it is computed over all online nodes (not only
+	// the ones the key may use).
+	if r.reserveEnabled() && len(eligible) > 0 {
+		kept := make([]*state.NodeSnapshot, 0, len(eligible))
+		for _, n := range eligible {
+			if r.keepsReserve(online, n, pol.RAMRequiredBytes) {
+				kept = append(kept, n)
+			}
+		}
+		if len(kept) == 0 {
+			return refused(dec, ReasonReserveLimit, "reserve capacity", errReserveExhausted)
+		}
+		eligible = kept
+	}
 
-	best := pickBestByScore(eligible, r.Latency, pol)
+	best := pickBestByScore(eligible, r.Latency, pol, opts)
 	if best == nil {
-		return pickedNode{}, pickDirect, errors.New("no nodes available")
+		if len(unroutable) > 0 {
+			return refused(dec, ReasonUnroutable, "ready nodes without data plane url", errReadyUnroutable(modelID, unroutable))
+		}
+		return refused(dec, ReasonNoNodes, "no eligible node", errors.New("no nodes available"))
+	}
+	// Scoring only ranks nodes too small for the model last; if even the best one is
+	// known to lack the RAM the policy requires, all are.
+	if r.RefuseOversizedLoads && !fitsRAM(best, pol) {
+		return refused(dec, ReasonOOMExcluded, "insufficient RAM on all nodes",
+			fmt.Errorf("no node has the %d MiB RAM model %s requires available", pol.RAMRequiredBytes>>20, modelID))
 	}
 
 	// Mark this node as the loading owner.
 	g.loadingNode = best.NodeID
+	g.loadingSince = now
+	dec.recordPick(modelID, best, eligible, r.Latency, pol, opts)
+	return placed(dec, best, pickCold, ReasonColdLoad, "cold load")
+}
+
+// fitsRAM reports whether the model's required RAM (policy) may fit on the node. Nodes
+// with unknown capacity and models without requirement always fit.
+func fitsRAM(n *state.NodeSnapshot, p policy.ModelPolicy) bool {
+	return !n.CapacityKnown() || p.RAMRequiredBytes == 0 || n.RAMAvailBytes >= p.RAMRequiredBytes
+}
 
-	return pickedNode{NodeID: best.NodeID, DataPlaneURL: best.DataPlaneURL}, pickDirect, nil
+// failedEverywhere returns an error if at least one node reports the model and all of
+// them report it in ERROR. The most recently reported load error is included.
+func failedEverywhere(nodes []*state.NodeSnapshot, modelID string) error {
+	var (
+		seen    int
+		lastErr string
+		lastAt  time.Time
+	)
+	for _, n := range nodes {
+		m, ok := n.Models[modelID]
+		if !ok {
+			continue
+		}
+		if m.State != state.ModelError {
+			return nil
+		}
+		seen++
+		if m.Error != "" && (lastErr == "" || m.LastSeen.After(lastAt)) {
+			lastErr = fmt.Sprintf("%s: %s", n.NodeID, m.Error)
+			lastAt = m.LastSeen
+		}
+	}
+	if seen == 0 {
+		return nil
+	}
+	if lastErr != "" {
+		return fmt.Errorf("model failed to load on all nodes (last error: %s)", lastErr)
+	}
+	return errors.New("model failed to load on all nodes")
 }
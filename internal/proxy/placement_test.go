@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestPlacementReasons(t *testing.T) {
+	const gib = 1 << 30
+	for _, tc := range []struct {
+		name      string
+		setup     func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store)
+		key       *policy.APIKeyRecord
+		readyOnly bool
+		reason    PlacementReason
+		node      string
+		mode      pickMode
+		status    int // for refusals
+	}{
+		{
+			name: "ready on a node",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				addNode(c, testNode{id: "a"})
+				addNode(c, testNode{id: "b", models: map[string]state.ModelState{"m": state.ModelReady}})
+			},
+			reason: ReasonReadyDirect, node: "b", mode: pickDirect,
+		},
+		{
+			name: "load in progress",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				addNode(c, testNode{id: "a"})
+				addNode(c, testNode{id: "b"})
+				g := r.getGate("m")
+				g.loadingNode, g.loadingSince = "b", time.Now()
+			},
+			reason: ReasonLoaderWait, node: "b", mode: pickWait,
+		},
+		{
+			name: "cold load",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				addNode(c, testNode{id: "a", avail: 8 * gib})
+				addNode(c, testNode{id: "b", avail: 40 * gib})
+			},
+			reason: ReasonColdLoad, node: "b", mode: pickCold,
+		},
+		{
+			name:   "model denied by ACL",
+			setup:  func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) { addNode(c, testNode{id: "a"}) },
+			key:    &policy.APIKeyRecord{AllowedModels: "other"},
+			reason: ReasonACLDenied, status: http.StatusForbidden,
+		},
+		{
+			name: "no node has the RAM, loaded anyway by default",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				upsertPolicy(t, s, policy.ModelPolicy{ModelID: "m", RAMRequiredBytes: 48 * gib})
+				addNode(c, testNode{id: "a", avail: 8 * gib})
+				addNode(c, testNode{id: "b", avail: 16 * gib})
+			},
+			// Nodes too small all score the same; the tie goes to the first node id.
+			reason: ReasonColdLoad, node: "a", mode: pickCold,
+		},
+		{
+			name: "no node has the RAM, refused",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				r.RefuseOversizedLoads = true
+				upsertPolicy(t, s, policy.ModelPolicy{ModelID: "m", RAMRequiredBytes: 48 * gib})
+				addNode(c, testNode{id: "a", avail: 8 * gib})
+				addNode(c, testNode{id: "b", avail: 16 * gib})
+			},
+			reason: ReasonOOMExcluded, status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "one node has the RAM with refusal on",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				r.RefuseOversizedLoads = true
+				upsertPolicy(t, s, policy.ModelPolicy{ModelID: "m", RAMRequiredBytes: 12 * gib})
+				addNode(c, testNode{id: "a", avail: 8 * gib})
+				addNode(c, testNode{id: "b", avail: 16 * gib})
+			},
+			reason: ReasonColdLoad, node: "b", mode: pickCold,
+		},
+		{
+			name:   "no nodes",
+			setup:  func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {},
+			reason: ReasonNoNodes, status: http.StatusServiceUnavailable,
+		},
+		{
+			name:      "ready-only without READY node",
+			setup:     func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) { addNode(c, testNode{id: "a"}) },
+			readyOnly: true,
+			reason:    ReasonNotReady, status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "failed on every node",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelError}})
+				addNode(c, testNode{id: "b"})
+			},
+			reason: ReasonLoadFailed, status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "node status stale",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				r.StaleNoColdLoads = true
+				r.StatusStaleAfter = time.Millisecond
+				addNode(c, testNode{id: "a"})
+				time.Sleep(5 * time.Millisecond)
+			},
+			reason: ReasonStatusStale, status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "reserve exhausted",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				r.ReserveRAMFraction = 0.9
+				addNode(c, testNode{id: "a", total: 64 * gib, avail: 32 * gib})
+			},
+			reason: ReasonReserveLimit, status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "max nodes reached",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				upsertPolicy(t, s, policy.ModelPolicy{ModelID: "m", MaxNodes: 1})
+				addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelReady}})
+				addNode(c, testNode{id: "b"})
+			},
+			key:    &policy.APIKeyRecord{AllowedNodes: "b"},
+			reason: ReasonMaxNodes, status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "ready only on nodes without data plane url",
+			setup: func(t *testing.T, r *Router, c *state.ClusterState, s *policy.Store) {
+				c.UpsertNodeHello("a", "test", "", "", "", state.DataPlaneTLS{}, nil)
+				setModels(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelReady}})
+			},
+			readyOnly: true,
+			reason:    ReasonUnroutable, status: http.StatusServiceUnavailable,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, cluster, store := newTestRouter(t)
+			tc.setup(t, r, cluster, store)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			if tc.key != nil {
+				req = auth.WithAuthRecord(req, tc.key)
+			}
+			pick := r.pickNodeForModel
+			if tc.readyOnly {
+				pick = r.pickReadyNodeForModel
+			}
+			res, err := pick(req, "m")
+
+			if res.Reason != tc.reason {
+				t.Fatalf("reason = %s (err %v), want %s", res.Reason, err, tc.reason)
+			}
+			if tc.status != 0 {
+				if err == nil {
+					t.Fatalf("placed on %s, want a refusal", res.NodeID)
+				}
+				if got := placementStatus(res.Reason); got != tc.status {
+					t.Errorf("status = %d, want %d", got, tc.status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("placement failed: %v", err)
+			}
+			if res.NodeID != tc.node || res.Mode != tc.mode {
+				t.Errorf("placed on %s (%s), want %s (%s)", res.NodeID, res.Mode, tc.node, tc.mode)
+			}
+		})
+	}
+}
+
+func upsertPolicy(t *testing.T, s *policy.Store, p policy.ModelPolicy) {
+	t.Helper()
+	if err := s.UpsertPolicy(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+}
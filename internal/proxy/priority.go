@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mcules/llm-router/internal/auth"
+)
+
+// Request priority levels (X-Priority header).
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+// requestPriority returns the effective priority of a request.
+// Any key may lower its priority ("low"); raising it ("high") is only honored for keys
+// owned by the admin, so regular clients cannot jump ahead of others.
+func requestPriority(req *http.Request) int {
+	var p int
+	switch strings.ToLower(strings.TrimSpace(req.Header.Get("X-Priority"))) {
+	case "low", "-1":
+		p = PriorityLow
+	case "high", "1":
+		p = PriorityHigh
+	default:
+		return PriorityNormal
+	}
+
+	if p == PriorityHigh {
+		rec := auth.GetAuthRecord(req)
+		if rec == nil || !auth.IsAdmin(rec.Owner) {
+			return PriorityNormal
+		}
+	}
+	return p
+}
+
+// latencyFirst reports whether the request asks for latency-first scoring
+// (X-Scoring: latency): among nodes with the model READY the one with the lowest
+// latency wins, regardless of RAM headroom.
+func latencyFirst(req *http.Request) bool {
+	return strings.EqualFold(strings.TrimSpace(req.Header.Get("X-Scoring")), "latency")
+}
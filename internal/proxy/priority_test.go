@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+func TestRequestPriority(t *testing.T) {
+	admin := &policy.APIKeyRecord{ID: "k1", Owner: "admin"}
+	user := &policy.APIKeyRecord{ID: "k2", Owner: "alice"}
+
+	cases := []struct {
+		name   string
+		header string
+		key    *policy.APIKeyRecord
+		want   int
+	}{
+		{"no header", "", user, PriorityNormal},
+		{"unknown value", "urgent", admin, PriorityNormal},
+		{"low by user", "low", user, PriorityLow},
+		{"low numeric", "-1", nil, PriorityLow},
+		{"high by admin key", "high", admin, PriorityHigh},
+		{"high numeric by admin key", " 1 ", admin, PriorityHigh},
+		{"high by user key", "high", user, PriorityNormal},
+		{"high without key", "HIGH", nil, PriorityNormal},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			if tc.header != "" {
+				req.Header.Set("X-Priority", tc.header)
+			}
+			if tc.key != nil {
+				req = auth.WithAuthRecord(req, tc.key)
+			}
+			if got := requestPriority(req); got != tc.want {
+				t.Errorf("requestPriority = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
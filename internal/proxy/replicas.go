@@ -0,0 +1,46 @@
+package proxy
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// BestReplica returns the online node with modelID READY that routing prefers, and the
+// other nodes with the model READY (sorted). It uses the same scoring as request
+// placement without request-specific inputs (priority, zone, ACLs). ok is false if no
+// node has the model READY.
+func (r *Router) BestReplica(ctx context.Context, modelID string) (best string, others []string, ok bool) {
+	var ready []*state.NodeSnapshot
+	for _, n := range r.Cluster.SnapshotOnline(time.Now(), r.NodeOfflineTTL) {
+		if n.DataPlaneURL == "" {
+			continue
+		}
+		if m, found := n.Models[modelID]; found && m.State == state.ModelReady {
+			ready = append(ready, n)
+		}
+	}
+	if len(ready) == 0 {
+		return "", nil, false
+	}
+
+	pol, _, _ := r.getPolicy(ctx, modelID)
+	opts := scoreOpts{
+		ModelPriorityWeight: r.ModelPriorityWeight,
+		PreferLeastModels:   r.PreferLeastModels,
+		GenSpeedWeight:      r.GenSpeedWeight,
+	}
+	keep := pickBestByScore(ready, r.Latency, pol, opts)
+	if keep == nil {
+		return "", nil, false
+	}
+	for _, n := range ready {
+		if n.NodeID != keep.NodeID {
+			others = append(others, n.NodeID)
+		}
+	}
+	sort.Strings(others)
+	return keep.NodeID, others, true
+}
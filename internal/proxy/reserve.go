@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"errors"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// errReserveExhausted is returned when every possible cold load would use up the
+// cluster's reserve capacity.
+var errReserveExhausted = errors.New("cluster reserve capacity reached, not loading another model")
+
+// reserveEnabled reports whether a cluster reserve is configured.
+func (r *Router) reserveEnabled() bool {
+	return r.ReserveNodes > 0 || r.ReserveRAMFraction > 0
+}
+
+// keepsReserve reports whether loading a model needing need bytes (0 = unknown) on
+// target leaves the cluster reserve intact:
+//   - at least ReserveNodes nodes stay below ReserveNodeUtil RAM utilization; with an
+//     unknown model size a load takes the target out of the reserve,
+//   - at least ReserveRAMFraction of the total RAM of nodes stays available.
+//
+// Loads on nodes outside the reserve never reduce the node reserve.
+func (r *Router) keepsReserve(nodes []*state.NodeSnapshot, target *state.NodeSnapshot, need uint64) bool {
+	if r.ReserveNodes > 0 {
+		spare := 0
+		for _, n := range nodes {
+			if n.NodeID == target.NodeID {
+				continue
+			}
+			if r.isReserveNode(n.RAMTotalBytes, n.RAMAvailBytes) {
+				spare++
+			}
+		}
+		if r.isReserveNode(target.RAMTotalBytes, target.RAMAvailBytes) && spare < r.ReserveNodes {
+			stillSpare := need > 0 && need < target.RAMAvailBytes &&
+				r.isReserveNode(target.RAMTotalBytes, target.RAMAvailBytes-need)
+			if !stillSpare {
+				return false
+			}
+		}
+	}
+
+	if r.ReserveRAMFraction > 0 {
+		var total, avail uint64
+		for _, n := range nodes {
+			total += n.RAMTotalBytes
+			avail += n.RAMAvailBytes
+		}
+		if need >= avail {
+			return false
+		}
+		if float64(avail-need) < r.ReserveRAMFraction*float64(total) {
+			return false
+		}
+	}
+	return true
+}
+
+// isReserveNode reports whether a node with the given RAM counts as spare capacity.
+func (r *Router) isReserveNode(total, avail uint64) bool {
+	if total == 0 {
+		return false
+	}
+	used := 1 - float64(avail)/float64(total)
+	return used < r.ReserveNodeUtil
+}
@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestKeepsReserve(t *testing.T) {
+	const gib = 1 << 30
+	node := func(id string, avail uint64) *state.NodeSnapshot {
+		return &state.NodeSnapshot{NodeID: id, RAMTotalBytes: 64 * gib, RAMAvailBytes: avail * gib}
+	}
+	for _, tc := range []struct {
+		name     string
+		nodes    []*state.NodeSnapshot
+		target   int
+		need     uint64 // GiB
+		reserve  int
+		fraction float64
+		want     bool
+	}{
+		{"another node stays spare", []*state.NodeSnapshot{node("a", 60), node("b", 60)}, 0, 40, 1, 0, true},
+		{"last spare node", []*state.NodeSnapshot{node("a", 60), node("b", 4)}, 0, 56, 1, 0, false},
+		{"small load keeps the node spare", []*state.NodeSnapshot{node("a", 60), node("b", 4)}, 0, 8, 1, 0, true},
+		{"unknown size uses up the node", []*state.NodeSnapshot{node("a", 60), node("b", 4)}, 0, 0, 1, 0, false},
+		{"target outside the reserve", []*state.NodeSnapshot{node("a", 60), node("b", 10)}, 1, 8, 1, 0, true},
+		{"RAM fraction kept", []*state.NodeSnapshot{node("a", 60), node("b", 60)}, 0, 40, 0, 0.5, true},
+		{"RAM fraction crossed", []*state.NodeSnapshot{node("a", 60), node("b", 30)}, 0, 40, 0, 0.5, false},
+		{"more than available", []*state.NodeSnapshot{node("a", 10)}, 0, 20, 0, 0.1, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, _ := newTestRouter(t)
+			r.ReserveNodes, r.ReserveRAMFraction = tc.reserve, tc.fraction
+			if got := r.keepsReserve(tc.nodes, tc.nodes[tc.target], tc.need*gib); got != tc.want {
+				t.Errorf("keepsReserve = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReserveRefusesLastNode(t *testing.T) {
+	const gib = 1 << 30
+	r, c, s := newTestRouter(t)
+	r.ReserveNodes = 1
+	upsertPolicy(t, s, policy.ModelPolicy{ModelID: "big", RAMRequiredBytes: 48 * gib})
+	upsertPolicy(t, s, policy.ModelPolicy{ModelID: "other", RAMRequiredBytes: 48 * gib})
+	addNode(c, testNode{id: "a", avail: 60 * gib})
+	addNode(c, testNode{id: "b", avail: 60 * gib})
+	place := func(model string, key *policy.APIKeyRecord) (PlacementResult, error) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		if key != nil {
+			req = auth.WithAuthRecord(req, key)
+		}
+		return r.pickNodeForModel(req, model)
+	}
+
+	// b stays spare while big loads on a.
+	res, err := place("big", &policy.APIKeyRecord{AllowedNodes: "a"})
+	if err != nil || res.Mode != pickCold || res.NodeID != "a" {
+		t.Fatalf("first load = %s on %s (%v), want a cold load on a", res.Mode, res.NodeID, err)
+	}
+	setModels(c, testNode{id: "a", avail: 12 * gib, models: map[string]state.ModelState{"big": state.ModelReady}})
+
+	// Loading other on b would fill the last spare node.
+	res, err = place("other", &policy.APIKeyRecord{AllowedNodes: "b"})
+	if !errors.Is(err, errReserveExhausted) || res.Reason != ReasonReserveLimit {
+		t.Fatalf("load on the last spare node = %s on %s (%v), want refused", res.Mode, res.NodeID, err)
+	}
+	if placementStatus(res.Reason) != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", placementStatus(res.Reason))
+	}
+
+	// Loaded models are still served.
+	if res, err := place("big", nil); err != nil || res.Mode != pickDirect {
+		t.Errorf("ready model = %s (%v), want it routed", res.Mode, err)
+	}
+
+	// Without the reserve the load goes ahead.
+	r.ReserveNodes = 0
+	if res, err := place("other", &policy.APIKeyRecord{AllowedNodes: "b"}); err != nil || res.NodeID != "b" {
+		t.Errorf("load without reserve = %s on %s (%v), want a cold load on b", res.Mode, res.NodeID, err)
+	}
+}
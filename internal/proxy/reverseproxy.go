@@ -11,6 +11,34 @@ import (
 
 type ctxKeyStart struct{}
 
+type ctxKeyRoute struct{}
+
+// routeInfo carries per-request placement details into the (shared) reverse proxy.
+type routeInfo struct {
+	ModelID string
+	Mode    pickMode
+	Reason  PlacementReason
+}
+
+func withRouteInfo(req *http.Request, modelID string, res PlacementResult) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), ctxKeyRoute{}, routeInfo{ModelID: modelID, Mode: res.Mode, Reason: res.Reason}))
+}
+
+type ctxKeyInternal struct{}
+
+// internalContext marks requests the router itself sends to a node (ready checks,
+// diagnostics, loads). They use the node's reverse proxy for its transport, path
+// mapping and upstream auth, but are not client traffic: their latency, 429s, spend
+// and prompt capture are not recorded, even if ctx derives from a client request.
+func internalContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKeyInternal{}, true)
+}
+
+func isInternal(req *http.Request) bool {
+	internal, _ := req.Context().Value(ctxKeyInternal{}).(bool)
+	return internal
+}
+
 var hopByHopHeaders = []string{
 	"Connection",
 	"Proxy-Connection",
@@ -23,18 +51,46 @@ var hopByHopHeaders = []string{
 	"Upgrade",
 }
 
+// nodeProxy is the cached reverse proxy of a node together with its own transport.
+type nodeProxy struct {
+	url       string // data plane URL
+	rev       uint64 // DataPlaneRev of the node the proxy was built for
+	proxy     *httputil.ReverseProxy
+	transport *http.Transport
+
+	// missingSince is set by PruneProxies while the node is offline or has another
+	// data plane URL (zero = present).
+	missingSince time.Time
+}
+
+// reverseProxy returns the cached proxy of a node. It is rebuilt (with a fresh
+// transport and TLS config) when the node's data plane URL changes or a hello changed
+// its TLS setup or endpoint paths (DataPlaneRev); a cached proxy costs no more than
+// these two comparisons per request.
 func (r *Router) reverseProxy(nodeID string, target *url.URL) *httputil.ReverseProxy {
-	key := target.String()
+	dpTLS, paths, rev, _ := r.Cluster.NodeDataPlane(nodeID)
+	u := target.String()
 
 	r.rpMu.Lock()
-	if p, ok := r.rpCache[key]; ok {
-		r.rpMu.Unlock()
-		return p
+	defer r.rpMu.Unlock()
+
+	if np, ok := r.rpCache[nodeID]; ok {
+		if np.url == u && np.rev == rev {
+			return np.proxy
+		}
+		// Requests in flight keep using the old transport; only idle connections go.
+		np.transport.CloseIdleConnections()
 	}
-	r.rpMu.Unlock()
 
+	tr := r.newNodeTransport(nodeID, dpTLS)
+	p := r.newReverseProxy(nodeID, target, tr, paths)
+	r.rpCache[nodeID] = &nodeProxy{url: u, rev: rev, proxy: p, transport: tr}
+	return p
+}
+
+func (r *Router) newReverseProxy(nodeID string, target *url.URL, tr *http.Transport, paths map[string]string) *httputil.ReverseProxy {
 	p := httputil.NewSingleHostReverseProxy(target)
-	p.Transport = r.transport
+	p.Transport = tr
 
 	// Flush frequently to support chunked streaming (SSE-like).
 	p.FlushInterval = 100 * time.Millisecond
@@ -45,8 +101,26 @@ func (r *Router) reverseProxy(nodeID string, target *url.URL) *httputil.ReverseP
 		ctx := context.WithValue(req.Context(), ctxKeyStart{}, time.Now())
 		*req = *req.WithContext(ctx)
 
+		// Backends serving other paths (e.g. /generate) get the node's mapped path; the
+		// data plane URL's own path is still prefixed by origDirector.
+		if len(paths) > 0 {
+			var modelID string
+			if info, ok := req.Context().Value(ctxKeyRoute{}).(routeInfo); ok {
+				modelID = info.ModelID
+			}
+			if p := upstreamPath(paths, req.URL.Path, modelID); p != req.URL.Path {
+				req.URL.Path, req.URL.RawPath = p, ""
+			}
+		}
+
 		origDirector(req)
 
+		if r.Recency != nil && !isInternal(req) {
+			if info, ok := req.Context().Value(ctxKeyRoute{}).(routeInfo); ok {
+				r.Recency.Touch(nodeID, info.ModelID)
+			}
+		}
+
 		// Make sure Host is target host (some clients depend on it).
 		req.Host = target.Host
 
@@ -60,17 +134,31 @@ func (r *Router) reverseProxy(nodeID string, target *url.URL) *httputil.ReverseP
 				req.Header.Del(strings.TrimSpace(f))
 			}
 		}
+
+		r.setUpstreamAuth(req, nodeID)
 	}
 
 	p.ModifyResponse = func(resp *http.Response) error {
-		// Record RTT (best-effort).
+		if resp.Request != nil && isInternal(resp.Request) {
+			return nil
+		}
+
+		// Record RTT (best-effort). A 429 means the node is overloaded and counts as error.
+		throttled := resp != nil && resp.StatusCode == http.StatusTooManyRequests
 		if r.Latency != nil && resp != nil && resp.Request != nil {
 			if v := resp.Request.Context().Value(ctxKeyStart{}); v != nil {
 				if start, ok := v.(time.Time); ok && !start.IsZero() {
-					r.Latency.ObserveOK(nodeID, time.Since(start))
+					if throttled {
+						r.Latency.ObserveError(nodeID, time.Since(start))
+					} else {
+						r.Latency.ObserveOK(nodeID, time.Since(start))
+					}
 				}
 			}
 		}
+		if throttled {
+			r.observeThrottle(nodeID, resp.Header, time.Now())
+		}
 
 		// Remove hop-by-hop response headers.
 		for _, h := range hopByHopHeaders {
@@ -81,12 +169,27 @@ func (r *Router) reverseProxy(nodeID string, target *url.URL) *httputil.ReverseP
 				resp.Header.Del(strings.TrimSpace(f))
 			}
 		}
+
+		// Advertise the serving node (headers are sent before any streamed body).
+		if r.ExposeRoutingHeaders {
+			resp.Header.Set("X-Served-By", nodeID)
+			if resp.Request != nil {
+				if info, ok := resp.Request.Context().Value(ctxKeyRoute{}).(routeInfo); ok {
+					resp.Header.Set("X-Served-Model", info.ModelID)
+					resp.Header.Set("X-Route-Mode", info.Mode.String())
+					resp.Header.Set("X-Route-Reason", string(info.Reason))
+				}
+			}
+		}
+
+		r.captureResponse(resp, nodeID)
+		r.accountResponse(resp)
 		return nil
 	}
 
 	p.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
 		// Record RTT as error (best-effort).
-		if r.Latency != nil && req != nil {
+		if r.Latency != nil && req != nil && !isInternal(req) {
 			if v := req.Context().Value(ctxKeyStart{}); v != nil {
 				if start, ok := v.(time.Time); ok && !start.IsZero() {
 					r.Latency.ObserveError(nodeID, time.Since(start))
@@ -96,9 +199,5 @@ func (r *Router) reverseProxy(nodeID string, target *url.URL) *httputil.ReverseP
 		http.Error(w, "upstream error", http.StatusBadGateway)
 	}
 
-	r.rpMu.Lock()
-	r.rpCache[key] = p
-	r.rpMu.Unlock()
-
 	return p
 }
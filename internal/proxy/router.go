@@ -2,18 +2,20 @@ package proxy
 
 import (
 	"bytes"
-	"encoding/json"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/mcules/llm-router/internal/metrics"
 	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/promptlog"
 	"github.com/mcules/llm-router/internal/state"
 )
 
@@ -22,8 +24,21 @@ type pickMode int
 const (
 	pickDirect pickMode = iota
 	pickWait
+	// pickCold routes directly, but the request itself triggers the model load on the node.
+	pickCold
 )
 
+func (m pickMode) String() string {
+	switch m {
+	case pickWait:
+		return "wait"
+	case pickCold:
+		return "cold"
+	default:
+		return "direct"
+	}
+}
+
 type pickedNode struct {
 	NodeID       string
 	DataPlaneURL string
@@ -32,7 +47,15 @@ type pickedNode struct {
 type modelGate struct {
 	mu          sync.Mutex
 	loadingNode string
-	notifyCh    chan struct{} // closed when model becomes READY somewhere
+	readyNode   string            // last node that reported the model READY
+	notifyCh    chan struct{}     // closed when model becomes READY or fails to load somewhere
+	canceled    uint64            // loads of the model canceled, on any node
+	canceledOn  map[string]uint64 // loads of the model canceled, by node (see waitModelReady)
+
+	loadingSince time.Time            // when loadingNode was assigned
+	loadsSeen    map[string]time.Time // nodes reporting the model LOADING, first seen (see noteReportedLoad)
+	lastLoad     time.Duration        // last measured time from loader assignment to READY
+	waiting      int                  // requests in waitModelReady
 }
 
 func newModelGate() *modelGate {
@@ -50,36 +73,219 @@ type Router struct {
 	// Optional RTT tracker (server-side).
 	Latency *metrics.LatencyTracker
 
-	transport *http.Transport
+	// Optional last-used tracker per node and model.
+	Recency *metrics.RecencyTracker
+
+	// Optional placement mode and loader wait statistics per model.
+	Placement *metrics.PlacementTracker
+
+	// ExposeRoutingHeaders adds X-Served-By (and model/mode) headers to proxied responses.
+	ExposeRoutingHeaders bool
+
+	// NormalizeModelNames matches request models to node residency, ACLs and policies
+	// ignoring case and surrounding whitespace.
+	NormalizeModelNames bool
+
+	// ModelPriorityWeight lets the policy priority of a model influence node selection
+	// (see scoreOpts). 0 keeps placement independent of model priority.
+	ModelPriorityWeight float64
+
+	// PreferLeastModels breaks placement ties towards nodes with fewer resident models.
+	PreferLeastModels bool
+
+	// GenSpeedWeight is the score bonus in bytes per token/sec of a node's generation
+	// speed, so fast-generating nodes are preferred. 0 disables the effect.
+	GenSpeedWeight int64
+
+	// Zone is the router's own zone; nodes in other zones get CrossZonePenalty (bytes)
+	// subtracted from their score, so same-zone nodes win unless they are clearly worse.
+	// Clients may request another zone via ZoneHeader. 0 or no zone disables the effect.
+	Zone             string
+	ZoneHeader       string
+	CrossZonePenalty int64
+
+	// WaitOnReportedLoads lets requests for a loading model wait for the node whose
+	// load is furthest along among all nodes reporting it LOADING, not only the node
+	// the router picked as loader (see furthestLoaderLocked).
+	WaitOnReportedLoads bool
+
+	// LoaderFailovers is how often a request waiting for a load is placed again when
+	// the loading node goes offline (see awaitLoad; 0 = the request fails).
+	LoaderFailovers int
+
+	// PlacementStrategy selects among nodes with the model READY (PlacementScore or
+	// PlacementConsistentHash). Cold loads always use the score.
+	PlacementStrategy string
+	// HashKeyHeader carries the consistent hashing key (e.g. X-Cache-Key).
+	HashKeyHeader string
+	// HashPrefixChars derives the key from the first characters of the prompt when the
+	// header is missing (0 = header only).
+	HashPrefixChars int
+
+	// Cluster reserve for cold loads (see keepsReserve): ReserveNodes nodes stay below
+	// ReserveNodeUtil RAM utilization and ReserveRAMFraction of the cluster RAM stays
+	// available. Loads that would break the reserve are refused with 503. 0 disables each.
+	ReserveNodes       int
+	ReserveNodeUtil    float64
+	ReserveRAMFraction float64
+
+	// ExcludeUnknownRAM skips nodes reporting RAM total 0 (unknown capacity). By default
+	// they stay eligible and are scored with the mean RAM of the other nodes.
+	ExcludeUnknownRAM bool
+
+	// StatusStaleAfter is the age of the most recent node status update after which the
+	// cluster snapshot counts as stale (0 = never). With StaleNoColdLoads, cold loads are
+	// then refused with 503 instead of being placed on possibly outdated RAM data.
+	StatusStaleAfter time.Duration
+	StaleNoColdLoads bool
+
+	// RefuseOversizedLoads refuses a cold load with 503 when every eligible node is
+	// known to have less RAM available than the model's policy requires. By default the
+	// best of them loads it anyway.
+	RefuseOversizedLoads bool
+
+	// PromptLog captures request/response pairs of opted-in API keys (nil = off).
+	PromptLog *promptlog.Logger
+
+	// Spend records responses per API key and model, charges their tokens at the
+	// model's CostPer1KTokens and backs EnforceBudget (nil = no accounting). BudgetWarnOnly lets keys over their
+	// monthly budget pass with a warning header instead of 402.
+	Spend          SpendAccounter
+	BudgetWarnOnly bool
+
+	// WarnStructuredOutput logs chat requests with a json_schema/json_object
+	// response_format that are routed to a node whose model reports no text generation.
+	WarnStructuredOutput bool
+
+	// EmbeddingsRequireReady makes /v1/embeddings route only to nodes that already
+	// have the model READY (503 otherwise) instead of triggering a load.
+	EmbeddingsRequireReady bool
+
+	// LoadRetryAfter is the Retry-After sent when a wait for a loading model times out
+	// and no earlier load of the model was measured (see loadRetryAfter).
+	LoadRetryAfter time.Duration
+
+	// EmbeddingsChunkSize is the batch size of streamed (NDJSON) embeddings requests.
+	EmbeddingsChunkSize int
+
+	// ModelFields are the body fields the model is read from, tried in order (empty =
+	// "model"), for clients that send it as e.g. "model_name" (see modelOf).
+	ModelFields []string
+
+	// DefaultModerationModel is used for /v1/moderations requests without "model".
+	DefaultModerationModel string
+
+	// EarlyModelCheck checks the model named in the X-Model header or "model" query
+	// parameter (ACL, reported by a node) before the body is read (see admitEarly).
+	EarlyModelCheck bool
+
+	// StreamOverrideHeader names a request header ("true"/"false") that forces "stream"
+	// in chat and completion bodies ("" = off). An API key's ForceStream takes precedence.
+	StreamOverrideHeader string
+
+	// MaxBodyBytes limits API request bodies (0 = unlimited, 413 above).
+	MaxBodyBytes int64
+	// BodyReadTimeout bounds reading an API request body (0 = unlimited, 408 after).
+	BodyReadTimeout time.Duration
+
+	// Per-node connection budget. Every node gets its own transport so a hot or
+	// slow node cannot starve connections of the others.
+	MaxConnsPerNode     int // 0 = unlimited
+	MaxIdleConnsPerNode int
+
+	// UpstreamTLS is the base TLS config for https data plane URLs (nil = strict
+	// verification against the system roots). Nodes may add a CA or skip verification
+	// in their hello (see nodeTLSConfig).
+	UpstreamTLS *tls.Config
+
+	// UpstreamCredentials maps node ids ("*" = all others) to the bearer token sent to
+	// the node instead of the client's Authorization header, which is always removed
+	// (see setUpstreamAuth). Empty = no Authorization header upstream.
+	UpstreamCredentials map[string]string
+
+	// Commands delivers the load commands of POST /v1/models/load (nil = loads go
+	// directly to the node's data plane, see startLoad).
+	Commands LoadCommander
+
+	// StaticUpstreams maps models to the base URL of an endpoint outside the cluster
+	// (e.g. a hosted API). Requests go there while no node has the model READY,
+	// bypassing loads (see staticUpstream).
+	StaticUpstreams map[string]string
+
+	// OverloadThreshold deprioritizes nodes whose EWMA latency (ms) times inflight
+	// requests reaches it, until it drops below half (see overloadedNodes; 0 = off).
+	OverloadThreshold float64
+	overloadMu        sync.Mutex
+	overloaded        map[string]bool // by node id
+
+	// InflightTokenUnit weights the requests the router has in flight on a node by their
+	// max_tokens in placement: such a request counts max_tokens/InflightTokenUnit
+	// inflight requests (see requestWeight; 0 = each counts one, as reported).
+	InflightTokenUnit int
+	inflightMu        sync.Mutex
+	inflight          map[string]*nodeInflight // by node id
+
+	// ThrottleBackoff is the longest time placement avoids a node after its backend
+	// answered 429 (see observeThrottle; 0 = 429s are only counted).
+	ThrottleBackoff time.Duration
+	throttleMu      sync.Mutex
+	throttled       map[string]*nodeThrottle // by node id
+
+	// MaxConcurrentRequests caps the API requests served at once by the router process
+	// (see LimitConcurrency; 0 = unlimited).
+	MaxConcurrentRequests int
+	// PriorityReserve is the share of MaxConcurrentRequests held back from lower
+	// priorities: normal requests leave it free for high ones, low requests twice that.
+	PriorityReserve float64
+	limitOnce       sync.Once
+	limit           *concurrencyLimit
 
 	rpMu    sync.Mutex
-	rpCache map[string]*httputil.ReverseProxy
+	rpCache map[string]*nodeProxy // by node id
 
 	gatesMu sync.Mutex
 	gates   map[string]*modelGate
 
+	unroutableWarned sync.Map // node id + model id -> struct{}, see warnUnroutable
+
+	verifiedMu sync.Mutex
+	verified   map[string]time.Time // last passed ready check by node id + model id, see readyVerified
+
 	Policies *policy.Store
 }
 
 func NewRouter(cluster *state.ClusterState, policies *policy.Store) *Router {
-	tr := &http.Transport{
+	return &Router{
+		Cluster:             cluster,
+		Policies:            policies,
+		NodeOfflineTTL:      5 * time.Second,
+		Latency:             nil,
+		MaxIdleConnsPerNode: 50,
+		EmbeddingsChunkSize: 64,
+		PlacementStrategy:   PlacementScore,
+		HashKeyHeader:       "X-Cache-Key",
+		ZoneHeader:          "X-Zone",
+		MaxBodyBytes:        32 << 20,
+		BodyReadTimeout:     30 * time.Second,
+		ReserveNodeUtil:     0.8,
+		LoadRetryAfter:      10 * time.Second,
+		rpCache:             map[string]*nodeProxy{},
+		gates:               map[string]*modelGate{},
+	}
+}
+
+// newNodeTransport creates the dedicated transport of a single node.
+func (r *Router) newNodeTransport(nodeID string, dpTLS state.DataPlaneTLS) *http.Transport {
+	return &http.Transport{
+		TLSClientConfig:       r.nodeTLSConfig(nodeID, dpTLS),
 		Proxy:                 http.ProxyFromEnvironment,
-		MaxIdleConns:          200,
-		MaxIdleConnsPerHost:   50,
+		MaxIdleConns:          r.MaxIdleConnsPerNode,
+		MaxIdleConnsPerHost:   r.MaxIdleConnsPerNode,
+		MaxConnsPerHost:       r.MaxConnsPerNode,
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   5 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 	}
-
-	return &Router{
-		Cluster:        cluster,
-		Policies:       policies,
-		NodeOfflineTTL: 5 * time.Second,
-		Latency:        nil,
-		transport:      tr,
-		rpCache:        map[string]*httputil.ReverseProxy{},
-		gates:          map[string]*modelGate{},
-	}
 }
 
 func (r *Router) getGate(modelID string) *modelGate {
@@ -101,91 +307,228 @@ func (r *Router) NotifyModelReady(nodeID, modelID string) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	if g.loadingNode == nodeID && !g.loadingSince.IsZero() {
+		g.lastLoad = time.Since(g.loadingSince)
+	}
 	g.loadingNode = ""
+	g.readyNode = nodeID
+	delete(g.loadsSeen, nodeID)
+	g.wakeLocked()
+}
+
+// NotifyModelFailed wakes waiters when a node reports ERROR for a model, so they can fail fast.
+func (r *Router) NotifyModelFailed(nodeID, modelID string) {
+	g := r.getGate(modelID)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
-	// Wake waiters.
+	if g.loadingNode == nodeID {
+		g.loadingNode = ""
+	}
+	delete(g.loadsSeen, nodeID)
+	g.wakeLocked()
+}
+
+// NotifyLoadCanceled implements control.LoadCancelNotifier: it releases the loader slot
+// and makes requests waiting for that load fail instead of waiting for the timeout.
+func (r *Router) NotifyLoadCanceled(nodeID, modelID string) {
+	g := r.getGate(modelID)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.loadingNode == nodeID {
+		g.loadingNode = ""
+	}
+	if g.canceledOn == nil {
+		g.canceledOn = map[string]uint64{}
+	}
+	g.canceled++
+	g.canceledOn[nodeID]++
+	g.wakeLocked()
+}
+
+// wakeLocked wakes all waiters of the gate. g.mu must be held.
+func (g *modelGate) wakeLocked() {
 	close(g.notifyCh)
 	g.notifyCh = make(chan struct{})
 }
 
-// NotifyModelState implements control.ModelStateNotifier.
-// It is intentionally minimal: we only need to wake waiters when a model becomes READY.
-// Other states are ignored for placement purposes.
+// NotifyModelState implements control.ModelStateNotifier. It wakes waiters when a model
+// becomes READY or fails to load, and tracks the loads nodes report (see
+// noteReportedLoad), so their start is known before the next placement.
 func (r *Router) NotifyModelState(nodeID, modelID string, st state.ModelState) {
-	if st == state.ModelReady {
+	switch st {
+	case state.ModelReady:
 		r.NotifyModelReady(nodeID, modelID)
+	case state.ModelError:
+		r.NotifyModelFailed(nodeID, modelID)
+	default:
+		r.noteReportedLoad(nodeID, modelID, st == state.ModelLoading, time.Now())
 	}
 }
 
+var (
+	// errModelLoadFailed is returned by waitModelReady when the node reports ERROR for the model.
+	errModelLoadFailed = errors.New("model load failed")
+	// errLoaderOffline is returned by waitModelReady when the loading node goes offline.
+	errLoaderOffline = errors.New("node went offline while waiting for model readiness")
+	// errLoadCanceled is returned by waitModelReady when the load was canceled by an operator.
+	errLoadCanceled = errors.New("model load was canceled")
+	// errWaitTimeout is returned by waitModelReady when the model is not READY in time.
+	errWaitTimeout = errors.New("timeout waiting for model readiness")
+)
+
 // waitModelReady waits until the selected node reports the model as READY (or we get a READY notify).
-func (r *Router) waitModelReady(modelID, nodeID string, timeout time.Duration) error {
+// It gives up early if the node goes offline (its last reported model states are stale then)
+// or reports the model as ERROR, and when the load on that node is canceled (a cancel on
+// another node does not concern it).
+func (r *Router) waitModelReady(modelID, nodeID string, timeout time.Duration) (err error) {
+	if r.Placement != nil {
+		start := time.Now()
+		defer func() { r.Placement.ObserveWait(modelID, time.Since(start), err == nil) }()
+	}
+
 	deadline := time.NewTimer(timeout)
 	defer deadline.Stop()
 
 	g := r.getGate(modelID)
+	g.mu.Lock()
+	canceled := g.canceledOn[nodeID]
+	g.mu.Unlock()
 
 	// Fast path: already READY on this node.
-	if r.isModelReadyOnNode(modelID, nodeID) {
+	m, online := r.modelStateOnNode(modelID, nodeID)
+	if m.State == state.ModelReady {
 		return nil
 	}
 
+	g.mu.Lock()
+	g.waiting++
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.waiting--
+		g.mu.Unlock()
+	}()
+
 	for {
+		if !online {
+			r.clearLoader(modelID, nodeID)
+			return errLoaderOffline
+		}
+		if m.State == state.ModelError {
+			r.clearLoader(modelID, nodeID)
+			if m.Error != "" {
+				return fmt.Errorf("%w on %s: %s", errModelLoadFailed, nodeID, m.Error)
+			}
+			return fmt.Errorf("%w on %s", errModelLoadFailed, nodeID)
+		}
+
 		g.mu.Lock()
 		ch := g.notifyCh
+		wasCanceled := g.canceledOn[nodeID] != canceled
 		g.mu.Unlock()
+		if wasCanceled {
+			return errLoadCanceled
+		}
 
 		select {
 		case <-deadline.C:
-			return errors.New("timeout waiting for model readiness")
+			return errWaitTimeout
 		case <-ch:
-			if r.isModelReadyOnNode(modelID, nodeID) {
-				return nil
-			}
 		case <-time.After(200 * time.Millisecond):
-			if r.isModelReadyOnNode(modelID, nodeID) {
-				return nil
-			}
 		}
+
+		m, online = r.modelStateOnNode(modelID, nodeID)
+		if m.State == state.ModelReady {
+			return nil
+		}
+	}
+}
+
+// writeWaitError answers a request whose waitModelReady failed. A timeout carries
+// Retry-After (see loadRetryAfter), so clients back off instead of retrying at once.
+func (r *Router) writeWaitError(w http.ResponseWriter, modelID string, err error) {
+	if errors.Is(err, errWaitTimeout) {
+		w.Header().Set("Retry-After", strconv.Itoa(r.loadRetryAfter(modelID, time.Now())))
+		http.Error(w, "model is still loading (timeout)", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusServiceUnavailable)
+}
+
+// loadRetryAfter estimates the seconds until the load of modelID in progress finishes:
+// the last measured load time of the model minus the time this load has been running.
+// Without a measurement, or once the estimate is exceeded, it is LoadRetryAfter.
+func (r *Router) loadRetryAfter(modelID string, now time.Time) int {
+	fallback := max(int(math.Ceil(r.LoadRetryAfter.Seconds())), 1)
+
+	g := r.getGate(modelID)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.loadingNode == "" || g.loadingSince.IsZero() || g.lastLoad <= 0 {
+		return fallback
 	}
+	remaining := g.lastLoad - now.Sub(g.loadingSince)
+	if remaining <= 0 {
+		return fallback
+	}
+	return max(int(math.Ceil(remaining.Seconds())), 1)
 }
 
-func (r *Router) isModelReadyOnNode(modelID, nodeID string) bool {
-	snap := r.Cluster.Snapshot()
-	for _, n := range snap {
+// modelStateOnNode returns the residency of the model on the node (zero if not reported)
+// and whether the node is online. Models of offline nodes are never reported.
+func (r *Router) modelStateOnNode(modelID, nodeID string) (state.ModelResidency, bool) {
+	for _, n := range r.Cluster.SnapshotOnline(time.Now(), r.NodeOfflineTTL) {
 		if n.NodeID != nodeID {
 			continue
 		}
-		if m, ok := n.Models[modelID]; ok && m.State == state.ModelReady {
-			return true
-		}
+		return n.Models[modelID], true
+	}
+	return state.ModelResidency{}, false
+}
+
+// clearLoader releases the loader slot of a model if it is still owned by nodeID and
+// wakes the requests waiting for it, so they notice right away.
+func (r *Router) clearLoader(modelID, nodeID string) {
+	g := r.getGate(modelID)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.loadingNode == nodeID {
+		g.loadingNode = ""
+		g.wakeLocked()
 	}
-	return false
 }
 
-// extractModelAndBody parses the request JSON body and extracts the "model" field.
-// It returns the model id and the raw body bytes for re-use in the proxy.
-func extractModelAndBody(req *http.Request) (string, []byte, error) {
+// extractModelAndBody reads the request JSON body and extracts the model (see modelOf).
+// It returns the model id and the raw body bytes for re-use in the proxy; a model
+// taken from elsewhere is written into the body's "model" field.
+func (r *Router) extractModelAndBody(req *http.Request) (string, []byte, error) {
 	raw, err := io.ReadAll(req.Body)
 	if err != nil {
 		return "", nil, fmt.Errorf("read body: %w", err)
 	}
 	_ = req.Body.Close()
 
-	var tmp struct {
-		Model string `json:"model"`
-	}
-	if err := json.Unmarshal(raw, &tmp); err != nil {
-		return "", nil, fmt.Errorf("invalid json: %w", err)
+	modelID, field, err := r.modelOf(req, raw)
+	if err != nil {
+		return "", nil, err
 	}
-	if tmp.Model == "" {
+	if modelID == "" {
 		return "", nil, errors.New("missing model field")
 	}
+	if raw, err = ensureBodyModel(raw, modelID, field); err != nil {
+		return "", nil, fmt.Errorf("invalid json: %w", err)
+	}
 
 	// Restore body for potential downstream reads (caller typically re-sets it anyway).
 	req.Body = io.NopCloser(bytes.NewReader(raw))
 	req.ContentLength = int64(len(raw))
 
-	return tmp.Model, raw, nil
+	return modelID, raw, nil
 }
 
 func (r *Router) buildTarget(node pickedNode) (*url.URL, error) {
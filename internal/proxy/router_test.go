@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestCancelFailsOnlyWaitersOfThatNode(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	loading := map[string]state.ModelState{"m": state.ModelLoading}
+	addNode(c, testNode{id: "a", models: loading})
+	addNode(c, testNode{id: "b", models: loading})
+
+	errA := make(chan error, 1)
+	errB := make(chan error, 1)
+	go func() { errA <- r.waitModelReady("m", "a", 5*time.Second) }()
+	go func() { errB <- r.waitModelReady("m", "b", 5*time.Second) }()
+	waitFor(t, func() bool { return gateWaiting(r, "m") == 2 })
+
+	r.NotifyLoadCanceled("a", "m")
+	if err := <-errA; !errors.Is(err, errLoadCanceled) {
+		t.Fatalf("waiter on a = %v, want errLoadCanceled", err)
+	}
+	select {
+	case err := <-errB:
+		t.Fatalf("waiter on b returned %v after a cancel on a", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	setModels(c, testNode{id: "b", models: map[string]state.ModelState{"m": state.ModelReady}})
+	r.NotifyModelReady("b", "m")
+	if err := <-errB; err != nil {
+		t.Fatalf("waiter on b = %v, want READY", err)
+	}
+}
+
+// gateWaiting returns the number of requests waiting for a load of modelID.
+func gateWaiting(r *Router, modelID string) int {
+	g := r.getGate(modelID)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.waiting
+}
+
+// waitFor polls cond for up to two seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("condition not met in time")
+}
@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// PruneProxies drops cached reverse proxies of nodes that have been offline, unknown or
+// on another data plane URL for at least grace, and closes their idle connections.
+// Without it, nodes churning through URLs (e.g. ephemeral ports) would leave a transport
+// behind for every node id that never comes back. Static upstreams are never cluster
+// nodes and are kept (there is one per configured model). It returns the number dropped.
+func (r *Router) PruneProxies(now time.Time, grace time.Duration) int {
+	current := map[string]string{}
+	for _, n := range r.Cluster.SnapshotOnline(now, r.NodeOfflineTTL) {
+		current[n.NodeID] = n.DataPlaneURL
+	}
+
+	r.rpMu.Lock()
+	defer r.rpMu.Unlock()
+
+	dropped := 0
+	for nodeID, np := range r.rpCache {
+		if isStaticNode(nodeID) {
+			continue
+		}
+		if u, ok := current[nodeID]; ok && u == np.url {
+			np.missingSince = time.Time{}
+			continue
+		}
+		if np.missingSince.IsZero() {
+			np.missingSince = now
+			continue
+		}
+		if now.Sub(np.missingSince) < grace {
+			continue
+		}
+		// Requests still in flight finish on the transport; only idle connections go.
+		np.transport.CloseIdleConnections()
+		delete(r.rpCache, nodeID)
+		dropped++
+	}
+	return dropped
+}
+
+// WriteProxyCacheMetrics writes the number of cached per-node reverse proxies (each
+// with its own connection pool) in the Prometheus text format.
+func (r *Router) WriteProxyCacheMetrics(w io.Writer) error {
+	r.rpMu.Lock()
+	n := len(r.rpCache)
+	r.rpMu.Unlock()
+
+	_, err := fmt.Fprintf(w, "# HELP llm_router_proxy_cache_entries Cached per-node reverse proxies (one connection pool each).\n"+
+		"# TYPE llm_router_proxy_cache_entries gauge\n"+
+		"llm_router_proxy_cache_entries %d\n", n)
+	return err
+}
@@ -0,0 +1,191 @@
+package proxy
+
+import (
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// cachedProxies returns the node ids with a cached reverse proxy.
+func cachedProxies(r *Router) map[string]bool {
+	r.rpMu.Lock()
+	defer r.rpMu.Unlock()
+	out := map[string]bool{}
+	for id := range r.rpCache {
+		out[id] = true
+	}
+	return out
+}
+
+func TestPruneProxiesKeepsStaticUpstreams(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	u, _ := url.Parse("https://api.example.com/v1")
+	r.reverseProxy(staticNodePrefix+"gpt-4o", u)
+
+	now := time.Now()
+	for i := range 3 {
+		if n := r.PruneProxies(now.Add(time.Duration(i)*time.Hour), time.Minute); n != 0 {
+			t.Fatalf("pass %d dropped %d proxies", i, n)
+		}
+	}
+	if !cachedProxies(r)[staticNodePrefix+"gpt-4o"] {
+		t.Error("static upstream proxy was pruned")
+	}
+}
+
+func TestPruneProxiesEvictsGoneTargets(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		closed int
+	)
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	backend.Config.ConnState = func(_ net.Conn, st http.ConnState) {
+		if st == http.StateClosed {
+			mu.Lock()
+			closed++
+			mu.Unlock()
+		}
+	}
+	backend.Start()
+	defer backend.Close()
+
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "gone", url: backend.URL})
+	addNode(c, testNode{id: "moved", url: backend.URL})
+	addNode(c, testNode{id: "stays", url: backend.URL})
+	target, _ := url.Parse(backend.URL)
+	for _, id := range []string{"gone", "moved", "stays"} {
+		w := httptest.NewRecorder()
+		r.reverseProxy(id, target).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request via %s = %d", id, w.Code)
+		}
+	}
+
+	n, _ := c.Node("gone")
+	n.LastHeartbeat = time.Now().Add(-time.Hour)
+	c.RestoreNode(*n)
+	c.UpsertNodeHello("moved", "test", "", "http://127.0.0.1:1", "", state.DataPlaneTLS{}, nil)
+
+	now := time.Now()
+	if n := r.PruneProxies(now, time.Minute); n != 0 {
+		t.Fatalf("first pass dropped %d proxies, want none within the grace", n)
+	}
+	if n := r.PruneProxies(now.Add(time.Minute), time.Minute); n != 2 {
+		t.Fatalf("dropped %d proxies after the grace, want 2", n)
+	}
+	if got := cachedProxies(r); len(got) != 1 || !got["stays"] {
+		t.Errorf("cached %v, want only stays", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := closed
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d idle connections closed, want 2", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPruneProxiesForgetsNodesBack(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	addNode(c, testNode{id: "n1"})
+	target, _ := url.Parse("http://n1")
+	r.reverseProxy("n1", target)
+
+	n, _ := c.Node("n1")
+	online := *n
+	n.LastHeartbeat = time.Now().Add(-time.Hour)
+	c.RestoreNode(*n)
+	now := time.Now()
+	r.PruneProxies(now, time.Minute)
+
+	// Back online before the grace ended.
+	online.LastHeartbeat = now.Add(time.Minute)
+	c.RestoreNode(online)
+	r.PruneProxies(now.Add(time.Minute), time.Minute)
+	if !cachedProxies(r)["n1"] {
+		t.Fatal("proxy of a node back online was pruned")
+	}
+}
+
+func TestReverseProxyCachedPerDataPlane(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer backend.Close()
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: backend.Certificate().Raw}))
+
+	r, cluster, _ := newTestRouter(t)
+	target, _ := url.Parse(backend.URL)
+	hello := func(dpTLS state.DataPlaneTLS, paths map[string]string) {
+		cluster.UpsertNodeHello("n1", "test", "", backend.URL, "", dpTLS, paths)
+	}
+
+	hello(state.DataPlaneTLS{CAPEM: caPEM}, nil)
+	first := r.reverseProxy("n1", target)
+	w := httptest.NewRecorder()
+	first.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("request with the node's CA = %d, want 200", w.Code)
+	}
+
+	// A reconnect repeats the hello, status updates do not touch the data plane.
+	hello(state.DataPlaneTLS{CAPEM: caPEM}, nil)
+	cluster.UpdateNodeStatus("n1", 64<<30, 32<<30, "", 0, 0, 0, nil)
+	if r.reverseProxy("n1", target) != first {
+		t.Fatal("proxy rebuilt although the data plane did not change")
+	}
+
+	for _, change := range []struct {
+		name  string
+		tls   state.DataPlaneTLS
+		paths map[string]string
+	}{
+		{"TLS verification", state.DataPlaneTLS{CAPEM: caPEM, InsecureSkipVerify: true}, nil},
+		{"CA bundle", state.DataPlaneTLS{}, nil},
+		{"endpoint paths", state.DataPlaneTLS{}, map[string]string{"chat": "/generate"}},
+	} {
+		prev := r.reverseProxy("n1", target)
+		hello(change.tls, change.paths)
+		if r.reverseProxy("n1", target) == prev {
+			t.Errorf("proxy kept after a hello changed the %s", change.name)
+		}
+	}
+
+	other, _ := url.Parse(strings.Replace(backend.URL, "127.0.0.1", "localhost", 1))
+	prev := r.reverseProxy("n1", target)
+	if r.reverseProxy("n1", other) == prev {
+		t.Error("proxy kept for another data plane URL")
+	}
+}
+
+func BenchmarkReverseProxyCached(b *testing.B) {
+	r := NewRouter(state.NewClusterState(), nil)
+	r.Cluster.UpsertNodeHello("n1", "test", "", "https://n1:8443", "",
+		state.DataPlaneTLS{CAPEM: strings.Repeat("x", 4096)}, map[string]string{"chat": "/generate", "completions": "/complete"})
+	target, _ := url.Parse("https://n1:8443")
+	r.reverseProxy("n1", target)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		r.reverseProxy("n1", target)
+	}
+}
@@ -14,17 +14,77 @@ const inflightPenaltyBytes = 512 * 1024 * 1024 // 512 MiB
 // Tuning: 8 MiB/ms => 100ms ~ 800MiB penalty (strong preference for low-latency nodes).
 const latencyPenaltyBytesPerMs = 8 * 1024 * 1024
 
+// scoreOpts carries per-request inputs to scoring.
+type scoreOpts struct {
+	// Priority is the request priority (PriorityLow..PriorityHigh).
+	Priority int
+
+	// ModelPriorityWeight scales load and latency penalties by the model's policy
+	// priority: factor = 1 + weight*priority (clamped at 0). 0 disables the effect.
+	ModelPriorityWeight float64
+
+	// PreferLeastModels breaks score ties towards nodes with fewer resident models,
+	// spreading cold loads across an otherwise idle cluster.
+	PreferLeastModels bool
+
+	// GenSpeedWeight is the score bonus in bytes per token/sec of the node's reported
+	// generation speed. 0 disables the effect.
+	GenSpeedWeight int64
+
+	// NeutralGenSpeed replaces the generation speed of nodes that report none (no
+	// recent generation), so they neither win nor lose on speed. Set by pickBestByScore.
+	NeutralGenSpeed float64
+
+	// NeutralRAM replaces the available RAM of nodes with unknown capacity (RAM total 0),
+	// so they neither win nor lose on RAM. Set by pickBestByScore.
+	NeutralRAM int64
+
+	// Zone is the preferred zone; nodes outside it (including nodes without a zone)
+	// lose CrossZonePenalty bytes of score. They remain eligible as fallback.
+	Zone             string
+	CrossZonePenalty int64
+
+	// LatencyFirst ranks nodes by EWMA latency only (see scoreNodeLatencyFirst).
+	LatencyFirst bool
+
+	// Overloaded nodes lose overloadPenaltyBytes of score (see overloadedNodes).
+	Overloaded map[string]bool
+
+	// Inflight replaces the reported inflight requests of the nodes it lists in the
+	// inflight penalty (see weightedInflight). nil = reported counts.
+	Inflight map[string]float64
+}
+
+// inflightOf returns the inflight load of n: its entry in Inflight, else the reported
+// count.
+func (o scoreOpts) inflightOf(n *state.NodeSnapshot) float64 {
+	if w, ok := o.Inflight[n.NodeID]; ok {
+		return w
+	}
+	return float64(n.InflightRequests)
+}
+
 // scoreNode returns a comparable score where higher is better.
-func scoreNode(n *state.NodeSnapshot, lat *metrics.LatencyTracker, p policy.ModelPolicy) int64 {
+func scoreNode(n *state.NodeSnapshot, lat *metrics.LatencyTracker, p policy.ModelPolicy, o scoreOpts) int64 {
 	ram := int64(n.RAMAvailBytes)
 
 	// OOM Protection: If we know the RAM requirements and it doesn't fit,
-	// give it a massive penalty.
-	if p.RAMRequiredBytes > 0 && n.RAMAvailBytes < p.RAMRequiredBytes {
+	// give it a massive penalty. Nodes with unknown capacity cannot be checked.
+	if !n.CapacityKnown() {
+		ram = o.NeutralRAM
+	} else if p.RAMRequiredBytes > 0 && n.RAMAvailBytes < p.RAMRequiredBytes {
 		return -1e15 // Extremely low score
 	}
 
-	pen := int64(n.InflightRequests) * int64(inflightPenaltyBytes)
+	pen := int64(o.inflightOf(n) * inflightPenaltyBytes)
+	// High-priority requests weigh load twice as strongly (prefer less-loaded nodes),
+	// low-priority requests accept busier nodes.
+	switch o.Priority {
+	case PriorityHigh:
+		pen *= 2
+	case PriorityLow:
+		pen /= 2
+	}
 
 	var latPen int64
 	if lat != nil {
@@ -33,6 +93,17 @@ func scoreNode(n *state.NodeSnapshot, lat *metrics.LatencyTracker, p policy.Mode
 		}
 	}
 
+	// Important models (positive priority) weigh load and latency more strongly and
+	// thus prefer the fastest node; negative priorities accept busier nodes.
+	if o.ModelPriorityWeight != 0 && p.Priority != 0 {
+		f := 1 + o.ModelPriorityWeight*float64(p.Priority)
+		if f < 0 {
+			f = 0
+		}
+		pen = int64(float64(pen) * f)
+		latPen = int64(float64(latPen) * f)
+	}
+
 	// Warm affinity: if the model is already on this node (even if not READY yet),
 	// give it a small bonus to prefer reusing the node.
 	var affinityBonus int64
@@ -40,25 +111,58 @@ func scoreNode(n *state.NodeSnapshot, lat *metrics.LatencyTracker, p policy.Mode
 		affinityBonus = 1024 * 1024 * 1024 // 1 GiB bonus
 	}
 
-	return ram - pen - latPen + affinityBonus
+	// Throughput: nodes that generate faster get a bonus (nodes without data the mean).
+	var speedBonus int64
+	if o.GenSpeedWeight > 0 {
+		speed := n.GenTokensPerSec
+		if speed <= 0 {
+			speed = o.NeutralGenSpeed
+		}
+		speedBonus = int64(speed * float64(o.GenSpeedWeight))
+	}
+
+	var zonePen int64
+	if o.Zone != "" && n.Zone != o.Zone {
+		zonePen = o.CrossZonePenalty
+	}
+
+	var overloadPen int64
+	if o.Overloaded[n.NodeID] {
+		overloadPen = overloadPenaltyBytes
+	}
+
+	return ram - pen - latPen + affinityBonus + speedBonus - zonePen - overloadPen
 }
 
-func pickBestByScore(nodes []*state.NodeSnapshot, lat *metrics.LatencyTracker, p policy.ModelPolicy) *state.NodeSnapshot {
+func pickBestByScore(nodes []*state.NodeSnapshot, lat *metrics.LatencyTracker, p policy.ModelPolicy, o scoreOpts) *state.NodeSnapshot {
+	o.NeutralRAM = neutralRAM(nodes)
+	o.NeutralGenSpeed = neutralGenSpeed(nodes)
+	score := scoreNode
+	if o.LatencyFirst {
+		score = scoreNodeLatencyFirst
+	}
+
 	var best *state.NodeSnapshot
 	var bestScore int64
 
 	for _, n := range nodes {
-		s := scoreNode(n, lat, p)
+		s := score(n, lat, p, o)
 		if best == nil || s > bestScore {
 			best = n
 			bestScore = s
 		} else if s == bestScore && best != nil {
 			// Tie-breaker: prefer node with fewer inflight requests
-			if n.InflightRequests < best.InflightRequests {
+			if ni, bi := o.inflightOf(n), o.inflightOf(best); ni < bi {
 				best = n
-			} else if n.InflightRequests == best.InflightRequests {
-				// Second tie-breaker: stable but fair based on NodeID
-				if n.NodeID < best.NodeID {
+			} else if ni == bi {
+				nm, bm := residentModels(n), residentModels(best)
+				if o.PreferLeastModels && nm != bm {
+					// Second tie-breaker: spread models across nodes
+					if nm < bm {
+						best = n
+					}
+				} else if n.NodeID < best.NodeID {
+					// Last tie-breaker: stable but fair based on NodeID
 					best = n
 				}
 			}
@@ -66,3 +170,66 @@ func pickBestByScore(nodes []*state.NodeSnapshot, lat *metrics.LatencyTracker, p
 	}
 	return best
 }
+
+// scoreNodeLatencyFirst ranks nodes by EWMA latency alone (lower is better); RAM only
+// matters for the OOM check. Nodes without latency data rank behind measured ones, ties
+// are broken by pickBestByScore (inflight, resident models, node id).
+func scoreNodeLatencyFirst(n *state.NodeSnapshot, lat *metrics.LatencyTracker, p policy.ModelPolicy, o scoreOpts) int64 {
+	if n.CapacityKnown() && p.RAMRequiredBytes > 0 && n.RAMAvailBytes < p.RAMRequiredBytes {
+		return -1e15
+	}
+	var overloadPen int64
+	if o.Overloaded[n.NodeID] {
+		overloadPen = 1e13 // behind nodes without latency data
+	}
+	if lat != nil {
+		if l, ok := lat.Get(n.NodeID); ok && l.EWMAms > 0 {
+			return -int64(l.EWMAms*1000) - overloadPen
+		}
+	}
+	return -1e12 - overloadPen
+}
+
+// neutralRAM is the mean available RAM of the nodes with known capacity (0 if none).
+func neutralRAM(nodes []*state.NodeSnapshot) int64 {
+	var sum, n int64
+	for _, node := range nodes {
+		if node.CapacityKnown() {
+			sum += int64(node.RAMAvailBytes)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / n
+}
+
+// neutralGenSpeed is the mean generation speed of the nodes reporting one (0 if none).
+func neutralGenSpeed(nodes []*state.NodeSnapshot) float64 {
+	var (
+		sum float64
+		n   int
+	)
+	for _, node := range nodes {
+		if node.GenTokensPerSec > 0 {
+			sum += node.GenTokensPerSec
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// residentModels counts the models a node holds (loaded or loading).
+func residentModels(n *state.NodeSnapshot) int {
+	c := 0
+	for _, m := range n.Models {
+		if m.State != state.ModelUnloaded {
+			c++
+		}
+	}
+	return c
+}
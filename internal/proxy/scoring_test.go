@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestGenSpeedScoringUnknownIsNeutral(t *testing.T) {
+	node := func(id string, tps float64) *state.NodeSnapshot {
+		return &state.NodeSnapshot{NodeID: id, RAMTotalBytes: 64 << 30, RAMAvailBytes: 32 << 30, GenTokensPerSec: tps}
+	}
+	fast, slow, unknown := node("fast", 30), node("slow", 10), node("unknown", 0)
+	o := scoreOpts{GenSpeedWeight: 100 << 20}
+	p := policy.ModelPolicy{ModelID: "m"}
+
+	if best := pickBestByScore([]*state.NodeSnapshot{slow, unknown, fast}, nil, p, o); best != fast {
+		t.Errorf("picked %s, want the fastest node", best.NodeID)
+	}
+	// A node whose speed expired is scored with the mean (20 t/s): ahead of the slow
+	// node, and of the fast one that has 4 GiB less RAM available.
+	short := node("fast-short", 30)
+	short.RAMAvailBytes -= 4 << 30
+	if best := pickBestByScore([]*state.NodeSnapshot{slow, unknown, short}, nil, p, o); best != unknown {
+		t.Errorf("picked %s, want the node without speed", best.NodeID)
+	}
+	// Without any reported speed nothing changes the RAM ranking.
+	bigger := &state.NodeSnapshot{NodeID: "bigger", RAMTotalBytes: 64 << 30, RAMAvailBytes: 40 << 30}
+	if best := pickBestByScore([]*state.NodeSnapshot{unknown, bigger}, nil, p, o); best != bigger {
+		t.Errorf("picked %s, want the node with more RAM", best.NodeID)
+	}
+}
+
+func TestPreferLeastModelsSpreadsColdLoads(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		prefer bool
+		want   []string
+	}{
+		{"off", false, []string{"a", "a", "a"}},
+		{"on", true, []string{"a", "b", "c"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.PreferLeastModels = tc.prefer
+			nodes := map[string]testNode{}
+			for _, id := range []string{"c", "b", "a"} {
+				nodes[id] = testNode{id: id, models: map[string]state.ModelState{}}
+				addNode(c, nodes[id])
+			}
+
+			var got []string
+			for _, m := range []string{"m1", "m2", "m3"} {
+				res, err := r.pickNodeForModel(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil), m)
+				if err != nil || res.Mode != pickCold {
+					t.Fatalf("%s: placed %s (%v), want a cold load", m, res.Mode, err)
+				}
+				got = append(got, res.NodeID)
+				// The node reports the load; RAM stays the same so only the tie-break decides.
+				n := nodes[res.NodeID]
+				n.models[m] = state.ModelReady
+				setModels(c, n)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("cold loads went to %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPreferLeastModelsIgnoresUnloaded(t *testing.T) {
+	a := &state.NodeSnapshot{NodeID: "a", RAMTotalBytes: 64 << 30, RAMAvailBytes: 32 << 30, Models: map[string]state.ModelResidency{
+		"x": {ModelID: "x", State: state.ModelUnloaded},
+		"y": {ModelID: "y", State: state.ModelUnloaded},
+	}}
+	b := &state.NodeSnapshot{NodeID: "b", RAMTotalBytes: 64 << 30, RAMAvailBytes: 32 << 30, Models: map[string]state.ModelResidency{
+		"z": {ModelID: "z", State: state.ModelLoading},
+	}}
+	o := scoreOpts{PreferLeastModels: true}
+	if best := pickBestByScore([]*state.NodeSnapshot{b, a}, nil, policy.ModelPolicy{ModelID: "m"}, o); best != a {
+		t.Errorf("picked %s, want a (unloaded models do not count)", best.NodeID)
+	}
+}
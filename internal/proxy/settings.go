@@ -0,0 +1,97 @@
+package proxy
+
+// Settings are the placement and proxy settings the router currently uses.
+type Settings struct {
+	NodeOfflineTTL         string  `json:"node_offline_ttl"`
+	ExposeRoutingHeaders   bool    `json:"expose_routing_headers"`
+	NormalizeModelNames    bool    `json:"normalize_model_names"`
+	ModelPriorityWeight    float64 `json:"model_priority_weight"`
+	PreferLeastModels      bool    `json:"prefer_least_models"`
+	GenSpeedWeightBytes    int64   `json:"gen_speed_weight_bytes"`
+	OverloadThreshold      float64 `json:"overload_threshold"`
+	PlacementStrategy      string  `json:"placement_strategy"`
+	HashKeyHeader          string  `json:"hash_key_header"`
+	HashPrefixChars        int     `json:"hash_prefix_chars"`
+	Zone                   string  `json:"zone"`
+	ZoneHeader             string  `json:"zone_header"`
+	CrossZonePenaltyBytes  int64   `json:"cross_zone_penalty_bytes"`
+	ExcludeUnknownRAM      bool    `json:"exclude_unknown_ram"`
+	ReserveNodes           int     `json:"reserve_nodes"`
+	ReserveNodeUtil        float64 `json:"reserve_node_util"`
+	ReserveRAMFraction     float64 `json:"reserve_ram_fraction"`
+	WarnStructuredOutput   bool    `json:"warn_structured_output"`
+	EmbeddingsRequireReady bool    `json:"embeddings_require_ready"`
+	EmbeddingsChunkSize    int     `json:"embeddings_chunk_size"`
+	LoadRetryAfter         string  `json:"load_retry_after"`
+	DefaultModerationModel string  `json:"default_moderation_model"`
+	EarlyModelCheck        bool    `json:"early_model_check"`
+	StreamOverrideHeader   string  `json:"stream_override_header"`
+	MaxBodyBytes           int64   `json:"max_body_bytes"`
+	BodyReadTimeout        string  `json:"body_read_timeout"`
+	MaxConnsPerNode        int     `json:"max_conns_per_node"`
+	MaxIdleConnsPerNode    int     `json:"max_idle_conns_per_node"`
+	MaxConcurrentRequests  int     `json:"max_concurrent_requests"`
+	PriorityReserve        float64 `json:"priority_reserve"`
+	StatusStaleAfter       string  `json:"status_stale_after"`
+	StaleNoColdLoads       bool    `json:"stale_no_cold_loads"`
+	RefuseOversizedLoads   bool    `json:"refuse_oversized_loads"`
+	WaitOnReportedLoads    bool    `json:"wait_on_reported_loads"`
+	LoaderFailovers        int     `json:"loader_failovers"`
+	InflightTokenUnit      int     `json:"inflight_token_unit"`
+	UpstreamTLSCustomCA    bool    `json:"upstream_tls_custom_ca"`
+	UpstreamTLSInsecure    bool    `json:"upstream_tls_insecure_skip_verify"`
+	UpstreamCredentials    int     `json:"upstream_credentials"`
+	StaticUpstreams        int     `json:"static_upstreams"`
+	ThrottleBackoff        string  `json:"upstream_throttle_backoff"`
+	SpendAccounting        bool    `json:"spend_accounting"`
+	BudgetWarnOnly         bool    `json:"budget_warn_only"`
+}
+
+// Settings returns the settings in effect, read from the live router fields.
+func (r *Router) Settings() Settings {
+	return Settings{
+		NodeOfflineTTL:         r.NodeOfflineTTL.String(),
+		ExposeRoutingHeaders:   r.ExposeRoutingHeaders,
+		NormalizeModelNames:    r.NormalizeModelNames,
+		ModelPriorityWeight:    r.ModelPriorityWeight,
+		PreferLeastModels:      r.PreferLeastModels,
+		GenSpeedWeightBytes:    r.GenSpeedWeight,
+		OverloadThreshold:      r.OverloadThreshold,
+		PlacementStrategy:      r.PlacementStrategy,
+		HashKeyHeader:          r.HashKeyHeader,
+		HashPrefixChars:        r.HashPrefixChars,
+		Zone:                   r.Zone,
+		ZoneHeader:             r.ZoneHeader,
+		CrossZonePenaltyBytes:  r.CrossZonePenalty,
+		ExcludeUnknownRAM:      r.ExcludeUnknownRAM,
+		ReserveNodes:           r.ReserveNodes,
+		ReserveNodeUtil:        r.ReserveNodeUtil,
+		ReserveRAMFraction:     r.ReserveRAMFraction,
+		WarnStructuredOutput:   r.WarnStructuredOutput,
+		EmbeddingsRequireReady: r.EmbeddingsRequireReady,
+		EmbeddingsChunkSize:    r.EmbeddingsChunkSize,
+		LoadRetryAfter:         r.LoadRetryAfter.String(),
+		DefaultModerationModel: r.DefaultModerationModel,
+		EarlyModelCheck:        r.EarlyModelCheck,
+		StreamOverrideHeader:   r.StreamOverrideHeader,
+		MaxBodyBytes:           r.MaxBodyBytes,
+		BodyReadTimeout:        r.BodyReadTimeout.String(),
+		MaxConnsPerNode:        r.MaxConnsPerNode,
+		MaxIdleConnsPerNode:    r.MaxIdleConnsPerNode,
+		MaxConcurrentRequests:  r.MaxConcurrentRequests,
+		PriorityReserve:        r.PriorityReserve,
+		StatusStaleAfter:       r.StatusStaleAfter.String(),
+		StaleNoColdLoads:       r.StaleNoColdLoads,
+		RefuseOversizedLoads:   r.RefuseOversizedLoads,
+		WaitOnReportedLoads:    r.WaitOnReportedLoads,
+		LoaderFailovers:        r.LoaderFailovers,
+		InflightTokenUnit:      r.InflightTokenUnit,
+		UpstreamTLSCustomCA:    r.UpstreamTLS != nil && r.UpstreamTLS.RootCAs != nil,
+		UpstreamTLSInsecure:    r.UpstreamTLS != nil && r.UpstreamTLS.InsecureSkipVerify,
+		UpstreamCredentials:    len(r.UpstreamCredentials),
+		StaticUpstreams:        len(r.StaticUpstreams),
+		ThrottleBackoff:        r.ThrottleBackoff.String(),
+		SpendAccounting:        r.Spend != nil,
+		BudgetWarnOnly:         r.BudgetWarnOnly,
+	}
+}
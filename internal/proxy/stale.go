@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// errStatusStale is returned instead of a cold load while no node status arrived for
+// StatusStaleAfter (with StaleNoColdLoads).
+var errStatusStale = errors.New("node status is stale, not loading a model on outdated capacity data")
+
+// WriteStatusMetrics writes the age of the most recent node status update and whether
+// it counts as stale, in the Prometheus text format (nothing before the first update).
+func (r *Router) WriteStatusMetrics(w io.Writer) error {
+	if r.Cluster.LastStatusUpdate().IsZero() {
+		return nil
+	}
+	age, stale := r.Cluster.StatusStale(time.Now(), r.StatusStaleAfter)
+	staleVal := 0
+	if stale {
+		staleVal = 1
+	}
+	_, err := fmt.Fprintf(w, "# HELP llm_router_last_status_age_seconds Seconds since the most recent status update of any node.\n"+
+		"# TYPE llm_router_last_status_age_seconds gauge\n"+
+		"llm_router_last_status_age_seconds %.3f\n"+
+		"# HELP llm_router_status_stale Whether the most recent node status is older than STATUS_STALE_SECONDS.\n"+
+		"# TYPE llm_router_status_stale gauge\n"+
+		"llm_router_status_stale %d\n",
+		age.Seconds(), staleVal)
+	return err
+}
@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestStaleStatusOnlyStopsColdLoads(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.StaleNoColdLoads = true
+	r.StatusStaleAfter = time.Millisecond
+	addNode(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelReady}})
+	time.Sleep(5 * time.Millisecond) // no status since
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if res, err := r.pickNodeForModel(req, "m"); err != nil || res.NodeID != "a" || res.Mode != pickDirect {
+		t.Errorf("READY model = %+v, %v, want routed to a", res, err)
+	}
+	if res, err := r.pickNodeForModel(req, "cold"); err == nil || res.Reason != ReasonStatusStale {
+		t.Errorf("cold model = %+v, %v, want refused as stale", res, err)
+	}
+
+	// The next status ends it.
+	setModels(c, testNode{id: "a", models: map[string]state.ModelState{"m": state.ModelReady}})
+	if res, err := r.pickNodeForModel(req, "cold"); err != nil || res.Mode != pickCold {
+		t.Errorf("cold model after a fresh status = %+v, %v, want a cold load", res, err)
+	}
+}
+
+func TestWriteStatusMetrics(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.StatusStaleAfter = time.Millisecond
+
+	var sb strings.Builder
+	if err := r.WriteStatusMetrics(&sb); err != nil || sb.Len() != 0 {
+		t.Fatalf("metrics before any status = %q, %v, want none", sb.String(), err)
+	}
+
+	addNode(c, testNode{id: "a"})
+	time.Sleep(5 * time.Millisecond)
+	if err := r.WriteStatusMetrics(&sb); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{"llm_router_last_status_age_seconds 0.", "llm_router_status_stale 1\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics lack %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// staticNodePrefix prefixes the node id of a static upstream ("static:<model>"), used
+// for node ACLs, PROXY_UPSTREAM_CREDENTIALS, latency and X-Served-By.
+const staticNodePrefix = "static:"
+
+// ParseStaticUpstreams parses comma separated "model=url" entries: models served by
+// an endpoint outside the cluster (e.g. a hosted API) at the base URL url.
+func ParseStaticUpstreams(s string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		modelID, raw, ok := strings.Cut(entry, "=")
+		modelID, raw = strings.TrimSpace(modelID), strings.TrimSpace(raw)
+		if !ok || modelID == "" || raw == "" {
+			return nil, fmt.Errorf("entry %q: expected model=url", entry)
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("model %s: invalid url %q", modelID, raw)
+		}
+		if _, dup := out[modelID]; dup {
+			return nil, fmt.Errorf("model %s listed twice", modelID)
+		}
+		out[modelID] = raw
+	}
+	return out, nil
+}
+
+// isStaticNode reports whether nodeID is a static upstream.
+func isStaticNode(nodeID string) bool {
+	return strings.HasPrefix(nodeID, staticNodePrefix)
+}
+
+// staticUpstream returns the static upstream of modelID as node, if one is configured
+// and the API key may use it (node ACL on "static:<model>").
+func (r *Router) staticUpstream(modelID string, rec *policy.APIKeyRecord) (*state.NodeSnapshot, bool) {
+	u, ok := r.StaticUpstreams[modelID]
+	if !ok {
+		return nil, false
+	}
+	nodeID := staticNodePrefix + modelID
+	if rec != nil && !auth.CheckACL(rec.AllowedNodes, nodeID) {
+		return nil, false
+	}
+	return &state.NodeSnapshot{NodeID: nodeID, DataPlaneURL: u}, true
+}
@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+// streamOverride returns the "stream" value forced for req: by the API key's
+// ForceStream or, if the key has none, by the StreamOverrideHeader ("true"/"false").
+// ok is false if neither applies.
+func (r *Router) streamOverride(req *http.Request) (stream, ok bool) {
+	if rec := auth.GetAuthRecord(req); rec != nil {
+		switch rec.ForceStream {
+		case policy.ForceStreamOn:
+			return true, true
+		case policy.ForceStreamOff:
+			return false, true
+		}
+	}
+	if r.StreamOverrideHeader == "" {
+		return false, false
+	}
+	v := strings.TrimSpace(req.Header.Get(r.StreamOverrideHeader))
+	if v == "" {
+		return false, false
+	}
+	stream, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, false
+	}
+	return stream, true
+}
+
+// applyStreamOverride sets "stream" in a chat or completion body if streamOverride
+// applies. Bodies that need no change are returned unchanged (byte for byte).
+func (r *Router) applyStreamOverride(req *http.Request, body []byte) []byte {
+	stream, ok := r.streamOverride(req)
+	if !ok {
+		return body
+	}
+	out, err := forceStream(body, stream)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// forceStream sets "stream" to stream. Without streaming, "stream_options" is removed
+// as well: OpenAI-compatible servers reject it for non-stream requests.
+func forceStream(body []byte, stream bool) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	var cur bool
+	if v, ok := fields["stream"]; ok && !bytes.Equal(bytes.TrimSpace(v), []byte("null")) {
+		if err := json.Unmarshal(v, &cur); err != nil {
+			cur = !stream // not a bool: replace it
+		}
+	}
+	_, hasOptions := fields["stream_options"]
+	if cur == stream && (stream || !hasOptions) {
+		return body, nil
+	}
+
+	fields["stream"] = json.RawMessage(strconv.FormatBool(stream))
+	if !stream {
+		delete(fields, "stream_options")
+	}
+	return json.Marshal(fields)
+}
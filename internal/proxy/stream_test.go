@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestStreamOverride(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		force  string // key's ForceStream ("-" = no key)
+		header string
+		stream bool
+		ok     bool
+	}{
+		{"nothing", "-", "", false, false},
+		{"key on", policy.ForceStreamOn, "", true, true},
+		{"key off", policy.ForceStreamOff, "", false, true},
+		{"header on", "", "true", true, true},
+		{"header off", "-", "0", false, true},
+		{"key wins over header", policy.ForceStreamOff, "true", false, true},
+		{"invalid header", "", "yes please", false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, _ := newTestRouter(t)
+			r.StreamOverrideHeader = "X-Force-Stream"
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			if tc.header != "" {
+				req.Header.Set("X-Force-Stream", tc.header)
+			}
+			if tc.force != "-" {
+				req = auth.WithAuthRecord(req, &policy.APIKeyRecord{ID: "k", ForceStream: tc.force})
+			}
+			stream, ok := r.streamOverride(req)
+			if stream != tc.stream || ok != tc.ok {
+				t.Errorf("streamOverride = %v, %v, want %v, %v", stream, ok, tc.stream, tc.ok)
+			}
+		})
+	}
+
+	// The header is ignored unless configured.
+	r, _, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("X-Force-Stream", "true")
+	if _, ok := r.streamOverride(req); ok {
+		t.Error("override applied without StreamOverrideHeader")
+	}
+}
+
+func TestForceStream(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		body      string
+		stream    bool
+		unchanged bool
+		want      map[string]any // fields checked in the result; nil value = absent
+	}{
+		{"on when missing", `{"model":"m"}`, true, false, map[string]any{"stream": true}},
+		{"on when false", `{"model":"m","stream":false}`, true, false, map[string]any{"stream": true}},
+		{"on already", `{"model":"m", "stream": true}`, true, true, nil},
+		{"off when true", `{"model":"m","stream":true,"stream_options":{"include_usage":true}}`, false, false, map[string]any{"stream": false, "stream_options": nil}},
+		{"off when missing", `{"model":"m"}`, false, true, nil},
+		{"off drops options", `{"model":"m","stream":false,"stream_options":{}}`, false, false, map[string]any{"stream": false, "stream_options": nil}},
+		{"on replaces non-bool", `{"model":"m","stream":"yes"}`, true, false, map[string]any{"stream": true}},
+		{"on replaces null", `{"model":"m","stream":null}`, true, false, map[string]any{"stream": true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := forceStream([]byte(tc.body), tc.stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.unchanged {
+				if string(out) != tc.body {
+					t.Errorf("body = %s, want it unchanged", out)
+				}
+				return
+			}
+			var got map[string]any
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got["model"] != "m" {
+				t.Errorf("model = %v, want other fields kept", got["model"])
+			}
+			for k, want := range tc.want {
+				if v, ok := got[k]; want == nil && ok || want != nil && v != want {
+					t.Errorf("%s = %v, want %v", k, v, want)
+				}
+			}
+		})
+	}
+
+	if _, err := forceStream([]byte(`not json`), true); err == nil {
+		t.Error("forceStream of invalid JSON succeeded")
+	}
+}
+
+func TestStreamOverrideReachesNode(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		force  string
+		body   string
+		stream bool
+	}{
+		{"forced on", policy.ForceStreamOn, `{"model":"m","messages":[]}`, true},
+		{"forced off", policy.ForceStreamOff, `{"model":"m","messages":[],"stream":true}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got struct{ Stream bool }
+			node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				b, _ := io.ReadAll(req.Body)
+				if err := json.Unmarshal(b, &got); err != nil {
+					t.Errorf("node got invalid body %s", b)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, `{}`)
+			}))
+			t.Cleanup(node.Close)
+
+			r, c, _ := newTestRouter(t)
+			addNode(c, testNode{id: "a", url: node.URL, models: map[string]state.ModelState{"m": state.ModelReady}})
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(tc.body))
+			req = auth.WithAuthRecord(req, &policy.APIKeyRecord{ID: "k", ForceStream: tc.force})
+			w := httptest.NewRecorder()
+			r.HandleChatCompletions(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if got.Stream != tc.stream {
+				t.Errorf("node got stream %v, want %v", got.Stream, tc.stream)
+			}
+		})
+	}
+}
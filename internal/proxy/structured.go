@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+)
+
+// textCapabilities are the reported capabilities of models that generate text. No node
+// reports structured output on its own: llama.cpp constrains any text-generating model
+// to a json_schema/json_object response_format with a grammar, so a model reporting
+// only other capabilities (e.g. embedding) is the one that cannot serve it.
+var textCapabilities = []string{"chat", "completion"}
+
+// responseFormatType returns response_format.type of a chat body ("" if absent).
+// Decoding into the small struct skips the (possibly large) schema without copying it;
+// the body itself is forwarded unchanged.
+func responseFormatType(body []byte) string {
+	var tmp struct {
+		ResponseFormat struct {
+			Type string `json:"type"`
+		} `json:"response_format"`
+	}
+	_ = json.Unmarshal(body, &tmp)
+	return tmp.ResponseFormat.Type
+}
+
+// warnStructuredOutput logs when a request asking for structured output (json_schema or
+// json_object) is routed to a node whose reported capabilities for the model include
+// no text generation (see textCapabilities). Nodes that report no capabilities at all
+// are not flagged; there is nothing to compare.
+func (r *Router) warnStructuredOutput(modelID, nodeID string, body []byte) {
+	if !r.WarnStructuredOutput {
+		return
+	}
+	format := responseFormatType(body)
+	if format != "json_schema" && format != "json_object" {
+		return
+	}
+	m, _ := r.modelStateOnNode(modelID, nodeID)
+	if len(m.Capabilities) == 0 || slices.ContainsFunc(m.Capabilities, func(c string) bool {
+		return slices.Contains(textCapabilities, c)
+	}) {
+		return
+	}
+	log.Printf("WARNING: structured output request (response_format=%s) for model %s routed to node %s, which reports no text generation for it (reported: %v)",
+		format, modelID, nodeID, m.Capabilities)
+}
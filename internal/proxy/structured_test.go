@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// largeSchemaBody returns a chat request with a json_schema response_format of about
+// 1 MiB, formatted the way the router must not touch (indentation, key order).
+func largeSchemaBody(t *testing.T, model string) []byte {
+	t.Helper()
+	var props strings.Builder
+	for i := range 8000 {
+		if i > 0 {
+			props.WriteString(",\n")
+		}
+		fmt.Fprintf(&props, `      "field_%05d": {"type": "string", "description": "value number %d of the record"}`, i, i)
+	}
+	body := fmt.Sprintf(`{
+  "model": %q,
+  "messages": [{"role": "user", "content": "fill the record"}],
+  "response_format": {"type": "json_schema", "json_schema": {"name": "record", "strict": true, "schema": {
+    "type": "object",
+    "properties": {
+%s
+    }
+  }}}
+}`, model, props.String())
+	if !json.Valid([]byte(body)) {
+		t.Fatal("test body is not valid json")
+	}
+	return []byte(body)
+}
+
+func TestStructuredOutputPassthroughAndWarning(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		caps []string
+		warn bool
+	}{
+		{"no capabilities reported", nil, false},
+		{"chat model", []string{"chat", "tools"}, false},
+		{"completion model", []string{"completion", "multimodal"}, false},
+		{"embedding-only model", []string{"embedding"}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				received []byte
+			)
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				b, _ := io.ReadAll(req.Body)
+				mu.Lock()
+				received = b
+				mu.Unlock()
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"choices":[]}`)
+			}))
+			defer upstream.Close()
+
+			r, cluster, _ := newTestRouter(t)
+			r.WarnStructuredOutput = true
+			cluster.UpsertNodeHello("n1", "test", "", upstream.URL, "", state.DataPlaneTLS{}, nil)
+			cluster.UpdateNodeStatus("n1", 64<<30, 32<<30, "", 0, 0, 0, map[string]state.ModelResidency{
+				"m": {ModelID: "m", State: state.ModelReady, Capabilities: tc.caps},
+			})
+
+			var logs bytes.Buffer
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(&logs)
+
+			body := largeSchemaBody(t, "m")
+			w := httptest.NewRecorder()
+			r.HandleChatCompletions(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !bytes.Equal(received, body) {
+				t.Errorf("upstream received %d bytes that differ from the %d sent", len(received), len(body))
+			}
+			if got := strings.Contains(logs.String(), "structured output request"); got != tc.warn {
+				t.Errorf("warning logged = %v, want %v\n%s", got, tc.warn, logs.String())
+			}
+		})
+	}
+}
+
+func TestResponseFormatType(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"model":"m"}`: "",
+		`{"response_format":{"type":"json_object"}}`:                         "json_object",
+		`{"response_format":{"type":"json_schema","json_schema":{"a":[1]}}}`: "json_schema",
+		`{"response_format":"text"}`:                                         "",
+		`not json`:                                                           "",
+	} {
+		if got := responseFormatType([]byte(body)); got != want {
+			t.Errorf("responseFormatType(%s) = %q, want %q", body, got, want)
+		}
+	}
+}
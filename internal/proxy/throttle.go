@@ -0,0 +1,158 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// nodeThrottle records the 429 responses of a node (see observeThrottle).
+type nodeThrottle struct {
+	total uint64    // 429 responses since start
+	last  time.Time // last 429
+	until time.Time // placement avoids the node before this (ThrottleBackoff)
+}
+
+// observeThrottle handles a 429 of a node's backend: it is counted, and with
+// ThrottleBackoff the node is avoided by placement for the backend's Retry-After
+// (at most ThrottleBackoff, the whole of it without Retry-After). With ThrottleBackoff
+// a 429 without Retry-After gets one, so clients back off as well.
+func (r *Router) observeThrottle(nodeID string, h http.Header, now time.Time) {
+	wait, ok := parseRetryAfter(h.Get("Retry-After"), now)
+	if !ok && r.ThrottleBackoff > 0 {
+		wait = r.ThrottleBackoff
+		h.Set("Retry-After", strconv.Itoa(max(int(math.Ceil(wait.Seconds())), 1)))
+	}
+
+	r.throttleMu.Lock()
+	defer r.throttleMu.Unlock()
+	if r.throttled == nil {
+		r.throttled = map[string]*nodeThrottle{}
+	}
+	t := r.throttled[nodeID]
+	if t == nil {
+		t = &nodeThrottle{}
+		r.throttled[nodeID] = t
+	}
+	t.total++
+	t.last = now
+	if r.ThrottleBackoff > 0 {
+		if until := now.Add(min(wait, r.ThrottleBackoff)); until.After(t.until) {
+			t.until = until
+		}
+	}
+}
+
+// PruneThrottles drops the 429 records of nodes that are offline or unknown and had
+// no 429 for at least grace, so node ids that never come back do not pile up. Their
+// metrics disappear with them. It returns the number dropped.
+func (r *Router) PruneThrottles(now time.Time, grace time.Duration) int {
+	online := map[string]bool{}
+	for _, n := range r.Cluster.SnapshotOnline(now, r.NodeOfflineTTL) {
+		online[n.NodeID] = true
+	}
+
+	r.throttleMu.Lock()
+	defer r.throttleMu.Unlock()
+
+	dropped := 0
+	for nodeID, t := range r.throttled {
+		if online[nodeID] || now.Sub(t.last) < grace || now.Before(t.until) {
+			continue
+		}
+		delete(r.throttled, nodeID)
+		dropped++
+	}
+	return dropped
+}
+
+// parseRetryAfter parses a Retry-After value in seconds or as HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// avoidThrottled drops the nodes backing off after a 429 from nodes, unless that would
+// leave none: a throttled node is still better than refusing the request.
+func (r *Router) avoidThrottled(nodes []*state.NodeSnapshot, now time.Time) []*state.NodeSnapshot {
+	if r.ThrottleBackoff <= 0 {
+		return nodes
+	}
+	r.throttleMu.Lock()
+	defer r.throttleMu.Unlock()
+	if len(r.throttled) == 0 {
+		return nodes
+	}
+	kept := make([]*state.NodeSnapshot, 0, len(nodes))
+	for _, n := range nodes {
+		if t := r.throttled[n.NodeID]; t == nil || !now.Before(t.until) {
+			kept = append(kept, n)
+		}
+	}
+	if len(kept) == 0 {
+		return nodes
+	}
+	return kept
+}
+
+// WriteThrottleMetrics writes the 429 responses per node and whether the node is
+// currently avoided, in the Prometheus text format (nothing before the first 429).
+func (r *Router) WriteThrottleMetrics(w io.Writer) error {
+	now := time.Now()
+	r.throttleMu.Lock()
+	ids := make([]string, 0, len(r.throttled))
+	for id := range r.throttled {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	type row struct {
+		total   uint64
+		backoff int
+	}
+	rows := make([]row, len(ids))
+	for i, id := range ids {
+		t := r.throttled[id]
+		rows[i].total = t.total
+		if now.Before(t.until) {
+			rows[i].backoff = 1
+		}
+	}
+	r.throttleMu.Unlock()
+
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprint(w, "# HELP llm_router_upstream_throttled_total Responses with status 429 from the node's backend (node overloaded).\n"+
+		"# TYPE llm_router_upstream_throttled_total counter\n"); err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if _, err := fmt.Fprintf(w, "llm_router_upstream_throttled_total{node=%q} %d\n", id, rows[i].total); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprint(w, "# HELP llm_router_upstream_backoff Whether placement currently avoids the node after a 429.\n"+
+		"# TYPE llm_router_upstream_backoff gauge\n"); err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if _, err := fmt.Fprintf(w, "llm_router_upstream_backoff{node=%q} %d\n", id, rows[i].backoff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// throttlingNode answers every completion with 429 and the given Retry-After ("" = none).
+func throttlingNode(t *testing.T, retryAfter string, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits.Add(1)
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUpstream429(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		backoff    time.Duration
+		retryAfter string // sent by the backend
+		wantHeader string // seen by the client
+		avoided    bool   // next request goes to b
+	}{
+		{"backoff off", 0, "", "", false},
+		{"backoff off keeps backend header", 0, "7", "7", false},
+		{"backoff without backend header", 30 * time.Second, "", "30", true},
+		{"backend header within backoff", 30 * time.Second, "5", "5", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.ThrottleBackoff = tc.backoff
+
+			var onA, onB atomic.Int32
+			a := throttlingNode(t, tc.retryAfter, &onA)
+			b := fakeLlama(t, "m", "loaded", &onB)
+			ready := map[string]state.ModelState{"m": state.ModelReady}
+			addNode(c, testNode{id: "a", url: a.URL, avail: 60 << 30, models: ready}) // a scores better
+			addNode(c, testNode{id: "b", url: b.URL, avail: 8 << 30, models: ready})
+
+			send := func() *httptest.ResponseRecorder {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"m","messages":[]}`))
+				r.HandleChatCompletions(w, req)
+				return w
+			}
+
+			w := send()
+			if w.Code != http.StatusTooManyRequests {
+				t.Fatalf("status = %d, want the backend's 429", w.Code)
+			}
+			if got := w.Header().Get("Retry-After"); got != tc.wantHeader {
+				t.Errorf("Retry-After = %q, want %q", got, tc.wantHeader)
+			}
+			if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+				t.Errorf("rate limit header = %q, want it passed through", got)
+			}
+
+			send()
+			if avoided := onB.Load() == 1; avoided != tc.avoided {
+				t.Errorf("second request on a=%d b=%d, want a avoided %v", onA.Load(), onB.Load(), tc.avoided)
+			}
+
+			var buf bytes.Buffer
+			if err := r.WriteThrottleMetrics(&buf); err != nil {
+				t.Fatal(err)
+			}
+			wantTotal := 1
+			if !tc.avoided {
+				wantTotal = 2
+			}
+			if want := fmt.Sprintf(`llm_router_upstream_throttled_total{node="a"} %d`, wantTotal); !strings.Contains(buf.String(), want) {
+				t.Errorf("metrics:\n%s\nwant %s", buf.String(), want)
+			}
+		})
+	}
+}
+
+func TestThrottleBackoffCapped(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	r.ThrottleBackoff = 10 * time.Second
+	now := time.Now()
+	h := http.Header{"Retry-After": {"3600"}}
+	r.observeThrottle("a", h, now)
+
+	if h.Get("Retry-After") != "3600" {
+		t.Errorf("Retry-After = %q, want the backend's value kept", h.Get("Retry-After"))
+	}
+	if until := r.throttled["a"].until; !until.Equal(now.Add(10 * time.Second)) {
+		t.Errorf("avoided until %v, want ThrottleBackoff after the 429", until.Sub(now))
+	}
+}
+
+func TestPruneThrottles(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.ThrottleBackoff = time.Minute
+	addNode(c, testNode{id: "online"})
+	now := time.Now()
+	r.observeThrottle("online", http.Header{}, now.Add(-time.Hour))
+	r.observeThrottle("gone", http.Header{}, now.Add(-time.Hour))
+	r.observeThrottle("recent", http.Header{}, now.Add(-time.Minute))
+
+	if n := r.PruneThrottles(now, 10*time.Minute); n != 1 {
+		t.Errorf("pruned %d, want 1", n)
+	}
+	for id, want := range map[string]bool{"online": true, "gone": false, "recent": true} {
+		if _, ok := r.throttled[id]; ok != want {
+			t.Errorf("record of %s kept = %v, want %v", id, ok, want)
+		}
+	}
+}
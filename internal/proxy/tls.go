@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// NewUpstreamTLS builds the base TLS config for https data plane URLs: the system roots
+// plus the PEM bundle in caFile (optional), or no verification at all. It returns nil
+// (strict defaults) if neither is set.
+func NewUpstreamTLS(caFile string, insecureSkipVerify bool) (*tls.Config, error) {
+	if caFile == "" && !insecureSkipVerify {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: insecureSkipVerify}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA file %s contains no valid certificates", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// nodeTLSConfig returns the TLS config for a node's https data plane: UpstreamTLS (or
+// strict defaults), plus the CA bundle the node reported, or no verification if the node
+// asked for it. It only takes effect for https URLs.
+func (r *Router) nodeTLSConfig(nodeID string, dpTLS state.DataPlaneTLS) *tls.Config {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if r.UpstreamTLS != nil {
+		cfg = r.UpstreamTLS.Clone()
+	}
+	if dpTLS.InsecureSkipVerify {
+		cfg.InsecureSkipVerify = true
+	}
+	if dpTLS.CAPEM != "" {
+		var pool *x509.CertPool
+		if cfg.RootCAs != nil {
+			pool = cfg.RootCAs.Clone()
+		} else if sys, err := x509.SystemCertPool(); err == nil {
+			pool = sys
+		} else {
+			pool = x509.NewCertPool()
+		}
+		if pool.AppendCertsFromPEM([]byte(dpTLS.CAPEM)) {
+			cfg.RootCAs = pool
+		} else {
+			log.Printf("WARNING: proxy: node %s reported a data plane CA bundle without valid certificates, ignoring it", nodeID)
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// errReadyUnroutable explains a refused request whose model is READY only on nodes
+// that did not report a data plane URL, instead of a generic "no nodes available".
+func errReadyUnroutable(modelID string, nodes []string) error {
+	return fmt.Errorf("model %s is ready on %s, but without data plane url (check DATA_PLANE_URL of the agent)",
+		modelID, strings.Join(nodes, ", "))
+}
+
+// warnUnroutable logs once per node and model (until PruneUnroutable forgets the pair) that a READY model cannot be routed to
+// because the node has no data plane URL.
+func (r *Router) warnUnroutable(nodeID, modelID string) {
+	if _, seen := r.unroutableWarned.LoadOrStore(nodeID+"\x00"+modelID, struct{}{}); seen {
+		return
+	}
+	log.Printf("WARNING: proxy: node %s reports model %s READY but has no data plane url; requests cannot be routed to it", nodeID, modelID)
+}
+
+// PruneUnroutable forgets the warnings of node and model pairs that are routable again
+// or gone: the node reports a data plane URL, the model left READY on it, or the node
+// is offline or unknown. A pair that becomes unroutable again is warned about again.
+// It returns the number forgotten.
+func (r *Router) PruneUnroutable(now time.Time) int {
+	online := map[string]*state.NodeSnapshot{}
+	for _, n := range r.Cluster.SnapshotOnline(now, r.NodeOfflineTTL) {
+		online[n.NodeID] = n
+	}
+
+	dropped := 0
+	r.unroutableWarned.Range(func(k, _ any) bool {
+		nodeID, modelID, _ := strings.Cut(k.(string), "\x00")
+		if n := online[nodeID]; n != nil && n.DataPlaneURL == "" && n.Models[modelID].State == state.ModelReady {
+			return true
+		}
+		r.unroutableWarned.Delete(k)
+		dropped++
+		return true
+	})
+	return dropped
+}
@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// addUnroutable adds a node without data plane URL reporting models.
+func addUnroutable(c *state.ClusterState, id string, models map[string]state.ModelState) {
+	c.UpsertNodeHello(id, "test", "", "", "", state.DataPlaneTLS{}, nil)
+	setModels(c, testNode{id: id, models: models})
+}
+
+func TestReadyOnlyOnUnroutableNode(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	r.RefuseOversizedLoads = true
+	addUnroutable(c, "a", map[string]state.ModelState{"m": state.ModelReady})
+
+	dec := &placementDecision{}
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKeyDecision{}, dec))
+	res, err := r.pickNodeForModel(req, "m")
+	if err == nil {
+		t.Fatalf("placed on %s, want a refusal", res.NodeID)
+	}
+	if res.Reason != ReasonUnroutable {
+		t.Errorf("reason = %s, want %s", res.Reason, ReasonUnroutable)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "ready on a") || !strings.Contains(msg, "DATA_PLANE_URL") {
+		t.Errorf("error = %q, want it to name the node and DATA_PLANE_URL", msg)
+	}
+	if !slices.Equal(dec.Unroutable, []string{"a"}) || !strings.Contains(dec.String(), "unroutable=a") {
+		t.Errorf("decision = %q, want unroutable=a", dec.String())
+	}
+}
+
+func TestUnroutableNodeSkippedForRoutableOne(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	addUnroutable(c, "a", map[string]state.ModelState{"m": state.ModelReady})
+	addNode(c, testNode{id: "b", models: map[string]state.ModelState{"m": state.ModelReady}})
+
+	res, err := r.pickNodeForModel(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil), "m")
+	if err != nil || res.NodeID != "b" || res.Reason != ReasonReadyDirect {
+		t.Fatalf("placed on %q (%s, %v), want b ready-direct", res.NodeID, res.Reason, err)
+	}
+}
+
+func warnedUnroutable(r *Router) []string {
+	var keys []string
+	r.unroutableWarned.Range(func(k, _ any) bool {
+		keys = append(keys, strings.ReplaceAll(k.(string), "\x00", "/"))
+		return true
+	})
+	slices.Sort(keys)
+	return keys
+}
+
+func TestPruneUnroutable(t *testing.T) {
+	r, c, _ := newTestRouter(t)
+	addUnroutable(c, "fixed", map[string]state.ModelState{"m": state.ModelReady})
+	addUnroutable(c, "unloaded", map[string]state.ModelState{"m": state.ModelReady})
+	addUnroutable(c, "gone", map[string]state.ModelState{"m": state.ModelReady})
+	addUnroutable(c, "still", map[string]state.ModelState{"m": state.ModelReady})
+	for _, id := range []string{"fixed", "unloaded", "gone", "still"} {
+		r.warnUnroutable(id, "m")
+	}
+
+	c.UpsertNodeHello("fixed", "test", "", "http://fixed", "", state.DataPlaneTLS{}, nil)
+	setModels(c, testNode{id: "unloaded"})
+	n, _ := c.Node("gone")
+	n.LastHeartbeat = time.Now().Add(-time.Hour)
+	c.RestoreNode(*n)
+
+	if got := r.PruneUnroutable(time.Now()); got != 3 {
+		t.Errorf("pruned %d, want 3", got)
+	}
+	if got := warnedUnroutable(r); !slices.Equal(got, []string{"still/m"}) {
+		t.Errorf("warned = %v, want [still/m]", got)
+	}
+
+	// A pair that breaks again is warned about again.
+	c.UpsertNodeHello("fixed", "test", "", "", "", state.DataPlaneTLS{}, nil)
+	setModels(c, testNode{id: "fixed", models: map[string]state.ModelState{"m": state.ModelReady}})
+	r.pickNodeForModel(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil), "m")
+	if got := warnedUnroutable(r); !slices.Contains(got, "fixed/m") {
+		t.Errorf("warned = %v, want fixed/m again", got)
+	}
+}
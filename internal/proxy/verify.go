@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/mcules/llm-router/internal/llama"
+)
+
+const (
+	// verifyTimeout bounds a ready check; a node that cannot answer in time fails it.
+	verifyTimeout = 2 * time.Second
+	// verifyFresh is how long a passed ready check counts for the node and model.
+	verifyFresh = 2 * time.Second
+)
+
+type ctxKeyExclude struct{}
+
+// withExcludedNode returns req with nodeID added to the nodes placement skips.
+func withExcludedNode(req *http.Request, nodeID string) *http.Request {
+	prev := excludedNodes(req)
+	next := make(map[string]bool, len(prev)+1)
+	for id := range prev {
+		next[id] = true
+	}
+	next[nodeID] = true
+	return req.WithContext(context.WithValue(req.Context(), ctxKeyExclude{}, next))
+}
+
+func excludedNodes(req *http.Request) map[string]bool {
+	ex, _ := req.Context().Value(ctxKeyExclude{}).(map[string]bool)
+	return ex
+}
+
+// pickVerified runs placement and, for models whose policy sets VerifyReady, checks
+// that a READY node picked directly still has the model loaded. A node failing the
+// check is excluded and placement runs again, possibly ending in a cold load.
+func (r *Router) pickVerified(req *http.Request, modelID string, readyOnly bool) (PlacementResult, error) {
+	for {
+		res, err := r.pickNode(req, modelID, readyOnly)
+		if err != nil || res.Reason != ReasonReadyDirect || r.readyVerified(req.Context(), res.node(), modelID) {
+			return res, err
+		}
+		req = withExcludedNode(req, res.NodeID)
+	}
+}
+
+// readyVerified reports whether the model may be routed to node: always without
+// VerifyReady, otherwise if the node passed a ready check within verifyFresh.
+func (r *Router) readyVerified(ctx context.Context, node pickedNode, modelID string) bool {
+	pol, _, _ := r.getPolicy(ctx, modelID)
+	if !pol.VerifyReady {
+		return true
+	}
+	key := node.NodeID + "\x00" + modelID
+	now := time.Now()
+	r.verifiedMu.Lock()
+	at, ok := r.verified[key]
+	r.verifiedMu.Unlock()
+	if ok && now.Sub(at) < verifyFresh {
+		return true
+	}
+
+	if err := r.probeModel(ctx, node, modelID); err != nil {
+		log.Printf("WARNING: proxy: ready check failed node=%s model=%s, placing again: %v", node.NodeID, modelID, err)
+		return false
+	}
+	r.verifiedMu.Lock()
+	if r.verified == nil {
+		r.verified = map[string]time.Time{}
+	}
+	for k, t := range r.verified {
+		if now.Sub(t) >= verifyFresh {
+			delete(r.verified, k)
+		}
+	}
+	r.verified[key] = now
+	r.verifiedMu.Unlock()
+	return true
+}
+
+// probeModel asks the node's llama.cpp router for its models (GET /models) and returns
+// an error unless modelID is listed as loaded. A node without the endpoint (404)
+// cannot be checked and passes.
+func (r *Router) probeModel(ctx context.Context, node pickedNode, modelID string) error {
+	target, err := r.buildTarget(node)
+	if err != nil {
+		return fmt.Errorf("invalid data plane url: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(internalContext(ctx), verifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/models", nil)
+	if err != nil {
+		return err
+	}
+
+	rec := httptest.NewRecorder()
+	r.reverseProxy(node.NodeID, target).ServeHTTP(rec, req)
+	if rec.Code == http.StatusNotFound {
+		return nil
+	}
+	if rec.Code/100 != 2 {
+		return fmt.Errorf("models status=%d", rec.Code)
+	}
+	var models llama.ModelsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &models); err != nil {
+		return fmt.Errorf("decode models: %w", err)
+	}
+	for _, m := range models.Data {
+		if m.ID != modelID {
+			continue
+		}
+		if m.Status.Failed || !strings.EqualFold(m.Status.Value, "loaded") {
+			return fmt.Errorf("model status %q", m.Status.Value)
+		}
+		return nil
+	}
+	return fmt.Errorf("model not listed")
+}
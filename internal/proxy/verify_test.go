@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/metrics"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// fakeLlama is a node data plane listing model with status and answering completions.
+func fakeLlama(t *testing.T, model, status string, completions *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/models":
+			fmt.Fprintf(w, `{"data":[{"id":%q,"status":{"value":%q}}]}`, model, status)
+		case "/v1/chat/completions":
+			completions.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReadyCheckFailsOverToAnotherNode(t *testing.T) {
+	r, c, store := newTestRouter(t)
+	r.Latency = metrics.NewLatencyTracker(0.2)
+	if err := store.UpsertPolicy(context.Background(), policy.ModelPolicy{ModelID: "m", VerifyReady: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var onA, onB atomic.Int32
+	a := fakeLlama(t, "m", "unloaded", &onA) // reports READY, but the model is gone
+	b := fakeLlama(t, "m", "loaded", &onB)
+	ready := map[string]state.ModelState{"m": state.ModelReady}
+	addNode(c, testNode{id: "a", url: a.URL, models: ready})
+	addNode(c, testNode{id: "b", url: b.URL, inflight: 3, models: ready}) // a scores better
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"m","messages":[]}`))
+	w := httptest.NewRecorder()
+	r.HandleChatCompletions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if onA.Load() != 0 || onB.Load() != 1 {
+		t.Errorf("completions on a=%d b=%d, want the request on b", onA.Load(), onB.Load())
+	}
+	// The ready checks are not client traffic: only the completion counts for latency.
+	if _, ok := r.Latency.Get("a"); ok {
+		t.Error("ready check on a was recorded as latency")
+	}
+	if l, ok := r.Latency.Get("b"); !ok || l.OK != 1 || l.Error != 0 {
+		t.Errorf("latency of b = %+v, want one observation", l)
+	}
+}
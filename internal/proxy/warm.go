@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/mcules/llm-router/internal/control"
+)
+
+// warmLoadTimeout bounds the load call; llama.cpp answers once the load has started.
+const warmLoadTimeout = 30 * time.Second
+
+// WarmLoad implements planner.WarmLoader: it starts the load of modelID on the node a
+// cold request would be placed on, without a client request, like POST /v1/models/load
+// (see startLoad). The node becomes the model's loader, so requests arriving meanwhile
+// wait for it instead of loading the model elsewhere. started is false if the model is
+// already READY or loading; a load queued for a reconnecting node counts as started.
+func (r *Router) WarmLoad(ctx context.Context, modelID string) (nodeID string, started bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/models/load", nil)
+	if err != nil {
+		return "", false, err
+	}
+	res, err := r.pickNode(req, modelID, false)
+	if err != nil {
+		return "", false, err
+	}
+	if res.Reason != ReasonColdLoad {
+		return res.NodeID, false, nil
+	}
+
+	if err := r.startLoad(ctx, res.node(), modelID); err != nil && !errors.Is(err, control.ErrQueued) {
+		r.clearLoader(modelID, res.NodeID)
+		return res.NodeID, false, err
+	}
+	return res.NodeID, true, nil
+}
+
+// sendLoad asks the node's llama.cpp router to load modelID (POST /models/load). It
+// goes through the node's reverse proxy as an internal request (see internalContext).
+func (r *Router) sendLoad(ctx context.Context, node pickedNode, modelID string) error {
+	target, err := r.buildTarget(node)
+	if err != nil {
+		return fmt.Errorf("invalid data plane url: %w", err)
+	}
+	body, _ := json.Marshal(struct {
+		Model string `json:"model"`
+	}{Model: modelID})
+
+	ctx, cancel := context.WithTimeout(internalContext(ctx), warmLoadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/models/load", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	r.reverseProxy(node.NodeID, target).ServeHTTP(rec, req)
+	if rec.Code/100 != 2 {
+		return fmt.Errorf("load status=%d: %s", rec.Code, bytes.TrimSpace(rec.Body.Bytes()))
+	}
+	return nil
+}
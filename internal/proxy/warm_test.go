@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// fakeCommands records load commands and answers them with err.
+type fakeCommands struct {
+	mu    sync.Mutex
+	err   error
+	loads []string // node/model
+}
+
+func (f *fakeCommands) SendLoad(nodeID, requestID, modelID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loads = append(f.loads, nodeID+"/"+modelID)
+	return f.err
+}
+
+func TestWarmLoadUsesStartLoad(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		err        error
+		wantLoader bool
+	}{
+		{"sent", nil, true},
+		{"queued", control.ErrQueued, true},
+		{"failed", errors.New("node stream not available"), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			cmds := &fakeCommands{err: tc.err}
+			r.Commands = cmds
+			addNode(c, testNode{id: "n1", models: map[string]state.ModelState{}})
+
+			nodeID, started, err := r.WarmLoad(context.Background(), "m")
+			if (err == nil) != tc.wantLoader || started != tc.wantLoader || nodeID != "n1" {
+				t.Fatalf("WarmLoad = %q, %v, %v", nodeID, started, err)
+			}
+			if len(cmds.loads) != 1 || cmds.loads[0] != "n1/m" {
+				t.Errorf("load commands = %v, want [n1/m]", cmds.loads)
+			}
+			g := r.getGate("m")
+			g.mu.Lock()
+			loader := g.loadingNode
+			g.mu.Unlock()
+			if (loader == "n1") != tc.wantLoader {
+				t.Errorf("loader = %q, want n1 = %v", loader, tc.wantLoader)
+			}
+		})
+	}
+}
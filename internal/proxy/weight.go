@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// Bounds of a request's inflight weight (see requestWeight): short answers still cost
+// prompt processing, and one huge request must not make a node look saturated forever.
+const (
+	minInflightWeight = 0.25
+	maxInflightWeight = 16
+)
+
+// nodeInflight counts the requests the router has in flight on a node.
+type nodeInflight struct {
+	count  int
+	weight float64
+}
+
+// requestWeight returns how many inflight requests a request counts as in placement:
+// its max_tokens (else max_completion_tokens or llama.cpp's n_predict) divided by
+// InflightTokenUnit, clamped to [minInflightWeight, maxInflightWeight]. Requests
+// without a positive limit, and all requests with InflightTokenUnit 0, count 1.
+func (r *Router) requestWeight(body []byte) float64 {
+	if r.InflightTokenUnit <= 0 {
+		return 1
+	}
+	var fields struct {
+		MaxTokens           *int `json:"max_tokens"`
+		MaxCompletionTokens *int `json:"max_completion_tokens"`
+		NPredict            *int `json:"n_predict"`
+	}
+	if json.Unmarshal(body, &fields) != nil {
+		return 1
+	}
+	for _, v := range []*int{fields.MaxTokens, fields.MaxCompletionTokens, fields.NPredict} {
+		if v != nil && *v > 0 {
+			w := float64(*v) / float64(r.InflightTokenUnit)
+			return min(max(w, minInflightWeight), maxInflightWeight)
+		}
+	}
+	return 1
+}
+
+// trackInflight counts a request with body as in flight on nodeID until the returned
+// func is called.
+func (r *Router) trackInflight(nodeID string, body []byte) (done func()) {
+	w := r.requestWeight(body)
+
+	r.inflightMu.Lock()
+	if r.inflight == nil {
+		r.inflight = map[string]*nodeInflight{}
+	}
+	n := r.inflight[nodeID]
+	if n == nil {
+		n = &nodeInflight{}
+		r.inflight[nodeID] = n
+	}
+	n.count++
+	n.weight += w
+	r.inflightMu.Unlock()
+
+	return func() {
+		r.inflightMu.Lock()
+		defer r.inflightMu.Unlock()
+		n.count--
+		n.weight -= w
+		if n.count == 0 {
+			delete(r.inflight, nodeID)
+		}
+	}
+}
+
+// weightedInflight returns the load of nodes for scoring with InflightTokenUnit set:
+// the router's own requests in flight count with their weight, the rest of the node's
+// reported inflight requests (other clients, or not reported yet) one each. It returns
+// nil with InflightTokenUnit 0, so scoring uses the reported counts.
+func (r *Router) weightedInflight(nodes []*state.NodeSnapshot) map[string]float64 {
+	if r.InflightTokenUnit <= 0 {
+		return nil
+	}
+	r.inflightMu.Lock()
+	defer r.inflightMu.Unlock()
+
+	out := make(map[string]float64, len(nodes))
+	for _, n := range nodes {
+		own := r.inflight[n.NodeID]
+		if own == nil {
+			out[n.NodeID] = float64(n.InflightRequests)
+			continue
+		}
+		others := max(int(n.InflightRequests), own.count) - own.count
+		out[n.NodeID] = float64(others) + own.weight
+	}
+	return out
+}
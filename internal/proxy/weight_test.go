@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/metrics"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+func TestRequestWeight(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	if w := r.requestWeight([]byte(`{"max_tokens":8000}`)); w != 1 {
+		t.Errorf("weight without InflightTokenUnit = %v, want 1", w)
+	}
+
+	r.InflightTokenUnit = 512
+	for body, want := range map[string]float64{
+		`{"max_tokens":1024}`:                         2,
+		`{"max_completion_tokens":256}`:               0.5,
+		`{"n_predict":512}`:                           1,
+		`{"max_tokens":0,"max_completion_tokens":64}`: minInflightWeight,
+		`{"max_tokens":1000000}`:                      maxInflightWeight,
+		`{"messages":[]}`:                             1,
+		`not json`:                                    1,
+	} {
+		if got := r.requestWeight([]byte(body)); got != want {
+			t.Errorf("requestWeight(%s) = %v, want %v", body, got, want)
+		}
+	}
+}
+
+// pickWithInflight places a request for m after tracking the given request bodies in
+// flight per node (each node reports them as its inflight requests).
+func pickWithInflight(t *testing.T, r *Router, c *state.ClusterState, bodies map[string][]string) string {
+	t.Helper()
+	ready := map[string]state.ModelState{"m": state.ModelReady}
+	for id, bs := range bodies {
+		addNode(c, testNode{id: id, inflight: uint32(len(bs)), models: ready})
+		for _, b := range bs {
+			t.Cleanup(r.trackInflight(id, []byte(b)))
+		}
+	}
+	res, err := r.pickNodeForModel(httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil), "m")
+	if err != nil {
+		t.Fatalf("placement: %v", err)
+	}
+	return res.NodeID
+}
+
+func TestWeightedInflightPlacement(t *testing.T) {
+	big := `{"max_tokens":8000}`
+	small := `{"max_tokens":50}`
+	bodies := map[string][]string{"a": {big}, "b": {small, small}}
+
+	for _, tc := range []struct {
+		name string
+		unit int
+		want string
+	}{
+		{"unweighted prefers fewer requests", 0, "a"},
+		{"weighted prefers the small requests", 512, "b"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.InflightTokenUnit = tc.unit
+			if got := pickWithInflight(t, r, c, bodies); got != tc.want {
+				t.Errorf("picked %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWeightedInflightOverload(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		unit int
+		want string
+	}{
+		// 100 ms x 2 requests stays below the threshold on b, a has one.
+		{"unweighted", 0, "a"},
+		// The big request weighs 15.6: a is overloaded, b carries 0.5.
+		{"weighted", 512, "b"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.InflightTokenUnit = tc.unit
+			r.OverloadThreshold = 1000
+			r.Latency = metrics.NewLatencyTracker(1)
+			r.Latency.ObserveOK("a", 100*time.Millisecond)
+			r.Latency.ObserveOK("b", 100*time.Millisecond)
+
+			bodies := map[string][]string{"a": {`{"max_tokens":8000}`}, "b": {`{"max_tokens":50}`, `{"max_tokens":50}`}}
+			if got := pickWithInflight(t, r, c, bodies); got != tc.want {
+				t.Errorf("picked %s, want %s", got, tc.want)
+			}
+			if over := r.overloadedNodes(c.Snapshot(), r.weightedInflight(c.Snapshot())); over["a"] != (tc.unit > 0) {
+				t.Errorf("overloaded = %v", over)
+			}
+		})
+	}
+}
+
+func TestInflightTieBreakWeighted(t *testing.T) {
+	// Latency-first scoring without latency data ties, so inflight decides.
+	nodes := []*state.NodeSnapshot{
+		{NodeID: "a", InflightRequests: 1},
+		{NodeID: "b", InflightRequests: 2},
+	}
+	o := scoreOpts{LatencyFirst: true}
+	if got := pickBestByScore(nodes, nil, policy.ModelPolicy{}, o); got.NodeID != "a" {
+		t.Errorf("reported counts: picked %s, want a", got.NodeID)
+	}
+	o.Inflight = map[string]float64{"a": 4, "b": 0.5}
+	if got := pickBestByScore(nodes, nil, policy.ModelPolicy{}, o); got.NodeID != "b" {
+		t.Errorf("weighted: picked %s, want b", got.NodeID)
+	}
+}
@@ -0,0 +1,17 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// preferredZone returns the zone placement should prefer: the client's ZoneHeader if
+// set, otherwise the router's own Zone ("" = no zone preference).
+func (r *Router) preferredZone(req *http.Request) string {
+	if r.ZoneHeader != "" {
+		if z := strings.TrimSpace(req.Header.Get(r.ZoneHeader)); z != "" {
+			return z
+		}
+	}
+	return r.Zone
+}
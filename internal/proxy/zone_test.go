@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// addZoneNode registers an online node in zone with the status n.
+func addZoneNode(c *state.ClusterState, zone string, n testNode) {
+	c.UpsertNodeHello(n.id, "test", "", "http://"+n.id, zone, state.DataPlaneTLS{}, nil)
+	setModels(c, n)
+}
+
+func TestZonePreference(t *testing.T) {
+	const gib = 1 << 30
+	ready := map[string]state.ModelState{"m": state.ModelReady}
+	for _, tc := range []struct {
+		name   string
+		nodes  func(t *testing.T, c *state.ClusterState)
+		header string
+		key    *policy.APIKeyRecord
+		want   string
+	}{
+		{
+			// The node id tie-break alone would pick a-us.
+			name: "same zone beats an equal node elsewhere",
+			nodes: func(t *testing.T, c *state.ClusterState) {
+				addZoneNode(c, "us", testNode{id: "a-us", models: ready})
+				addZoneNode(c, "eu", testNode{id: "b-eu", models: ready})
+			},
+			want: "b-eu",
+		},
+		{
+			name: "cold load in the same zone",
+			nodes: func(t *testing.T, c *state.ClusterState) {
+				addZoneNode(c, "us", testNode{id: "a-us"})
+				addZoneNode(c, "eu", testNode{id: "b-eu"})
+			},
+			want: "b-eu",
+		},
+		{
+			name: "cross zone when the same-zone node may not be used",
+			nodes: func(t *testing.T, c *state.ClusterState) {
+				addZoneNode(c, "us", testNode{id: "a-us", models: ready})
+				addZoneNode(c, "eu", testNode{id: "b-eu", models: ready})
+			},
+			key:  &policy.APIKeyRecord{AllowedNodes: "a-us"},
+			want: "a-us",
+		},
+		{
+			name: "cross zone when the same-zone node is offline",
+			nodes: func(t *testing.T, c *state.ClusterState) {
+				addZoneNode(c, "us", testNode{id: "a-us"})
+				addZoneNode(c, "eu", testNode{id: "b-eu", models: ready})
+				takeOffline(t, c, "b-eu")
+			},
+			want: "a-us",
+		},
+		{
+			name: "penalty outweighed by free RAM",
+			nodes: func(t *testing.T, c *state.ClusterState) {
+				addZoneNode(c, "us", testNode{id: "a-us", avail: 40 * gib, models: ready})
+				addZoneNode(c, "eu", testNode{id: "b-eu", avail: 8 * gib, models: ready})
+			},
+			want: "a-us",
+		},
+		{
+			name: "client zone header",
+			nodes: func(t *testing.T, c *state.ClusterState) {
+				addZoneNode(c, "us", testNode{id: "a-us", models: ready})
+				addZoneNode(c, "eu", testNode{id: "b-eu", models: ready})
+			},
+			header: "us",
+			want:   "a-us",
+		},
+		{
+			name: "blank header keeps the router zone",
+			nodes: func(t *testing.T, c *state.ClusterState) {
+				addZoneNode(c, "eu", testNode{id: "b-eu", models: ready})
+				addZoneNode(c, "us", testNode{id: "c-us", models: ready})
+			},
+			header: "  ",
+			want:   "b-eu",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, c, _ := newTestRouter(t)
+			r.Zone = "eu"
+			r.ZoneHeader = "X-Zone"
+			r.CrossZonePenalty = 4 * gib
+			tc.nodes(t, c)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			if tc.header != "" {
+				req.Header.Set("X-Zone", tc.header)
+			}
+			if tc.key != nil {
+				req = auth.WithAuthRecord(req, tc.key)
+			}
+			res, err := r.pickNodeForModel(req, "m")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.NodeID != tc.want {
+				t.Errorf("placed on %s, want %s", res.NodeID, tc.want)
+			}
+		})
+	}
+}
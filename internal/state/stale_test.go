@@ -0,0 +1,37 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusStale(t *testing.T) {
+	cs := NewClusterState()
+	now := time.Now()
+	if age, stale := cs.StatusStale(now, time.Second); age != 0 || stale {
+		t.Fatalf("before any status: age %v, stale %v, want 0, false", age, stale)
+	}
+
+	cs.UpsertNodeHello("n1", "test", "", "http://n1", "", DataPlaneTLS{}, nil)
+	cs.UpdateNodeStatus("n1", 64<<30, 32<<30, "", 0, 0, 0, nil)
+	last := cs.LastStatusUpdate()
+	if last.IsZero() {
+		t.Fatal("status update not recorded")
+	}
+
+	// Updates stall: the snapshot keeps its values, only the age tells.
+	for _, tc := range []struct {
+		later time.Duration
+		after time.Duration
+		want  bool
+	}{
+		{time.Second, time.Minute, false},
+		{2 * time.Minute, time.Minute, true},
+		{time.Hour, 0, false}, // guard off
+	} {
+		age, stale := cs.StatusStale(last.Add(tc.later), tc.after)
+		if age != tc.later || stale != tc.want {
+			t.Errorf("%v after the last status (limit %v): age %v, stale %v, want %v, %v", tc.later, tc.after, age, stale, tc.later, tc.want)
+		}
+	}
+}
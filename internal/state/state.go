@@ -1,7 +1,8 @@
 package state
 
 import (
-	"log"
+	"maps"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,11 +16,21 @@ const (
 	ModelError    ModelState = "error"
 )
 
+// NormalizeModelID folds case and surrounding whitespace for model id comparison.
+func NormalizeModelID(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
 type ModelResidency struct {
 	ModelID     string
 	State       ModelState
 	LoadedSince time.Time
 	LastSeen    time.Time
+	Error       string // last load error reported with ModelError
+
+	// Capabilities as reported by the node (best-effort, may be empty).
+	Capabilities []string
+	MaxContext   uint32
 }
 
 type NodeSnapshot struct {
@@ -27,11 +38,31 @@ type NodeSnapshot struct {
 	Version          string
 	LlamaBaseURL     string
 	DataPlaneURL     string
+	Zone             string // topology label reported by the agent ("" = none)
+	DataPlaneTLS     DataPlaneTLS
 	LastHeartbeat    time.Time
 	RAMTotalBytes    uint64
 	RAMAvailBytes    uint64
+	RAMSource        string // origin of RAMAvailBytes: "memavailable", "memfree", "memfree+cache", "static" ("" = older agent)
 	InflightRequests uint32
-	Models           map[string]ModelResidency
+	TotalSlots       uint32 // llama.cpp slots = max parallel requests (0 = unknown)
+	// GenTokensPerSec is the node's EWMA generation speed per request (0 = unknown).
+	GenTokensPerSec float64
+	Models          map[string]ModelResidency
+
+	// EndpointPaths maps logical endpoints ("chat", "chat:<model>", ...) to upstream
+	// paths for non-OpenAI backends (nil = identity). Replaced on hello, never modified.
+	EndpointPaths map[string]string
+
+	// DataPlaneRev changes whenever a hello changes the data plane URL, TLS setup or
+	// endpoint paths, so caches built from them compare it instead of the values.
+	DataPlaneRev uint64
+}
+
+// CapacityKnown reports whether the node reported a plausible RAM size. A zero total
+// means the agent could not read it; the available RAM is meaningless then.
+func (n *NodeSnapshot) CapacityKnown() bool {
+	return n.RAMTotalBytes > 0
 }
 
 // IsOnline returns true if the node heartbeat is within the given TTL.
@@ -45,9 +76,21 @@ func (n *NodeSnapshot) IsOnline(now time.Time, ttl time.Duration) bool {
 	return now.Sub(n.LastHeartbeat) <= ttl
 }
 
+// DataPlaneTLS is the TLS setup a node reports for an https data plane URL.
+type DataPlaneTLS struct {
+	CAPEM              string // CA bundle trusted for this node in addition to the server's roots
+	InsecureSkipVerify bool
+}
+
 type ClusterState struct {
 	mu    sync.RWMutex
 	nodes map[string]*NodeSnapshot
+
+	// lastStatus is the time of the most recent status update of any node.
+	lastStatus time.Time
+
+	// dataPlaneRev is the last DataPlaneRev handed out (unique across nodes).
+	dataPlaneRev uint64
 }
 
 func NewClusterState() *ClusterState {
@@ -56,7 +99,7 @@ func NewClusterState() *ClusterState {
 	}
 }
 
-func (cs *ClusterState) UpsertNodeHello(nodeID, version, llamaBaseURL, dataPlaneURL string) {
+func (cs *ClusterState) UpsertNodeHello(nodeID, version, llamaBaseURL, dataPlaneURL, zone string, dpTLS DataPlaneTLS, endpointPaths map[string]string) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
@@ -68,13 +111,20 @@ func (cs *ClusterState) UpsertNodeHello(nodeID, version, llamaBaseURL, dataPlane
 		}
 		cs.nodes[nodeID] = n
 	}
+	if !ok || n.DataPlaneURL != dataPlaneURL || n.DataPlaneTLS != dpTLS || !maps.Equal(n.EndpointPaths, endpointPaths) {
+		cs.dataPlaneRev++
+		n.DataPlaneRev = cs.dataPlaneRev
+	}
 	n.Version = version
 	n.LlamaBaseURL = llamaBaseURL
 	n.DataPlaneURL = dataPlaneURL
+	n.Zone = zone
+	n.DataPlaneTLS = dpTLS
+	n.EndpointPaths = endpointPaths
 	n.LastHeartbeat = time.Now()
 }
 
-func (cs *ClusterState) UpdateNodeStatus(nodeID string, ramTotal, ramAvail uint64, inflight uint32, models map[string]ModelResidency) {
+func (cs *ClusterState) UpdateNodeStatus(nodeID string, ramTotal, ramAvail uint64, ramSource string, inflight, totalSlots uint32, genTPS float64, models map[string]ModelResidency) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
@@ -89,10 +139,33 @@ func (cs *ClusterState) UpdateNodeStatus(nodeID string, ramTotal, ramAvail uint6
 	}
 	n.RAMTotalBytes = ramTotal
 	n.RAMAvailBytes = ramAvail
+	n.RAMSource = ramSource
 	n.InflightRequests = inflight
+	n.TotalSlots = totalSlots
+	n.GenTokensPerSec = genTPS
 	n.LastHeartbeat = time.Now()
 	n.Models = models
-	log.Printf("DEBUG: ClusterState updated node %s, last_heartbeat=%v, total nodes: %d", nodeID, n.LastHeartbeat.Format("15:04:05.000"), len(cs.nodes))
+	cs.lastStatus = n.LastHeartbeat
+}
+
+// LastStatusUpdate returns the time of the most recent status update of any node
+// (zero if none arrived yet).
+func (cs *ClusterState) LastStatusUpdate() time.Time {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.lastStatus
+}
+
+// StatusStale returns the age of the most recent status update and whether it exceeds
+// after. A wedged control plane stops all updates while the snapshot keeps its last
+// values. Before the first update, or with after <= 0, it is never stale.
+func (cs *ClusterState) StatusStale(now time.Time, after time.Duration) (age time.Duration, stale bool) {
+	last := cs.LastStatusUpdate()
+	if last.IsZero() {
+		return 0, false
+	}
+	age = now.Sub(last)
+	return age, after > 0 && age > after
 }
 
 func (cs *ClusterState) Snapshot() []*NodeSnapshot {
@@ -106,6 +179,40 @@ func (cs *ClusterState) Snapshot() []*NodeSnapshot {
 	return out
 }
 
+// RestoreNode replaces a node with n as recorded elsewhere (debug dumps). The node
+// keeps n's LastHeartbeat, so an old one leaves it offline until its agent connects.
+func (cs *ClusterState) RestoreNode(n NodeSnapshot) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.nodes[n.NodeID] = cloneNode(&n)
+}
+
+// Node returns a snapshot of a single node.
+func (cs *ClusterState) Node(nodeID string) (*NodeSnapshot, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	n, ok := cs.nodes[nodeID]
+	if !ok {
+		return nil, false
+	}
+	return cloneNode(n), true
+}
+
+// NodeDataPlane returns the data plane TLS setup, endpoint paths and DataPlaneRev of a
+// node without copying the whole node (used per proxied request). The paths must not
+// be modified.
+func (cs *ClusterState) NodeDataPlane(nodeID string) (DataPlaneTLS, map[string]string, uint64, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	n, ok := cs.nodes[nodeID]
+	if !ok {
+		return DataPlaneTLS{}, nil, 0, false
+	}
+	return n.DataPlaneTLS, n.EndpointPaths, n.DataPlaneRev, true
+}
+
 // SnapshotOnline returns a snapshot filtered by heartbeat TTL.
 func (cs *ClusterState) SnapshotOnline(now time.Time, ttl time.Duration) []*NodeSnapshot {
 	all := cs.Snapshot()
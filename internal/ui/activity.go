@@ -6,11 +6,11 @@ import (
 )
 
 type activityRow struct {
-	At    time.Time
-	Type  string
-	Node  string
-	Model string
-	Note  string
+	At    time.Time `json:"at"`
+	Type  string    `json:"type"`
+	Node  string    `json:"node,omitempty"`
+	Model string    `json:"model,omitempty"`
+	Note  string    `json:"note,omitempty"`
 }
 
 func (h *Handler) activity(w http.ResponseWriter, r *http.Request) {
@@ -32,5 +32,11 @@ func (h *Handler) activity(w http.ResponseWriter, r *http.Request) {
 	vm := h.newViewModel("Activity")
 	vm.Activity = rows
 	vm.User = h.getUser(r)
+	// The activity log (and its export) only holds the latest events since the start.
+	var kept int
+	if h.Activity != nil {
+		kept = h.Activity.Cap()
+	}
+	vm.Data = struct{ ActivityCap int }{ActivityCap: kept}
 	h.render(w, "activity.html", vm)
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"sort"
 
+	"github.com/mcules/llm-router/internal/auth"
 	"github.com/mcules/llm-router/internal/policy"
 )
 
@@ -14,7 +15,7 @@ func (h *Handler) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		cookie, err := r.Cookie("session")
 		if err != nil {
-			http.Redirect(w, r, "/ui/login", http.StatusFound)
+			h.redirect(w, r, "/ui/login", http.StatusFound)
 			return
 		}
 
@@ -23,7 +24,7 @@ func (h *Handler) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		username := cookie.Value
 		u, exists, err := h.PolicyStore.GetUser(r.Context(), username)
 		if err != nil || !exists {
-			http.Redirect(w, r, "/ui/login", http.StatusFound)
+			h.redirect(w, r, "/ui/login", http.StatusFound)
 			return
 		}
 
@@ -52,23 +53,23 @@ func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "session",
 		Value:    u.Username,
-		Path:     "/",
+		Path:     h.BasePath + "/",
 		HttpOnly: true,
 		MaxAge:   86400,
 	})
 
-	http.Redirect(w, r, "/ui/", http.StatusFound)
+	h.redirect(w, r, "/ui/", http.StatusFound)
 }
 
 func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "session",
 		Value:    "",
-		Path:     "/",
+		Path:     h.BasePath + "/",
 		HttpOnly: true,
 		MaxAge:   -1,
 	})
-	http.Redirect(w, r, "/ui/login", http.StatusFound)
+	h.redirect(w, r, "/ui/login", http.StatusFound)
 }
 
 func (h *Handler) users(w http.ResponseWriter, r *http.Request) {
@@ -116,6 +117,9 @@ func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if h.readOnly(w) {
+		return
+	}
 
 	username := r.FormValue("username")
 	nodes := r.FormValue("allowed_nodes")
@@ -131,7 +135,7 @@ func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.Redirect(w, r, "/ui/users", http.StatusSeeOther)
+	h.redirect(w, r, "/ui/users", http.StatusSeeOther)
 }
 
 func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
@@ -139,6 +143,9 @@ func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if h.readOnly(w) {
+		return
+	}
 
 	// Password can be changed for self, or by admin for others
 	currentUser := h.getUser(r)
@@ -167,9 +174,9 @@ func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
 	// If changing own password, maybe redirect to login?
 	// For now, just back to users or dashboard
 	if currentUser.Username == "admin" && targetUser != "admin" {
-		http.Redirect(w, r, "/ui/users", http.StatusSeeOther)
+		h.redirect(w, r, "/ui/users", http.StatusSeeOther)
 	} else {
-		http.Redirect(w, r, "/ui/", http.StatusSeeOther)
+		h.redirect(w, r, "/ui/", http.StatusSeeOther)
 	}
 }
 
@@ -178,6 +185,9 @@ func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if h.readOnly(w) {
+		return
+	}
 
 	username := r.FormValue("username")
 	password := r.FormValue("password")
@@ -195,7 +205,7 @@ func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.Redirect(w, r, "/ui/users", http.StatusSeeOther)
+	h.redirect(w, r, "/ui/users", http.StatusSeeOther)
 }
 
 func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
@@ -203,6 +213,9 @@ func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if h.readOnly(w) {
+		return
+	}
 
 	username := r.FormValue("username")
 	if username == "admin" {
@@ -215,7 +228,12 @@ func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.Redirect(w, r, "/ui/users", http.StatusSeeOther)
+	h.redirect(w, r, "/ui/users", http.StatusSeeOther)
+}
+
+// isAdmin reports whether the user is the built-in admin account.
+func isAdmin(u *policy.UserRecord) bool {
+	return u != nil && auth.IsAdmin(u.Username)
 }
 
 func (h *Handler) getUser(r *http.Request) *policy.UserRecord {
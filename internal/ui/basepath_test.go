@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/httpx"
+)
+
+func TestRedirectsUnderBasePath(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	h.BasePath = "/llm"
+	mux := http.NewServeMux()
+	h.Register(mux)
+	srv := httpx.StripBasePath("/llm", mux)
+
+	get := func(path string, session bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if session {
+			req.AddCookie(&http.Cookie{Name: "session", Value: "admin"})
+		}
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if loc := get("/llm/ui/models", false).Header().Get("Location"); loc != "/llm/ui/login" {
+		t.Errorf("without session redirected to %q, want /llm/ui/login", loc)
+	}
+
+	rec := get("/llm/ui/logout", true)
+	if loc := rec.Header().Get("Location"); loc != "/llm/ui/login" {
+		t.Errorf("logout redirected to %q, want /llm/ui/login", loc)
+	}
+	if c := rec.Result().Cookies(); len(c) != 1 || c[0].Path != "/llm/" {
+		t.Errorf("logout cookies = %v, want the session cookie on /llm/", c)
+	}
+
+	if body := get("/llm/ui/login", false).Body.String(); !strings.Contains(body, `action="/llm/ui/login"`) {
+		t.Error("login form does not post below the base path")
+	}
+	body := get("/llm/ui/", true).Body.String()
+	if !strings.Contains(body, `href="/llm/ui/models"`) || strings.Contains(body, `href="/ui/`) {
+		t.Error("navigation links do not carry the base path")
+	}
+}
@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mcules/llm-router/internal/activity"
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// ReplicaPicker chooses the replica of a model to keep when consolidating.
+type ReplicaPicker interface {
+	BestReplica(ctx context.Context, modelID string) (best string, others []string, ok bool)
+}
+
+// consolidatePlan is the confirmation shown on the models page before consolidating.
+type consolidatePlan struct {
+	ModelID string
+	Keep    string   // preselected: the best-scoring replica
+	Nodes   []string // all READY replicas, Keep first
+}
+
+// consolidatePlanFor returns the plan for modelID, or nil if it has fewer than two
+// READY replicas.
+func (h *Handler) consolidatePlanFor(ctx context.Context, modelID string) *consolidatePlan {
+	if h.Replicas == nil || modelID == "" {
+		return nil
+	}
+	best, others, ok := h.Replicas.BestReplica(ctx, modelID)
+	if !ok || len(others) == 0 {
+		return nil
+	}
+	return &consolidatePlan{ModelID: modelID, Keep: best, Nodes: append([]string{best}, others...)}
+}
+
+// consolidateModel unloads a model from all online nodes but one, to reclaim RAM while
+// keeping it available. The models page asks for confirmation first (?consolidate=)
+// and preselects the replica routing would pick; the form posts the one to keep.
+func (h *Handler) consolidateModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isAdmin(h.getUser(r)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if h.readOnly(w) {
+		return
+	}
+
+	modelID := r.FormValue("model_id")
+	keep := r.FormValue("keep")
+	if modelID == "" || keep == "" {
+		http.Error(w, "missing model_id or keep", http.StatusBadRequest)
+		return
+	}
+
+	// Re-check against the current state: the page may be outdated.
+	var targets []string
+	keepReady := false
+	for _, n := range h.Cluster.SnapshotOnline(time.Now(), h.NodeOfflineTTL) {
+		m, ok := n.Models[modelID]
+		if !ok || m.State != state.ModelReady {
+			continue
+		}
+		if n.NodeID == keep {
+			keepReady = true
+			continue
+		}
+		targets = append(targets, n.NodeID)
+	}
+	if !keepReady {
+		http.Error(w, fmt.Sprintf("model %s is no longer ready on %s", modelID, keep), http.StatusConflict)
+		return
+	}
+
+	var unloaded, failed []string
+	for _, nodeID := range targets {
+		reqID := fmt.Sprintf("unload-consolidate-%d", time.Now().UnixNano())
+		if err := h.Commands.SendUnload(nodeID, reqID, modelID); err != nil && !errors.Is(err, control.ErrQueued) {
+			log.Printf("ui: consolidate: unload failed node=%s model=%s err=%v", nodeID, modelID, err)
+			failed = append(failed, nodeID)
+			continue
+		}
+		unloaded = append(unloaded, nodeID)
+
+		if h.Activity != nil {
+			h.Activity.Add(activity.Event{
+				At:     time.Now(),
+				Type:   activity.EventManualUnload,
+				NodeID: nodeID,
+				Model:  modelID,
+				Note:   "consolidate, kept on " + keep,
+			})
+		}
+	}
+
+	q := url.Values{}
+	q.Set("consolidated", modelID)
+	q.Set("kept", keep)
+	q.Set("unloaded", strings.Join(unloaded, ","))
+	if len(failed) > 0 {
+		q.Set("failed", strings.Join(failed, ","))
+	}
+	h.redirect(w, r, "/ui/models?"+q.Encode(), http.StatusSeeOther)
+}
+
+// consolidateResult is the outcome shown after consolidating.
+type consolidateResult struct {
+	ModelID  string
+	Kept     string
+	Unloaded []string
+	Failed   []string
+}
+
+func consolidateResultFrom(q url.Values) *consolidateResult {
+	modelID := q.Get("consolidated")
+	if modelID == "" {
+		return nil
+	}
+	return &consolidateResult{
+		ModelID:  modelID,
+		Kept:     q.Get("kept"),
+		Unloaded: splitList(q.Get("unloaded")),
+		Failed:   splitList(q.Get("failed")),
+	}
+}
+
+// splitList splits a comma separated query value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
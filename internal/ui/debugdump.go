@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/debugdump"
+	"github.com/mcules/llm-router/internal/proxy"
+)
+
+// GateReporter returns the loader coordination state of all models.
+type GateReporter interface {
+	GateStates() []proxy.GateState
+}
+
+// debugDump serves the control state as one JSON file for bug reports (admin only);
+// DEBUG_RESTORE_FILE loads it into a development server.
+func (h *Handler) debugDump(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(h.getUser(r)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	now := time.Now()
+	d := debugdump.Dump{
+		Format:      debugdump.Format,
+		CreatedAt:   now,
+		Maintenance: h.Maintenance.Status(),
+	}
+	nodes := h.Cluster.Snapshot()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeID < nodes[j].NodeID })
+	for _, n := range nodes {
+		d.Nodes = append(d.Nodes, *n)
+		if h.Connections != nil {
+			if c, ok := h.Connections.Connection(n.NodeID); ok {
+				if d.Connections == nil {
+					d.Connections = map[string]control.ConnStats{}
+				}
+				d.Connections[n.NodeID] = c
+			}
+		}
+	}
+	if h.Latency != nil {
+		d.Latency = h.Latency.Snapshot()
+	}
+	if h.Placement != nil {
+		d.Placement = h.Placement.Snapshot()
+	}
+	if h.Gates != nil {
+		d.Gates = h.Gates.GateStates()
+	}
+	if h.Activity != nil {
+		d.Activity = h.Activity.List()
+	}
+	if h.Settings != nil {
+		d.Settings = h.Settings()
+	}
+	d.Redact()
+
+	name := "llm-router-dump-" + now.Format("20060102-150405") + ".json"
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(d)
+}
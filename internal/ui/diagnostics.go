@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/mcules/llm-router/internal/proxy"
+)
+
+// NodeDiagnoser sends synthetic requests to a node through the API proxy path.
+type NodeDiagnoser interface {
+	Diagnose(ctx context.Context, nodeID string) proxy.DiagnosticResult
+}
+
+// diagnoseNode reports whether the server can reach a node's data plane via the proxy.
+func (h *Handler) diagnoseNode(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(h.getUser(r)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if h.Diagnostics == nil {
+		http.Error(w, "diagnostics not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	nodeID := r.FormValue("node_id")
+	if nodeID == "" {
+		http.Error(w, "node_id required", http.StatusBadRequest)
+		return
+	}
+
+	res := h.Diagnostics.Diagnose(r.Context(), nodeID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !res.OK {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,257 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcules/llm-router/internal/activity"
+)
+
+// eventReplaySize bounds the events kept for clients reconnecting with Last-Event-ID.
+const eventReplaySize = 256
+
+// snapshotInterval is how often the cluster snapshot is sent to the dashboard streams.
+const snapshotInterval = 2 * time.Second
+
+// sseEvent is an event of the /ui/events stream that carries an id.
+type sseEvent struct {
+	ID   uint64
+	Type string
+	Data []byte
+}
+
+// eventSub is a connected stream: numbered events (closed when the stream falls behind)
+// and the latest snapshot not yet sent (older ones are replaced).
+type eventSub struct {
+	events   chan sseEvent
+	snapshot chan []byte
+}
+
+// eventHub numbers the dashboard events, keeps the last eventReplaySize of them for
+// replay and fans them out to the connected streams. Snapshots carry no id: they are
+// marshaled once per interval for all streams, and a slow stream only gets the latest.
+// Publishing never blocks on a stream.
+type eventHub struct {
+	mu     sync.Mutex
+	buf    []sseEvent // oldest first
+	lastID uint64
+	subs   map[*eventSub]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: map[*eventSub]struct{}{}}
+}
+
+func (hub *eventHub) publish(typ string, data []byte) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.lastID++
+	ev := sseEvent{ID: hub.lastID, Type: typ, Data: data}
+	if len(hub.buf) >= eventReplaySize {
+		hub.buf = append(hub.buf[:0], hub.buf[1:]...)
+	}
+	hub.buf = append(hub.buf, ev)
+
+	for sub := range hub.subs {
+		select {
+		case sub.events <- ev:
+		default:
+			// Too slow: end the stream, the browser reconnects and replays the gap.
+			delete(hub.subs, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// publishSnapshot hands a snapshot to every stream, replacing one it has not sent yet.
+func (hub *eventHub) publishSnapshot(data []byte) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for sub := range hub.subs {
+		select {
+		case <-sub.snapshot: // drop the stale one
+		default:
+		}
+		sub.snapshot <- data // cannot block: capacity 1, only filled under hub.mu
+	}
+}
+
+// subscribers returns the number of connected streams.
+func (hub *eventHub) subscribers() int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return len(hub.subs)
+}
+
+// subscribe registers a stream receiving all events after the returned id. ok is false
+// if max streams are connected already (0 = unlimited).
+func (hub *eventHub) subscribe(max int) (sub *eventSub, lastID uint64, ok bool) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if max > 0 && len(hub.subs) >= max {
+		return nil, 0, false
+	}
+	sub = &eventSub{
+		events:   make(chan sseEvent, 64),
+		snapshot: make(chan []byte, 1),
+	}
+	hub.subs[sub] = struct{}{}
+	return sub, hub.lastID, true
+}
+
+func (hub *eventHub) unsubscribe(sub *eventSub) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if _, ok := hub.subs[sub]; ok {
+		delete(hub.subs, sub)
+		close(sub.events)
+	}
+}
+
+// runSnapshots publishes snapshot() every interval while streams are connected.
+func (hub *eventHub) runSnapshots(interval time.Duration, snapshot func() []byte) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for range t.C {
+		if hub.subscribers() == 0 {
+			continue
+		}
+		if data := snapshot(); data != nil {
+			hub.publishSnapshot(data)
+		}
+	}
+}
+
+// between returns the events with after < id <= upTo. ok is false if some of them are no
+// longer buffered, or after is unknown (e.g. issued before a server restart).
+func (hub *eventHub) between(after, upTo uint64) ([]sseEvent, bool) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if after > upTo {
+		return nil, false
+	}
+	if after == upTo {
+		return nil, true
+	}
+	if len(hub.buf) == 0 || hub.buf[0].ID > after+1 {
+		return nil, false
+	}
+	var out []sseEvent
+	for _, ev := range hub.buf {
+		if ev.ID > after && ev.ID <= upTo {
+			out = append(out, ev)
+		}
+	}
+	return out, true
+}
+
+// publishActivity forwards an activity log entry to the dashboard streams.
+func (hub *eventHub) publishActivity(e activity.Event) {
+	data, err := json.Marshal(activityRow{
+		At:    e.At,
+		Type:  string(e.Type),
+		Node:  e.NodeID,
+		Model: e.Model,
+		Note:  e.Note,
+	})
+	if err != nil {
+		return
+	}
+	hub.publish("activity", data)
+}
+
+// events streams activity events (with ids) and a cluster snapshot every 2 seconds.
+// A reconnecting browser sends Last-Event-ID: the events it missed are replayed, or, if
+// they are no longer buffered, a "resync" event and a snapshot are sent right away.
+// Beyond MaxEventStreams connected streams, further ones get 503.
+func (h *Handler) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before replaying, so no event falls between replay and live stream.
+	sub, upTo, ok := h.hub.subscribe(h.MaxEventStreams)
+	if !ok {
+		w.Header().Set("Retry-After", "10")
+		http.Error(w, "too many event streams", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.hub.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	// Send initial pulse
+	_, _ = fmt.Fprintf(w, ": ok\n\n")
+
+	if last := strings.TrimSpace(r.Header.Get("Last-Event-ID")); last != "" {
+		after, err := strconv.ParseUint(last, 10, 64)
+		missed, complete := h.hub.between(after, upTo)
+		if err != nil || !complete {
+			// The gap cannot be replayed: tell the client and send the full state.
+			_, _ = fmt.Fprintf(w, "id: %d\nevent: resync\ndata: {}\n\n", upTo)
+			if err := writeSnapshot(w, h.snapshotJSON()); err != nil {
+				return
+			}
+		}
+		for _, ev := range missed {
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+		}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-sub.events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		case data := <-sub.snapshot:
+			if err := writeSnapshot(w, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, ev sseEvent) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+	return err
+}
+
+// snapshotJSON is the payload of a snapshot event.
+func (h *Handler) snapshotJSON() []byte {
+	payload, _ := json.Marshal(map[string]any{
+		"ts":    time.Now().UnixMilli(),
+		"nodes": h.Cluster.Snapshot(),
+	})
+	return payload
+}
+
+func writeSnapshot(w http.ResponseWriter, payload []byte) error {
+	_, err := fmt.Fprintf(w, "event: snapshot\ndata: %s\n\n", payload)
+	return err
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEventsRequireSession(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	h.MaxEventStreams = 1
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ui/events", nil))
+		if w.Code != http.StatusFound || w.Header().Get("Location") != "/ui/login" {
+			t.Fatalf("unauthenticated /ui/events = %d %q, want redirect to login", w.Code, w.Header().Get("Location"))
+		}
+	}
+	if n := h.hub.subscribers(); n != 0 {
+		t.Errorf("unauthenticated requests hold %d stream slots", n)
+	}
+}
+
+func TestEventHubCap(t *testing.T) {
+	hub := newEventHub()
+	a, _, ok := hub.subscribe(2)
+	if !ok {
+		t.Fatal("first subscribe refused")
+	}
+	if _, _, ok := hub.subscribe(2); !ok {
+		t.Fatal("second subscribe refused")
+	}
+	if _, _, ok := hub.subscribe(2); ok {
+		t.Fatal("third subscribe accepted beyond the cap")
+	}
+	hub.unsubscribe(a)
+	if _, _, ok := hub.subscribe(2); !ok {
+		t.Fatal("subscribe refused after a slot was freed")
+	}
+}
+
+func TestEventHubSlowConsumer(t *testing.T) {
+	hub := newEventHub()
+	slow, _, _ := hub.subscribe(0)
+	fast, _, _ := hub.subscribe(0)
+
+	// Snapshots: the slow stream keeps only the latest, publishing never blocks.
+	for _, s := range []string{"s1", "s2", "s3"} {
+		hub.publishSnapshot([]byte(s))
+		if s == "s1" {
+			<-fast.snapshot
+		}
+	}
+	if got := string(<-slow.snapshot); got != "s3" {
+		t.Errorf("slow stream snapshot = %q, want latest s3", got)
+	}
+	if got := string(<-fast.snapshot); got != "s3" {
+		t.Errorf("fast stream snapshot = %q, want s3", got)
+	}
+
+	// Events: a stream that falls behind is closed, the others keep receiving.
+	var received int
+	for i := 0; i < 100; i++ {
+		hub.publish("activity", []byte("{}"))
+		for len(fast.events) > 0 {
+			<-fast.events
+			received++
+		}
+	}
+	if received != 100 {
+		t.Errorf("fast stream received %d events, want 100", received)
+	}
+	n := 0
+	for range slow.events { // closed once it fell behind
+		n++
+	}
+	if n != cap(slow.events) {
+		t.Errorf("slow stream got %d events before being closed, want %d", n, cap(slow.events))
+	}
+	if got := hub.subscribers(); got != 1 {
+		t.Errorf("subscribers = %d, want 1", got)
+	}
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mcules/llm-router/internal/activity"
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+// exportRange parses the from and to query parameters ("2006-01-02", inclusive,
+// server local time). They default to the first day of the current month and today.
+func exportRange(r *http.Request, now time.Time) (from, to time.Time, err error) {
+	from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.ParseInLocation("2006-01-02", v, now.Location()); err != nil {
+			return from, to, fmt.Errorf("invalid from %q (YYYY-MM-DD)", v)
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.ParseInLocation("2006-01-02", v, now.Location()); err != nil {
+			return from, to, fmt.Errorf("invalid to %q (YYYY-MM-DD)", v)
+		}
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("to %s is before from %s", policy.DayOf(to), policy.DayOf(from))
+	}
+	return from, to, nil
+}
+
+// startCSV sets the headers of a CSV download named prefix-from_to.csv.
+func startCSV(w http.ResponseWriter, prefix string, from, to time.Time) *csv.Writer {
+	name := fmt.Sprintf("%s-%s_%s.csv", prefix, policy.DayOf(from), policy.DayOf(to))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	return csv.NewWriter(w)
+}
+
+// csvCell neutralizes text that spreadsheets would evaluate as a formula (leading =, +,
+// -, @, tab or carriage return) by prefixing a single quote. Model ids, key names and
+// notes come from clients and nodes.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// exportUsage streams the usage per day, API key and model of a date range as CSV
+// (admin only). Rows are written as they are read, so large exports are not buffered.
+func (h *Handler) exportUsage(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(h.getUser(r)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	from, to, err := exportRange(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cw := startCSV(w, "llm-router-usage", from, to)
+	_ = cw.Write([]string{"day", "key_id", "key_name", "owner", "model", "requests", "tokens", "cost"})
+	err = h.PolicyStore.EachUsage(r.Context(), policy.DayOf(from), policy.DayOf(to), func(u policy.UsageRow) error {
+		return cw.Write([]string{
+			u.Day, csvCell(u.KeyID), csvCell(u.KeyName), csvCell(u.Owner), csvCell(u.ModelID),
+			strconv.FormatInt(u.Requests, 10),
+			strconv.FormatInt(u.Tokens, 10),
+			strconv.FormatFloat(u.Cost, 'f', 6, 64),
+		})
+	})
+	cw.Flush()
+	if err != nil {
+		// The status is sent already; a truncated file is all the client can get.
+		log.Printf("ui: usage export: %v", err)
+	}
+}
+
+// exportActivity streams the activity events of a date range as CSV, oldest first
+// (admin only). Only the events still kept in memory are available: the response
+// states the oldest one (X-Activity-Oldest) and whether older events of the range were
+// dropped already (X-Activity-Incomplete).
+func (h *Handler) exportActivity(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(h.getUser(r)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	from, to, err := exportRange(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	end := to.AddDate(0, 0, 1)
+
+	var events []activity.Event
+	if h.Activity != nil {
+		events = h.Activity.List()
+		if len(events) > 0 {
+			oldest := events[len(events)-1].At
+			w.Header().Set("X-Activity-Oldest", oldest.Format(time.RFC3339))
+			if len(events) >= h.Activity.Cap() && from.Before(oldest) {
+				w.Header().Set("X-Activity-Incomplete", "true")
+			}
+		}
+	}
+
+	cw := startCSV(w, "llm-router-activity", from, to)
+	_ = cw.Write([]string{"time", "type", "node", "model", "note"})
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if e.At.Before(from) || !e.At.Before(end) {
+			continue
+		}
+		if err := cw.Write([]string{e.At.Format(time.RFC3339), csvCell(string(e.Type)), csvCell(e.NodeID), csvCell(e.Model), csvCell(e.Note)}); err != nil {
+			break
+		}
+	}
+	cw.Flush()
+}
@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/activity"
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+func TestCSVCell(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                       "",
+		"qwen3-8b":               "qwen3-8b",
+		"=HYPERLINK(\"x\")":      "'=HYPERLINK(\"x\")",
+		"+1":                     "'+1",
+		"-2+3":                   "'-2+3",
+		"@SUM(A1)":               "'@SUM(A1)",
+		"\tcmd":                  "'\tcmd",
+		"\rcmd":                  "'\rcmd",
+		"idle 5m (>= 5m) = fine": "idle 5m (>= 5m) = fine",
+	} {
+		if got := csvCell(in); got != want {
+			t.Errorf("csvCell(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// getCSV requests path as user and returns the response and its parsed rows.
+func getCSV(t *testing.T, h *Handler, handler http.HandlerFunc, path string, user *policy.UserRecord) (*httptest.ResponseRecorder, [][]string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler(w, asUser(httptest.NewRequest(http.MethodGet, path, nil), user))
+	if w.Code != http.StatusOK {
+		return w, nil
+	}
+	rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v\n%s", err, w.Body.String())
+	}
+	return w, rows
+}
+
+func TestExportActivity(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	h.Activity = activity.New(4)
+	at := func(day, clock string) time.Time {
+		ts, err := time.ParseInLocation("2006-01-02 15:04", day+" "+clock, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	h.Activity.Add(activity.Event{At: at("2026-09-01", "23:59"), Type: activity.EventTTLUnload, NodeID: "n1", Model: "a"})
+	h.Activity.Add(activity.Event{At: at("2026-09-02", "00:00"), Type: activity.EventManualUnload, NodeID: "n1", Model: "b", Note: "ui"})
+	h.Activity.Add(activity.Event{At: at("2026-09-03", "23:59"), Type: activity.EventLoadFailed, NodeID: "n2", Model: "c", Note: "=HYPERLINK(\"http://evil\")"})
+
+	w, rows := getCSV(t, h, h.exportActivity, "/ui/export/activity.csv?from=2026-09-02&to=2026-09-03", testAdmin)
+	want := [][]string{
+		{"time", "type", "node", "model", "note"},
+		{at("2026-09-02", "00:00").Format(time.RFC3339), string(activity.EventManualUnload), "n1", "b", "ui"},
+		{at("2026-09-03", "23:59").Format(time.RFC3339), string(activity.EventLoadFailed), "n2", "c", "'=HYPERLINK(\"http://evil\")"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q\nwant %q", rows, want)
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "llm-router-activity-2026-09-02_2026-09-03.csv") {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if got := w.Header().Get("X-Activity-Oldest"); got != at("2026-09-01", "23:59").Format(time.RFC3339) {
+		t.Errorf("X-Activity-Oldest = %q", got)
+	}
+	if w.Header().Get("X-Activity-Incomplete") != "" {
+		t.Error("marked incomplete although no event was dropped")
+	}
+
+	// Two more events drop the oldest: a range starting before what is kept is incomplete.
+	h.Activity.Add(activity.Event{At: at("2026-09-04", "08:00"), Type: activity.EventTTLUnload})
+	h.Activity.Add(activity.Event{At: at("2026-09-04", "09:00"), Type: activity.EventTTLUnload})
+	w, rows = getCSV(t, h, h.exportActivity, "/ui/export/activity.csv?from=2026-09-01&to=2026-09-30", testAdmin)
+	if w.Header().Get("X-Activity-Incomplete") != "true" {
+		t.Error("not marked incomplete after events of the range were dropped")
+	}
+	if len(rows) != 5 {
+		t.Errorf("got %d rows, want header and 4 events", len(rows))
+	}
+}
+
+func TestExportRangeAndAccess(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	for _, tc := range []struct {
+		query string
+		user  *policy.UserRecord
+		want  int
+	}{
+		{"", testAdmin, http.StatusOK},
+		{"?from=2026-09-01&to=2026-09-01", testAdmin, http.StatusOK},
+		{"?from=2026-09-02&to=2026-09-01", testAdmin, http.StatusBadRequest},
+		{"?from=09/01/2026", testAdmin, http.StatusBadRequest},
+		{"?to=yesterday", testAdmin, http.StatusBadRequest},
+		{"", &policy.UserRecord{Username: "alice"}, http.StatusForbidden},
+	} {
+		for name, handler := range map[string]http.HandlerFunc{"activity": h.exportActivity, "usage": h.exportUsage} {
+			w, _ := getCSV(t, h, handler, "/ui/export/x.csv"+tc.query, tc.user)
+			if w.Code != tc.want {
+				t.Errorf("%s export %q as %s = %d, want %d", name, tc.query, tc.user.Username, w.Code, tc.want)
+			}
+		}
+	}
+}
+
+func TestExportUsage(t *testing.T) {
+	h, _, store := newTestHandler(t)
+	ctx := context.Background()
+	if err := store.CreateAPIKey(ctx, policy.APIKeyRecord{ID: "k1", Name: "-2+3", Prefix: "sk-1", HashedKey: "h", CreatedAt: time.Now(), Owner: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, day := range []string{"2026-08-31", "2026-09-01", "2026-09-30", "2026-10-01"} {
+		if err := store.RecordUsage(ctx, day, "k1", "m", 2, 300, 0.5); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, rows := getCSV(t, h, h.exportUsage, "/ui/export/usage.csv?from=2026-09-01&to=2026-09-30", testAdmin)
+	want := [][]string{
+		{"day", "key_id", "key_name", "owner", "model", "requests", "tokens", "cost"},
+		{"2026-09-01", "k1", "'-2+3", "alice", "m", "2", "300", "0.500000"},
+		{"2026-09-30", "k1", "'-2+3", "alice", "m", "2", "300", "0.500000"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q\nwant %q", rows, want)
+	}
+}
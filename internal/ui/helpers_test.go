@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// newTestHandler returns a handler on an empty cluster and a fresh policy store.
+func newTestHandler(t *testing.T) (*Handler, *state.ClusterState, *policy.Store) {
+	t.Helper()
+	store, err := policy.Open(filepath.Join(t.TempDir(), "policies.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cluster := state.NewClusterState()
+	h, err := NewHandler(cluster, nil, store, nil, nil, "templates")
+	if err != nil {
+		t.Fatalf("new handler: %v", err)
+	}
+	h.NodeOfflineTTL = time.Minute
+	return h, cluster, store
+}
+
+// asUser returns r as sent by a logged in user (as authMiddleware would).
+func asUser(r *http.Request, u *policy.UserRecord) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), ctxKeyUser{}, u))
+}
+
+var testAdmin = &policy.UserRecord{Username: "admin"}
+
+// addNode registers an online node reporting models (model id -> state).
+func addNode(cluster *state.ClusterState, nodeID string, models map[string]state.ModelState) {
+	cluster.UpsertNodeHello(nodeID, "test", "", "http://"+nodeID, "", state.DataPlaneTLS{}, nil)
+	res := make(map[string]state.ModelResidency, len(models))
+	for id, st := range models {
+		res[id] = state.ModelResidency{ModelID: id, State: st}
+	}
+	cluster.UpdateNodeStatus(nodeID, 64<<30, 32<<30, "", 0, 0, 0, res)
+}
@@ -1,12 +1,29 @@
 package ui
 
 import (
-	"github.com/mcules/llm-router/internal/policy"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/policy"
 )
 
 func (h *Handler) keys(w http.ResponseWriter, r *http.Request) {
-	keys, err := h.PolicyStore.ListAPIKeys(r.Context())
+	user := h.getUser(r)
+
+	// Admins see all keys, everyone else only their own.
+	var (
+		keys []policy.APIKeyRecord
+		err  error
+	)
+	if isAdmin(user) {
+		keys, err = h.PolicyStore.ListAPIKeys(r.Context())
+	} else {
+		keys, err = h.PolicyStore.ListAPIKeysByOwner(r.Context(), user.Username)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -23,28 +40,54 @@ func (h *Handler) keys(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	now := time.Now()
+	rows := make([]keyRow, 0, len(keys))
+	for i := range keys {
+		rows = append(rows, keyRow{APIKeyRecord: keys[i], MonthSpend: h.Auth.MonthSpend(&keys[i], now)})
+	}
+
 	vm := h.newViewModel("API Keys")
-	vm.User = h.getUser(r)
+	vm.User = user
 	vm.Data = struct {
-		Keys      []policy.APIKeyRecord
+		Keys      []keyRow
+		Month     string
 		NewKey    string
 		AllNodes  []string
 		AllModels []string
+		IsAdmin   bool
+		MaxKeys   int
 	}{
-		Keys:      keys,
+		Keys:      rows,
+		Month:     policy.MonthOf(now),
 		NewKey:    r.URL.Query().Get("new_key"),
 		AllNodes:  mapToSortedSlice(allNodes),
 		AllModels: mapToSortedSlice(allModels),
+		IsAdmin:   isAdmin(user),
+		MaxKeys:   h.Auth.MaxKeysPerUser,
 	}
 
 	h.render(w, "keys.html", vm)
 }
 
+// keyRow is an API key with its spend in the current month (stored and not yet written).
+type keyRow struct {
+	policy.APIKeyRecord
+	MonthSpend float64
+}
+
+// OverBudget reports whether the key reached its monthly budget.
+func (k keyRow) OverBudget() bool {
+	return k.MonthlyBudget > 0 && k.MonthSpend >= k.MonthlyBudget
+}
+
 func (h *Handler) createKey(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if h.readOnly(w) {
+		return
+	}
 
 	name := r.FormValue("name")
 	if name == "" {
@@ -54,13 +97,33 @@ func (h *Handler) createKey(w http.ResponseWriter, r *http.Request) {
 	nodes := r.FormValue("allowed_nodes")
 	models := r.FormValue("allowed_models")
 
-	key, _, err := h.Auth.GenerateKey(r.Context(), name, nodes, models)
+	// Only the admin sets budgets, so users cannot lift their own.
+	var budget float64
+	if isAdmin(h.getUser(r)) {
+		var err error
+		if budget, err = parseCost(r.FormValue("monthly_budget")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	key, rec, err := h.Auth.GenerateKey(r.Context(), name, h.getUser(r).Username, nodes, models)
+	if errors.Is(err, auth.ErrKeyLimitReached) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if budget > 0 {
+		if err := h.PolicyStore.SetAPIKeyBudget(r.Context(), rec.ID, budget); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
-	http.Redirect(w, r, "/ui/keys?new_key="+key, http.StatusSeeOther)
+	h.redirect(w, r, "/ui/keys?new_key="+key, http.StatusSeeOther)
 }
 
 func (h *Handler) deleteKey(w http.ResponseWriter, r *http.Request) {
@@ -68,6 +131,9 @@ func (h *Handler) deleteKey(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if h.readOnly(w) {
+		return
+	}
 
 	id := r.FormValue("id")
 	if id == "" {
@@ -75,10 +141,201 @@ func (h *Handler) deleteKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Non-admins may only delete their own keys.
+	user := h.getUser(r)
+	if !isAdmin(user) {
+		rec, ok, err := h.PolicyStore.GetAPIKey(r.Context(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok || rec.Owner != user.Username {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
 	if err := h.PolicyStore.DeleteAPIKey(r.Context(), id); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	http.Redirect(w, r, "/ui/keys", http.StatusSeeOther)
+	h.redirect(w, r, "/ui/keys", http.StatusSeeOther)
+}
+
+// setKeyBudget sets the monthly budget of a key (admin only; 0 = unlimited).
+func (h *Handler) setKeyBudget(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.readOnly(w) {
+		return
+	}
+	if !isAdmin(h.getUser(r)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "Missing key ID", http.StatusBadRequest)
+		return
+	}
+	budget, err := parseCost(r.FormValue("monthly_budget"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.PolicyStore.SetAPIKeyBudget(r.Context(), id, budget); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.redirect(w, r, "/ui/keys", http.StatusSeeOther)
+}
+
+// setKeyStream sets the stream override of a key (own keys, admins all).
+func (h *Handler) setKeyStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.readOnly(w) {
+		return
+	}
+
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "Missing key ID", http.StatusBadRequest)
+		return
+	}
+	user := h.getUser(r)
+	if !isAdmin(user) {
+		rec, ok, err := h.PolicyStore.GetAPIKey(r.Context(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok || rec.Owner != user.Username {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	mode := r.FormValue("force_stream")
+	if mode != "" && mode != policy.ForceStreamOn && mode != policy.ForceStreamOff {
+		http.Error(w, "invalid stream override", http.StatusBadRequest)
+		return
+	}
+	if err := h.PolicyStore.SetAPIKeyForceStream(r.Context(), id, mode); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.redirect(w, r, "/ui/keys", http.StatusSeeOther)
+}
+
+// keyPermissions is the effective reach of an API key in the current cluster.
+type keyPermissions struct {
+	KeyID         string `json:"key_id"`
+	Name          string `json:"name"`
+	AllowedNodes  string `json:"allowed_nodes"`
+	AllowedModels string `json:"allowed_models"`
+
+	// Nodes and models the key can use (a model counts if it is resident on an allowed node).
+	Nodes  []string `json:"nodes"`
+	Models []string `json:"models"`
+	// Models permitted by the model ACL but only resident on nodes the key may not use.
+	UnreachableModels []string `json:"unreachable_models"`
+
+	// ACL entries that match no known node/model, usually typos.
+	UnknownNodeEntries  []string `json:"unknown_node_entries"`
+	UnknownModelEntries []string `json:"unknown_model_entries"`
+}
+
+// testKey reports which known nodes and models a key may use (read-only, admin).
+func (h *Handler) testKey(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(h.getUser(r)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "Missing key ID", http.StatusBadRequest)
+		return
+	}
+	rec, ok, err := h.PolicyStore.GetAPIKey(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Key not found", http.StatusNotFound)
+		return
+	}
+
+	knownNodes := make(map[string]struct{})
+	knownModels := make(map[string]struct{})
+	nodes := make(map[string]struct{})
+	models := make(map[string]struct{})
+	permitted := make(map[string]struct{})
+	for _, n := range h.Cluster.Snapshot() {
+		knownNodes[n.NodeID] = struct{}{}
+		nodeOK := auth.CheckACL(rec.AllowedNodes, n.NodeID)
+		if nodeOK {
+			nodes[n.NodeID] = struct{}{}
+		}
+		for modelID := range n.Models {
+			knownModels[modelID] = struct{}{}
+			if !auth.CheckACL(rec.AllowedModels, modelID) {
+				continue
+			}
+			permitted[modelID] = struct{}{}
+			if nodeOK {
+				models[modelID] = struct{}{}
+			}
+		}
+	}
+	unreachable := make(map[string]struct{})
+	for modelID := range permitted {
+		if _, ok := models[modelID]; !ok {
+			unreachable[modelID] = struct{}{}
+		}
+	}
+
+	res := keyPermissions{
+		KeyID:               rec.ID,
+		Name:                rec.Name,
+		AllowedNodes:        rec.AllowedNodes,
+		AllowedModels:       rec.AllowedModels,
+		Nodes:               mapToSortedSlice(nodes),
+		Models:              mapToSortedSlice(models),
+		UnreachableModels:   mapToSortedSlice(unreachable),
+		UnknownNodeEntries:  unknownACLEntries(rec.AllowedNodes, knownNodes),
+		UnknownModelEntries: unknownACLEntries(rec.AllowedModels, knownModels),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// unknownACLEntries returns the entries of a comma separated ACL that match none of known.
+func unknownACLEntries(acl string, known map[string]struct{}) []string {
+	out := []string{}
+	if acl == "*" || acl == "" {
+		return out
+	}
+	for _, p := range strings.Split(acl, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, ok := known[p]; !ok {
+			out = append(out, p)
+		}
+	}
+	return out
 }
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mcules/llm-router/internal/logtail"
+)
+
+// Log stream bounds: lines sent on connect, and lines per flush interval afterwards
+// (100 lines/s); further lines of an interval are dropped and reported.
+const (
+	logBacklogLines   = 200
+	logFlushInterval  = 250 * time.Millisecond
+	logLinesPerFlush  = 25
+	logKeepAliveEvery = 15 * time.Second
+)
+
+// logs serves the log tail page, or with ?stream=1 the log lines as SSE (admin only,
+// logs may contain request metadata). ?level=warning|error filters the lines.
+func (h *Handler) logs(w http.ResponseWriter, r *http.Request) {
+	user := h.getUser(r)
+	if !isAdmin(user) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if h.Logs == nil {
+		http.Error(w, "Log capture not available", http.StatusNotFound)
+		return
+	}
+
+	level := logtail.ParseLevel(r.URL.Query().Get("level"))
+	if r.URL.Query().Get("stream") == "" {
+		vm := h.newViewModel("Logs")
+		vm.User = user
+		vm.Data = struct{ Level string }{Level: level.String()}
+		h.render(w, "logs.html", vm)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	backlog, ch := h.Logs.Subscribe()
+	defer h.Logs.Unsubscribe(ch)
+
+	// A reconnecting browser already has the lines up to Last-Event-ID (unless the
+	// server restarted and the sequence is lower now).
+	seen, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	if n := len(backlog); n > 0 && seen > backlog[n-1].Seq {
+		seen = 0
+	}
+
+	var lastSeq uint64
+	var filtered []logtail.Line
+	for _, l := range backlog {
+		lastSeq = l.Seq
+		if l.Seq > seen && l.AtLeast(level) {
+			filtered = append(filtered, l)
+		}
+	}
+	if len(filtered) > logBacklogLines {
+		filtered = filtered[len(filtered)-logBacklogLines:]
+	}
+	for _, l := range filtered {
+		if err := writeLogLine(w, l); err != nil {
+			return
+		}
+	}
+	_, _ = fmt.Fprintf(w, ": ok\n\n")
+	flusher.Flush()
+
+	flush := time.NewTicker(logFlushInterval)
+	defer flush.Stop()
+	lastWrite := time.Now()
+
+	var (
+		batch   []logtail.Line
+		dropped int
+	)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case l := <-ch:
+			// A gap in the sequence means the subscription channel overflowed.
+			if lastSeq > 0 && l.Seq > lastSeq+1 {
+				dropped += int(l.Seq - lastSeq - 1)
+			}
+			lastSeq = l.Seq
+			if !l.AtLeast(level) {
+				continue
+			}
+			if len(batch) >= logLinesPerFlush {
+				dropped++
+				continue
+			}
+			batch = append(batch, l)
+		case now := <-flush.C:
+			if len(batch) == 0 && dropped == 0 {
+				if now.Sub(lastWrite) < logKeepAliveEvery {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			}
+			for _, l := range batch {
+				if err := writeLogLine(w, l); err != nil {
+					return
+				}
+			}
+			if dropped > 0 {
+				if _, err := fmt.Fprintf(w, "event: dropped\ndata: {\"count\":%d}\n\n", dropped); err != nil {
+					return
+				}
+			}
+			batch, dropped = batch[:0], 0
+			lastWrite = now
+			flusher.Flush()
+		}
+	}
+}
+
+func writeLogLine(w http.ResponseWriter, l logtail.Line) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: line\ndata: %s\n\n", l.Seq, data)
+	return err
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mcules/llm-router/internal/maintenance"
+)
+
+// readOnly rejects a mutation with 503 while maintenance mode is on and reports whether
+// it did. Every mutating handler calls it before touching the store or the nodes.
+func (h *Handler) readOnly(w http.ResponseWriter) bool {
+	if !h.Maintenance.Enabled() {
+		return false
+	}
+	w.Header().Set("Retry-After", "60")
+	http.Error(w, maintenance.ErrReadOnly.Error(), http.StatusServiceUnavailable)
+	return true
+}
+
+// maintenanceMode returns the maintenance status as JSON (GET) or turns it on or off
+// (POST enabled=true|false, optional reason). Admin only. The form on the pages is
+// redirected back; clients sending Accept: application/json get the new status.
+func (h *Handler) maintenanceMode(w http.ResponseWriter, r *http.Request) {
+	user := h.getUser(r)
+	if !isAdmin(user) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if h.Maintenance == nil {
+		http.Error(w, "Maintenance mode not available", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+		if err != nil {
+			http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+			return
+		}
+		reason := strings.TrimSpace(r.FormValue("reason"))
+		if h.Maintenance.Set(enabled, user.Username, reason) {
+			if enabled {
+				log.Printf("WARNING: maintenance mode enabled by %s (%s): mutations rejected, planner paused", user.Username, reason)
+			} else {
+				log.Printf("maintenance mode disabled by %s", user.Username)
+			}
+		}
+		if !strings.Contains(r.Header.Get("Accept"), "application/json") {
+			target := r.Referer()
+			if target == "" {
+				target = h.BasePath + "/ui/"
+			}
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(h.Maintenance.Status())
+}
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/proxy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// fakeGates reports fixed gate states.
+type fakeGates []proxy.GateState
+
+func (f fakeGates) GateStates() []proxy.GateState { return f }
+
+func TestModelGroups(t *testing.T) {
+	h, cluster, _ := newTestHandler(t)
+	since := time.Now().Add(-time.Minute)
+	h.Gates = fakeGates{{
+		ModelID:       "m",
+		LoadingNode:   "b",
+		LoadingSince:  since,
+		ReportedLoads: map[string]time.Time{"b": since, "c": since},
+		Waiting:       3,
+	}}
+	addNode(cluster, "a", map[string]state.ModelState{"m": state.ModelReady, "other": state.ModelReady})
+	addNode(cluster, "b", map[string]state.ModelState{"m": state.ModelLoading})
+	addNode(cluster, "c", map[string]state.ModelState{"m": state.ModelLoading})
+	addNode(cluster, "d", map[string]state.ModelState{"m": state.ModelError})
+
+	get := func(t *testing.T, user *policy.UserRecord) map[string]modelGroup {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.modelsStatus(w, asUser(httptest.NewRequest(http.MethodGet, "/ui/models/status", nil), user))
+		var out struct{ Models []modelGroup }
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		groups := map[string]modelGroup{}
+		for _, g := range out.Models {
+			groups[g.ModelID] = g
+		}
+		return groups
+	}
+
+	t.Run("all nodes", func(t *testing.T) {
+		m := get(t, testAdmin)["m"]
+		if m.Ready != 1 || m.Loading != 2 || m.Errors != 1 || len(m.Nodes) != 4 {
+			t.Errorf("m: %d ready, %d loading, %d error on %d nodes, want 1/2/1 on 4", m.Ready, m.Loading, m.Errors, len(m.Nodes))
+		}
+		if m.Gate == nil || m.Gate.LoadingNode != "b" || m.Gate.Waiting != 3 || len(m.Gate.ReportedLoads) != 2 {
+			t.Errorf("gate = %+v, want loader b with 3 waiting and both reported loads", m.Gate)
+		}
+	})
+
+	t.Run("node ACL", func(t *testing.T) {
+		user := &policy.UserRecord{Username: "alice", AllowedNodes: "a,c", AllowedModels: "m"}
+		groups := get(t, user)
+		if _, ok := groups["other"]; ok {
+			t.Error("model outside the model ACL listed")
+		}
+		m := groups["m"]
+		if m.Ready != 1 || m.Loading != 1 || m.Errors != 0 || len(m.Nodes) != 2 {
+			t.Errorf("m: %d ready, %d loading, %d error on %d nodes, want 1/1/0 on 2", m.Ready, m.Loading, m.Errors, len(m.Nodes))
+		}
+		g := m.Gate
+		if g == nil {
+			t.Fatal("gate missing")
+		}
+		if g.LoadingNode != "" || g.LoadingSince.IsZero() || g.Waiting != 3 {
+			t.Errorf("gate = %+v, want the hidden loader's id removed, its load still shown", g)
+		}
+		if _, ok := g.ReportedLoads["b"]; ok || len(g.ReportedLoads) != 1 {
+			t.Errorf("reported loads = %v, want only c", g.ReportedLoads)
+		}
+	})
+
+	t.Run("page hides the loader", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.models(w, asUser(httptest.NewRequest(http.MethodGet, "/ui/models", nil), &policy.UserRecord{Username: "alice", AllowedNodes: "a"}))
+		body := w.Body.String()
+		if !strings.Contains(body, "nicht freigegebenen Node") || strings.Contains(body, `font-mono">b<`) {
+			t.Errorf("models page does not hide the loader b")
+		}
+		if !strings.Contains(body, "3 Anfrage(n) warten") {
+			t.Errorf("models page does not show the waiting requests")
+		}
+	})
+}
@@ -2,12 +2,15 @@ package ui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mcules/llm-router/internal/auth"
 	"github.com/mcules/llm-router/internal/policy"
@@ -19,6 +22,12 @@ type PolicyViewRow struct {
 	TTLSecs          int
 	Priority         int
 	Pinned           bool
+	ParamDefaults    string
+	ParamMax         string
+	CostPer1KTokens  float64
+	VerifyReady      bool
+	MaxNodes         int
+	Version          int64
 }
 
 func (h *Handler) policies(w http.ResponseWriter, r *http.Request) {
@@ -47,6 +56,15 @@ func (h *Handler) policies(w http.ResponseWriter, r *http.Request) {
 
 	vm := h.newViewModel("Policies")
 	vm.Policies = filtered
+	// ?edit=<model> fills the form with the policy, including the version it is based on.
+	if edit := r.URL.Query().Get("edit"); edit != "" {
+		for _, row := range filtered {
+			if row.ModelID == edit {
+				vm.Data = row
+				break
+			}
+		}
+	}
 	vm.User = user
 	h.render(w, "policies.html", vm)
 }
@@ -56,11 +74,17 @@ func (h *Handler) deletePolicy(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	if h.readOnly(w) {
+		return
+	}
 	modelID := r.FormValue("model_id")
 	if modelID != "" {
-		_ = h.PolicyStore.Delete(r.Context(), modelID)
+		if err := h.PolicyStore.Delete(r.Context(), modelID); err != nil {
+			writeStoreError(w, "delete policy", err)
+			return
+		}
 	}
-	http.Redirect(w, r, "/ui/policies", http.StatusFound)
+	h.redirect(w, r, "/ui/policies", http.StatusFound)
 }
 
 func (h *Handler) upsertPolicy(w http.ResponseWriter, r *http.Request) {
@@ -68,6 +92,9 @@ func (h *Handler) upsertPolicy(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	if h.readOnly(w) {
+		return
+	}
 	modelID := r.FormValue("model_id")
 	if modelID == "" {
 		http.Error(w, "missing model_id", http.StatusBadRequest)
@@ -76,21 +103,65 @@ func (h *Handler) upsertPolicy(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch existing or start new
 	p, _, _ := h.PolicyStore.GetPolicy(r.Context(), modelID)
+	p.ModelID = modelID
 
-	if r.FormValue("ram_required_bytes") != "" {
-		p.RAMRequiredBytes = parseUint64Default(r.FormValue("ram_required_bytes"), p.RAMRequiredBytes)
+	var err error
+	if p.RAMRequiredBytes, err = parseFormUint64(r, "ram_required_bytes", p.RAMRequiredBytes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 	if r.FormValue("ttl_secs") != "" {
-		p.TTLSecs = int64(parseIntDefault(r.FormValue("ttl_secs"), int(p.TTLSecs)))
+		ttl, err := parseTTL(r.FormValue("ttl_secs"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		p.TTLSecs = ttl
 	}
-	if r.FormValue("priority") != "" {
-		p.Priority = parseIntDefault(r.FormValue("priority"), p.Priority)
+	if p.Priority, err = parseFormInt(r, "priority", p.Priority); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if p.MaxNodes, err = parseFormInt(r, "max_nodes", p.MaxNodes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 	if r.FormValue("pinned") != "" {
 		p.Pinned = r.FormValue("pinned") == "true"
 	}
+	if r.FormValue("verify_ready") != "" {
+		p.VerifyReady = r.FormValue("verify_ready") == "true"
+	}
+	if _, ok := r.Form["param_defaults"]; ok {
+		p.ParamDefaults = strings.TrimSpace(r.FormValue("param_defaults"))
+	}
+	if _, ok := r.Form["param_max"]; ok {
+		p.ParamMax = strings.TrimSpace(r.FormValue("param_max"))
+	}
+	if r.FormValue("cost_per_1k_tokens") != "" {
+		cost, err := parseCost(r.FormValue("cost_per_1k_tokens"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		p.CostPer1KTokens = cost
+	}
+	if err := p.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The write is based on the version read above, or on the one the client read.
+	version, err := parseFormInt(r, "version", int(p.Version))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.Version = int64(version)
 
-	_ = h.PolicyStore.Upsert(r.Context(), p)
+	if err := h.PolicyStore.Upsert(r.Context(), p); err != nil {
+		writeStoreError(w, "save policy", err)
+		return
+	}
 
 	http.Redirect(w, r, r.Referer(), http.StatusFound)
 }
@@ -100,31 +171,158 @@ func (h *Handler) savePolicy(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	if h.readOnly(w) {
+		return
+	}
 
 	modelID := r.FormValue("model_id")
-	ram := parseUint64Default(r.FormValue("ram_required_bytes"), 0)
-	ttl := parseIntDefault(r.FormValue("ttl_secs"), 0)
-	prio := parseIntDefault(r.FormValue("priority"), 0)
 	pinned := r.FormValue("pinned") != ""
 
 	if modelID == "" {
 		http.Error(w, "model_id is required", http.StatusBadRequest)
 		return
 	}
+	ram, err := parseFormUint64(r, "ram_required_bytes", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	prio, err := parseFormInt(r, "priority", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	maxNodes, err := parseFormInt(r, "max_nodes", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ttl, err := parseTTL(r.FormValue("ttl_secs"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cost, err := parseCost(r.FormValue("cost_per_1k_tokens"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	version, err := parseFormInt(r, "version", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	err := h.PolicyStore.Upsert(r.Context(), policy.ModelPolicy{
+	p := policy.ModelPolicy{
 		ModelID:          modelID,
 		RAMRequiredBytes: ram,
-		TTLSecs:          int64(ttl),
+		TTLSecs:          ttl,
 		Priority:         prio,
 		Pinned:           pinned,
-	})
-	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to save policy: %v", err), http.StatusInternalServerError)
+		ParamDefaults:    strings.TrimSpace(r.FormValue("param_defaults")),
+		ParamMax:         strings.TrimSpace(r.FormValue("param_max")),
+		CostPer1KTokens:  cost,
+		VerifyReady:      r.FormValue("verify_ready") != "",
+		MaxNodes:         maxNodes,
+		Version:          int64(version),
+	}
+	if err := p.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	http.Redirect(w, r, "/ui/policies", http.StatusFound)
+	if err := h.PolicyStore.Upsert(r.Context(), p); err != nil {
+		writeStoreError(w, "save policy", err)
+		return
+	}
+
+	h.redirect(w, r, "/ui/policies", http.StatusFound)
+}
+
+// writeStoreError answers a failed policy write: 409 if the policy was changed
+// meanwhile, 503 while the degraded store rejects changes, 500 otherwise.
+func writeStoreError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, policy.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, policy.ErrStoreDegraded):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, fmt.Sprintf("failed to %s: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// parseCost parses a non-negative amount such as a cost or budget ("" = 0; a decimal
+// comma is accepted).
+func parseCost(s string) (float64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return v, nil
+}
+
+// parseTTL parses a TTL in seconds ("3600") or as duration ("30m", "2h", "1h30m"),
+// rounded up to whole seconds ("" = 0).
+func parseTTL(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil && v >= 0 {
+		return v, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid ttl %q (seconds or duration such as 30m, 2h)", s)
+	}
+	return int64((d + time.Second - 1) / time.Second), nil
+}
+
+// formatTTL formats a TTL in seconds as compact duration ("2h", "1h30m", "45s").
+func formatTTL(secs int) string {
+	if secs <= 0 {
+		return "0s"
+	}
+	out := (time.Duration(secs) * time.Second).String()
+	if strings.HasSuffix(out, "m0s") {
+		out = strings.TrimSuffix(out, "0s")
+	}
+	if strings.HasSuffix(out, "h0m") {
+		out = strings.TrimSuffix(out, "0m")
+	}
+	return out
+}
+
+// parseFormInt parses the integer form field name; an empty field yields def.
+func parseFormInt(r *http.Request, name string, def int) (int, error) {
+	s := strings.TrimSpace(r.FormValue(name))
+	if s == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return def, fmt.Errorf("invalid %s %q: not an integer", name, s)
+	}
+	return v, nil
+}
+
+// parseFormUint64 parses the non-negative integer form field name; an empty field
+// yields def.
+func parseFormUint64(r *http.Request, name string, def uint64) (uint64, error) {
+	s := strings.TrimSpace(r.FormValue(name))
+	if s == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return def, fmt.Errorf("invalid %s %q: not a non-negative integer", name, s)
+	}
+	return v, nil
 }
 
 func parseIntDefault(s string, def int) int {
@@ -253,6 +451,12 @@ func policyToRow(p reflect.Value) PolicyViewRow {
 		TTLSecs:          int(getIntField(p, []string{"TTLSecs", "TtlSecs", "ttl_secs", "ttlSeconds", "TTLSeconds"})),
 		Priority:         int(getIntField(p, []string{"Priority", "priority"})),
 		Pinned:           getBoolField(p, []string{"Pinned", "pinned"}),
+		ParamDefaults:    getStringField(p, []string{"ParamDefaults", "param_defaults"}),
+		ParamMax:         getStringField(p, []string{"ParamMax", "param_max"}),
+		CostPer1KTokens:  getFloatField(p, []string{"CostPer1KTokens", "cost_per_1k_tokens"}),
+		VerifyReady:      getBoolField(p, []string{"VerifyReady", "verify_ready"}),
+		MaxNodes:         int(getIntField(p, []string{"MaxNodes", "max_nodes"})),
+		Version:          getIntField(p, []string{"Version", "version"}),
 	}
 	return row
 }
@@ -310,6 +514,17 @@ func getBoolField(v reflect.Value, names []string) bool {
 	return false
 }
 
+func getFloatField(v reflect.Value, names []string) float64 {
+	f := findField(v, names)
+	if !f.IsValid() {
+		return 0
+	}
+	if f.CanFloat() {
+		return f.Float()
+	}
+	return 0
+}
+
 func setStringField(v reflect.Value, names []string, val string) {
 	f := findFieldWritable(v, names)
 	if f.IsValid() && f.Kind() == reflect.String {
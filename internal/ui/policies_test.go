@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mcules/llm-router/internal/policy"
+)
+
+func TestSavePolicyRejectsInvalidForm(t *testing.T) {
+	for _, path := range []string{"/ui/policies/save", "/ui/policies/upsert"} {
+		for _, tc := range []struct {
+			name  string
+			field string
+			value string
+		}{
+			{"version", "version", "abc"},
+			{"priority", "priority", "high"},
+			{"max nodes", "max_nodes", "-1"},
+			{"ttl", "ttl_secs", "forever"},
+			{"cost", "cost_per_1k_tokens", "-1"},
+			{"param max", "param_max", "{"},
+		} {
+			t.Run(path+" "+tc.name, func(t *testing.T) {
+				h, _, store := newTestHandler(t)
+				ctx := context.Background()
+				if err := store.UpsertPolicy(ctx, policy.ModelPolicy{ModelID: "m", TTLSecs: 60}); err != nil {
+					t.Fatal(err)
+				}
+
+				form := url.Values{"model_id": {"m"}, "ttl_secs": {"120"}, tc.field: {tc.value}}
+				req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				w := httptest.NewRecorder()
+				if path == "/ui/policies/save" {
+					h.savePolicy(w, asUser(req, testAdmin))
+				} else {
+					h.upsertPolicy(w, asUser(req, testAdmin))
+				}
+
+				if w.Code != http.StatusBadRequest {
+					t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+				}
+				if p, _, _ := store.GetPolicy(ctx, "m"); p.TTLSecs != 60 {
+					t.Errorf("ttl = %d, want the policy unchanged", p.TTLSecs)
+				}
+			})
+		}
+	}
+}
+
+func TestUpsertPolicyStaleVersionConflict(t *testing.T) {
+	h, _, store := newTestHandler(t)
+	ctx := context.Background()
+	if err := store.UpsertPolicy(ctx, policy.ModelPolicy{ModelID: "m", TTLSecs: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	save := func(ttl, version string) int {
+		form := url.Values{"model_id": {"m"}, "ttl_secs": {ttl}, "version": {version}}
+		req := httptest.NewRequest(http.MethodPost, "/ui/policies/upsert", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.upsertPolicy(w, asUser(req, testAdmin))
+		return w.Code
+	}
+	// Both admins loaded version 1.
+	if code := save("120", "1"); code != http.StatusFound {
+		t.Fatalf("first save: status %d", code)
+	}
+	if code := save("300", "1"); code != http.StatusConflict {
+		t.Fatalf("stale save: status %d, want 409", code)
+	}
+	if p, _, _ := store.GetPolicy(ctx, "m"); p.TTLSecs != 120 {
+		t.Errorf("ttl = %d, want the first save kept", p.TTLSecs)
+	}
+}
+
+// postPolicyForm sends form to the policy handler and returns the status.
+func postPolicyForm(h http.HandlerFunc, path string, form url.Values) (int, string) {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h(w, asUser(req, testAdmin))
+	return w.Code, w.Body.String()
+}
+
+func TestPolicyWritesFailClosed(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		failClosed bool
+		want       int
+	}{
+		{"degraded store rejects changes", true, http.StatusServiceUnavailable},
+		{"database error", false, http.StatusInternalServerError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _, store := newTestHandler(t)
+			if err := store.UpsertPolicy(context.Background(), policy.ModelPolicy{ModelID: "m", TTLSecs: 60}); err != nil {
+				t.Fatal(err)
+			}
+			store.DegradedAfter = 1
+			store.FailClosed = tc.failClosed
+			store.Close() // every query fails from now on
+			store.GetPolicy(context.Background(), "other")
+
+			for _, op := range []struct {
+				handler http.HandlerFunc
+				path    string
+				form    url.Values
+			}{
+				{h.savePolicy, "/ui/policies/save", url.Values{"model_id": {"m"}, "ttl_secs": {"120"}}},
+				{h.upsertPolicy, "/ui/policies/upsert", url.Values{"model_id": {"m"}, "ttl_secs": {"120"}}},
+				{h.deletePolicy, "/ui/policies/delete", url.Values{"model_id": {"m"}}},
+			} {
+				if code, body := postPolicyForm(op.handler, op.path, op.form); code != tc.want {
+					t.Errorf("%s = %d (%s), want %d", op.path, code, strings.TrimSpace(body), tc.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadyReportsDegradedStore(t *testing.T) {
+	h, _, store := newTestHandler(t)
+	mux := http.NewServeMux()
+	h.RegisterProbes(mux)
+	ready := func() (int, string) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		var out struct {
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, out.Status
+	}
+
+	if code, status := ready(); code != http.StatusOK || status != "ready" {
+		t.Fatalf("/ready = %d %q, want 200 ready", code, status)
+	}
+
+	store.DegradedAfter = 1
+	store.Close() // every query fails from now on
+	store.GetPolicy(context.Background(), "m")
+	if code, status := ready(); code != http.StatusServiceUnavailable || status != "degraded" {
+		t.Errorf("/ready with a failing store = %d %q, want 503 degraded", code, status)
+	}
+}
+
+func TestReadyReportsStaleStatus(t *testing.T) {
+	h, cluster, _ := newTestHandler(t)
+	h.StatusStaleAfter = time.Millisecond
+	mux := http.NewServeMux()
+	h.RegisterProbes(mux)
+	ready := func() (int, string, bool) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		var out struct {
+			Status  string `json:"status"`
+			Control struct {
+				Stale bool `json:"stale"`
+			} `json:"control"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, out.Status, out.Control.Stale
+	}
+
+	// No status yet: nothing can be stale.
+	if code, status, _ := ready(); code != http.StatusOK || status != "ready" {
+		t.Fatalf("/ready before any status = %d %q, want 200 ready", code, status)
+	}
+
+	addNode(cluster, "n1", nil)
+	time.Sleep(5 * time.Millisecond) // updates stall
+	if code, status, stale := ready(); code != http.StatusServiceUnavailable || status != "degraded" || !stale {
+		t.Errorf("/ready with stalled updates = %d %q (stale %v), want 503 degraded", code, status, stale)
+	}
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mcules/llm-router/internal/activity"
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// reclaimIdle unloads every READY, unpinned model that has been idle for at least
+// the given number of minutes, independent of per-model TTL policies.
+func (h *Handler) reclaimIdle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isAdmin(h.getUser(r)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if h.readOnly(w) {
+		return
+	}
+	if h.Recency == nil {
+		http.Error(w, "last-used tracking not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	minutes := parseIntDefault(r.FormValue("idle_minutes"), 0)
+	if minutes <= 0 {
+		http.Error(w, "idle_minutes must be > 0", http.StatusBadRequest)
+		return
+	}
+	unloaded, freedBytes := h.unloadIdle(r.Context(), time.Now(), minutes)
+
+	q := url.Values{}
+	q.Set("reclaimed", strconv.Itoa(unloaded))
+	q.Set("freed", strconv.FormatUint(freedBytes, 10))
+	h.redirect(w, r, "/ui/models?"+q.Encode(), http.StatusSeeOther)
+}
+
+// unloadIdle sends an unload for every READY, unpinned model idle for at least minutes
+// at now and returns how many it unloaded and the RAM their policies require.
+func (h *Handler) unloadIdle(ctx context.Context, now time.Time, minutes int) (unloaded int, freedBytes uint64) {
+	for _, n := range h.Cluster.SnapshotOnline(now, h.NodeOfflineTTL) {
+		for _, m := range n.Models {
+			if m.State != state.ModelReady {
+				continue
+			}
+
+			idle := now.Sub(h.Recency.IdleSince(n.NodeID, m.ModelID, m.LoadedSince))
+			if idle < time.Duration(minutes)*time.Minute {
+				continue
+			}
+
+			pol, ok, err := h.getPolicy(ctx, m.ModelID)
+			if err != nil {
+				log.Printf("ui: reclaim idle: get policy: %v", err)
+				continue
+			}
+			if ok && pol.Pinned {
+				continue
+			}
+
+			reqID := fmt.Sprintf("unload-idle-%d", time.Now().UnixNano())
+			if err := h.Commands.SendUnload(n.NodeID, reqID, m.ModelID); err != nil && !errors.Is(err, control.ErrQueued) {
+				log.Printf("ui: reclaim idle: unload failed node=%s model=%s err=%v", n.NodeID, m.ModelID, err)
+				continue
+			}
+
+			unloaded++
+			freedBytes += pol.RAMRequiredBytes
+
+			if h.Activity != nil {
+				h.Activity.Add(activity.Event{
+					At:     time.Now(),
+					Type:   activity.EventIdleUnload,
+					NodeID: n.NodeID,
+					Model:  m.ModelID,
+					Note:   fmt.Sprintf("idle %s (>= %dm)", idle.Truncate(time.Second), minutes),
+				})
+			}
+		}
+	}
+	return unloaded, freedBytes
+}
+
+// getPolicy looks up the policy of modelID the way the planner does, so a policy stored
+// under a differently cased name still pins its model with NormalizeModelNames.
+func (h *Handler) getPolicy(ctx context.Context, modelID string) (policy.ModelPolicy, bool, error) {
+	if h.NormalizeModelNames {
+		return h.PolicyStore.GetPolicyFold(ctx, modelID)
+	}
+	return h.PolicyStore.GetPolicy(ctx, modelID)
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcules/llm-router/internal/metrics"
+	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/state"
+)
+
+// fakeCommands records the unloads sent to nodes.
+type fakeCommands struct {
+	mu      sync.Mutex
+	unloads []string // "node/model"
+}
+
+func (f *fakeCommands) SendUnload(nodeID, _, modelID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unloads = append(f.unloads, nodeID+"/"+modelID)
+	return nil
+}
+
+func (f *fakeCommands) SendCancelLoad(string, string, string) error { return nil }
+
+func TestReclaimIdleHonoursFoldedPins(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		normalize bool
+		want      int
+	}{
+		{"exact lookup", false, 2},
+		{"folded lookup", true, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, cluster, store := newTestHandler(t)
+			cmds := &fakeCommands{}
+			h.Commands = cmds
+			h.Recency = metrics.NewRecencyTracker()
+			h.NormalizeModelNames = tc.normalize
+
+			// The policy pins the model under a differently cased name.
+			if err := store.UpsertPolicy(context.Background(), policy.ModelPolicy{ModelID: "Qwen3-8B", Pinned: true}); err != nil {
+				t.Fatal(err)
+			}
+			addNode(cluster, "n1", map[string]state.ModelState{"qwen3-8b": state.ModelReady, "other": state.ModelReady})
+
+			// No request was recorded since the tracker started: an hour later both
+			// models are idle.
+			h.NodeOfflineTTL = 2 * time.Hour
+			unloaded, _ := h.unloadIdle(context.Background(), time.Now().Add(time.Hour), 30)
+			if unloaded != tc.want {
+				t.Errorf("unloaded %d, want %d (unloads %v)", unloaded, tc.want, cmds.unloads)
+			}
+			if unloaded == 1 && cmds.unloads[0] != "n1/other" {
+				t.Errorf("unloads = %v, want only n1/other", cmds.unloads)
+			}
+		})
+	}
+}
+
+func TestReclaimBannerOnlyAfterUnloads(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	for query, want := range map[string]bool{
+		"":                      false,
+		"?reclaimed=0&freed=0":  false,
+		"?reclaimed=2&freed=10": true,
+	} {
+		w := httptest.NewRecorder()
+		h.models(w, asUser(httptest.NewRequest(http.MethodGet, "/ui/models"+query, nil), testAdmin))
+		if w.Code != http.StatusOK {
+			t.Fatalf("models%s = %d", query, w.Code)
+		}
+		if got := strings.Contains(w.Body.String(), "Modell(e) entladen"); got != want {
+			t.Errorf("models%s: banner shown = %v, want %v", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventHubBetween(t *testing.T) {
+	hub := newEventHub()
+	for i := 0; i < eventReplaySize+10; i++ {
+		hub.publish("activity", []byte("{}"))
+	}
+	last := uint64(eventReplaySize + 10)
+
+	for _, tc := range []struct {
+		name         string
+		after, upTo  uint64
+		wantN        int
+		wantComplete bool
+	}{
+		{"up to date", last, last, 0, true},
+		{"recent gap", last - 5, last, 5, true},
+		{"oldest buffered", 10, last, eventReplaySize, true},
+		{"evicted", 9, last, 0, false},
+		{"id from before a restart", last + 100, last, 0, false},
+	} {
+		got, complete := hub.between(tc.after, tc.upTo)
+		if complete != tc.wantComplete || len(got) != tc.wantN {
+			t.Errorf("%s: %d events (complete %v), want %d (complete %v)", tc.name, len(got), complete, tc.wantN, tc.wantComplete)
+			continue
+		}
+		for i, ev := range got {
+			if ev.ID != tc.after+uint64(i)+1 {
+				t.Errorf("%s: event %d has id %d, want %d", tc.name, i, ev.ID, tc.after+uint64(i)+1)
+				break
+			}
+		}
+	}
+}
+
+// readEvents connects to the stream at url with lastID as Last-Event-ID and returns
+// the first n events as "id event data" ("-" for a missing id). Before reading, it calls
+// connected (if set) once the stream is subscribed.
+func readEvents(t *testing.T, h *Handler, url, lastID string, n int, connected func()) []string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	subs := h.hub.subscribers()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if connected != nil {
+		for h.hub.subscribers() == subs {
+			time.Sleep(time.Millisecond)
+		}
+		connected()
+	}
+
+	var out []string
+	id, typ, data := "-", "", ""
+	sc := bufio.NewScanner(resp.Body)
+	for len(out) < n && sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			typ = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && typ != "":
+			if typ == "snapshot" {
+				data = "" // the timestamp changes
+			}
+			out = append(out, strings.TrimSpace(fmt.Sprintf("%s %s %s", id, typ, data)))
+			id, typ, data = "-", "", ""
+		}
+	}
+	if len(out) < n {
+		t.Fatalf("got events %q, want %d (%v)", out, n, sc.Err())
+	}
+	return out
+}
+
+func TestEventsReplay(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	srv := httptest.NewServer(http.HandlerFunc(h.events))
+	t.Cleanup(srv.Close)
+
+	for i := 1; i <= 3; i++ {
+		h.hub.publish("activity", []byte(fmt.Sprintf(`{"n":%d}`, i)))
+	}
+
+	// Missed events come first, in order, then the stream goes on live.
+	got := readEvents(t, h, srv.URL, "1", 3, func() {
+		h.hub.publish("activity", []byte(`{"n":4}`))
+	})
+	want := []string{`2 activity {"n":2}`, `3 activity {"n":3}`, `4 activity {"n":4}`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+
+	// A client that is up to date gets nothing replayed.
+	got = readEvents(t, h, srv.URL, "4", 1, func() {
+		h.hub.publish("activity", []byte(`{"n":5}`))
+	})
+	if got[0] != `5 activity {"n":5}` {
+		t.Errorf("first event = %q, want the live event 5", got[0])
+	}
+}
+
+func TestEventsResync(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	srv := httptest.NewServer(http.HandlerFunc(h.events))
+	t.Cleanup(srv.Close)
+
+	for i := 0; i < eventReplaySize+10; i++ {
+		h.hub.publish("activity", []byte("{}"))
+	}
+	last := eventReplaySize + 10
+
+	// Ids that are evicted, unknown or garbled cannot be replayed: the client is told
+	// to resync and gets a full snapshot.
+	for _, lastID := range []string{"1", fmt.Sprint(last + 100), "abc"} {
+		got := readEvents(t, h, srv.URL, lastID, 2, nil)
+		want := []string{fmt.Sprintf("%d resync {}", last), "- snapshot"}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("Last-Event-ID %s: events = %q, want %q", lastID, got, want)
+		}
+	}
+}
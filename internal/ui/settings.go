@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// settings serves the effective server configuration as JSON (admin only), with
+// secrets redacted. ?download=1 offers it as a file.
+func (h *Handler) settings(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(h.getUser(r)) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if h.Settings == nil {
+		http.Error(w, "Settings not available", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("download") != "" {
+		name := "llm-router-config-" + time.Now().Format("20060102-150405") + ".json"
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(h.Settings())
+}
@@ -2,10 +2,11 @@ package ui
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"log"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -13,15 +14,28 @@ import (
 
 	"github.com/mcules/llm-router/internal/activity"
 	"github.com/mcules/llm-router/internal/auth"
+	"github.com/mcules/llm-router/internal/control"
+	"github.com/mcules/llm-router/internal/logtail"
+	"github.com/mcules/llm-router/internal/maintenance"
 	"github.com/mcules/llm-router/internal/metrics"
 	"github.com/mcules/llm-router/internal/policy"
+	"github.com/mcules/llm-router/internal/proxy"
 	"github.com/mcules/llm-router/internal/state"
 )
 
 type CommandSender interface {
 	SendUnload(nodeID, requestID, modelID string) error
+	SendCancelLoad(nodeID, requestID, modelID string) error
 }
 
+// ConnectionReporter returns the control stream history of a node.
+type ConnectionReporter interface {
+	Connection(nodeID string) (control.ConnStats, bool)
+}
+
+// flapHighlight is how long a node is marked unstable on the nodes page after a flap.
+const flapHighlight = 10 * time.Minute
+
 type Handler struct {
 	Cluster        *state.ClusterState
 	Commands       CommandSender
@@ -29,9 +43,41 @@ type Handler struct {
 	Auth           *auth.Authenticator
 	Activity       *activity.Log
 	Latency        *metrics.LatencyTracker
+	Recency        *metrics.RecencyTracker
+	Placement      *metrics.PlacementTracker
+	Diagnostics    NodeDiagnoser
+	Connections    ConnectionReporter
+	Replicas       ReplicaPicker
+	Gates          GateReporter
 	templateDir    string
 	templates      map[string]*template.Template
+	hub            *eventHub
 	NodeOfflineTTL time.Duration
+
+	// MaxEventStreams caps the connected /ui/events streams (0 = unlimited).
+	MaxEventStreams int
+
+	// StatusStaleAfter is the age of the most recent node status update after which
+	// /ready reports degraded and the pages warn (0 = never).
+	StatusStaleAfter time.Duration
+
+	// Settings returns the effective configuration (secrets redacted) for /ui/config.
+	Settings func() any
+
+	// Logs holds the recent server log lines for /ui/logs (nil = not available).
+	Logs *logtail.Buffer
+
+	// Maintenance is the read-only switch (nil = not available); mutating handlers
+	// reject requests with 503 while it is on.
+	Maintenance *maintenance.Mode
+
+	// NormalizeModelNames looks up policies ignoring case and surrounding whitespace,
+	// like the planner does.
+	NormalizeModelNames bool
+
+	// BasePath is the external path prefix when served behind a reverse proxy at a
+	// subpath (e.g. "/llm"). It is prepended to redirects and template links.
+	BasePath string
 }
 
 type viewModel struct {
@@ -44,6 +90,19 @@ type viewModel struct {
 	Activity  []activityRow
 	User      *policy.UserRecord
 	Data      any
+
+	// NoNodes is set while no node has connected yet, so pages can show setup guidance.
+	NoNodes bool
+	// NoneOnline is set if nodes are known but none of them is currently online.
+	NoneOnline bool
+	// StatusAge is the age of the most recent node status update (HasStatus: one arrived);
+	// StatusStale is set once it exceeds StatusStaleAfter.
+	StatusAge   time.Duration
+	HasStatus   bool
+	StatusStale bool
+
+	// Maintenance is the read-only mode, shown as a banner on every page.
+	Maintenance maintenance.Status
 }
 
 type nodeView struct {
@@ -53,23 +112,42 @@ type nodeView struct {
 	Age           string
 	RAMAvail      uint64
 	RAMTotal      uint64
+	RAMUnknown    bool // node reported RAM total 0
+	RAMSource     string
 	Inflight      uint32
+	Slots         uint32 // 0 = unknown
 	DataPlaneURL  string
+	Zone          string
+
+	EWMAms    float64
+	ErrRate   float64
+	GenTokens float64 // generation speed in tokens/sec (0 = unknown)
 
-	EWMAms  float64
-	ErrRate float64
+	Conn     control.ConnStats
+	Flapping bool // re-attached quickly after a disconnect within flapHighlight
 }
 
 type modelGroup struct {
-	ModelID string
-	Nodes   []modelNodeInfo
+	ModelID string          `json:"model_id"`
+	Nodes   []modelNodeInfo `json:"nodes"`
+	Ready   int             `json:"ready"`   // nodes with the model READY
+	Loading int             `json:"loading"` // nodes with the model LOADING
+	Errors  int             `json:"error"`   // nodes with the model in ERROR
+
+	// Cold starts and loader waits (only if HasPlacement).
+	Placement    metrics.ModelPlacement `json:"-"`
+	HasPlacement bool                   `json:"-"`
+
+	// Loader coordination of the router (nil before the model was requested).
+	Gate *proxy.GateState `json:"gate,omitempty"`
 }
 
 type modelNodeInfo struct {
-	NodeID      string
-	State       string
-	LastSeen    time.Time
-	LoadedSince time.Time
+	NodeID      string    `json:"node_id"`
+	State       string    `json:"state"`
+	LastSeen    time.Time `json:"last_seen,omitzero"`
+	LoadedSince time.Time `json:"loaded_since,omitzero"`
+	LastUsed    time.Time `json:"last_used,omitzero"`
 }
 
 func NewHandler(cluster *state.ClusterState, commands CommandSender, store *policy.Store, act *activity.Log, lat *metrics.LatencyTracker, templateDir string) (*Handler, error) {
@@ -82,10 +160,18 @@ func NewHandler(cluster *state.ClusterState, commands CommandSender, store *poli
 		Latency:        lat,
 		templateDir:    templateDir,
 		templates:      make(map[string]*template.Template),
+		hub:            newEventHub(),
 		NodeOfflineTTL: 5 * time.Second,
+
+		MaxEventStreams: 100,
+	}
+	if act != nil {
+		act.Subscribe(h.hub.publishActivity)
 	}
+	go h.hub.runSnapshots(snapshotInterval, h.snapshotJSON)
 
 	funcMap := template.FuncMap{
+		"base": func() string { return h.BasePath },
 		"formatRAM": func(b uint64) string {
 			if b == 0 {
 				return "0 GB"
@@ -98,10 +184,12 @@ func NewHandler(cluster *state.ClusterState, commands CommandSender, store *poli
 			}
 			return t.Format("02.01.2006 15:04:05")
 		},
-		"upper": strings.ToUpper,
+		"formatTTL": formatTTL,
+		"upper":     strings.ToUpper,
+		"join":      strings.Join,
 	}
 
-	pages := []string{"dashboard.html", "nodes.html", "models.html", "policies.html", "activity.html", "keys.html", "login.html", "users.html"}
+	pages := []string{"dashboard.html", "nodes.html", "models.html", "policies.html", "activity.html", "keys.html", "login.html", "users.html", "logs.html"}
 	for _, page := range pages {
 		tpl := template.New(page).Funcs(funcMap)
 		tpl, err := tpl.ParseFiles(
@@ -125,9 +213,14 @@ func (h *Handler) Register(mux *http.ServeMux) {
 	mux.HandleFunc("/ui/", h.authMiddleware(h.dashboard))
 
 	mux.HandleFunc("/ui/nodes", h.authMiddleware(h.nodes))
+	mux.HandleFunc("/ui/nodes/diagnose", h.authMiddleware(h.diagnoseNode))
 	mux.HandleFunc("/ui/models", h.authMiddleware(h.models))
+	mux.HandleFunc("/ui/models/status", h.authMiddleware(h.modelsStatus))
 	mux.HandleFunc("/ui/models/unload", h.authMiddleware(h.unloadModel))
-	mux.HandleFunc("/ui/events", h.events) // SSE normally doesn't need auth if pages are protected
+	mux.HandleFunc("/ui/models/cancel-load", h.authMiddleware(h.cancelLoad))
+	mux.HandleFunc("/ui/models/reclaim-idle", h.authMiddleware(h.reclaimIdle))
+	mux.HandleFunc("/ui/models/consolidate", h.authMiddleware(h.consolidateModel))
+	mux.HandleFunc("/ui/events", h.authMiddleware(h.events))
 
 	mux.HandleFunc("/ui/policies", h.authMiddleware(h.policies))
 	mux.HandleFunc("/ui/policies/save", h.authMiddleware(h.savePolicy))
@@ -137,6 +230,9 @@ func (h *Handler) Register(mux *http.ServeMux) {
 	mux.HandleFunc("/ui/keys", h.authMiddleware(h.keys))
 	mux.HandleFunc("/ui/keys/create", h.authMiddleware(h.createKey))
 	mux.HandleFunc("/ui/keys/delete", h.authMiddleware(h.deleteKey))
+	mux.HandleFunc("/ui/keys/test", h.authMiddleware(h.testKey))
+	mux.HandleFunc("/ui/keys/budget", h.authMiddleware(h.setKeyBudget))
+	mux.HandleFunc("/ui/keys/stream", h.authMiddleware(h.setKeyStream))
 
 	mux.HandleFunc("/ui/users", h.authMiddleware(h.users))
 	mux.HandleFunc("/ui/users/create", h.authMiddleware(h.createUser))
@@ -145,12 +241,54 @@ func (h *Handler) Register(mux *http.ServeMux) {
 	mux.HandleFunc("/ui/users/password", h.authMiddleware(h.changePassword))
 
 	mux.HandleFunc("/ui/activity", h.authMiddleware(h.activity))
+	mux.HandleFunc("/ui/config", h.authMiddleware(h.settings))
+	mux.HandleFunc("/ui/debug/dump", h.authMiddleware(h.debugDump))
+	mux.HandleFunc("/ui/export/usage.csv", h.authMiddleware(h.exportUsage))
+	mux.HandleFunc("/ui/export/activity.csv", h.authMiddleware(h.exportActivity))
+	mux.HandleFunc("/ui/logs", h.authMiddleware(h.logs))
+	mux.HandleFunc("/ui/maintenance", h.authMiddleware(h.maintenanceMode))
+
+	h.RegisterProbes(mux)
+}
 
+// RegisterProbes registers the unauthenticated /health and /ready endpoints; they are
+// also served on the metrics listener (see METRICS_ADDR).
+func (h *Handler) RegisterProbes(mux *http.ServeMux) {
 	// Simple health endpoint for the server itself
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write([]byte(`{"status":"ok"}`))
 	})
+
+	// Readiness: degraded while the policy store keeps failing or no node status arrived
+	// for StatusStaleAfter (wedged control plane).
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		store := h.PolicyStore.Health()
+		age, stale := h.Cluster.StatusStale(time.Now(), h.StatusStaleAfter)
+		healthy := store.Healthy && !stale
+		status := "ready"
+		if !healthy {
+			status = "degraded"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status": status,
+			"store":  store,
+			"control": map[string]any{
+				"last_status_age_seconds": age.Seconds(),
+				"stale":                   stale,
+			},
+			"maintenance": h.Maintenance.Status(),
+		})
+	})
+}
+
+// redirect redirects to a path of this server, honoring BasePath.
+func (h *Handler) redirect(w http.ResponseWriter, r *http.Request, path string, code int) {
+	http.Redirect(w, r, h.BasePath+path, code)
 }
 
 func (h *Handler) render(w http.ResponseWriter, page string, vm viewModel) {
@@ -176,7 +314,7 @@ func (h *Handler) dashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.URL.Path == "/ui" {
-		http.Redirect(w, r, "/ui/", http.StatusFound)
+		h.redirect(w, r, "/ui/", http.StatusFound)
 		return
 	}
 	vm := h.newViewModel("Dashboard")
@@ -202,7 +340,6 @@ func (h *Handler) nodes(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 		online := n.IsOnline(now, ttl)
-		log.Printf("DEBUG: UI nodes: node %s online=%v (last: %v, ttl: %v, now: %v)", n.NodeID, online, n.LastHeartbeat.Format("15:04:05.000"), ttl, now.Format("15:04:05.000"))
 
 		age := "n/a"
 		if !n.LastHeartbeat.IsZero() {
@@ -221,6 +358,11 @@ func (h *Handler) nodes(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		var conn control.ConnStats
+		if h.Connections != nil {
+			conn, _ = h.Connections.Connection(n.NodeID)
+		}
+
 		views = append(views, nodeView{
 			NodeID:        n.NodeID,
 			Online:        online,
@@ -228,10 +370,17 @@ func (h *Handler) nodes(w http.ResponseWriter, r *http.Request) {
 			Age:           age,
 			RAMAvail:      n.RAMAvailBytes,
 			RAMTotal:      n.RAMTotalBytes,
+			RAMUnknown:    !n.CapacityKnown(),
+			RAMSource:     n.RAMSource,
 			Inflight:      n.InflightRequests,
+			Slots:         n.TotalSlots,
 			DataPlaneURL:  n.DataPlaneURL,
+			Zone:          n.Zone,
 			EWMAms:        ewma,
 			ErrRate:       errRate,
+			GenTokens:     n.GenTokensPerSec,
+			Conn:          conn,
+			Flapping:      conn.FlappingSince(now.Add(-flapHighlight)),
 		})
 	}
 
@@ -241,14 +390,59 @@ func (h *Handler) nodes(w http.ResponseWriter, r *http.Request) {
 	})
 	vm.NodeViews = views
 	vm.User = user
+	vm.Data = struct {
+		IsAdmin bool
+	}{
+		IsAdmin: isAdmin(user),
+	}
 	h.render(w, "nodes.html", vm)
 }
 
 func (h *Handler) models(w http.ResponseWriter, r *http.Request) {
-	now := time.Now()
+	user := h.getUser(r)
+	groups := h.modelGroups(user, time.Now())
+
+	vm := h.newViewModel("Models")
+	vm.Models = groups
+	vm.User = user
+	data := struct {
+		IsAdmin      bool
+		Reclaimed    int
+		Queued       string
+		Freed        uint64
+		CanReduce    bool
+		Consolidate  *consolidatePlan
+		Consolidated *consolidateResult
+	}{
+		IsAdmin:      isAdmin(user),
+		Reclaimed:    parseIntDefault(r.URL.Query().Get("reclaimed"), 0),
+		Queued:       r.URL.Query().Get("queued"),
+		Freed:        parseUint64Default(r.URL.Query().Get("freed"), 0),
+		Consolidated: consolidateResultFrom(r.URL.Query()),
+	}
+	data.CanReduce = data.IsAdmin && h.Replicas != nil
+	if data.CanReduce {
+		data.Consolidate = h.consolidatePlanFor(r.Context(), r.URL.Query().Get("consolidate"))
+	}
+	vm.Data = data
+	h.render(w, "models.html", vm)
+}
+
+// modelsStatus serves the models of the models page as JSON: per model the state on
+// each online node, the number of nodes READY, LOADING and in ERROR, and the router's
+// loader state (assigned loader, since when, requests waiting).
+func (h *Handler) modelsStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(struct {
+		Models []modelGroup `json:"models"`
+	}{Models: h.modelGroups(h.getUser(r), time.Now())})
+}
+
+// modelGroups groups the models of the online nodes the user may see by model id.
+func (h *Handler) modelGroups(user *policy.UserRecord, now time.Time) []modelGroup {
 	ttl := h.NodeOfflineTTL
 	nodes := h.Cluster.Snapshot()
-	user := h.getUser(r)
 
 	groupsMap := make(map[string]*modelGroup)
 
@@ -256,9 +450,7 @@ func (h *Handler) models(w http.ResponseWriter, r *http.Request) {
 		if user != nil && !auth.CheckACL(user.AllowedNodes, n.NodeID) {
 			continue
 		}
-		online := n.IsOnline(now, ttl)
-		log.Printf("DEBUG: UI models: node %s online=%v (last: %v, ttl: %v, now: %v)", n.NodeID, online, n.LastHeartbeat.Format("15:04:05.000"), ttl, now.Format("15:04:05.000"))
-		if !online {
+		if !n.IsOnline(now, ttl) {
 			continue
 		}
 
@@ -273,12 +465,31 @@ func (h *Handler) models(w http.ResponseWriter, r *http.Request) {
 				groupsMap[m.ModelID] = group
 			}
 
-			group.Nodes = append(group.Nodes, modelNodeInfo{
+			info := modelNodeInfo{
 				NodeID:      n.NodeID,
 				State:       string(m.State),
 				LastSeen:    m.LastSeen,
 				LoadedSince: m.LoadedSince,
-			})
+			}
+			if h.Recency != nil {
+				info.LastUsed, _ = h.Recency.LastUsed(n.NodeID, m.ModelID)
+			}
+			group.Nodes = append(group.Nodes, info)
+			switch m.State {
+			case state.ModelReady:
+				group.Ready++
+			case state.ModelLoading:
+				group.Loading++
+			case state.ModelError:
+				group.Errors++
+			}
+		}
+	}
+
+	gates := map[string]proxy.GateState{}
+	if h.Gates != nil {
+		for _, gs := range h.Gates.GateStates() {
+			gates[gs.ModelID] = gs
 		}
 	}
 
@@ -287,17 +498,44 @@ func (h *Handler) models(w http.ResponseWriter, r *http.Request) {
 		sort.Slice(g.Nodes, func(i, j int) bool {
 			return g.Nodes[i].NodeID < g.Nodes[j].NodeID
 		})
+		if h.Placement != nil {
+			g.Placement, g.HasPlacement = h.Placement.Get(g.ModelID)
+		}
+		if gs, ok := gates[g.ModelID]; ok {
+			gs = visibleGate(gs, user)
+			g.Gate = &gs
+		}
 		groups = append(groups, *g)
 	}
 
 	sort.Slice(groups, func(i, j int) bool {
 		return strings.ToLower(groups[i].ModelID) < strings.ToLower(groups[j].ModelID)
 	})
+	return groups
+}
 
-	vm := h.newViewModel("Models")
-	vm.Models = groups
-	vm.User = user
-	h.render(w, "models.html", vm)
+// visibleGate returns gs without the node ids user's node ACL hides. A hidden loader
+// keeps its LoadingSince, so the load still shows as in progress.
+func visibleGate(gs proxy.GateState, user *policy.UserRecord) proxy.GateState {
+	if user == nil {
+		return gs
+	}
+	if gs.LoadingNode != "" && !auth.CheckACL(user.AllowedNodes, gs.LoadingNode) {
+		gs.LoadingNode = ""
+	}
+	if gs.ReadyNode != "" && !auth.CheckACL(user.AllowedNodes, gs.ReadyNode) {
+		gs.ReadyNode = ""
+	}
+	if len(gs.ReportedLoads) > 0 {
+		loads := make(map[string]time.Time, len(gs.ReportedLoads))
+		for nodeID, since := range gs.ReportedLoads {
+			if auth.CheckACL(user.AllowedNodes, nodeID) {
+				loads[nodeID] = since
+			}
+		}
+		gs.ReportedLoads = loads
+	}
+	return gs
 }
 
 func (h *Handler) unloadModel(w http.ResponseWriter, r *http.Request) {
@@ -305,6 +543,9 @@ func (h *Handler) unloadModel(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	if h.readOnly(w) {
+		return
+	}
 
 	nodeID := r.FormValue("node_id")
 	modelID := r.FormValue("model_id")
@@ -314,7 +555,9 @@ func (h *Handler) unloadModel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	reqID := fmt.Sprintf("unload-%d", time.Now().UnixNano())
-	if err := h.Commands.SendUnload(nodeID, reqID, modelID); err != nil {
+	err := h.Commands.SendUnload(nodeID, reqID, modelID)
+	queued := errors.Is(err, control.ErrQueued)
+	if err != nil && !queued {
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
 	}
@@ -330,52 +573,69 @@ func (h *Handler) unloadModel(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	http.Redirect(w, r, "/ui/models", http.StatusFound)
+	h.redirectCommand(w, r, nodeID, queued)
 }
 
-func (h *Handler) events(w http.ResponseWriter, r *http.Request) {
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+// cancelLoad aborts a model load in progress on a node. Waiting requests fail once the
+// node confirms the cancellation.
+func (h *Handler) cancelLoad(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if h.readOnly(w) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	// Send initial pulse
-	_, _ = fmt.Fprintf(w, ": ok\n\n")
-	flusher.Flush()
-
-	t := time.NewTicker(2 * time.Second)
-	defer t.Stop()
-
-	for {
-		select {
-		case <-r.Context().Done():
-			return
-		case <-t.C:
-			snap := h.Cluster.Snapshot()
-			payload, _ := json.Marshal(map[string]any{
-				"ts":    time.Now().UnixMilli(),
-				"nodes": snap,
-			})
-
-			_, err := fmt.Fprintf(w, "event: snapshot\ndata: %s\n\n", payload)
-			if err != nil {
-				return
-			}
-			flusher.Flush()
-		}
+	nodeID := r.FormValue("node_id")
+	modelID := r.FormValue("model_id")
+	if nodeID == "" || modelID == "" {
+		http.Error(w, "missing node_id or model_id", http.StatusBadRequest)
+		return
+	}
+
+	reqID := fmt.Sprintf("cancel-%d", time.Now().UnixNano())
+	err := h.Commands.SendCancelLoad(nodeID, reqID, modelID)
+	queued := errors.Is(err, control.ErrQueued)
+	if err != nil && !queued {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	h.redirectCommand(w, r, nodeID, queued)
+}
+
+// redirectCommand returns to the models page after a node command; if the command was
+// queued for the reconnecting node, the page says so.
+func (h *Handler) redirectCommand(w http.ResponseWriter, r *http.Request, nodeID string, queued bool) {
+	if !queued {
+		h.redirect(w, r, "/ui/models", http.StatusFound)
+		return
 	}
+	h.redirect(w, r, "/ui/models?queued="+url.QueryEscape(nodeID), http.StatusFound)
 }
 
 func (h *Handler) newViewModel(title string) viewModel {
+	now := time.Now()
+	nodes := h.Cluster.Snapshot()
+
+	online := 0
+	for _, n := range nodes {
+		if n.IsOnline(now, h.NodeOfflineTTL) {
+			online++
+		}
+	}
+
+	age, stale := h.Cluster.StatusStale(now, h.StatusStaleAfter)
 	return viewModel{
-		Title: title,
-		Now:   time.Now(),
-		Nodes: h.Cluster.Snapshot(),
+		Title:       title,
+		Now:         now,
+		Nodes:       nodes,
+		NoNodes:     len(nodes) == 0,
+		NoneOnline:  len(nodes) > 0 && online == 0,
+		StatusAge:   age.Truncate(time.Second),
+		HasStatus:   !h.Cluster.LastStatusUpdate().IsZero(),
+		StatusStale: stale,
+		Maintenance: h.Maintenance.Status(),
 	}
 }
@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mcules/llm-router/internal/activity"
+)
+
+// queueSize bounds the events waiting for delivery. Further events are dropped.
+const queueSize = 256
+
+// Payload is the JSON body posted to the webhook URL.
+type Payload struct {
+	Type      string    `json:"type"`
+	NodeID    string    `json:"node_id,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Dispatcher posts activity events to an external webhook.
+// If Secret is set, the body is signed with HMAC-SHA256 (header X-Signature-256: sha256=<hex>).
+type Dispatcher struct {
+	URL     string
+	Secret  string
+	Timeout time.Duration
+	// Retries is the number of additional attempts after a failed delivery.
+	Retries int
+	// RetryBackoff is the pause before the first retry; it doubles per attempt.
+	RetryBackoff time.Duration
+	// Events limits delivery to these event types (empty = all).
+	Events map[activity.EventType]bool
+
+	client *http.Client
+	queue  chan Payload
+}
+
+func NewDispatcher(url, secret string) *Dispatcher {
+	return &Dispatcher{
+		URL:          url,
+		Secret:       secret,
+		Timeout:      5 * time.Second,
+		Retries:      3,
+		RetryBackoff: time.Second,
+		queue:        make(chan Payload, queueSize),
+	}
+}
+
+// ParseEvents parses a comma separated list of event types.
+func ParseEvents(list string) map[activity.EventType]bool {
+	out := map[activity.EventType]bool{}
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out[activity.EventType(s)] = true
+		}
+	}
+	return out
+}
+
+// Handle enqueues an event for delivery. It never blocks (activity.Log subscriber).
+func (d *Dispatcher) Handle(e activity.Event) {
+	if len(d.Events) > 0 && !d.Events[e.Type] {
+		return
+	}
+	p := Payload{
+		Type:      string(e.Type),
+		NodeID:    e.NodeID,
+		Model:     e.Model,
+		Reason:    e.Note,
+		Timestamp: e.At.UTC(),
+	}
+	select {
+	case d.queue <- p:
+	default:
+		log.Printf("webhook: queue full, dropping event type=%s node=%s model=%s", e.Type, e.NodeID, e.Model)
+	}
+}
+
+// Run delivers queued events until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.client = &http.Client{Timeout: d.Timeout}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-d.queue:
+			d.deliver(ctx, p)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, p Payload) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		log.Printf("webhook: marshal: %v", err)
+		return
+	}
+
+	backoff := d.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = d.post(ctx, body)
+		if err == nil {
+			return
+		}
+		if attempt >= d.Retries {
+			log.Printf("webhook: giving up on event type=%s after %d attempts: %v", p.Type, attempt+1, err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(d.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}